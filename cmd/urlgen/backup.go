@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"log/slog"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/s3"
+	"my_project/urlgen/pkg/version"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	backupManifestFile = "manifest.json"         // Описание снимка (записывается последним, снимок без него неполон)
+	backupLinksFile    = "links.jsonl.gz"        // Ссылки, по одной в строке JSON
+	backupClicksFile   = "clicks_daily.jsonl.gz" // Количество переходов по ссылкам за сутки
+	backupIdLayout     = "20060102T150405Z"      // Формат идентификатора снимка (время создания в UTC)
+)
+
+// backupManifest - Тип данных, описывающий снимок резервной копии
+type backupManifest struct {
+	FormatVersion int          `json:"format_version"` // Версия формата снимка
+	CreatedAt     time.Time    `json:"created_at"`     // Время создания
+	AppVersion    string       `json:"app_version"`    // Версия urlgen, создавшей снимок
+	SchemaVersion int          `json:"schema_version"` // Последняя примененная миграция БД
+	Files         []backupFile `json:"files"`          // Файлы снимка
+}
+
+// backupFile - Тип данных, описывающий файл снимка
+type backupFile struct {
+	Name    string `json:"name"`    // Имя файла в снимке
+	Records int    `json:"records"` // Количество записей
+	Size    int64  `json:"size"`    // Размер сжатого файла в байтах
+	SHA256  string `json:"sha256"`  // Хеш сжатого файла
+}
+
+// backupResult - Тип данных, описывающий итог создания снимка
+type backupResult struct {
+	Snapshot        string   `json:"snapshot"`          // Идентификатор снимка
+	Links           int      `json:"links"`             // Количество сохраненных ссылок
+	ClickAggregates int      `json:"click_aggregates"`  // Количество сохраненных суточных счетчиков переходов
+	Size            int64    `json:"size"`              // Размер снимка в байтах
+	Deleted         []string `json:"deleted,omitempty"` // Снимки, удаленные по сроку хранения
+}
+
+// backupStore - Тип данных, описывающий хранилище снимков (бакет и префикс ключей)
+type backupStore struct {
+	client *s3.Client // Клиент хранилища
+	prefix string     // Префикс ключей снимков
+}
+
+// backupStoreFromEnv - Функция, позволяющая получить хранилище снимков из переменных BACKUP_S3_*
+func backupStoreFromEnv() (*backupStore, error) {
+
+	client, err := s3.ClientCreate(os.Getenv("BACKUP_S3_ENDPOINT"), os.Getenv("BACKUP_S3_BUCKET"),
+		os.Getenv("BACKUP_S3_REGION"), os.Getenv("BACKUP_S3_ACCESS_KEY"), os.Getenv("BACKUP_S3_SECRET_KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("%w (BACKUP_S3_ENDPOINT, BACKUP_S3_BUCKET, BACKUP_S3_ACCESS_KEY, BACKUP_S3_SECRET_KEY)", err)
+	}
+
+	prefix := strings.Trim(os.Getenv("BACKUP_S3_PREFIX"), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &backupStore{client: client, prefix: prefix}, nil
+}
+
+// key - Метод, возвращающий ключ файла снимка в хранилище
+func (b *backupStore) key(snapshot, name string) string {
+	return b.prefix + snapshot + "/" + name
+}
+
+// snapshots - Метод, позволяющий получить идентификаторы снимков хранилища по возрастанию времени
+// с признаком полноты (наличия описания) и ключами их файлов
+func (b *backupStore) snapshots(ctx context.Context) ([]string, map[string][]string, map[string]bool, error) {
+
+	objects, err := b.client.List(ctx, b.prefix)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	keys := map[string][]string{}
+	complete := map[string]bool{}
+
+	for _, o := range objects {
+		snapshot, name, found := strings.Cut(strings.TrimPrefix(o.Key, b.prefix), "/")
+		if _, err := time.Parse(backupIdLayout, snapshot); !found || err != nil {
+			continue
+		}
+
+		keys[snapshot] = append(keys[snapshot], o.Key)
+		if name == backupManifestFile {
+			complete[snapshot] = true
+		}
+	}
+
+	ids := make([]string, 0, len(keys))
+	for id := range keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids, keys, complete, nil
+}
+
+// newBackupCommand - Функция, реализующая создание команды backup (снимок ссылок и переходов в S3)
+func newBackupCommand(opts *globalOptions) *cobra.Command {
+
+	var (
+		every     time.Duration
+		retention string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Save links and daily click counts to S3-compatible storage",
+		Long: "backup works with the database directly (server settings come from --config or the\n" +
+			"environment) and uploads a snapshot of gzip-compressed JSON lines to the BACKUP_S3_BUCKET\n" +
+			"bucket (AWS S3, MinIO). Snapshots older than --retention are deleted, except the newest one.\n" +
+			"With --every the command keeps running and takes a snapshot at each interval.",
+		Example: "  urlgen backup\n" +
+			"  urlgen backup --every 24h --retention 30d",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if every < 0 || (every > 0 && every < time.Minute) {
+				return errors.New("error: --every must be at least 1m")
+			}
+
+			db, logger, err := openDatabase(opts)
+			if err != nil {
+				return err
+			}
+			defer db.CloseConnection()
+
+			if retention == "" {
+				retention = os.Getenv("BACKUP_RETENTION")
+			}
+
+			var keep time.Duration
+			if retention != "" {
+				keep, err = config.ParseRetention(retention)
+				if err != nil || keep <= 0 {
+					return errors.New("error: --retention must be a duration such as \"720h\" or \"30d\"")
+				}
+			}
+
+			store, err := backupStoreFromEnv()
+			if err != nil {
+				return err
+			}
+
+			run := func() error {
+				result, err := createBackup(cmd.Context(), db, store, keep)
+				if err != nil {
+					return err
+				}
+
+				return printOutput(cmd, opts, result, func() error {
+					w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+					fmt.Fprintf(w, "Snapshot:\t%s\n", result.Snapshot)
+					fmt.Fprintf(w, "Links:\t%d\n", result.Links)
+					fmt.Fprintf(w, "Daily click counts:\t%d\n", result.ClickAggregates)
+					fmt.Fprintf(w, "Size:\t%d bytes\n", result.Size)
+					for _, id := range result.Deleted {
+						fmt.Fprintf(w, "Deleted:\t%s\n", id)
+					}
+
+					return w.Flush()
+				})
+			}
+
+			if every == 0 {
+				return run()
+			}
+
+			return runScheduledBackups(cmd.Context(), every, logger, run)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.DurationVar(&every, "every", 0, "keep running and take a snapshot at this interval, e.g. 24h")
+	flags.StringVar(&retention, "retention", "", "delete snapshots older than this, e.g. 30d (BACKUP_RETENTION)")
+
+	return cmd
+}
+
+// runScheduledBackups - Функция, реализующая создание снимков с заданным интервалом до прерывания команды
+// (неудачный снимок журналируется и не останавливает расписание)
+func runScheduledBackups(ctx context.Context, every time.Duration, logger *slog.Logger, run func() error) error {
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if err := run(); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.ErrorContext(ctx, "Failed to create backup", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// createBackup - Функция, реализующая создание снимка и удаление снимков старше keep (0 - без удаления)
+func createBackup(ctx context.Context, db *database.Database, store *backupStore,
+	keep time.Duration) (*backupResult, error) {
+
+	now := time.Now().UTC()
+
+	manifest := backupManifest{
+		FormatVersion: config.BackupFormatVersion,
+		CreatedAt:     now,
+		AppVersion:    version.Get().Version,
+	}
+
+	migrations, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		if m.AppliedAt != nil {
+			manifest.SchemaVersion = max(manifest.SchemaVersion, m.Version)
+		}
+	}
+
+	result := backupResult{Snapshot: now.Format(backupIdLayout)}
+
+	links, err := uploadBackupFile(ctx, store, result.Snapshot, backupLinksFile, func(write func(any) error) error {
+		return db.ExportRows(ctx, func(r database.RowData) error { return write(r) })
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error: failed to back up links: %w", err)
+	}
+
+	clicks, err := uploadBackupFile(ctx, store, result.Snapshot, backupClicksFile, func(write func(any) error) error {
+		return db.ExportClickAggregates(ctx, func(a database.ClickAggregate) error { return write(a) })
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error: failed to back up clicks: %w", err)
+	}
+
+	manifest.Files = []backupFile{links, clicks}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.client.Put(ctx, store.key(result.Snapshot, backupManifestFile), bytes.NewReader(data), "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("error: failed to upload backup manifest: %w", err)
+	}
+
+	result.Links, result.ClickAggregates = links.Records, clicks.Records
+	result.Size = links.Size + clicks.Size + int64(len(data))
+
+	if keep > 0 {
+		result.Deleted, err = pruneBackups(ctx, store, now.Add(-keep))
+		if err != nil {
+			return nil, fmt.Errorf("error: snapshot %s was created, but old snapshots were not deleted: %w",
+				result.Snapshot, err)
+		}
+	}
+
+	return &result, nil
+}
+
+// uploadBackupFile - Функция, реализующая запись файла снимка: записи, переданные export функции write,
+// сжимаются во временный файл, который затем загружается в хранилище
+func uploadBackupFile(ctx context.Context, store *backupStore, snapshot, name string,
+	export func(write func(any) error) error) (backupFile, error) {
+
+	file := backupFile{Name: name}
+
+	tmp, err := os.CreateTemp("", "urlgen-backup-*")
+	if err != nil {
+		return file, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	zw := gzip.NewWriter(io.MultiWriter(tmp, hash))
+	encoder := json.NewEncoder(zw)
+
+	err = export(func(v any) error {
+		file.Records++
+		return encoder.Encode(v)
+	})
+	if err != nil {
+		return file, err
+	}
+
+	if err = zw.Close(); err != nil {
+		return file, err
+	}
+
+	if file.Size, err = tmp.Seek(0, io.SeekCurrent); err != nil {
+		return file, err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return file, err
+	}
+
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return file, store.client.Put(ctx, store.key(snapshot, name), tmp, "application/gzip")
+}
+
+// pruneBackups - Функция, реализующая удаление снимков, созданных до before
+// (новейший полный снимок не удаляется, даже если он старше срока хранения)
+func pruneBackups(ctx context.Context, store *backupStore, before time.Time) ([]string, error) {
+
+	ids, keys, complete, err := store.snapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	newest := ""
+	for _, id := range ids {
+		if complete[id] {
+			newest = id
+		}
+	}
+
+	var deleted []string
+	for _, id := range ids {
+		created, _ := time.Parse(backupIdLayout, id)
+		if id == newest || !created.Before(before) {
+			continue
+		}
+
+		for _, key := range keys[id] {
+			if err = store.client.Delete(ctx, key); err != nil {
+				return deleted, err
+			}
+		}
+
+		deleted = append(deleted, id)
+	}
+
+	return deleted, nil
+}
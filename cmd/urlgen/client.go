@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/internal/server"
+	applog "my_project/urlgen/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+)
+
+// requestTimeout - Время ожидания ответа сервера
+const requestTimeout = 30 * time.Second
+
+// apiClient - Тип данных, реализующий вызовы API сервера
+// (по сети или в процессе, обработчиком сервера поверх БД, в локальном режиме)
+type apiClient struct {
+	baseUrl   string             // Адрес сервера без завершающей косой черты
+	token     string             // JWT или ключ API
+	workspace string             // Рабочее пространство (пустая строка - пространство по умолчанию)
+	http      *http.Client       // Клиент HTTP
+	close     func() error       // Освобождение ресурсов локального режима
+	db        *database.Database // БД локального режима (nil при работе через сервер)
+}
+
+// newClient - Функция, реализующая создание клиента API по общим флагам команд
+func newClient(ctx context.Context, opts *globalOptions) (*apiClient, error) {
+
+	if opts.local {
+		return localClient(ctx, opts)
+	}
+
+	if opts.token == "" {
+		return nil, errors.New("error: --token or URLGEN_TOKEN is required (or use --local)")
+	}
+
+	return &apiClient{
+		baseUrl:   strings.TrimSuffix(opts.apiUrl, "/"),
+		token:     opts.token,
+		workspace: opts.workspace,
+		http:      &http.Client{Timeout: requestTimeout},
+		close:     func() error { return nil },
+	}, nil
+}
+
+// localClient - Функция, реализующая создание клиента, который вызывает обработчик сервера в процессе
+// от имени пользователя --user (настройки сервера читаются из файла и переменных окружения)
+func localClient(ctx context.Context, opts *globalOptions) (*apiClient, error) {
+
+	if opts.user == "" {
+		return nil, errors.New("error: --user or ADMIN_USERNAME is required in local mode")
+	}
+
+	db, logger, err := openDatabase(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Журнал запросов в стандартный вывод смешался бы с выводом команды
+	if target := os.Getenv("ACCESS_LOG"); target == "" || target == "stdout" {
+		_ = os.Setenv("ACCESS_LOG", "off")
+	}
+
+	srv, err := server.NewServer(db, logger, ctx)
+	if err != nil {
+		_ = db.CloseConnection()
+		return nil, err
+	}
+
+	closeAll := func() error {
+		closeCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+
+		return errors.Join(srv.Close(closeCtx), db.CloseConnection())
+	}
+
+	token, err := srv.IssueToken(ctx, opts.user)
+	if err != nil {
+		_ = closeAll()
+		return nil, err
+	}
+
+	return &apiClient{
+		baseUrl:   "http://localhost",
+		token:     token,
+		workspace: opts.workspace,
+		http:      &http.Client{Transport: handlerTransport{handler: srv.Handler()}},
+		close:     closeAll,
+		db:        db,
+	}, nil
+}
+
+// openDatabase - Функция, реализующая загрузку настроек сервера и подключение к БД для локального режима
+// (журнал пишется в стандартный поток ошибок, по умолчанию только предупреждения и ошибки)
+func openDatabase(opts *globalOptions) (*database.Database, *slog.Logger, error) {
+
+	var args []string
+	if opts.configFile != "" {
+		args = []string{"-config", opts.configFile}
+	}
+
+	if _, err := config.Load(args); err != nil {
+		return nil, nil, err
+	}
+
+	return connectDatabase("")
+}
+
+// connectDatabase - Функция, реализующая подключение к БД по строке подключения (пустая строка - DATABASE_URL)
+func connectDatabase(dsn string) (*database.Database, *slog.Logger, error) {
+
+	if dsn != "" {
+		if err := os.Setenv("DATABASE_URL", dsn); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	logger, level, err := applog.FromEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if os.Getenv("LOG_LEVEL") == "" {
+		level.Set(slog.LevelWarn)
+	}
+
+	db, err := database.GetConnection(logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error: failed to connect to database: %w", err)
+	}
+
+	return &db, logger, nil
+}
+
+// Close - Метод, реализующий освобождение ресурсов клиента
+func (c *apiClient) Close() error {
+	return c.close()
+}
+
+// do - Метод, реализующий вызов API с телом запроса и ответа в JSON (nil - без тела)
+// (ответ с ошибкой возвращается ошибкой с текстом сервера)
+func (c *apiClient) do(ctx context.Context, method, path string, body, result any) error {
+
+	var reader io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.workspace != "" {
+		req.Header.Set("X-Workspace-Id", c.workspace)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+		message := strings.TrimSpace(string(text))
+		if message == "" {
+			message = "Error: " + resp.Status
+		}
+
+		return errors.New(message)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// handlerTransport - Тип данных, реализующий передачу запросов обработчику HTTP в процессе
+type handlerTransport struct {
+	handler http.Handler
+}
+
+// RoundTrip - Метод, реализующий обработку запроса обработчиком и возврат записанного ответа
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	// Запрос приводится к виду, в котором его получает сервер
+	in := req.Clone(req.Context())
+	in.RemoteAddr = "127.0.0.1:0"
+	in.RequestURI = req.URL.RequestURI()
+
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, in)
+
+	return recorder.Result(), nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"my_project/urlgen/config"
+	"my_project/urlgen/internal/server"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// linkDetails - Тип данных, описывающий сведения о ссылке, которые выводит команда expand
+type linkDetails struct {
+	server.Link
+	Status string `json:"status"` // Состояние переходов по ссылке
+	Clicks int    `json:"clicks"` // Переходы за все время
+}
+
+// newExpandCommand - Функция, реализующая создание команды expand (сведения о короткой ссылке)
+func newExpandCommand(opts *globalOptions) *cobra.Command {
+
+	var domain string
+
+	cmd := &cobra.Command{
+		Use:     "expand <code | short url>",
+		Aliases: []string{"lookup"},
+		Short:   "Show the destination, status, owner, clicks and expiry of a short link",
+		Example: "  urlgen expand abc123\n" +
+			"  urlgen expand http://exmpl.lnk/abc123\n" +
+			"  urlgen expand https://go.example.com/launch",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			linkDomain, code, err := parseShortLink(args[0], domain)
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			link := server.Link{}
+
+			err = client.do(cmd.Context(), http.MethodGet, linkPath(linkDomain, code, ""), nil, &link)
+			if err != nil {
+				return err
+			}
+
+			// Переходы за все время жизни ссылки
+			query := url.Values{"from": {link.CreatedAt.UTC().Format(time.RFC3339)}, "top": {"1"}}
+			stats := server.LinkStatsResponse{}
+
+			err = client.do(cmd.Context(), http.MethodGet, linkPath(linkDomain, code, "/stats")+"&"+query.Encode(),
+				nil, &stats)
+			if err != nil {
+				return err
+			}
+
+			details := linkDetails{Link: link, Status: linkStatus(link, time.Now()), Clicks: stats.Total}
+
+			return printOutput(cmd, opts, details, func() error { return printLink(cmd, details) })
+		},
+	}
+
+	cmd.Flags().StringVar(&domain, "domain", "", "branded domain of a bare code")
+
+	return cmd
+}
+
+// parseShortLink - Функция, реализующая получение домена и кода из короткой ссылки или кода
+// (домен основы коротких ссылок config.GenUrl - основной домен; для кода домен задается флагом)
+func parseShortLink(arg, domain string) (string, string, error) {
+
+	if !strings.Contains(arg, "://") {
+		return strings.ToLower(domain), arg, nil
+	}
+
+	u, err := url.Parse(arg)
+	if err != nil {
+		return "", "", fmt.Errorf("error: invalid short url %q", arg)
+	}
+
+	code := strings.TrimPrefix(u.Path, "/")
+	if code == "" || strings.Contains(code, "/") {
+		return "", "", fmt.Errorf("error: no code in short url %q", arg)
+	}
+
+	if base, err := url.Parse(config.GenUrl); err == nil && strings.EqualFold(base.Host, u.Host) {
+		return "", code, nil
+	}
+
+	return strings.ToLower(u.Hostname()), code, nil
+}
+
+// linkPath - Функция, возвращающая путь API ссылки на домене коротких ссылок с суффиксом (например "/stats")
+func linkPath(domain, code, suffix string) string {
+	return "/api/v1/links/" + url.PathEscape(code) + suffix + "?" + url.Values{"domain": {domain}}.Encode()
+}
+
+// linkStatus - Функция, возвращающая состояние переходов по ссылке
+func linkStatus(link server.Link, now time.Time) string {
+
+	switch {
+	case !link.Active:
+		return "disabled"
+	case link.ExpiresAt != nil && !link.ExpiresAt.After(now):
+		return "expired"
+	case link.ActiveFrom != nil && link.ActiveFrom.After(now):
+		return "not live yet"
+	case link.MaxClicks != 0 && link.ClickCount >= link.MaxClicks:
+		return "click limit reached"
+	}
+
+	return "active"
+}
+
+// printLink - Функция, реализующая вывод сведений о ссылке
+func printLink(cmd *cobra.Command, details linkDetails) error {
+
+	link := details.Link
+
+	expires := "never"
+	if link.ExpiresAt != nil {
+		expires = link.ExpiresAt.Local().Format(time.RFC3339)
+	}
+
+	var owner []string
+	if link.UserId != 0 {
+		owner = append(owner, "user "+strconv.Itoa(link.UserId))
+	}
+	if link.WorkspaceId != 0 {
+		owner = append(owner, "workspace "+strconv.Itoa(link.WorkspaceId))
+	}
+	if owner == nil {
+		owner = []string{"-"}
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Short URL:\t%s\n", link.ShortUrl)
+	fmt.Fprintf(w, "Destination:\t%s\n", link.Url)
+	fmt.Fprintf(w, "Status:\t%s\n", details.Status)
+	fmt.Fprintf(w, "Owner:\t%s\n", strings.Join(owner, ", "))
+	fmt.Fprintf(w, "Clicks:\t%d\n", details.Clicks)
+	fmt.Fprintf(w, "Created:\t%s\n", link.CreatedAt.Local().Format(time.RFC3339))
+	fmt.Fprintf(w, "Expires:\t%s\n", expires)
+
+	return w.Flush()
+}
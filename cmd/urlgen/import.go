@@ -0,0 +1,430 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"my_project/urlgen/config"
+	"my_project/urlgen/internal/server"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importColumns - Столбцы файла импорта (столбец url обязателен, метки в столбце tags разделяются ";")
+var importColumns = []string{"url", "alias", "domain", "ttl", "expires_at", "tags", "max_clicks", "redirect_status"}
+
+// importRow - Тип данных, описывающий строку файла импорта
+type importRow struct {
+	line   int      // Номер строки в файле
+	record []string // Значения столбцов
+}
+
+// importSummary - Тип данных, описывающий итог импорта
+type importSummary struct {
+	Rows     int    `json:"rows"`             // Количество прочитанных строк
+	Accepted int    `json:"accepted"`         // Количество созданных (или прошедших проверку) ссылок
+	Rejected int    `json:"rejected"`         // Количество отклоненных строк
+	DryRun   bool   `json:"dry_run"`          // Проверка без создания ссылок
+	Report   string `json:"report,omitempty"` // Файл отклоненных строк
+}
+
+// newImportCommand - Функция, реализующая создание команды import (массовое создание ссылок из CSV)
+func newImportCommand(opts *globalOptions) *cobra.Command {
+
+	var (
+		dryRun    bool
+		report    string
+		batchSize int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import <file.csv | ->",
+		Short: "Create links from a CSV file through the bulk API",
+		Long: "import reads a CSV file with a header row (columns " + strings.Join(importColumns, ", ") +
+			";\nonly url is required, tags are separated by \";\") and creates the links in batches.\n" +
+			"Rejected rows are written to the report file with an error column.",
+		Example: "  urlgen import links.csv\n" +
+			"  urlgen import links.csv --dry-run --report rejected.csv",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if batchSize < 1 || batchSize > config.BulkMaxLinks {
+				return fmt.Errorf("error: --batch-size must be from 1 to %d", config.BulkMaxLinks)
+			}
+
+			in := io.Reader(os.Stdin)
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				in = f
+			}
+
+			if report == "" {
+				report = "import-rejected.csv"
+				if args[0] != "-" {
+					report = strings.TrimSuffix(args[0], ".csv") + ".rejected.csv"
+				}
+			}
+
+			client, err := newClient(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			imp := &importer{client: client, dryRun: dryRun, reportPath: report, progress: cmd.ErrOrStderr(),
+				batchSize: batchSize}
+
+			summary, err := imp.run(cmd, in)
+			if closeErr := imp.closeReport(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+
+			summary.DryRun = dryRun
+			if summary.Rejected != 0 {
+				summary.Report = report
+			}
+
+			return printOutput(cmd, opts, summary, func() error {
+				verb := "created"
+				if dryRun {
+					verb = "valid"
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "%d rows: %d %s, %d rejected\n", summary.Rows, summary.Accepted, verb,
+					summary.Rejected)
+				if summary.Rejected != 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Rejected rows are written to %s\n", report)
+				}
+
+				return nil
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&dryRun, "dry-run", false, "check the rows without creating links")
+	flags.StringVar(&report, "report", "", "file of rejected rows (default <file>.rejected.csv)")
+	flags.IntVar(&batchSize, "batch-size", config.BulkMaxLinks, "links per bulk request")
+
+	return cmd
+}
+
+// importer - Тип данных, реализующий импорт строк CSV через API массового создания ссылок
+type importer struct {
+	client     *apiClient
+	dryRun     bool
+	reportPath string    // Файл отклоненных строк (создается при первой отклоненной строке)
+	progress   io.Writer // Вывод хода импорта
+	batchSize  int       // Количество строк в одном запросе
+
+	// Замена строки, отклоненной с ошибкой reason, для повторной отправки (nil или false - строка отклоняется)
+	fallback func(row importRow, reason string) (importRow, bool)
+
+	// Обработка созданной или уже существующей ссылки строки (nil - без обработки; не вызывается при проверке)
+	accepted func(row importRow, link server.Link) error
+
+	header  []string      // Заголовок файла
+	index   []int         // Номер столбца файла для каждого из importColumns (-1 - столбца нет)
+	report  *os.File      // Открытый файл отклоненных строк
+	csv     *csv.Writer   // Запись отклоненных строк
+	batch   []importRow   // Строки, ожидающие отправки
+	summary importSummary // Итог импорта
+}
+
+// run - Метод, реализующий чтение файла и отправку строк пачками по batchSize
+func (imp *importer) run(cmd *cobra.Command, in io.Reader) (importSummary, error) {
+
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return imp.summary, errors.New("error: the file is empty")
+	}
+	if err != nil {
+		return imp.summary, err
+	}
+
+	if err = imp.setHeader(header); err != nil {
+		return imp.summary, err
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			imp.summary.Rows++
+			imp.summary.Rejected++
+			if err = imp.reject(importRow{line: parseErr.StartLine}, parseErr.Err.Error()); err != nil {
+				return imp.summary, err
+			}
+			continue
+		}
+		if err != nil {
+			return imp.summary, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if err = imp.add(cmd, importRow{line: line, record: record}); err != nil {
+			return imp.summary, err
+		}
+	}
+
+	return imp.summary, imp.flush(cmd)
+}
+
+// add - Метод, реализующий добавление строки в пачку с отправкой заполненной пачки
+func (imp *importer) add(cmd *cobra.Command, row importRow) error {
+
+	imp.summary.Rows++
+	imp.batch = append(imp.batch, row)
+
+	if len(imp.batch) < imp.batchSize {
+		return nil
+	}
+
+	return imp.flush(cmd)
+}
+
+// flush - Метод, реализующий отправку накопленной пачки строк
+func (imp *importer) flush(cmd *cobra.Command) error {
+
+	if len(imp.batch) == 0 {
+		return nil
+	}
+
+	accepted, rejected, err := imp.send(cmd, imp.batch)
+	if err != nil {
+		return err
+	}
+
+	imp.summary.Accepted += accepted
+	imp.summary.Rejected += rejected
+	imp.batch = imp.batch[:0]
+
+	fmt.Fprintf(imp.progress, "%d rows processed, %d rejected\n", imp.summary.Rows, imp.summary.Rejected)
+	return nil
+}
+
+// setHeader - Метод, реализующий сопоставление столбцов файла столбцам импорта
+func (imp *importer) setHeader(header []string) error {
+
+	// Метка порядка байтов, которую добавляют табличные редакторы, не входит в название столбца
+	header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+
+	imp.header = header
+	imp.index = make([]int, len(importColumns))
+
+	for i := range imp.index {
+		imp.index[i] = -1
+	}
+
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		known := false
+		for j, column := range importColumns {
+			if name == column {
+				imp.index[j], known = i, true
+			}
+		}
+
+		if !known {
+			return fmt.Errorf("error: unknown column %q, expected %s", header[i], strings.Join(importColumns, ", "))
+		}
+	}
+
+	if imp.index[0] == -1 {
+		return errors.New("error: the file has no url column")
+	}
+
+	return nil
+}
+
+// value - Метод, возвращающий значение столбца импорта строки файла
+func (imp *importer) value(record []string, column int) string {
+
+	i := imp.index[column]
+	if i == -1 || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// linkRequest - Метод, реализующий получение запроса на создание ссылки из строки файла
+func (imp *importer) linkRequest(record []string) (server.LinkRequest, error) {
+
+	req := server.LinkRequest{
+		Url:    imp.value(record, 0),
+		Alias:  imp.value(record, 1),
+		Domain: imp.value(record, 2),
+		TTL:    imp.value(record, 3),
+	}
+
+	if req.Url == "" {
+		return req, errors.New("url is empty")
+	}
+
+	if v := imp.value(record, 4); v != "" {
+		expiresAt, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return req, errors.New("expires_at must be RFC 3339")
+		}
+		req.ExpiresAt = &expiresAt
+	}
+
+	for _, tag := range strings.Split(imp.value(record, 5), ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			req.Tags = append(req.Tags, tag)
+		}
+	}
+
+	if v := imp.value(record, 6); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return req, errors.New("max_clicks must be an integer")
+		}
+		req.MaxClicks = n
+	}
+
+	if v := imp.value(record, 7); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return req, errors.New("redirect_status must be an integer")
+		}
+		req.RedirectStatus = n
+	}
+
+	return req, nil
+}
+
+// send - Метод, реализующий отправку пачки строк и запись отклоненных строк в отчет
+// (возвращает количество принятых и отклоненных строк)
+func (imp *importer) send(cmd *cobra.Command, batch []importRow) (int, int, error) {
+
+	accepted, rejected := 0, 0
+
+	req := server.BulkLinkRequest{DryRun: imp.dryRun}
+	var sent []importRow
+
+	for _, row := range batch {
+		link, err := imp.linkRequest(row.record)
+		if err != nil {
+			rejected++
+			if err = imp.reject(row, err.Error()); err != nil {
+				return accepted, rejected, err
+			}
+			continue
+		}
+
+		req.Links = append(req.Links, link)
+		sent = append(sent, row)
+	}
+
+	if len(req.Links) == 0 {
+		return accepted, rejected, nil
+	}
+
+	resp := server.BulkLinkResponse{}
+
+	err := imp.client.do(cmd.Context(), http.MethodPost, "/api/v1/links/bulk", req, &resp)
+	if err != nil {
+		return accepted, rejected, fmt.Errorf("%w (rows %d-%d)", err, sent[0].line, sent[len(sent)-1].line)
+	}
+
+	if len(resp.Results) != len(sent) {
+		return accepted, rejected, errors.New("error: unexpected number of results in the bulk response")
+	}
+
+	var retried []importRow
+
+	for i, result := range resp.Results {
+		if result.Error == "" {
+			accepted++
+			if imp.accepted != nil && result.Link != nil {
+				if err = imp.accepted(sent[i], *result.Link); err != nil {
+					return accepted, rejected, err
+				}
+			}
+			continue
+		}
+
+		if imp.fallback != nil {
+			if row, ok := imp.fallback(sent[i], result.Error); ok {
+				retried = append(retried, row)
+				continue
+			}
+		}
+
+		rejected++
+		if err = imp.reject(sent[i], result.Error); err != nil {
+			return accepted, rejected, err
+		}
+	}
+
+	if len(retried) == 0 {
+		return accepted, rejected, nil
+	}
+
+	// Замененные строки отправляются еще раз без повторной замены
+	fallback := imp.fallback
+	imp.fallback = nil
+	defer func() { imp.fallback = fallback }()
+
+	retryAccepted, retryRejected, err := imp.send(cmd, retried)
+
+	return accepted + retryAccepted, rejected + retryRejected, err
+}
+
+// reject - Метод, реализующий запись отклоненной строки в отчет (строка файла, номер строки и ошибка)
+func (imp *importer) reject(row importRow, reason string) error {
+
+	if imp.report == nil {
+		f, err := os.Create(imp.reportPath)
+		if err != nil {
+			return err
+		}
+
+		imp.report, imp.csv = f, csv.NewWriter(f)
+
+		if err = imp.csv.Write(append(append([]string{}, imp.header...), "line", "error")); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(imp.header))
+	copy(record, row.record)
+
+	return imp.csv.Write(append(record, strconv.Itoa(row.line), reason))
+}
+
+// closeReport - Метод, реализующий запись и закрытие файла отклоненных строк
+func (imp *importer) closeReport() error {
+
+	if imp.report == nil {
+		return nil
+	}
+
+	imp.csv.Flush()
+
+	return errors.Join(imp.csv.Error(), imp.report.Close())
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/internal/server"
+	"my_project/urlgen/pkg/bitly"
+	"os"
+	"strings"
+	"time"
+)
+
+// bitlyColumns - Столбцы выгрузки Bitly в CSV, из которых читаются ссылки, по названию столбца
+// (в нижнем регистре, пробелы заменены на "_"; остальные столбцы пропускаются)
+var bitlyColumns = map[string]string{
+	"long_url":        "long_url",
+	"destination":     "long_url",
+	"destination_url": "long_url",
+	"bitlink":         "bitlink",
+	"link":            "bitlink",
+	"short_url":       "bitlink",
+	"short_link":      "bitlink",
+	"custom_bitlink":  "custom",
+	"custom_bitlinks": "custom",
+	"tags":            "tags",
+	"archived":        "archived",
+}
+
+// bitlyImportSummary - Тип данных, описывающий итог переноса ссылок из Bitly
+type bitlyImportSummary struct {
+	importSummary
+	Renamed      int `json:"renamed"`                 // Ссылки, созданные с новым кодом (код Bitly занят или недопустим)
+	Skipped      int `json:"skipped,omitempty"`       // Ссылки из архива, пропущенные без --archived
+	ClickDays    int `json:"click_days,omitempty"`    // Количество перенесенных суток истории переходов
+	Clicks       int `json:"clicks,omitempty"`        // Количество перенесенных переходов
+	ClicksFailed int `json:"clicks_failed,omitempty"` // Ссылки, история переходов которых не получена
+}
+
+// bitlyClickTarget - Тип данных, описывающий созданную ссылку, история переходов которой переносится из Bitly
+type bitlyClickTarget struct {
+	bitlink  string // Ссылка Bitly без схемы ("bit.ly/3abcXyz")
+	shortUrl string // Созданная короткая ссылка
+}
+
+// newImportBitlyCommand - Функция, реализующая создание команды import-bitly (перенос ссылок из Bitly)
+func newImportBitlyCommand(opts *globalOptions) *cobra.Command {
+
+	var (
+		token     string
+		apiUrl    string
+		group     string
+		archived  bool
+		allCodes  bool
+		clicks    bool
+		domain    string
+		dryRun    bool
+		report    string
+		batchSize int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import-bitly [export.csv | -]",
+		Short: "Move links from Bitly through the bulk API",
+		Long: "import-bitly reads the links of a Bitly group through the Bitly API (--bitly-token) or from a\n" +
+			"Bitly CSV export and creates them in batches. Custom back-halves are kept as aliases where\n" +
+			"possible (--all-codes keeps generated ones too); a link whose back-half is taken or invalid\n" +
+			"gets a new code. --clicks also moves the daily click history of the created links from the\n" +
+			"Bitly API; it writes to the database directly and requires --local.",
+		Example: "  urlgen import-bitly --bitly-token $BITLY_TOKEN --dry-run\n" +
+			"  urlgen --local --user admin import-bitly --bitly-token $BITLY_TOKEN --clicks\n" +
+			"  urlgen import-bitly bitly-export.csv --domain go.example.com",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if batchSize < 1 || batchSize > config.BulkMaxLinks {
+				return fmt.Errorf("error: --batch-size must be from 1 to %d", config.BulkMaxLinks)
+			}
+
+			if token == "" && (len(args) == 0 || clicks) {
+				return errors.New("error: --bitly-token or BITLY_TOKEN is required to read links or clicks from Bitly")
+			}
+
+			if clicks && !opts.local {
+				return errors.New("error: --clicks writes to the database directly and requires --local")
+			}
+
+			if report == "" {
+				report = "bitly-rejected.csv"
+				if len(args) != 0 && args[0] != "-" {
+					report = strings.TrimSuffix(args[0], ".csv") + ".rejected.csv"
+				}
+			}
+
+			client, err := newClient(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			api := bitly.ClientCreate(apiUrl, token)
+
+			summary := bitlyImportSummary{}
+			bitlinks := map[int]string{}
+			var targets []bitlyClickTarget
+
+			imp := &importer{client: client, dryRun: dryRun, reportPath: report, progress: cmd.ErrOrStderr(),
+				batchSize: batchSize}
+
+			// Файл отклоненных строк совместим с командой import
+			if err = imp.setHeader(append([]string{}, importColumns...)); err != nil {
+				return err
+			}
+
+			// Ссылка с занятым или недопустимым кодом Bitly создается с новым кодом
+			imp.fallback = func(row importRow, reason string) (importRow, bool) {
+				if row.record[1] == "" || (!strings.Contains(reason, "alias") && reason != "code is reserved") {
+					return row, false
+				}
+
+				summary.Renamed++
+				row.record = append([]string{}, row.record...)
+				row.record[1] = ""
+
+				return row, true
+			}
+
+			// История переходов переносится только для ссылок, созданных этим запуском
+			started := time.Now().Add(-time.Second)
+			imp.accepted = func(row importRow, link server.Link) error {
+				if clicks && bitlinks[row.line] != "" && !link.CreatedAt.Before(started) {
+					targets = append(targets, bitlyClickTarget{bitlink: bitlinks[row.line], shortUrl: link.ShortUrl})
+				}
+				return nil
+			}
+
+			add := func(line int, link bitly.Bitlink) error {
+				bitlinks[line] = bitlyId(link.Id)
+				return imp.add(cmd, importRow{line: line, record: bitlyRecord(link, domain, allCodes)})
+			}
+
+			if len(args) == 0 {
+				err = readBitlyApi(cmd, api, group, archived, add)
+			} else {
+				err = readBitlyExport(cmd, args[0], archived, imp, &summary.Skipped, add)
+			}
+			if err == nil {
+				err = imp.flush(cmd)
+			}
+			if err == nil && len(targets) != 0 {
+				err = importBitlyClicks(cmd, api, client.db, targets, &summary)
+			}
+			if closeErr := imp.closeReport(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+
+			summary.importSummary = imp.summary
+			summary.DryRun = dryRun
+			if summary.Rejected != 0 {
+				summary.Report = report
+			}
+
+			return printOutput(cmd, opts, summary, func() error {
+				verb := "created"
+				if dryRun {
+					verb = "valid"
+				}
+
+				out := cmd.OutOrStdout()
+
+				fmt.Fprintf(out, "%d links: %d %s, %d rejected, %d with a new code\n", summary.Rows, summary.Accepted,
+					verb, summary.Rejected, summary.Renamed)
+				if summary.Skipped != 0 {
+					fmt.Fprintf(out, "%d archived links skipped\n", summary.Skipped)
+				}
+				if clicks && !dryRun {
+					fmt.Fprintf(out, "%d clicks on %d days moved, history of %d links failed\n", summary.Clicks,
+						summary.ClickDays, summary.ClicksFailed)
+				}
+				if summary.Rejected != 0 {
+					fmt.Fprintf(out, "Rejected links are written to %s\n", report)
+				}
+
+				return nil
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&token, "bitly-token", os.Getenv("BITLY_TOKEN"), "Bitly access token (BITLY_TOKEN)")
+	flags.StringVar(&apiUrl, "bitly-api-url", bitly.DefaultApiUrl, "Bitly API address")
+	flags.StringVar(&group, "group", "", "Bitly group guid (default group of the token user if empty)")
+	flags.BoolVar(&archived, "archived", false, "import archived Bitly links too")
+	flags.BoolVar(&allCodes, "all-codes", false, "keep generated Bitly back-halves too, not only custom ones")
+	flags.BoolVar(&clicks, "clicks", false, "move the daily click history of the created links (requires --local)")
+	flags.StringVar(&domain, "domain", "", "branded domain of the created links (default the main domain)")
+	flags.BoolVar(&dryRun, "dry-run", false, "check the links without creating them")
+	flags.StringVar(&report, "report", "", "file of rejected links (default <file>.rejected.csv or bitly-rejected.csv)")
+	flags.IntVar(&batchSize, "batch-size", config.BulkMaxLinks, "links per bulk request")
+
+	return cmd
+}
+
+// readBitlyApi - Функция, реализующая чтение ссылок группы Bitly через API (группа по умолчанию, если не задана)
+func readBitlyApi(cmd *cobra.Command, api *bitly.Client, group string, archived bool,
+	add func(line int, link bitly.Bitlink) error) error {
+
+	if group == "" {
+		var err error
+
+		group, err = api.DefaultGroup(cmd.Context())
+		if err != nil {
+			return err
+		}
+	}
+
+	line := 0
+
+	return api.Bitlinks(cmd.Context(), group, archived, func(link bitly.Bitlink) error {
+		line++
+		return add(line, link)
+	})
+}
+
+// readBitlyExport - Функция, реализующая чтение ссылок из выгрузки Bitly в CSV (или "-" - стандартного ввода)
+// (ссылки из архива без archived пропускаются и учитываются в skipped)
+func readBitlyExport(cmd *cobra.Command, path string, archived bool, imp *importer, skipped *int,
+	add func(line int, link bitly.Bitlink) error) error {
+
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		in = f
+	}
+
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return errors.New("error: the file is empty")
+	}
+	if err != nil {
+		return err
+	}
+
+	header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+		if column, found := bitlyColumns[name]; found {
+			index[column] = i
+		}
+	}
+
+	if _, found := index["long_url"]; !found {
+		return errors.New("error: the file has no long url column, expected a Bitly export")
+	}
+
+	value := func(record []string, column string) string {
+		if i, found := index[column]; found && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	list := func(v string) []string {
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' || r == ' ' })
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			imp.summary.Rows++
+			imp.summary.Rejected++
+			if err = imp.reject(importRow{line: parseErr.StartLine}, parseErr.Err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		link := bitly.Bitlink{
+			Id:             value(record, "bitlink"),
+			LongUrl:        value(record, "long_url"),
+			CustomBitlinks: list(value(record, "custom")),
+			Tags:           strings.FieldsFunc(value(record, "tags"), func(r rune) bool { return r == ',' || r == ';' }),
+			Archived:       strings.EqualFold(value(record, "archived"), "true"),
+		}
+
+		if link.Archived && !archived {
+			*skipped++
+			continue
+		}
+
+		if err = add(line, link); err != nil {
+			return err
+		}
+	}
+}
+
+// bitlyRecord - Функция, реализующая получение строки импорта (столбцы importColumns) из ссылки Bitly
+// (кодом становится первый пользовательский код Bitly, с allCodes - иначе и сгенерированный)
+func bitlyRecord(link bitly.Bitlink, domain string, allCodes bool) []string {
+
+	record := make([]string, len(importColumns))
+	record[0] = link.LongUrl
+	record[2] = domain
+
+	for _, custom := range link.CustomBitlinks {
+		if backHalf := bitlyBackHalf(custom); backHalf != "" {
+			record[1] = backHalf
+			break
+		}
+	}
+
+	if record[1] == "" && allCodes {
+		record[1] = bitlyBackHalf(link.Id)
+	}
+
+	tags := make([]string, 0, len(link.Tags))
+	for _, tag := range link.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	record[5] = strings.Join(tags, ";")
+
+	return record
+}
+
+// bitlyId - Функция, возвращающая ссылку Bitly без схемы и завершающей косой черты ("bit.ly/3abcXyz")
+func bitlyId(link string) string {
+
+	link = strings.TrimPrefix(strings.TrimPrefix(link, "https://"), "http://")
+
+	return strings.TrimSuffix(link, "/")
+}
+
+// bitlyBackHalf - Функция, возвращающая код ссылки Bitly (часть после домена)
+func bitlyBackHalf(link string) string {
+
+	_, backHalf, _ := strings.Cut(bitlyId(link), "/")
+
+	return backHalf
+}
+
+// importBitlyClicks - Функция, реализующая перенос суточной истории переходов созданных ссылок из Bitly в БД
+// (ошибка получения истории одной ссылки выводится и не прерывает перенос)
+func importBitlyClicks(cmd *cobra.Command, api *bitly.Client, db *database.Database, targets []bitlyClickTarget,
+	summary *bitlyImportSummary) error {
+
+	progress := cmd.ErrOrStderr()
+
+	for i, target := range targets {
+		counts, err := api.DailyClicks(cmd.Context(), target.bitlink)
+		if err != nil {
+			if cmd.Context().Err() != nil {
+				return cmd.Context().Err()
+			}
+
+			summary.ClicksFailed++
+			fmt.Fprintf(progress, "Failed to read clicks of %s: %v\n", target.bitlink, err)
+			continue
+		}
+
+		for _, c := range counts {
+			err = db.ImportClicks(cmd.Context(), database.ClickAggregate{ShortUrl: target.shortUrl, Day: c.Day,
+				Clicks: c.Clicks})
+			if err != nil {
+				return fmt.Errorf("error: failed to write clicks of %s: %w", target.shortUrl, err)
+			}
+
+			summary.ClickDays++
+			summary.Clicks += c.Clicks
+		}
+
+		if (i+1)%100 == 0 || i == len(targets)-1 {
+			fmt.Fprintf(progress, "Click history of %d of %d links moved\n", i+1, len(targets))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"my_project/urlgen/config"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Главная функция командной строки
+func main() {
+
+	// Прерывание команды по сигналам SIGINT и SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// globalOptions - Тип данных, описывающий общие флаги команд
+type globalOptions struct {
+	apiUrl     string // Адрес сервера (URLGEN_API_URL)
+	token      string // JWT или ключ API (URLGEN_TOKEN)
+	workspace  string // Рабочее пространство (URLGEN_WORKSPACE, пустая строка - пространство по умолчанию)
+	local      bool   // Работать с БД напрямую, без сервера
+	user       string // Пользователь, от имени которого выполняются команды в локальном режиме
+	configFile string // Файл настроек сервера для локального режима
+	output     string // Формат вывода: table, json или yaml (URLGEN_OUTPUT)
+}
+
+// newRootCommand - Функция, реализующая создание корневой команды urlgen
+func newRootCommand() *cobra.Command {
+
+	opts := &globalOptions{}
+
+	root := &cobra.Command{
+		Use:   "urlgen",
+		Short: "Run the short link server and manage short links from the command line",
+		Long: "urlgen runs the server (serve) and manages short links through its API (--api-url\n" +
+			"and --token) or directly in the database with --local, using the server settings.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateOutput(opts.output)
+		},
+	}
+
+	apiUrl := os.Getenv("URLGEN_API_URL")
+	if apiUrl == "" {
+		apiUrl = "http://localhost" + config.ServerPort
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.apiUrl, "api-url", apiUrl, "server address (URLGEN_API_URL)")
+	flags.StringVar(&opts.token, "token", os.Getenv("URLGEN_TOKEN"), "access token or API key (URLGEN_TOKEN)")
+	flags.StringVar(&opts.workspace, "workspace", os.Getenv("URLGEN_WORKSPACE"),
+		"workspace id, default workspace if empty (URLGEN_WORKSPACE)")
+	flags.BoolVar(&opts.local, "local", false, "work with the database directly instead of the server")
+	flags.StringVar(&opts.user, "user", os.Getenv("ADMIN_USERNAME"),
+		"user to act as in local mode (ADMIN_USERNAME)")
+	flags.StringVar(&opts.configFile, "config", os.Getenv("CONFIG_FILE"),
+		"server configuration file (CONFIG_FILE)")
+
+	output := os.Getenv("URLGEN_OUTPUT")
+	if output == "" {
+		output = outputTable
+	}
+
+	flags.StringVarP(&opts.output, "output", "o", output, "output format: table, json or yaml (URLGEN_OUTPUT)")
+
+	_ = root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	root.AddCommand(
+		newServeCommand(opts),
+		newInitCommand(opts),
+		newShortenCommand(opts),
+		newExpandCommand(opts),
+		newImportCommand(opts),
+		newImportBitlyCommand(opts),
+		newStatsCommand(opts),
+		newPurgeCommand(opts),
+		newMigrateCommand(opts),
+		newBackupCommand(opts),
+		newRestoreCommand(opts),
+		newTuiCommand(opts),
+	)
+
+	return root
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"my_project/urlgen/database"
+	"text/tabwriter"
+	"time"
+)
+
+// migrationInfo - Тип данных, описывающий миграцию в выводе команды migrate
+type migrationInfo struct {
+	Version   int        `json:"version"`              // Номер миграции
+	Name      string     `json:"name"`                 // Название миграции
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // Время применения (nil - не применена)
+}
+
+// migrationInfos - Функция, возвращающая сведения о миграциях для вывода
+func migrationInfos(migrations []database.Migration) []migrationInfo {
+
+	infos := make([]migrationInfo, 0, len(migrations))
+	for _, m := range migrations {
+		infos = append(infos, migrationInfo{Version: m.Version, Name: m.Name, AppliedAt: m.AppliedAt})
+	}
+
+	return infos
+}
+
+// newMigrateCommand - Функция, реализующая создание команды migrate (управление схемой БД встроенными миграциями)
+func newMigrateCommand(opts *globalOptions) *cobra.Command {
+
+	var dsn string
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply, revert or list the database schema migrations",
+		Long: "migrate manages the database schema with the migrations built into urlgen.\n" +
+			"The database is taken from the server settings (--config or DATABASE_URL) or from --dsn.",
+	}
+
+	cmd.PersistentFlags().StringVar(&dsn, "dsn", "", "PostgreSQL connection string instead of the server settings")
+
+	// Подключение к БД по --dsn или по настройкам сервера
+	open := func() (*database.Database, error) {
+		if dsn != "" {
+			db, _, err := connectDatabase(dsn)
+			return db, err
+		}
+
+		db, _, err := openDatabase(opts)
+		return db, err
+	}
+
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			db, err := open()
+			if err != nil {
+				return err
+			}
+			defer db.CloseConnection()
+
+			applied, err := db.MigrateUp(cmd.Context())
+
+			// Миграции, примененные до ошибки, выводятся вместе с ней
+			outErr := printOutput(cmd, opts, migrationInfos(applied), func() error {
+				for _, m := range applied {
+					fmt.Fprintf(cmd.OutOrStdout(), "Applied %04d_%s\n", m.Version, m.Name)
+				}
+				if err == nil && len(applied) == 0 {
+					fmt.Fprintln(cmd.OutOrStdout(), "The schema is up to date")
+				}
+
+				return nil
+			})
+
+			return errors.Join(err, outErr)
+		},
+	}
+
+	var (
+		steps int
+		yes   bool
+	)
+
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revert the last applied migrations",
+		Long: "down reverts the last --steps applied migrations (one by default).\n" +
+			"Reverting the first migration drops every table with all links.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if steps < 1 {
+				return errors.New("error: --steps must be a positive number")
+			}
+
+			db, err := open()
+			if err != nil {
+				return err
+			}
+			defer db.CloseConnection()
+
+			question := fmt.Sprintf("Revert the last %d migration(s)? Reverted changes lose their data", steps)
+			if !yes && !confirm(cmd, question) {
+				return errors.New("error: aborted")
+			}
+
+			reverted, err := db.MigrateDown(cmd.Context(), steps)
+
+			// Миграции, примененные до ошибки, выводятся вместе с ней
+			outErr := printOutput(cmd, opts, migrationInfos(reverted), func() error {
+				for _, m := range reverted {
+					fmt.Fprintf(cmd.OutOrStdout(), "Reverted %04d_%s\n", m.Version, m.Name)
+				}
+				if err == nil && len(reverted) == 0 {
+					fmt.Fprintln(cmd.OutOrStdout(), "No applied migrations")
+				}
+
+				return nil
+			})
+
+			return errors.Join(err, outErr)
+		},
+	}
+
+	down.Flags().IntVar(&steps, "steps", 1, "number of migrations to revert")
+	down.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation")
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "List the migrations and when they were applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			db, err := open()
+			if err != nil {
+				return err
+			}
+			defer db.CloseConnection()
+
+			migrations, err := db.MigrationStatus(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, opts, migrationInfos(migrations), func() error {
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+				fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+				for _, m := range migrations {
+					applied := "pending"
+					if m.AppliedAt != nil {
+						applied = m.AppliedAt.Local().Format(time.RFC3339)
+					}
+
+					fmt.Fprintf(w, "%04d\t%s\t%s\n", m.Version, m.Name, applied)
+				}
+
+				return w.Flush()
+			})
+		},
+	}
+
+	cmd.AddCommand(up, down, status)
+
+	return cmd
+}
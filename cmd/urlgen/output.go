@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Форматы вывода команд (флаг --output)
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// outputFormats - Допустимые значения флага --output
+var outputFormats = []string{outputTable, outputJSON, outputYAML}
+
+// validateOutput - Функция, проверяющая значение флага --output
+func validateOutput(format string) error {
+
+	for _, f := range outputFormats {
+		if format == f {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("error: --output must be one of table, json or yaml, not %q", format)
+}
+
+// printOutput - Функция, реализующая вывод результата команды в формате --output
+// (table - функцией table, json и yaml - значения v с названиями полей из тегов json)
+func printOutput(cmd *cobra.Command, opts *globalOptions, v any, table func() error) error {
+
+	switch opts.output {
+	case outputJSON:
+		return printJSON(cmd, v)
+	case outputYAML:
+		return printYAML(cmd, v)
+	}
+
+	return table()
+}
+
+// printJSON - Функция, реализующая вывод значения в JSON с отступами
+func printJSON(cmd *cobra.Command, v any) error {
+
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// printYAML - Функция, реализующая вывод значения в YAML
+// (значение проходит через JSON, чтобы поля назывались так же, как в API, и сохраняли порядок)
+func printYAML(cmd *cobra.Command, v any) error {
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON - подмножество YAML, поэтому разбирается в дерево узлов с исходным порядком ключей
+	node := yaml.Node{}
+	if err = yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+
+	if err = encoder.Encode(&node); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// resetStyle - Функция, реализующая замену стиля JSON узлов YAML (скобки и кавычки) на обычный
+func resetStyle(node *yaml.Node) {
+
+	node.Style = 0
+
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
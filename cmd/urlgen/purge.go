@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"my_project/urlgen/config"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// purgeTarget - Тип данных, описывающий вид ссылок, удаляемых командой purge
+type purgeTarget struct {
+	kind  string                                                     // Вид ссылок в выводе json и yaml
+	name  string                                                     // Название в сводке
+	count func(ctx context.Context, before time.Time) (int64, error) // Подсчет ссылок
+	purge func(ctx context.Context, before time.Time) (int64, error) // Удаление ссылок
+}
+
+// purgeResult - Тип данных, описывающий итог удаления ссылок одного вида
+type purgeResult struct {
+	Kind    string `json:"kind"`    // Вид ссылок ("expired" или "soft_deleted")
+	Found   int64  `json:"found"`   // Количество найденных ссылок
+	Deleted int64  `json:"deleted"` // Количество удаленных ссылок
+}
+
+// newPurgeCommand - Функция, реализующая создание команды purge (окончательное удаление ссылок из БД)
+func newPurgeCommand(opts *globalOptions) *cobra.Command {
+
+	var (
+		expired     bool
+		softDeleted bool
+		olderThan   string
+		yes         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Delete expired and soft-deleted links with their clicks for good",
+		Long: "purge works with the database directly (server settings come from --config or the\n" +
+			"environment): it counts the links to delete, asks for confirmation and deletes them\n" +
+			"with their clicks. --older-than keeps links that expired or were deleted recently.",
+		Example: "  urlgen purge --expired --soft-deleted --older-than 90d\n" +
+			"  urlgen purge --soft-deleted --yes",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if !expired && !softDeleted {
+				return errors.New("error: nothing to purge, use --expired and/or --soft-deleted")
+			}
+
+			var age time.Duration
+			if olderThan != "" {
+				var err error
+
+				age, err = config.ParseRetention(olderThan)
+				if err != nil || age < 0 {
+					return errors.New("error: --older-than must be a duration such as \"720h\" or \"90d\"")
+				}
+			}
+
+			db, _, err := openDatabase(opts)
+			if err != nil {
+				return err
+			}
+			defer db.CloseConnection()
+
+			var targets []purgeTarget
+			if expired {
+				targets = append(targets, purgeTarget{"expired", "Expired links", db.CountExpiredRows, db.PurgeExpiredRows})
+			}
+			if softDeleted {
+				targets = append(targets, purgeTarget{"soft_deleted", "Soft-deleted links", db.CountSoftDeletedRows,
+					db.PurgeSoftDeletedRows})
+			}
+
+			before := time.Now().Add(-age)
+			results := make([]purgeResult, len(targets))
+
+			// При выводе json и yaml сводка перед подтверждением выводится в поток ошибок
+			out := cmd.OutOrStdout()
+			if opts.output != outputTable {
+				out = cmd.ErrOrStderr()
+			}
+
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+			var total int64
+			for i, t := range targets {
+				results[i].Kind = t.kind
+				if results[i].Found, err = t.count(cmd.Context(), before); err != nil {
+					return err
+				}
+
+				total += results[i].Found
+				fmt.Fprintf(w, "%s:\t%d\n", t.name, results[i].Found)
+			}
+
+			if err = w.Flush(); err != nil {
+				return err
+			}
+
+			if total == 0 {
+				return printOutput(cmd, opts, results, func() error {
+					_, err := fmt.Fprintln(cmd.OutOrStdout(), "Nothing to purge")
+					return err
+				})
+			}
+
+			if !yes && !confirm(cmd, fmt.Sprintf("Delete %d links and their clicks for good?", total)) {
+				return errors.New("error: aborted")
+			}
+
+			for i, t := range targets {
+				if results[i].Found == 0 {
+					continue
+				}
+
+				results[i].Deleted, err = t.purge(cmd.Context(), before)
+				if err != nil {
+					return fmt.Errorf("error: failed to purge %s after %d deleted: %w", strings.ToLower(t.name),
+						results[i].Deleted, err)
+				}
+			}
+
+			return printOutput(cmd, opts, results, func() error {
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				for i, t := range targets {
+					if results[i].Found != 0 {
+						fmt.Fprintf(w, "%s deleted:\t%d\n", t.name, results[i].Deleted)
+					}
+				}
+
+				return w.Flush()
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&expired, "expired", false, "delete links whose expiry has passed")
+	flags.BoolVar(&softDeleted, "soft-deleted", false, "delete links removed through the API")
+	flags.StringVar(&olderThan, "older-than", "", "only links expired or deleted longer ago, e.g. 90d")
+	flags.BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation")
+
+	return cmd
+}
+
+// confirm - Функция, реализующая запрос подтверждения (согласие - ответ "y" или "yes")
+func confirm(cmd *cobra.Command, question string) bool {
+
+	fmt.Fprint(cmd.ErrOrStderr(), question+" [y/N] ")
+
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
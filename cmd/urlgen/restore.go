@@ -0,0 +1,445 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/redis"
+	"my_project/urlgen/pkg/s3"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// restoreLatest - Аргумент команды restore, выбирающий новейший полный снимок
+const restoreLatest = "latest"
+
+// restoreConflicts - Допустимые значения флага --on-conflict
+var restoreConflicts = []string{database.RestoreFail, database.RestoreSkip, database.RestoreOverwrite}
+
+// raiseCodeCounterScript - Скрипт Redis, поднимающий существующий счетчик кодов до ARGV[1]
+// (отсутствующий счетчик сервер создает сам по последнему идентификатору строк)
+const raiseCodeCounterScript = `
+local v = redis.call('GET', KEYS[1])
+if v and tonumber(v) < tonumber(ARGV[1]) then
+  redis.call('SET', KEYS[1], ARGV[1])
+  return 1
+end
+return 0
+`
+
+// restoreResult - Тип данных, описывающий итог восстановления из снимка
+type restoreResult struct {
+	Snapshot        string `json:"snapshot"`         // Идентификатор снимка
+	CheckOnly       bool   `json:"check_only"`       // Только проверка снимка без восстановления
+	Links           int    `json:"links"`            // Количество ссылок в снимке
+	Restored        int    `json:"restored"`         // Количество записанных ссылок
+	Replaced        int    `json:"replaced"`         // Количество замененных ссылок БД
+	Skipped         int    `json:"skipped"`          // Количество ссылок, пропущенных из-за совпадения
+	Detached        int    `json:"detached"`         // Количество ссылок без пользователя, пространства или ключа API в БД
+	ClickAggregates int    `json:"click_aggregates"` // Количество восстановленных суточных счетчиков переходов
+}
+
+// newRestoreCommand - Функция, реализующая создание команды restore (восстановление ссылок и переходов из снимка)
+func newRestoreCommand(opts *globalOptions) *cobra.Command {
+
+	var (
+		conflict  string
+		checkOnly bool
+		yes       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore <snapshot | latest>",
+		Short: "Restore links and daily click counts from a backup snapshot",
+		Long: "restore downloads a snapshot made by backup from the BACKUP_S3_BUCKET bucket, checks it\n" +
+			"(manifest, file hashes, record counts, schema version) and writes it to the database in one\n" +
+			"transaction. Links that already exist fail the restore unless --on-conflict is skip or overwrite.",
+		Example: "  urlgen restore latest --check\n" +
+			"  urlgen restore 20261014T030000Z --on-conflict skip",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			valid := false
+			for _, c := range restoreConflicts {
+				valid = valid || conflict == c
+			}
+			if !valid {
+				return fmt.Errorf("error: --on-conflict must be one of fail, skip or overwrite, not %q", conflict)
+			}
+
+			// Настройки хранилища читаются вместе с настройками сервера
+			db, _, err := openDatabase(opts)
+			if err != nil {
+				return err
+			}
+			defer db.CloseConnection()
+
+			store, err := backupStoreFromEnv()
+			if err != nil {
+				return err
+			}
+
+			snapshot, manifest, err := readBackupManifest(cmd.Context(), store, args[0])
+			if err != nil {
+				return err
+			}
+
+			files, err := downloadBackupFiles(cmd.Context(), store, snapshot, manifest)
+			for _, path := range files {
+				defer os.Remove(path)
+			}
+			if err != nil {
+				return err
+			}
+
+			result := restoreResult{Snapshot: snapshot, CheckOnly: checkOnly}
+			for _, f := range manifest.Files {
+				if f.Name == backupLinksFile {
+					result.Links = f.Records
+				}
+			}
+
+			if !checkOnly {
+				if err = requireSchemaUpToDate(cmd.Context(), db); err != nil {
+					return err
+				}
+
+				question := fmt.Sprintf("Restore %d links from snapshot %s (on conflict: %s)?", result.Links, snapshot,
+					conflict)
+				if !yes && !confirm(cmd, question) {
+					return errors.New("error: aborted")
+				}
+
+				if err = restoreBackup(cmd.Context(), db, files, conflict, &result); err != nil {
+					return err
+				}
+
+				if err = raiseCodeCounter(cmd.Context(), db); err != nil {
+					return err
+				}
+			}
+
+			return printOutput(cmd, opts, result, func() error {
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintf(w, "Snapshot:\t%s\n", result.Snapshot)
+				fmt.Fprintf(w, "Links:\t%d\n", result.Links)
+
+				if checkOnly {
+					fmt.Fprintln(w, "Check:\tok")
+					return w.Flush()
+				}
+
+				fmt.Fprintf(w, "Restored:\t%d\n", result.Restored)
+				fmt.Fprintf(w, "Replaced:\t%d\n", result.Replaced)
+				fmt.Fprintf(w, "Skipped:\t%d\n", result.Skipped)
+				fmt.Fprintf(w, "Without owner:\t%d\n", result.Detached)
+				fmt.Fprintf(w, "Daily click counts:\t%d\n", result.ClickAggregates)
+
+				return w.Flush()
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&conflict, "on-conflict", database.RestoreFail, "existing links: fail, skip or overwrite")
+	flags.BoolVar(&checkOnly, "check", false, "only check the snapshot, do not restore it")
+	flags.BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation")
+
+	_ = cmd.RegisterFlagCompletionFunc("on-conflict", cobra.FixedCompletions(restoreConflicts,
+		cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+// readBackupManifest - Функция, позволяющая получить описание снимка (latest - новейшего полного снимка)
+// и проверить, что его формат и версия схемы поддерживаются
+func readBackupManifest(ctx context.Context, store *backupStore, snapshot string) (string, *backupManifest, error) {
+
+	if snapshot == restoreLatest {
+		ids, _, complete, err := store.snapshots(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+
+		snapshot = ""
+		for _, id := range ids {
+			if complete[id] {
+				snapshot = id
+			}
+		}
+
+		if snapshot == "" {
+			return "", nil, errors.New("error: no complete backup snapshots found")
+		}
+	}
+
+	body, err := store.client.Get(ctx, store.key(snapshot, backupManifestFile))
+	if errors.Is(err, s3.ErrNotFound) {
+		return "", nil, fmt.Errorf("error: snapshot %s not found or incomplete (no %s)", snapshot, backupManifestFile)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	defer body.Close()
+
+	manifest := backupManifest{}
+	if err = json.NewDecoder(body).Decode(&manifest); err != nil {
+		return "", nil, fmt.Errorf("error: invalid manifest of snapshot %s: %w", snapshot, err)
+	}
+
+	if manifest.FormatVersion != config.BackupFormatVersion {
+		return "", nil, fmt.Errorf("error: snapshot %s has format version %d, this urlgen reads version %d",
+			snapshot, manifest.FormatVersion, config.BackupFormatVersion)
+	}
+
+	migrations, err := database.Migrations()
+	if err != nil {
+		return "", nil, err
+	}
+	if n := len(migrations); n == 0 || manifest.SchemaVersion > migrations[n-1].Version {
+		return "", nil, fmt.Errorf("error: snapshot %s was made with schema version %d by urlgen %s, upgrade urlgen "+
+			"to restore it", snapshot, manifest.SchemaVersion, manifest.AppVersion)
+	}
+
+	names := map[string]bool{}
+	for _, f := range manifest.Files {
+		names[f.Name] = true
+	}
+	if !names[backupLinksFile] || !names[backupClicksFile] {
+		return "", nil, fmt.Errorf("error: manifest of snapshot %s does not list %s and %s", snapshot,
+			backupLinksFile, backupClicksFile)
+	}
+
+	return snapshot, &manifest, nil
+}
+
+// downloadBackupFiles - Функция, реализующая загрузку файлов снимка во временные файлы с проверкой размера,
+// хеша и количества записей (возвращает пути загруженных файлов по имени, в том числе при ошибке)
+func downloadBackupFiles(ctx context.Context, store *backupStore, snapshot string,
+	manifest *backupManifest) (map[string]string, error) {
+
+	files := map[string]string{}
+
+	for _, f := range manifest.Files {
+		tmp, err := os.CreateTemp("", "urlgen-restore-*")
+		if err != nil {
+			return files, err
+		}
+		files[f.Name] = tmp.Name()
+
+		err = downloadBackupFile(ctx, store, store.key(snapshot, f.Name), tmp)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return files, fmt.Errorf("error: failed to download %s: %w", f.Name, err)
+		}
+
+		info, err := os.Stat(tmp.Name())
+		if err != nil {
+			return files, err
+		}
+
+		sum, err := fileSHA256(tmp.Name())
+		if err != nil {
+			return files, err
+		}
+
+		if info.Size() != f.Size || sum != f.SHA256 {
+			return files, fmt.Errorf("error: %s of snapshot %s is damaged (size or SHA-256 does not match the manifest)",
+				f.Name, snapshot)
+		}
+
+		records, err := decodeBackupFile(tmp.Name(), func(json.RawMessage) error { return nil })
+		if err != nil {
+			return files, fmt.Errorf("error: %s of snapshot %s is damaged: %w", f.Name, snapshot, err)
+		}
+		if records != f.Records {
+			return files, fmt.Errorf("error: %s of snapshot %s has %d records, the manifest lists %d", f.Name,
+				snapshot, records, f.Records)
+		}
+	}
+
+	return files, nil
+}
+
+// downloadBackupFile - Функция, реализующая загрузку объекта хранилища в файл
+func downloadBackupFile(ctx context.Context, store *backupStore, key string, w io.Writer) error {
+
+	body, err := store.client.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	_, err = io.Copy(w, body)
+
+	return err
+}
+
+// fileSHA256 - Функция, возвращающая SHA-256 файла
+func fileSHA256(path string) (string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// decodeBackupFile - Функция, реализующая последовательное чтение записей сжатого файла снимка
+// (возвращает количество прочитанных записей; ошибка fn прерывает чтение)
+func decodeBackupFile[T any](path string, fn func(T) error) (int, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+
+	decoder := json.NewDecoder(zr)
+
+	records := 0
+	for {
+		var v T
+
+		err = decoder.Decode(&v)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+
+		records++
+		if err = fn(v); err != nil {
+			return records, err
+		}
+	}
+}
+
+// requireSchemaUpToDate - Функция, проверяющая, что к БД применены все миграции (снимок пишется в текущую схему)
+func requireSchemaUpToDate(ctx context.Context, db *database.Database) error {
+
+	migrations, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.AppliedAt == nil {
+			return errors.New("error: the database schema is not up to date, run \"urlgen migrate up\" first")
+		}
+	}
+
+	return nil
+}
+
+// restoreBackup - Функция, реализующая запись ссылок и переходов снимка в БД в одной транзакции
+// (переходы восстанавливаются только для записанных ссылок)
+func restoreBackup(ctx context.Context, db *database.Database, files map[string]string, conflict string,
+	result *restoreResult) error {
+
+	restore, err := db.BeginRestore(ctx, conflict)
+	if err != nil {
+		return err
+	}
+	defer restore.Rollback(ctx)
+
+	restored := map[string]bool{}
+
+	_, err = decodeBackupFile(files[backupLinksFile], func(row database.RowData) error {
+		outcome, err := restore.RestoreRow(ctx, row)
+		if err != nil {
+			return fmt.Errorf("error: failed to restore %s: %w", row.ShortUrl, err)
+		}
+
+		if !outcome.Restored {
+			if conflict == database.RestoreFail {
+				return fmt.Errorf("error: %s already exists, nothing was restored (use --on-conflict skip or "+
+					"overwrite)", row.ShortUrl)
+			}
+
+			result.Skipped++
+			return nil
+		}
+
+		restored[row.ShortUrl] = true
+		result.Restored++
+		if outcome.Replaced {
+			result.Replaced++
+		}
+		if outcome.Detached {
+			result.Detached++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = decodeBackupFile(files[backupClicksFile], func(a database.ClickAggregate) error {
+		if !restored[a.ShortUrl] {
+			return nil
+		}
+
+		result.ClickAggregates++
+		return restore.RestoreClicks(ctx, a)
+	})
+	if err != nil {
+		return fmt.Errorf("error: failed to restore clicks, nothing was restored: %w", err)
+	}
+
+	return restore.Commit(ctx)
+}
+
+// raiseCodeCounter - Функция, реализующая подъем счетчика кодов в Redis (REDIS_URL) до последнего
+// идентификатора строк, чтобы стратегия "redis" не выдала коды восстановленных ссылок повторно
+func raiseCodeCounter(ctx context.Context, db *database.Database) error {
+
+	rawUrl := os.Getenv("REDIS_URL")
+	if rawUrl == "" {
+		return nil
+	}
+
+	client, err := redis.ClientCreate(rawUrl)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	last, err := db.LastRowId(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Do(ctx, "EVAL", raiseCodeCounterScript, "1", config.CodeCounterKey, strconv.Itoa(last))
+	if err != nil {
+		return fmt.Errorf("error: links were restored, but the code counter in Redis was not raised: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/internal/server"
+	applog "my_project/urlgen/pkg/logger"
+	"my_project/urlgen/pkg/tracing"
+	"my_project/urlgen/pkg/upgrade"
+	"my_project/urlgen/pkg/version"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// newServeCommand - Функция, реализующая создание команды serve (запуск сервера)
+// (каждая настройка config.Settings доступна флагом, например --server-addr или --log-level)
+func newServeCommand(opts *globalOptions) *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the short link server",
+		Long: "serve runs the server with the settings from --config, the environment and the flags below;\n" +
+			"a flag wins over the variable, the variable over the file. SIGHUP reloads the file,\n" +
+			"SIGUSR1 switches logging to debug for 15 minutes (a second SIGUSR1 switches it back),\n" +
+			"SIGUSR2 starts the current binary on the same sockets and stops this process once it serves,\n" +
+			"SIGINT and SIGTERM drain the requests in flight and stop the server.",
+		Example: "  urlgen serve --config urlgen.yaml\n" +
+			"  urlgen serve --server-addr :8080 --log-level debug\n" +
+			"  urlgen serve --tls-acme-domains ex.mpl --tls-acme-email ops@ex.mpl --auth-signup-enabled",
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+	for _, s := range config.Settings {
+		usage := s.Description + " (" + s.Env + ")"
+		if s.Default != "" {
+			usage += ", default " + s.Default
+		}
+
+		flags.String(s.Flag(), "", usage)
+		if s.Toggle() {
+			flags.Lookup(s.Flag()).NoOptDefVal = "true"
+		}
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+
+		// Заданные флаги передаются загрузке настроек в том виде, в котором их принимает config.Load
+		var loadArgs []string
+		if opts.configFile != "" {
+			loadArgs = append(loadArgs, "-config", opts.configFile)
+		}
+		flags.Visit(func(f *pflag.Flag) {
+			loadArgs = append(loadArgs, "-"+f.Name, f.Value.String())
+		})
+
+		return runServer(cmd.Context(), loadArgs)
+	}
+
+	return cmd
+}
+
+// runServer - Функция, реализующая запуск сервера до отмены контекста (сигналов SIGINT и SIGTERM)
+// или до готовности нового процесса, запущенного обновлением
+func runServer(ctx context.Context, args []string) error {
+
+	// Сокеты, переданные прежним процессом при обновлении, забираются до запуска остальных компонентов
+	upgrader, err := upgrade.UpgraderCreate()
+	if err != nil {
+		return err
+	}
+
+	// Загрузка настроек из файла, переменных окружения и флагов
+	settings, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	// Создание журнала
+	logger, level, err := applog.FromEnv()
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	if settings.File != "" {
+		logger.Info("Configuration file loaded", "file", settings.File)
+	}
+
+	// Настройка трассировки (спаны отправляются сборщику OTLP, если он задан)
+	shutdownTracing, err := tracing.FromEnv(ctx)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		return err
+	}
+
+	// Подключение к БД
+	db, err := database.GetConnection(logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		return err
+	}
+	defer func() {
+		err = db.CloseConnection()
+		if err != nil {
+			logger.Error("Failed to close database", "error", err)
+		}
+	}()
+
+	// Создание первоначального пользователя, если он задан
+	if username := os.Getenv("ADMIN_USERNAME"); username != "" {
+		if os.Getenv("ADMIN_PASSWORD") == "" {
+			err = errors.New("error: ADMIN_PASSWORD is required when ADMIN_USERNAME is set")
+			logger.Error("Failed to create admin user", "error", err)
+			return err
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(os.Getenv("ADMIN_PASSWORD")), bcrypt.DefaultCost)
+		if err != nil {
+			logger.Error("Failed to hash admin password", "error", err)
+			return err
+		}
+
+		err = db.EnsureAdmin(ctx, username, string(hash))
+		if err != nil {
+			logger.Error("Failed to create admin user", "error", err)
+			return err
+		}
+	}
+
+	// Создание сервера
+	newServer, err := server.NewServer(&db, logger, ctx)
+	if err != nil {
+		logger.Error("Failed to create server", "error", err)
+		return err
+	}
+
+	// Временное изменение уровня журнала через API администратора и по сигналу SIGUSR1
+	levels := applog.LevelCreate(level)
+	newServer.UseLogLevel(levels)
+	go debugOnSignal(ctx, levels, logger)
+
+	// Применение изменяемых настроек по сигналу SIGHUP
+	go reloadOnSignal(ctx, settings, levels, newServer, logger)
+
+	httpServer := &http.Server{
+		Addr:    settings.Get("server.addr"),
+		Handler: newServer.Handler(),
+	}
+	servers := []*http.Server{httpServer}
+
+	tlsSettings := server.TLSSettingsFromEnv()
+
+	var serve func(*http.Server, net.Listener) error
+	switch {
+	case tlsSettings.Manual():
+		// Сертификат и ключ заданы вручную
+		serve = func(srv *http.Server, l net.Listener) error {
+			return srv.ServeTLS(l, tlsSettings.CertFile, tlsSettings.KeyFile)
+		}
+	case tlsSettings.Auto():
+		// Автоматическое получение сертификатов для доменов по протоколу ACME
+		tlsConfig, challengeHandler := tlsSettings.AutocertManager()
+
+		httpServer.Addr = config.HTTPSPort
+		httpServer.TLSConfig = tlsConfig
+
+		servers = append(servers, &http.Server{
+			Addr:    config.HTTPPort,
+			Handler: challengeHandler,
+		})
+
+		serve = func(srv *http.Server, l net.Listener) error {
+			if srv.TLSConfig != nil {
+				return srv.ServeTLS(l, "", "")
+			}
+			return srv.Serve(l)
+		}
+	default:
+		serve = func(srv *http.Server, l net.Listener) error {
+			return srv.Serve(l)
+		}
+	}
+
+	// Отладочный сервер (pprof и expvar) всегда без TLS на отдельном адресе
+	if debug := server.DebugSettingsFromEnv(); debug.Addr != "" {
+		debugServer := &http.Server{
+			Addr:    debug.Addr,
+			Handler: debug.Handler(),
+		}
+		servers = append(servers, debugServer)
+
+		serveMain := serve
+		serve = func(srv *http.Server, l net.Listener) error {
+			if srv == debugServer {
+				return srv.Serve(l)
+			}
+			return serveMain(srv, l)
+		}
+
+		logger.Info("Debug server started", "addr", debug.Addr, "authenticated", debug.Token != "")
+		if debug.Token == "" {
+			logger.Warn("Debug server has no DEBUG_TOKEN, keep its address private")
+		}
+	}
+
+	// Открытие сокетов (после обновления по SIGUSR2 - переданных прежним процессом)
+	listeners := make([]net.Listener, len(servers))
+	for i, srv := range servers {
+		listeners[i], err = upgrader.Listen(srv.Addr)
+		if err != nil {
+			logger.Error("Failed to listen", "addr", srv.Addr, "error", err)
+			return err
+		}
+	}
+
+	// Запуск сервера
+	build := version.Get()
+	logger.Info("Server started", "addr", httpServer.Addr, "version", build.Version, "commit", build.Commit)
+
+	serveErr := make(chan error, len(servers))
+	for i, srv := range servers {
+		go func(srv *http.Server, l net.Listener) {
+			serveErr <- serve(srv, l)
+		}(srv, listeners[i])
+	}
+
+	// Прежний процесс получает сообщение о готовности и начинает остановку
+	if err = upgrader.Ready(); err != nil {
+		logger.Error("Failed to notify the previous process", "error", err)
+	}
+
+	pidFile := os.Getenv("PID_FILE")
+	if pidFile != "" {
+		if err = os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+			logger.Error("Failed to write PID file", "file", pidFile, "error", err)
+			return err
+		}
+		defer removePidFile(pidFile, logger)
+	}
+
+	// Обновление без закрытия сокетов по сигналу SIGUSR2
+	upgraded := make(chan struct{})
+	go upgradeOnSignal(ctx, upgrader, upgraded, logger)
+
+	select {
+	case err = <-serveErr:
+		logger.Error("Failed to start server", "error", err)
+		return err
+	case <-ctx.Done():
+		logger.Info("Shutdown signal received, draining requests")
+	case <-upgraded:
+		logger.Info("New process is serving, draining requests")
+	}
+
+	// Остановка сервера: прекращение приема соединений и ожидание обработки текущих запросов
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	for _, srv := range servers {
+		if err = srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Failed to shutdown server gracefully", "error", err)
+		}
+	}
+
+	// Запись буферизованных переходов и остановка очистки кеша
+	if err = newServer.Close(shutdownCtx); err != nil {
+		logger.Error("Failed to close server", "error", err)
+	}
+
+	// Отправка оставшихся спанов
+	if err = shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("Failed to flush traces", "error", err)
+	}
+
+	logger.Info("Server stopped")
+
+	return nil
+}
+
+// reloadOnSignal - Функция, реализующая применение изменений файла настроек по сигналу SIGHUP (до завершения работы)
+func reloadOnSignal(ctx context.Context, settings *config.Loaded, level *applog.Level, srv *server.Server,
+	logger *slog.Logger) {
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+
+		applied, restartRequired, err := settings.Reload(func() error {
+			newLevel := slog.LevelInfo
+			if name := os.Getenv("LOG_LEVEL"); name != "" {
+				l, err := applog.ParseLevel(name)
+				if err != nil {
+					return err
+				}
+				newLevel = l
+			}
+
+			if err := srv.Reload(); err != nil {
+				return err
+			}
+
+			level.SetBase(newLevel)
+			return nil
+		})
+
+		if len(restartRequired) != 0 {
+			logger.Warn("Changed settings require a restart", "settings", restartRequired)
+		}
+
+		if err != nil {
+			logger.Error("Failed to reload settings, previous settings are kept", "error", err)
+			continue
+		}
+
+		logger.Info("Configuration reloaded", "changed", applied)
+	}
+}
+
+// debugOnSignal - Функция, реализующая переключение журнала на уровень debug по сигналу SIGUSR1
+// (на config.LogLevelOverrideDuration; повторный сигнал досрочно восстанавливает настроенный уровень)
+func debugOnSignal(ctx context.Context, level *applog.Level, logger *slog.Logger) {
+
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	defer signal.Stop(usr1)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-usr1:
+		}
+
+		if level.Restore() {
+			logger.Info("Log level was restored", "level", level.State().Level)
+			continue
+		}
+
+		state := level.Override(slog.LevelDebug, config.LogLevelOverrideDuration)
+		logger.Info("Log level was raised to debug", "until", state.Until)
+	}
+}
+
+// upgradeOnSignal - Функция, реализующая обновление без закрытия сокетов по сигналу SIGUSR2: исполняемый файл
+// запускается заново с переданными сокетами, а после его готовности закрывается канал upgraded
+// (при неудаче текущий процесс продолжает работу)
+func upgradeOnSignal(ctx context.Context, upgrader *upgrade.Upgrader, upgraded chan<- struct{}, logger *slog.Logger) {
+
+	usr2 := make(chan os.Signal, 1)
+	signal.Notify(usr2, syscall.SIGUSR2)
+	defer signal.Stop(usr2)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-usr2:
+		}
+
+		logger.Info("Upgrade signal received, starting new process")
+
+		pid, err := upgrader.Upgrade(ctx, config.UpgradeTimeout)
+		if errors.Is(err, upgrade.ErrInProgress) {
+			continue
+		}
+		if err != nil {
+			logger.Error("Failed to upgrade, this process keeps serving", "error", err)
+			continue
+		}
+
+		logger.Info("New process is ready", "pid", pid)
+		close(upgraded)
+		return
+	}
+}
+
+// removePidFile - Функция, реализующая удаление файла PID при остановке
+// (файл, уже перезаписанный новым процессом после обновления, остается)
+func removePidFile(path string, logger *slog.Logger) {
+
+	data, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		return
+	}
+
+	if err = os.Remove(path); err != nil {
+		logger.Error("Failed to remove PID file", "file", path, "error", err)
+	}
+}
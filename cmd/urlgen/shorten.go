@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"my_project/urlgen/internal/server"
+	"net/http"
+)
+
+// newShortenCommand - Функция, реализующая создание команды shorten (создание короткой ссылки)
+func newShortenCommand(opts *globalOptions) *cobra.Command {
+
+	req := server.LinkRequest{}
+
+	cmd := &cobra.Command{
+		Use:   "shorten <url>",
+		Short: "Create a short link and print it",
+		Example: "  urlgen shorten https://example.com/very/long/path\n" +
+			"  urlgen shorten https://example.com --alias launch --ttl 72h --domain go.example.com",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			client, err := newClient(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			req.Url = args[0]
+
+			link := server.Link{}
+
+			err = client.do(cmd.Context(), http.MethodPost, "/api/v1/links", req, &link)
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, opts, link, func() error {
+				_, err := fmt.Fprintln(cmd.OutOrStdout(), link.ShortUrl)
+				return err
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&req.Alias, "alias", "", "custom code instead of a generated one")
+	flags.StringVar(&req.TTL, "ttl", "", "lifetime of the link, e.g. 72h")
+	flags.StringVar(&req.Domain, "domain", "", "branded domain from SHORT_DOMAINS")
+
+	return cmd
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"my_project/urlgen/internal/server"
+	"net/http"
+	"net/url"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// linkStatsSummary - Тип данных, описывающий сводку переходов по ссылке
+type linkStatsSummary struct {
+	Code        string                `json:"code"`          // Код короткой ссылки
+	ShortUrl    string                `json:"short_url"`     // Короткая ссылка
+	ClicksTotal int                   `json:"clicks_total"`  // Переходы за все время
+	ClicksToday int                   `json:"clicks_today"`  // Переходы с начала суток (UTC)
+	ClicksWeek  int                   `json:"clicks_week"`   // Переходы с начала недели (понедельник, UTC)
+	Referrers   []server.StatsCounter `json:"top_referrers"` // Самые частые источники переходов
+	Countries   []server.StatsCounter `json:"top_countries"` // Самые частые страны
+}
+
+// newStatsCommand - Функция, реализующая создание команды stats (статистика рабочего пространства или ссылки)
+func newStatsCommand(opts *globalOptions) *cobra.Command {
+
+	var (
+		domain string
+		asJSON bool
+		top    int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stats [code | short url]",
+		Short: "Show total links, clicks today and this week and the top links",
+		Long: "stats without arguments summarizes the workspace: links, clicks today and this week\n" +
+			"and the most visited links of the week; with a code it summarizes one link.\n" +
+			"Days and weeks (from Monday) are counted in UTC, bot clicks are not counted.",
+		Example: "  urlgen stats\n" +
+			"  urlgen stats --top 20 --output json\n" +
+			"  urlgen stats abc123",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if asJSON {
+				opts.output = outputJSON
+			}
+
+			client, err := newClient(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if len(args) == 0 {
+				stats := server.WorkspaceStatsResponse{}
+
+				err = client.do(cmd.Context(), http.MethodGet, "/api/v1/stats?top="+strconv.Itoa(top), nil, &stats)
+				if err != nil {
+					return err
+				}
+
+				return printOutput(cmd, opts, stats, func() error { return printWorkspaceStats(cmd, stats) })
+			}
+
+			linkDomain, code, err := parseShortLink(args[0], domain)
+			if err != nil {
+				return err
+			}
+
+			summary, err := linkSummary(cmd.Context(), client, linkDomain, code, top)
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, opts, summary, func() error { return printLinkStats(cmd, summary) })
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&domain, "domain", "", "branded domain of a bare code")
+	flags.BoolVar(&asJSON, "json", false, "print JSON instead of a table")
+	flags.IntVar(&top, "top", 10, "size of the top lists")
+
+	_ = flags.MarkDeprecated("json", "use --output json")
+
+	return cmd
+}
+
+// linkSummary - Функция, реализующая получение сводки переходов по ссылке за все время ее жизни
+func linkSummary(ctx context.Context, client *apiClient, domain, code string, top int) (linkStatsSummary, error) {
+
+	link := server.Link{}
+
+	err := client.do(ctx, http.MethodGet, linkPath(domain, code, ""), nil, &link)
+	if err != nil {
+		return linkStatsSummary{}, err
+	}
+
+	query := url.Values{
+		"bucket": {"day"},
+		"from":   {link.CreatedAt.UTC().Format(time.RFC3339)},
+		"top":    {strconv.Itoa(top)},
+	}
+	stats := server.LinkStatsResponse{}
+
+	err = client.do(ctx, http.MethodGet, linkPath(domain, code, "/stats")+"&"+query.Encode(), nil, &stats)
+	if err != nil {
+		return linkStatsSummary{}, err
+	}
+
+	summary := linkStatsSummary{
+		Code:        link.Code,
+		ShortUrl:    link.ShortUrl,
+		ClicksTotal: stats.Total,
+		Referrers:   stats.Referrers,
+		Countries:   stats.Countries,
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+	for _, point := range stats.Timeline {
+		if !point.Time.Before(week) {
+			summary.ClicksWeek += point.Clicks
+		}
+		if !point.Time.Before(today) {
+			summary.ClicksToday += point.Clicks
+		}
+	}
+
+	return summary, nil
+}
+
+// printWorkspaceStats - Функция, реализующая вывод сводной статистики рабочего пространства таблицей
+func printWorkspaceStats(cmd *cobra.Command, stats server.WorkspaceStatsResponse) error {
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Links:\t%d\n", stats.Links)
+	fmt.Fprintf(w, "Clicks today:\t%d\n", stats.ClicksToday)
+	fmt.Fprintf(w, "Clicks this week:\t%d\n", stats.ClicksWeek)
+
+	if len(stats.TopLinks) != 0 {
+		fmt.Fprintf(w, "\nCODE\tCLICKS\tSHORT URL\n")
+		for _, link := range stats.TopLinks {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", link.Code, link.Clicks, link.ShortUrl)
+		}
+	}
+
+	return w.Flush()
+}
+
+// printLinkStats - Функция, реализующая вывод сводки переходов по ссылке таблицей
+func printLinkStats(cmd *cobra.Command, summary linkStatsSummary) error {
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Short URL:\t%s\n", summary.ShortUrl)
+	fmt.Fprintf(w, "Clicks:\t%d\n", summary.ClicksTotal)
+	fmt.Fprintf(w, "Clicks today:\t%d\n", summary.ClicksToday)
+	fmt.Fprintf(w, "Clicks this week:\t%d\n", summary.ClicksWeek)
+
+	for _, list := range []struct {
+		title    string
+		counters []server.StatsCounter
+	}{{"REFERRER", summary.Referrers}, {"COUNTRY", summary.Countries}} {
+		if len(list.counters) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%s\tCLICKS\n", list.title)
+		for _, c := range list.counters {
+			fmt.Fprintf(w, "%s\t%d\n", c.Value, c.Clicks)
+		}
+	}
+
+	return w.Flush()
+}
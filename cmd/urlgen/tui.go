@@ -0,0 +1,525 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"my_project/urlgen/internal/server"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tuiPageSize    = 500                    // Размер страницы при загрузке списка ссылок
+	tuiDetailDelay = 300 * time.Millisecond // Задержка загрузки сведений о выбранной ссылке (при быстрой прокрутке)
+	tuiTopLinks    = 100                    // Размер списка самых посещаемых ссылок (переходы за неделю в списке)
+	tuiCodeWidth   = 16                     // Ширина столбца кода ссылки
+	tuiStatusWidth = 19                     // Ширина столбца состояния ссылки
+)
+
+// Сообщения интерфейса с результатами запросов к API
+type (
+	tuiLinksMsg struct {
+		links []server.Link
+		err   error
+	}
+	tuiStatsMsg struct {
+		stats server.WorkspaceStatsResponse
+		err   error
+	}
+	tuiDetailMsg struct {
+		summary linkStatsSummary
+		err     error
+	}
+	tuiToggledMsg struct {
+		link server.Link
+		err  error
+	}
+	tuiDetailDueMsg struct{ shortUrl string }
+	tuiTickMsg      time.Time
+)
+
+// tuiModel - Тип данных, описывающий состояние интерактивного интерфейса
+type tuiModel struct {
+	ctx     context.Context
+	client  *apiClient
+	refresh time.Duration // Период обновления счетчиков переходов
+	limit   int           // Максимальное количество загружаемых ссылок
+
+	links    []server.Link // Загруженные ссылки
+	visible  []int         // Индексы ссылок, подходящих под фильтр
+	selected int           // Позиция выбранной ссылки в visible
+	scroll   int           // Позиция первой показанной ссылки в visible
+	filter   string        // Строка поиска
+	editing  bool          // Вводится ли строка поиска
+
+	stats      server.WorkspaceStatsResponse // Сводная статистика рабочего пространства
+	weekClicks map[string]int                // Переходы за неделю по коротким ссылкам
+	updatedAt  time.Time                     // Время последнего обновления статистики
+	detail     *linkStatsSummary             // Переходы по выбранной ссылке (nil - загружаются)
+
+	message       string // Результат последнего действия или ошибка
+	width, height int
+}
+
+// newTuiCommand - Функция, реализующая создание команды tui (интерактивный интерфейс в терминале)
+func newTuiCommand(opts *globalOptions) *cobra.Command {
+
+	var (
+		refresh time.Duration
+		limit   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Browse, search and toggle links in a terminal dashboard",
+		Long: "tui shows the links of the workspace with the clicks of this week and the workspace\n" +
+			"counters, refreshed every --refresh. Keys: up/down, pgup/pgdown, home/end to move,\n" +
+			"/ to search by code, url or tag, space to enable or disable the selected link,\n" +
+			"r to reload the links, q to quit.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if refresh < time.Second {
+				return errors.New("error: --refresh must be at least 1s")
+			}
+			if limit < 1 {
+				return errors.New("error: --limit must be a positive number")
+			}
+
+			client, err := newClient(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			model := &tuiModel{ctx: cmd.Context(), client: client, refresh: refresh, limit: limit}
+
+			_, err = tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(cmd.Context())).Run()
+			if errors.Is(err, tea.ErrProgramKilled) {
+				return nil
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().DurationVar(&refresh, "refresh", 5*time.Second, "how often the click counters are refreshed")
+	cmd.Flags().IntVar(&limit, "limit", 5000, "maximum number of links to load")
+
+	return cmd
+}
+
+// Init - Метод, реализующий первые запросы интерфейса
+func (m *tuiModel) Init() tea.Cmd {
+	return tea.Batch(m.loadLinks(), m.loadStats(), m.tick())
+}
+
+// Update - Метод, реализующий обработку нажатий клавиш и результатов запросов
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m, m.editFilter(msg)
+		}
+		return m, m.handleKey(msg)
+
+	case tuiLinksMsg:
+		if msg.err != nil {
+			m.message = msg.err.Error()
+			return m, nil
+		}
+
+		selected := m.selectedUrl()
+		m.links = msg.links
+		m.applyFilter(selected)
+		return m, m.selectionChanged()
+
+	case tuiStatsMsg:
+		if msg.err != nil {
+			m.message = msg.err.Error()
+			return m, nil
+		}
+
+		m.stats, m.updatedAt = msg.stats, time.Now()
+		m.weekClicks = make(map[string]int, len(msg.stats.TopLinks))
+		for _, link := range msg.stats.TopLinks {
+			m.weekClicks[link.ShortUrl] = link.Clicks
+		}
+
+	case tuiDetailDueMsg:
+		if link := m.current(); link != nil && link.ShortUrl == msg.shortUrl {
+			return m, m.loadDetail(*link)
+		}
+
+	case tuiDetailMsg:
+		if msg.err != nil {
+			m.message = msg.err.Error()
+			return m, nil
+		}
+
+		if link := m.current(); link != nil && link.ShortUrl == msg.summary.ShortUrl {
+			m.detail = &msg.summary
+		}
+
+	case tuiToggledMsg:
+		if msg.err != nil {
+			m.message = msg.err.Error()
+			return m, nil
+		}
+
+		for i := range m.links {
+			if m.links[i].ShortUrl == msg.link.ShortUrl {
+				m.links[i] = msg.link
+			}
+		}
+
+		state := "enabled"
+		if !msg.link.Active {
+			state = "disabled"
+		}
+		m.message = msg.link.ShortUrl + " " + state
+
+	case tuiTickMsg:
+		cmds := []tea.Cmd{m.loadStats(), m.tick()}
+		if link := m.current(); link != nil {
+			cmds = append(cmds, m.loadDetail(*link))
+		}
+		return m, tea.Batch(cmds...)
+	}
+
+	return m, nil
+}
+
+// handleKey - Метод, реализующий обработку клавиш при просмотре списка
+func (m *tuiModel) handleKey(msg tea.KeyMsg) tea.Cmd {
+
+	previous := m.current()
+	page := max(m.listHeight()-1, 1)
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		m.selected--
+	case "down", "j":
+		m.selected++
+	case "pgup":
+		m.selected -= page
+	case "pgdown":
+		m.selected += page
+	case "home", "g":
+		m.selected = 0
+	case "end", "G":
+		m.selected = len(m.visible) - 1
+	case "/":
+		m.editing = true
+		return nil
+	case "esc":
+		m.filter = ""
+		m.applyFilter(m.selectedUrl())
+	case " ", "t":
+		if link := m.current(); link != nil {
+			return m.toggle(*link)
+		}
+	case "r":
+		m.message = "Reloading links"
+		return tea.Batch(m.loadLinks(), m.loadStats())
+	default:
+		return nil
+	}
+
+	m.selected = min(max(m.selected, 0), max(len(m.visible)-1, 0))
+
+	if m.current() != previous {
+		return m.selectionChanged()
+	}
+	return nil
+}
+
+// editFilter - Метод, реализующий ввод строки поиска (enter - завершить ввод, esc - сбросить поиск)
+func (m *tuiModel) editFilter(msg tea.KeyMsg) tea.Cmd {
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return tea.Quit
+	case tea.KeyEnter:
+		m.editing = false
+		return nil
+	case tea.KeyEsc:
+		m.editing = false
+		m.filter = ""
+	case tea.KeyBackspace:
+		if runes := []rune(m.filter); len(runes) != 0 {
+			m.filter = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+	default:
+		return nil
+	}
+
+	m.applyFilter(m.selectedUrl())
+	return m.selectionChanged()
+}
+
+// applyFilter - Метод, реализующий отбор ссылок по строке поиска (код, короткая и исходная ссылки, метки)
+// с сохранением выбора ссылки selectedUrl, если она подходит под фильтр
+func (m *tuiModel) applyFilter(selectedUrl string) {
+
+	needle := strings.ToLower(m.filter)
+
+	m.visible = m.visible[:0]
+	m.selected = 0
+
+	for i, link := range m.links {
+		text := strings.ToLower(link.Code + " " + link.ShortUrl + " " + link.Url + " " + strings.Join(link.Tags, " "))
+		if !strings.Contains(text, needle) {
+			continue
+		}
+
+		if link.ShortUrl == selectedUrl {
+			m.selected = len(m.visible)
+		}
+		m.visible = append(m.visible, i)
+	}
+}
+
+// current - Метод, возвращающий выбранную ссылку (nil - список пуст)
+func (m *tuiModel) current() *server.Link {
+
+	if m.selected < 0 || m.selected >= len(m.visible) {
+		return nil
+	}
+
+	return &m.links[m.visible[m.selected]]
+}
+
+// selectedUrl - Метод, возвращающий короткую ссылку выбранной ссылки (пустая строка - список пуст)
+func (m *tuiModel) selectedUrl() string {
+
+	if link := m.current(); link != nil {
+		return link.ShortUrl
+	}
+
+	return ""
+}
+
+// selectionChanged - Метод, реализующий отложенную загрузку сведений о новой выбранной ссылке
+func (m *tuiModel) selectionChanged() tea.Cmd {
+
+	link := m.current()
+	if link == nil {
+		m.detail = nil
+		return nil
+	}
+
+	if m.detail != nil && m.detail.ShortUrl == link.ShortUrl {
+		return nil
+	}
+
+	m.detail = nil
+	shortUrl := link.ShortUrl
+
+	return tea.Tick(tuiDetailDelay, func(time.Time) tea.Msg { return tuiDetailDueMsg{shortUrl: shortUrl} })
+}
+
+// tick - Метод, реализующий таймер обновления счетчиков
+func (m *tuiModel) tick() tea.Cmd {
+	return tea.Tick(m.refresh, func(t time.Time) tea.Msg { return tuiTickMsg(t) })
+}
+
+// loadLinks - Метод, реализующий загрузку ссылок рабочего пространства страницами
+func (m *tuiModel) loadLinks() tea.Cmd {
+
+	ctx, client, limit := m.ctx, m.client, m.limit
+
+	return func() tea.Msg {
+		var links []server.Link
+
+		for len(links) < limit {
+			size := min(tuiPageSize, limit-len(links))
+			page := []server.Link{}
+
+			path := "/api/v1/links?offset=" + strconv.Itoa(len(links)) + "&limit=" + strconv.Itoa(size)
+			if err := client.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+				return tuiLinksMsg{err: err}
+			}
+
+			links = append(links, page...)
+			if len(page) < size {
+				break
+			}
+		}
+
+		return tuiLinksMsg{links: links}
+	}
+}
+
+// loadStats - Метод, реализующий загрузку сводной статистики рабочего пространства
+func (m *tuiModel) loadStats() tea.Cmd {
+
+	ctx, client := m.ctx, m.client
+
+	return func() tea.Msg {
+		stats := server.WorkspaceStatsResponse{}
+
+		err := client.do(ctx, http.MethodGet, "/api/v1/stats?top="+strconv.Itoa(tuiTopLinks), nil, &stats)
+		return tuiStatsMsg{stats: stats, err: err}
+	}
+}
+
+// loadDetail - Метод, реализующий загрузку переходов по ссылке
+func (m *tuiModel) loadDetail(link server.Link) tea.Cmd {
+
+	ctx, client := m.ctx, m.client
+
+	return func() tea.Msg {
+		summary, err := linkSummary(ctx, client, link.Domain, link.Code, 1)
+		return tuiDetailMsg{summary: summary, err: err}
+	}
+}
+
+// toggle - Метод, реализующий включение или отключение переходов по ссылке
+func (m *tuiModel) toggle(link server.Link) tea.Cmd {
+
+	ctx, client := m.ctx, m.client
+	active := !link.Active
+
+	return func() tea.Msg {
+		updated := server.Link{}
+
+		err := client.do(ctx, http.MethodPatch, linkPath(link.Domain, link.Code, ""), server.LinkUpdate{Active: &active}, &updated)
+		return tuiToggledMsg{link: updated, err: err}
+	}
+}
+
+// listHeight - Метод, возвращающий количество строк списка ссылок на экране
+func (m *tuiModel) listHeight() int {
+
+	height := m.height
+	if height <= 0 {
+		height = 24
+	}
+
+	// Заголовок (4 строки), сведения о ссылке (5 строк) и подсказка (2 строки)
+	return max(height-11, 3)
+}
+
+// View - Метод, реализующий вывод экрана интерфейса
+func (m *tuiModel) View() string {
+
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(truncate(s, width))
+		b.WriteByte('\n')
+	}
+
+	header := fmt.Sprintf("Links: %d   Clicks today: %d   This week: %d", m.stats.Links, m.stats.ClicksToday, m.stats.ClicksWeek)
+	if !m.updatedAt.IsZero() {
+		header += "   (updated " + m.updatedAt.Format("15:04:05") + ")"
+	}
+	line(header)
+
+	switch {
+	case m.editing:
+		line("Search: " + m.filter + "_")
+	case m.filter != "":
+		line(fmt.Sprintf("Search: %s (%d of %d links, esc to clear)", m.filter, len(m.visible), len(m.links)))
+	default:
+		line(fmt.Sprintf("%d links", len(m.links)))
+	}
+	line("")
+	line(fmt.Sprintf("%-*s  %-*s  %6s  %s", tuiCodeWidth, "CODE", tuiStatusWidth, "STATUS", "WEEK", "DESTINATION"))
+
+	height := m.listHeight()
+	m.scroll = min(max(m.scroll, m.selected-height+1), m.selected)
+	m.scroll = max(m.scroll, 0)
+
+	now := time.Now()
+	for row := 0; row < height; row++ {
+		i := m.scroll + row
+		if i >= len(m.visible) {
+			line("")
+			continue
+		}
+
+		link := m.links[m.visible[i]]
+
+		week := "-"
+		if clicks, found := m.weekClicks[link.ShortUrl]; found {
+			week = strconv.Itoa(clicks)
+		} else if m.weekClicks != nil && len(m.stats.TopLinks) < tuiTopLinks {
+			week = "0"
+		}
+
+		text := fmt.Sprintf("%-*s  %-*s  %6s  %s", tuiCodeWidth, truncate(link.Code, tuiCodeWidth),
+			tuiStatusWidth, linkStatus(link, now), week, link.Url)
+
+		if i != m.selected {
+			line(text)
+			continue
+		}
+
+		// Выбранная ссылка выделяется инверсией цветов на всю ширину экрана
+		text = truncate(text, width)
+		b.WriteString("\x1b[7m" + text + strings.Repeat(" ", max(width-len([]rune(text)), 0)) + "\x1b[0m\n")
+	}
+
+	line("")
+	if link := m.current(); link != nil {
+		line("Short URL:   " + link.ShortUrl)
+		line("Destination: " + link.Url)
+
+		status := "Status:      " + linkStatus(*link, now)
+		if len(link.Tags) != 0 {
+			status += "   Tags: " + strings.Join(link.Tags, ", ")
+		}
+		line(status)
+
+		if m.detail != nil {
+			line(fmt.Sprintf("Clicks:      %d total, %d today, %d this week",
+				m.detail.ClicksTotal, m.detail.ClicksToday, m.detail.ClicksWeek))
+		} else {
+			line("Clicks:      loading...")
+		}
+	} else {
+		line("No links")
+		line("")
+		line("")
+		line("")
+	}
+
+	line("")
+	line("up/down move  / search  space enable/disable  r reload  q quit")
+	b.WriteString(truncate(m.message, width))
+
+	return b.String()
+}
+
+// truncate - Функция, реализующая обрезку строки до заданного количества символов
+func truncate(s string, n int) string {
+
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(runes[:n])
+	}
+
+	return string(runes[:n-1]) + "~"
+}
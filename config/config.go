@@ -3,12 +3,106 @@ package config
 import "time"
 
 const (
-	GenUrl                 = "http://exmpl.lnk/" // Основа генерируемой короткой ссылки
-	ServerPort             = ":4000"             // Порт, на котором развернуто приложение
-	TableNameDB            = " \"GenTable\""     // Название таблицы в БД (начинается с пробела)
-	UrlColName             = "url"               // Название столбца с исходными ссылками в БД
-	ShortUrlColName        = "short_url"         // Название столбца с короткими ссылками в БД
-	ShortUrlLen            = 10                  // Длина части выходной короткой ссылки после длины основы "GenUrl" (до 32 символов)
-	CacheDefaultExpiration = 20 * time.Minute    // Время жизни кеша по умолчанию
-	CacheCleanupTime       = 20 * time.Minute    // Время очистки кеша по умолчанию
+	GenUrl                       = "http://exmpl.lnk/"     // Основа генерируемой короткой ссылки
+	ServerPort                   = ":4000"                 // Порт, на котором развернуто приложение
+	HTTPSPort                    = ":443"                  // Порт HTTPS при автоматическом получении сертификатов
+	HTTPPort                     = ":80"                   // Порт для проверок ACME HTTP-01 и перенаправления на HTTPS
+	TableNameDB                  = " \"GenTable\""         // Название таблицы в БД (начинается с пробела)
+	UsersTableNameDB             = " \"Users\""            // Название таблицы пользователей в БД (начинается с пробела)
+	ClicksTableNameDB            = " \"Clicks\""           // Название таблицы переходов в БД (начинается с пробела)
+	DomainRulesTableNameDB       = " \"DomainRules\""      // Название таблицы правил доменов назначения в БД (начинается с пробела)
+	WebhooksTableNameDB          = " \"Webhooks\""         // Название таблицы вебхуков в БД (начинается с пробела)
+	WorkspacesTableNameDB        = " \"Workspaces\""       // Название таблицы рабочих пространств в БД (начинается с пробела)
+	MembersTableNameDB           = " \"WorkspaceMembers\"" // Название таблицы участников рабочих пространств в БД (начинается с пробела)
+	ApiKeysTableNameDB           = " \"ApiKeys\""          // Название таблицы ключей API в БД (начинается с пробела)
+	ResetsTableNameDB            = " \"PasswordResets\""   // Название таблицы запросов сброса пароля в БД (начинается с пробела)
+	CodePoolTableNameDB          = " \"CodePool\""         // Название таблицы заранее созданных кодов в БД (начинается с пробела)
+	MigrationsTableNameDB        = " \"SchemaMigrations\"" // Название таблицы примененных миграций схемы БД (начинается с пробела)
+	AuditLogTableNameDB          = " \"AuditLog\""         // Название таблицы журнала аудита в БД (начинается с пробела)
+	FeatureFlagsTableNameDB      = " \"FeatureFlags\""     // Название таблицы флагов функций в БД (начинается с пробела)
+	UrlColName                   = "url"                   // Название столбца с исходными ссылками в БД
+	ShortUrlColName              = "short_url"             // Название столбца с короткими ссылками в БД
+	UserIdColName                = "user_id"               // Название столбца с идентификатором владельца ссылки в БД
+	WorkspaceIdColName           = "workspace_id"          // Название столбца с идентификатором рабочего пространства в БД
+	ShortUrlLen                  = 10                      // Длина части выходной короткой ссылки после длины основы "GenUrl" по умолчанию
+	ShortUrlMaxLen               = 32                      // Максимальная длина генерируемого кода короткой ссылки
+	CodeSaturation               = 0.01                    // Доля занятых кодов, после которой длина генерируемых кодов увеличивается
+	CodeMaxAttempts              = 5                       // Количество попыток сохранения ссылки с новым кодом при совпадении кодов
+	CodeFilterAttempts           = 100                     // Количество замен кода из хеша, зарезервированного или оскорбительного
+	CodeEntropyBits              = 96                      // Энтропия случайных кодов NanoID по умолчанию, в битах
+	CodeMinEntropyBits           = 32                      // Минимальная энтропия случайных кодов NanoID, в битах
+	EmojiCodeLen                 = 4                       // Длина кодов из эмодзи
+	CodeCounterKey               = "urlgen:code_counter"   // Ключ счетчика кодов в Redis
+	RateLimitKeyPrefix           = "urlgen:rate:"          // Префикс ключей счетчиков ограничения частоты в Redis
+	RateLimitTimeout             = 50 * time.Millisecond   // Время ожидания хранилища счетчиков, после которого запрос пропускается
+	CodeIdBlockSize              = 100                     // Количество идентификаторов строк, запрашиваемых генератором кодов за раз
+	CodePoolSize                 = 10000                   // Количество заранее созданных кодов по умолчанию
+	CodePoolBatchSize            = 1000                    // Количество кодов, добавляемых в запас одним запросом
+	CodePoolCheckInterval        = 10 * time.Second        // Интервал проверки запаса заранее созданных кодов
+	CodeSignatureLen             = 4                       // Длина подписи HMAC сгенерированного кода по умолчанию
+	UnsignedCodesRefreshInterval = 5 * time.Second         // Минимальный интервал дочитывания кодов без подписи из БД
+	CacheDefaultExpiration       = 20 * time.Minute        // Время жизни кеша по умолчанию
+	CacheCleanupTime             = 20 * time.Minute        // Время очистки кеша по умолчанию
+	TokenTTL                     = 15 * time.Minute        // Время жизни выпускаемых JWT
+	DefaultRedirectStatus        = 302                     // Статус перехода по короткой ссылке по умолчанию
+	ClickBufferSize              = 10000                   // Размер буфера событий переходов
+	ClickBatchSize               = 500                     // Максимальный размер пачки записываемых событий переходов
+	ClickFlushInterval           = time.Second             // Максимальное время ожидания записи событий переходов
+	ClickHouseTable              = "clicks"                // Таблица переходов в ClickHouse
+	ClickHouseBatchSize          = 5000                    // Максимальный размер пачки переходов, вставляемой в ClickHouse
+	ClickHouseFlushInterval      = 5 * time.Second         // Максимальное время ожидания вставки переходов в ClickHouse
+	ClickHouseTimeout            = 30 * time.Second        // Время ожидания вставки пачки переходов и создания таблицы ClickHouse
+	BigQueryClicksTable          = "daily_clicks"          // Таблица суточного количества переходов в BigQuery
+	BigQueryLinksTable           = "links"                 // Таблица ссылок в BigQuery
+	BigQueryExportHour           = 1                       // Час (UTC), после которого в BigQuery выгружаются прошедшие сутки
+	BigQueryExportDays           = 3                       // Количество последних суток, переходы которых выгружаются заново
+	BigQueryCheckInterval        = 10 * time.Minute        // Интервал проверки необходимости выгрузки в BigQuery
+	BigQueryExportTimeout        = 30 * time.Minute        // Наибольшее время выгрузки в BigQuery
+	ShutdownTimeout              = 15 * time.Second        // Время ожидания завершения обработки запросов при остановке
+	UpgradeTimeout               = time.Minute             // Время ожидания готовности нового процесса при обновлении (SIGUSR2)
+	ErrorReportFlushTimeout      = 2 * time.Second         // Время ожидания отправки сообщений об ошибках при остановке и панике
+	HealthSlowLatency            = 500 * time.Millisecond  // Время ответа зависимости, после которого она считается деградировавшей
+	HealthGeoIPMaxAge            = 30 * 24 * time.Hour     // Возраст базы GeoIP, после которого она считается устаревшей
+	HealthQueueDegraded          = 0.8                     // Заполненность очереди вебхуков, после которой она считается деградировавшей
+	FeatureFlagsRefreshInterval  = 30 * time.Second        // Интервал перечитывания флагов функций, измененных через API
+	WebhooksRefreshInterval      = 30 * time.Second        // Интервал перечитывания вебхуков, измененных через API
+	LeaderCheckInterval          = 10 * time.Second        // Интервал проверки блокировки ведущего экземпляра и попыток ее получить
+	StatsDInterval               = 10 * time.Second        // Интервал отправки метрик в StatsD по умолчанию
+	InterstitialDelaySeconds     = 5                       // Время показа промежуточной страницы перед переходом, в секундах
+	LogLevelOverrideDuration     = 15 * time.Minute        // Время действия временного уровня журнала по умолчанию (и по SIGUSR1)
+	LogLevelOverrideMaxDuration  = 24 * time.Hour          // Максимальное время действия временного уровня журнала
+	BulkMaxLinks                 = 1000                    // Максимальное количество ссылок в одном запросе массового создания
+	AdminBulkBatchSize           = 500                     // Размер пачки ссылок, изменяемых в одной транзакции массовой операции
+	AdminBulkMaxCodes            = 1000                    // Максимальное количество кодов измененных ссылок в ответе массовой операции
+	PurgeBatchSize               = 1000                    // Количество ссылок, удаляемых одним запросом при очистке БД
+	BackupFormatVersion          = 1                       // Версия формата снимков резервной копии
+	AliasMinLen                  = 3                       // Минимальная длина пользовательского кода короткой ссылки
+	AliasMaxLen                  = 64                      // Максимальная длина пользовательского кода короткой ссылки
+	PasswordMinLen               = 8                       // Минимальная длина пароля пользователя
+	PasswordResetTTL             = time.Hour               // Время действия ссылки сброса пароля
+	LinkPasswordMaxFailures      = 10                      // Количество неверных паролей ссылки, после которого ввод пароля приостанавливается
+	LinkPasswordFailureWindow    = 15 * time.Minute        // Окно учета неверных паролей ссылки
+	IdempotencyTTL               = 24 * time.Hour          // Время хранения ответа на запрос с заголовком Idempotency-Key
+	IdempotencyKeyMaxLen         = 255                     // Максимальная длина значения заголовка Idempotency-Key
+	WebhookWorkers               = 4                       // Количество обработчиков доставки вебхуков
+	WebhookQueueSize             = 1000                    // Размер очереди доставки вебхуков
+	WebhookMaxAttempts           = 5                       // Максимальное количество попыток доставки вебхука
+	WebhookBackoff               = time.Second             // Задержка перед первым повтором доставки вебхука
+	WebhookTimeout               = 5 * time.Second         // Время ожидания ответа получателя вебхука
+	StreamSchemaVersion          = 1                       // Версия схемы сообщений потоков событий
+	StreamTopicPrefix            = "urlgen."               // Префикс тем потоков событий по умолчанию
+	StreamBatchTimeout           = 100 * time.Millisecond  // Наибольшее время накопления пачки сообщений потока событий
+	NATSReconnectBuffer          = 8 << 20                 // Размер буфера сообщений NATS на время переподключения
+	NATSFlushTimeout             = 5 * time.Second         // Время ожидания отправки оставшихся сообщений NATS при остановке
+	RabbitMQExchange             = "urlgen"                // Обменник RabbitMQ по умолчанию
+	RabbitMQQueueSize            = 1000                    // Размер очереди пачек сообщений на отправку в RabbitMQ
+	RabbitMQConfirmTimeout       = 5 * time.Second         // Время ожидания подтверждения публикации пачки сообщений RabbitMQ
+	RabbitMQRetryDelay           = 5 * time.Second         // Пауза перед повторным подключением к RabbitMQ после ошибки
+	RabbitMQCloseTimeout         = 10 * time.Second        // Время ожидания отправки оставшихся сообщений RabbitMQ при остановке
+	SlackRequestMaxAge           = 5 * time.Minute         // Наибольшее расхождение времени запроса Slack с текущим
+	TelegramPollTimeout          = 30 * time.Second        // Время ожидания сообщений боту Telegram при долгом опросе
+	TelegramTimeout              = 10 * time.Second        // Время ожидания ответа Bot API Telegram
+	TelegramRetryDelay           = 5 * time.Second         // Задержка повтора после ошибки получения сообщений Telegram
+	TelegramStatsPeriod          = 7 * 24 * time.Hour      // Период статистики переходов в ответе бота Telegram
+	TelegramStatsTop             = 3                       // Размер списков самых частых значений в ответе бота Telegram
 )
@@ -0,0 +1,430 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Источники значений настроек
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// Loaded - Тип данных, описывающий загруженные настройки приложения
+// (значения применяются к переменным окружения, откуда их читают компоненты)
+type Loaded struct {
+	File    string            // Прочитанный файл настроек (пустая строка, если файл не задан)
+	sources map[string]string // Источник значения по ключу настройки
+}
+
+// Load - Функция, позволяющая загрузить настройки из файла, переменных окружения и флагов командной строки
+// (приоритет: флаг, переменная окружения, файл, значение по умолчанию; файл задается флагом -config
+// или переменной CONFIG_FILE; все ошибки значений возвращаются вместе)
+func Load(args []string) (*Loaded, error) {
+
+	flags := flag.NewFlagSet("urlgen", flag.ContinueOnError)
+
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "configuration file (YAML)")
+
+	values := make([]string, len(Settings))
+	for i, s := range Settings {
+		usage := s.Description + " (" + s.Env + ")"
+		if s.Default != "" {
+			usage += ", default " + s.Default
+		}
+
+		flags.StringVar(&values[i], s.Flag(), "", usage)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() != 0 {
+		return nil, fmt.Errorf("error: unexpected argument %q", flags.Arg(0))
+	}
+
+	setFlags := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	loaded := Loaded{File: *configFile, sources: map[string]string{}}
+
+	var fileValues map[string]string
+
+	if loaded.File != "" {
+		var err error
+
+		fileValues, err = readSettingsFile(loaded.File)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+
+	for i, s := range Settings {
+		value, source := s.Default, sourceDefault
+
+		if v, found := fileValues[s.Key]; found {
+			value, source = v, sourceFile
+		}
+		if v, found := os.LookupEnv(s.Env); found {
+			value, source = v, sourceEnv
+		}
+		if setFlags[s.Flag()] {
+			value, source = values[i], sourceFlag
+		}
+
+		if source == sourceFile || source == sourceFlag || (source == sourceDefault && value != "") {
+			if err := os.Setenv(s.Env, value); err != nil {
+				return nil, err
+			}
+		}
+
+		loaded.sources[s.Key] = source
+
+		if err := s.validate(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", s.Key, loaded.describeSource(s, source), err))
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("error: invalid configuration:\n  %w", joinLines(errs))
+	}
+
+	return &loaded, nil
+}
+
+// Reload - Метод, позволяющий повторно прочитать файл настроек и применить изменения настроек,
+// допускающих изменение без перезапуска (значения из переменных окружения и флагов не меняются);
+// новые значения передаются компонентам вызовом apply, при ошибке проверки или apply
+// восстанавливаются прежние значения; возвращает примененные ключи и ключи, изменение которых
+// требует перезапуска
+func (l *Loaded) Reload(apply func() error) ([]string, []string, error) {
+
+	var fileValues map[string]string
+
+	if l.File != "" {
+		var err error
+
+		fileValues, err = readSettingsFile(l.File)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var (
+		errs            []error
+		changed         []Setting
+		applied         []string
+		restartRequired []string
+	)
+
+	for _, s := range Settings {
+		if l.sources[s.Key] == sourceEnv || l.sources[s.Key] == sourceFlag {
+			continue
+		}
+
+		value, source := s.Default, sourceDefault
+		if v, found := fileValues[s.Key]; found {
+			value, source = v, sourceFile
+		}
+
+		if value == os.Getenv(s.Env) {
+			continue
+		}
+
+		if !s.reloadable {
+			restartRequired = append(restartRequired, s.Key)
+			continue
+		}
+
+		if err := s.validate(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", s.Key, l.describeSource(s, source), err))
+			continue
+		}
+
+		changed = append(changed, s)
+	}
+
+	if len(errs) != 0 {
+		return nil, restartRequired, fmt.Errorf("error: invalid configuration:\n  %w", joinLines(errs))
+	}
+
+	// Новые значения применяются только после проверки всех изменений
+	previous := map[string]string{}
+	sources := map[string]string{}
+
+	for _, s := range changed {
+		value, source := s.Default, sourceDefault
+		if v, found := fileValues[s.Key]; found {
+			value, source = v, sourceFile
+		}
+
+		previous[s.Env] = os.Getenv(s.Env)
+		sources[s.Key] = source
+
+		setEnv(s.Env, value)
+		applied = append(applied, s.Key)
+	}
+
+	if err := apply(); err != nil {
+		for env, value := range previous {
+			setEnv(env, value)
+		}
+
+		return nil, restartRequired, err
+	}
+
+	for key, source := range sources {
+		l.sources[key] = source
+	}
+
+	return applied, restartRequired, nil
+}
+
+// setEnv - Функция, реализующая запись переменной окружения (пустое значение удаляет переменную)
+func setEnv(env, value string) {
+	if value == "" {
+		_ = os.Unsetenv(env)
+	} else {
+		_ = os.Setenv(env, value)
+	}
+}
+
+// Get - Метод, возвращающий действующее значение настройки по ключу
+func (l *Loaded) Get(key string) string {
+
+	for _, s := range Settings {
+		if s.Key == key {
+			return os.Getenv(s.Env)
+		}
+	}
+
+	return ""
+}
+
+// Source - Метод, возвращающий источник значения настройки ("default", "file", "env" или "flag")
+func (l *Loaded) Source(key string) string {
+	return l.sources[key]
+}
+
+// describeSource - Метод, возвращающий описание источника значения для сообщения об ошибке
+func (l *Loaded) describeSource(s Setting, source string) string {
+
+	switch source {
+	case sourceFile:
+		return "from " + l.File
+	case sourceEnv:
+		return "from " + s.Env
+	case sourceFlag:
+		return "from -" + s.Flag()
+	default:
+		return "set " + s.Env + ", -" + s.Flag() + " or " + s.Key + " in the configuration file"
+	}
+}
+
+// ParseRetention - Функция, реализующая разбор срока хранения (time.ParseDuration с поддержкой дней "d")
+func ParseRetention(v string) (time.Duration, error) {
+
+	if days, found := strings.CutSuffix(v, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(v)
+}
+
+// validate - Метод, проверяющий значение настройки (пустое значение допустимо для необязательных настроек)
+func (s Setting) validate(value string) error {
+
+	if value == "" {
+		if s.required {
+			return errors.New("is required")
+		}
+		return nil
+	}
+
+	shown := strconv.Quote(value)
+	if s.secret {
+		shown = "value"
+	}
+
+	switch s.kind {
+	case kindBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s must be true or false", shown)
+		}
+	case kindInt:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("%s must be a non-negative integer", shown)
+		}
+	case kindDuration:
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("%s must be a duration such as \"90s\", \"20m\" or \"1h\"", shown)
+		}
+	case kindRetention:
+		days, isDays := strings.CutSuffix(value, "d")
+		if isDays {
+			if n, err := strconv.Atoi(days); err != nil || n <= 0 {
+				return fmt.Errorf("%s must be a positive number of days such as \"90d\"", shown)
+			}
+		} else if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a duration such as \"720h\" or \"90d\"", shown)
+		}
+	case kindEnum:
+		if !slices.Contains(s.values, strings.ToLower(value)) {
+			return fmt.Errorf("%s must be one of %s", shown, strings.Join(s.values, ", "))
+		}
+	}
+
+	return nil
+}
+
+// readSettingsFile - Функция, реализующая чтение файла настроек YAML в значения по ключам вида "раздел.имя"
+// (неизвестные ключи считаются ошибкой, списки объединяются через запятую)
+func readSettingsFile(path string) (map[string]string, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error: failed to read configuration file: %w", err)
+	}
+
+	var doc map[string]any
+
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error: failed to parse configuration file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flattenSettings("", doc, values)
+
+	var errs []error
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !slices.ContainsFunc(Settings, func(s Setting) bool { return s.Key == key }) {
+			msg := "unknown setting " + key
+			if suggestion := closestSetting(key); suggestion != "" {
+				msg += ", did you mean " + suggestion + "?"
+			}
+
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("error: invalid configuration file %s:\n  %w", path, joinLines(errs))
+	}
+
+	return values, nil
+}
+
+// flattenSettings - Функция, реализующая преобразование вложенных разделов файла настроек в плоские ключи
+func flattenSettings(prefix string, node map[string]any, values map[string]string) {
+
+	for name, v := range node {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		switch v := v.(type) {
+		case nil:
+		case map[string]any:
+			flattenSettings(key, v, values)
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, scalarSetting(item))
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = scalarSetting(v)
+		}
+	}
+}
+
+// scalarSetting - Функция, возвращающая строковое представление значения из файла настроек
+func scalarSetting(v any) string {
+
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// closestSetting - Функция, возвращающая известный ключ настройки, ближайший к ошибочному
+// (пустая строка, если подходящего ключа нет)
+func closestSetting(key string) string {
+
+	best, bestDistance := "", 4
+
+	for _, s := range Settings {
+		if d := editDistance(key, s.Key); d < bestDistance {
+			best, bestDistance = s.Key, d
+		}
+	}
+
+	return best
+}
+
+// editDistance - Функция, реализующая вычисление расстояния Левенштейна между строками
+func editDistance(a, b string) int {
+
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// joinLines - Функция, реализующая объединение ошибок в одну, по ошибке на строке
+func joinLines(errs []error) error {
+
+	lines := make([]string, 0, len(errs))
+	for _, err := range errs {
+		lines = append(lines, err.Error())
+	}
+
+	return errors.New(strings.Join(lines, "\n  "))
+}
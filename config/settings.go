@@ -0,0 +1,289 @@
+package config
+
+import "strconv"
+
+// settingKind - Тип данных, описывающий допустимый вид значения настройки
+type settingKind int
+
+const (
+	kindString    settingKind = iota // Произвольная строка
+	kindBool                         // "true" или "false"
+	kindInt                          // Неотрицательное целое число
+	kindDuration                     // Длительность time.ParseDuration, например "20m"
+	kindRetention                    // Длительность с поддержкой дней, например "90d"
+	kindEnum                         // Одно из перечисленных значений
+	kindList                         // Список значений через запятую
+)
+
+// Setting - Тип данных, описывающий настройку приложения
+// (значение задается ключом файла настроек, переменной окружения или флагом командной строки)
+type Setting struct {
+	Key         string      // Ключ в файле настроек вида "раздел.имя"
+	Env         string      // Переменная окружения
+	Description string      // Описание для справки
+	Default     string      // Значение по умолчанию (пустая строка - значение по умолчанию выбирает потребитель)
+	kind        settingKind // Вид значения
+	values      []string    // Допустимые значения для kindEnum
+	required    bool        // Обязательна ли настройка
+	secret      bool        // Скрывать ли значение в сообщениях об ошибках
+	reloadable  bool        // Применяется ли изменение без перезапуска (по сигналу SIGHUP)
+}
+
+// Flag - Метод, возвращающий название флага командной строки настройки (например "server-addr")
+func (s Setting) Flag() string {
+
+	flag := []byte(s.Key)
+	for i, c := range flag {
+		if c == '.' || c == '_' {
+			flag[i] = '-'
+		}
+	}
+
+	return string(flag)
+}
+
+// Toggle - Метод, возвращающий, является ли настройка переключателем ("true" или "false")
+func (s Setting) Toggle() bool {
+	return s.kind == kindBool
+}
+
+// Settings - Настройки приложения, поддерживаемые файлом настроек, переменными окружения и флагами
+var Settings = []Setting{
+	// Сервер
+	{Key: "server.addr", Env: "SERVER_ADDR", Default: ServerPort, Description: "address to listen on"},
+	{Key: "server.trust_proxy", Env: "TRUST_PROXY", kind: kindBool,
+		Description: "trust X-Forwarded-For from a reverse proxy"},
+	{Key: "server.trust_proxy_hops", Env: "TRUST_PROXY_HOPS", kind: kindInt,
+		Description: "number of reverse proxies in front of the server"},
+	{Key: "server.compression", Env: "COMPRESSION", kind: kindEnum, values: []string{"on", "off"},
+		Description: "gzip compression of API responses"},
+	{Key: "server.compression_min_size", Env: "COMPRESSION_MIN_SIZE", kind: kindInt,
+		Description: "smallest response compressed, in bytes"},
+	{Key: "server.pid_file", Env: "PID_FILE", Description: "file the PID of the serving process is written to"},
+	{Key: "server.rate_limit", Env: "RATE_LIMIT",
+		Description: "API requests allowed per client IP as count/window, e.g. 100/1m"},
+	{Key: "server.rate_limit_backend", Env: "RATE_LIMIT_BACKEND", kind: kindEnum, values: []string{"memory", "redis"},
+		Description: "where rate limit counters live (redis shares them between instances)"},
+	{Key: "server.cors_allowed_origins", Env: "CORS_ALLOWED_ORIGINS", kind: kindList,
+		Description: "origins allowed to call the API from browsers"},
+	{Key: "server.cors_allowed_methods", Env: "CORS_ALLOWED_METHODS", kind: kindList,
+		Description: "methods allowed in CORS requests"},
+	{Key: "server.cors_allowed_headers", Env: "CORS_ALLOWED_HEADERS", kind: kindList,
+		Description: "headers allowed in CORS requests"},
+	{Key: "server.cors_max_age", Env: "CORS_MAX_AGE", kind: kindInt, Description: "preflight cache time, in seconds"},
+
+	// База данных
+	{Key: "database.url", Env: "DATABASE_URL", secret: true, Description: "PostgreSQL connection string"},
+	{Key: "database.max_conns", Env: "DATABASE_MAX_CONNS", kind: kindInt,
+		Description: "maximum number of database connections"},
+
+	// Redis
+	{Key: "redis.url", Env: "REDIS_URL", secret: true, Description: "Redis connection URL"},
+
+	// Журналы
+	{Key: "log.level", Env: "LOG_LEVEL", kind: kindEnum, values: []string{"debug", "info", "warn", "error"},
+		reloadable: true, Description: "log level"},
+	{Key: "log.format", Env: "LOG_FORMAT", kind: kindEnum, values: []string{"console", "text", "json"},
+		Description: "log format"},
+	{Key: "log.access", Env: "ACCESS_LOG", Description: "access log: stdout, off or a file path"},
+
+	// Трассировка
+	{Key: "tracing.otlp_endpoint", Env: "OTEL_EXPORTER_OTLP_ENDPOINT",
+		Description: "OTLP/HTTP collector, e.g. http://localhost:4318; tracing is off without it"},
+	{Key: "tracing.service_name", Env: "OTEL_SERVICE_NAME", Description: "service name of the spans, default urlgen"},
+	{Key: "tracing.sample_ratio", Env: "TRACING_SAMPLE_RATIO",
+		Description: "share of new traces recorded, from 0 to 1, default 1"},
+
+	// Метрики StatsD
+	{Key: "statsd.addr", Env: "STATSD_ADDR",
+		Description: "StatsD agent host:port, e.g. localhost:8125; metrics are pushed only with it"},
+	{Key: "statsd.format", Env: "STATSD_FORMAT", kind: kindEnum, values: []string{"statsd", "dogstatsd"},
+		Description: "statsd (labels in names) or dogstatsd (labels as tags)"},
+	{Key: "statsd.prefix", Env: "STATSD_PREFIX", Description: "prefix of metric names"},
+	{Key: "statsd.tags", Env: "STATSD_TAGS", kind: kindList, Description: "constant dogstatsd tags, e.g. env:prod"},
+	{Key: "statsd.interval", Env: "STATSD_INTERVAL", kind: kindDuration, Description: "push interval, default 10s"},
+
+	// Сообщения об ошибках
+	{Key: "errors.sentry_dsn", Env: "SENTRY_DSN", secret: true,
+		Description: "Sentry project DSN; errors are reported only with it"},
+	{Key: "errors.environment", Env: "SENTRY_ENVIRONMENT", Description: "environment of the reported errors"},
+	{Key: "errors.sample_rate", Env: "SENTRY_SAMPLE_RATE",
+		Description: "share of errors reported, from 0 to 1, default 1"},
+
+	// Отладка
+	{Key: "debug.addr", Env: "DEBUG_ADDR",
+		Description: "address of the pprof and expvar server, e.g. 127.0.0.1:6060; off without it"},
+	{Key: "debug.token", Env: "DEBUG_TOKEN", secret: true,
+		Description: "token required by the debug server (bearer or basic auth password)"},
+
+	// Кеш
+	{Key: "cache.ttl", Env: "CACHE_TTL", kind: kindDuration, reloadable: true,
+		Description: "lifetime of cached links"},
+
+	// Переходы
+	{Key: "redirect.status", Env: "REDIRECT_STATUS", kind: kindEnum, values: []string{"301", "302", "307", "308"},
+		Description: "default redirect status"},
+	{Key: "redirect.cache_max_age", Env: "REDIRECT_CACHE_MAX_AGE", kind: kindDuration,
+		Description: "Cache-Control max-age of redirects"},
+	{Key: "redirect.etag", Env: "REDIRECT_ETAG", kind: kindBool, Description: "send ETag with redirects"},
+	{Key: "redirect.count_head_clicks", Env: "COUNT_HEAD_CLICKS", kind: kindBool,
+		Description: "count HEAD requests as clicks"},
+
+	// Исходные ссылки и коды
+	{Key: "urls.domain_policy", Env: "DOMAIN_POLICY", kind: kindEnum, values: []string{"block", "allow"},
+		reloadable: true, Description: "domain list mode"},
+	{Key: "urls.allowed_schemes", Env: "URL_ALLOWED_SCHEMES", kind: kindList,
+		Description: "schemes allowed in destinations"},
+	{Key: "urls.strip_fragment", Env: "URL_STRIP_FRAGMENT", kind: kindBool,
+		Description: "drop #fragment from destinations"},
+	{Key: "urls.block_private", Env: "URL_BLOCK_PRIVATE", kind: kindBool,
+		Description: "reject destinations in private networks"},
+	{Key: "urls.code_strategy", Env: "CODE_STRATEGY", Default: "hash",
+		Description: "code generator: hash, sequence, snowflake, pool, emoji, nanoid, redis or a registered one"},
+	{Key: "urls.code_strategy_candidate", Env: "CODE_STRATEGY_CANDIDATE",
+		Description: "code generator rolled out by the candidate_generator flag"},
+	{Key: "urls.code_pool_size", Env: "CODE_POOL_SIZE", kind: kindInt,
+		Description: "number of pre-generated codes kept by the pool generator"},
+	{Key: "urls.code_entropy_bits", Env: "CODE_ENTROPY_BITS", kind: kindInt,
+		Description: "entropy of nanoid codes, in bits"},
+	{Key: "urls.code_length", Env: "CODE_LENGTH", kind: kindInt, Default: strconv.Itoa(ShortUrlLen),
+		Description: "length of generated codes, grows as codes run out"},
+	{Key: "urls.code_alphabet", Env: "CODE_ALPHABET", Description: "characters of generated codes"},
+	{Key: "urls.code_max_attempts", Env: "CODE_MAX_ATTEMPTS", kind: kindInt,
+		Description: "attempts to save a link with a new code when codes collide"},
+	{Key: "urls.code_node_id", Env: "CODE_NODE_ID", kind: kindInt,
+		Description: "node id of snowflake codes, unique per instance"},
+	{Key: "urls.code_blocked_words", Env: "CODE_BLOCKED_WORDS", kind: kindList, reloadable: true,
+		Description: "words generated codes must not contain"},
+	{Key: "urls.code_signing_key", Env: "CODE_SIGNING_KEY", secret: true,
+		Description: "HMAC key that signs generated codes"},
+	{Key: "urls.code_signature_length", Env: "CODE_SIGNATURE_LENGTH", kind: kindInt,
+		Description: "length of code signatures"},
+	{Key: "urls.code_checksum", Env: "CODE_CHECKSUM", kind: kindBool,
+		Description: "append a check character to generated codes"},
+	{Key: "urls.code_case_insensitive", Env: "CODE_CASE_INSENSITIVE", kind: kindBool,
+		Description: "ignore the case of codes"},
+	{Key: "urls.short_domains", Env: "SHORT_DOMAINS", kind: kindList,
+		Description: "branded domains with their own codes"},
+	{Key: "urls.reserved_codes", Env: "RESERVED_CODES", kind: kindList, reloadable: true,
+		Description: "codes that cannot be used as aliases"},
+	{Key: "urls.safe_browsing_api_key", Env: "SAFE_BROWSING_API_KEY", secret: true,
+		Description: "Google Safe Browsing API key"},
+	{Key: "urls.safe_browsing_warn", Env: "SAFE_BROWSING_WARN", kind: kindBool,
+		Description: "warn before redirecting to flagged destinations"},
+
+	// Флаги функций
+	{Key: "flags.features", Env: "FEATURE_FLAGS", kind: kindList, reloadable: true,
+		Description: "feature flags as name=percentage[@domain|domain]"},
+
+	// Аутентификация
+	{Key: "auth.jwt_secret", Env: "JWT_SECRET", required: true, secret: true,
+		Description: "secret used to sign access tokens"},
+	{Key: "auth.signup_enabled", Env: "SIGNUP_ENABLED", kind: kindBool, Description: "allow self registration"},
+	{Key: "auth.admin_username", Env: "ADMIN_USERNAME", Description: "operator created on start"},
+	{Key: "auth.admin_password", Env: "ADMIN_PASSWORD", secret: true, Description: "password of the initial user"},
+	{Key: "auth.password_reset_url", Env: "PASSWORD_RESET_URL",
+		Description: "page that completes a password reset"},
+	{Key: "auth.oidc_issuer_url", Env: "OIDC_ISSUER_URL", Description: "OpenID Connect issuer"},
+	{Key: "auth.oidc_client_id", Env: "OIDC_CLIENT_ID", Description: "OpenID Connect client id"},
+	{Key: "auth.oidc_client_secret", Env: "OIDC_CLIENT_SECRET", secret: true,
+		Description: "OpenID Connect client secret"},
+	{Key: "auth.oidc_redirect_url", Env: "OIDC_REDIRECT_URL", Description: "OpenID Connect callback URL"},
+	{Key: "auth.oidc_scopes", Env: "OIDC_SCOPES", kind: kindList, Description: "OpenID Connect scopes"},
+
+	// Квоты ключей API
+	{Key: "quotas.tiers", Env: "API_KEY_TIERS", kind: kindList, reloadable: true,
+		Description: "API key quota tiers as name:daily_creates/links"},
+	{Key: "quotas.default_tier", Env: "API_KEY_DEFAULT_TIER", reloadable: true,
+		Description: "tier of API keys without one"},
+
+	// Аналитика и приватность
+	{Key: "analytics.geoip_db_path", Env: "GEOIP_DB_PATH", Description: "MaxMind GeoIP2 database"},
+	{Key: "analytics.ip_privacy", Env: "IP_PRIVACY", kind: kindEnum, values: []string{"off", "truncate", "hash"},
+		Description: "how client IPs are stored"},
+	{Key: "analytics.ip_hash_salt", Env: "IP_HASH_SALT", secret: true, Description: "salt of hashed IPs"},
+	{Key: "analytics.click_retention", Env: "CLICK_RETENTION", kind: kindRetention,
+		Description: "how long clicks are kept, e.g. 90d"},
+	{Key: "analytics.clickhouse_url", Env: "CLICKHOUSE_URL", secret: true,
+		Description: "ClickHouse HTTP interface clicks are stored in and stats are read from, e.g. http://localhost:8123/urlgen"},
+	{Key: "analytics.bigquery_dataset", Env: "BIGQUERY_DATASET",
+		Description: "BigQuery dataset daily click aggregates and links are exported to; off without it"},
+	{Key: "analytics.bigquery_project", Env: "BIGQUERY_PROJECT", Description: "BigQuery project, default the key's project"},
+	{Key: "analytics.bigquery_credentials_file", Env: "BIGQUERY_CREDENTIALS_FILE",
+		Description: "service account key in JSON, default GOOGLE_APPLICATION_CREDENTIALS"},
+	{Key: "analytics.bigquery_export_hour", Env: "BIGQUERY_EXPORT_HOUR", kind: kindInt,
+		Description: "hour (UTC) after which the previous days are exported, default 1"},
+
+	// Страницы ошибок
+	{Key: "pages.not_found_template", Env: "NOT_FOUND_TEMPLATE", Description: "template of the 404 page"},
+	{Key: "pages.gone_template", Env: "GONE_TEMPLATE", Description: "template of the 410 page"},
+	{Key: "pages.not_yet_live_template", Env: "NOT_YET_LIVE_TEMPLATE",
+		Description: "template of links not live yet"},
+
+	// Резервные копии
+	{Key: "backup.s3_endpoint", Env: "BACKUP_S3_ENDPOINT",
+		Description: "S3-compatible storage, e.g. https://s3.amazonaws.com"},
+	{Key: "backup.s3_bucket", Env: "BACKUP_S3_BUCKET", Description: "bucket of backup snapshots"},
+	{Key: "backup.s3_region", Env: "BACKUP_S3_REGION", Description: "region of the bucket, default us-east-1"},
+	{Key: "backup.s3_access_key", Env: "BACKUP_S3_ACCESS_KEY", Description: "access key id"},
+	{Key: "backup.s3_secret_key", Env: "BACKUP_S3_SECRET_KEY", secret: true, Description: "secret access key"},
+	{Key: "backup.s3_prefix", Env: "BACKUP_S3_PREFIX", Default: "urlgen", Description: "key prefix of snapshots"},
+	{Key: "backup.retention", Env: "BACKUP_RETENTION", kind: kindRetention,
+		Description: "how long snapshots are kept, e.g. 30d"},
+
+	// Интеграции
+	{Key: "slack.signing_secret", Env: "SLACK_SIGNING_SECRET", secret: true,
+		Description: "signing secret of the Slack app serving /shorten"},
+	{Key: "slack.team_keys", Env: "SLACK_TEAM_KEYS", kind: kindList, secret: true,
+		Description: "API key of each Slack workspace as team_id:key"},
+	{Key: "slack.response_type", Env: "SLACK_RESPONSE_TYPE", kind: kindEnum, values: []string{"ephemeral", "in_channel"},
+		Description: "who sees the short link: the caller only or the whole channel"},
+	{Key: "discord.public_key", Env: "DISCORD_PUBLIC_KEY", Description: "public key of the Discord application"},
+	{Key: "discord.guild_keys", Env: "DISCORD_GUILD_KEYS", kind: kindList, secret: true,
+		Description: "API key of each Discord server as guild_id:key"},
+	{Key: "telegram.bot_token", Env: "TELEGRAM_BOT_TOKEN", secret: true, Description: "token of the Telegram bot"},
+	{Key: "telegram.api_key", Env: "TELEGRAM_API_KEY", secret: true,
+		Description: "API key the Telegram bot creates links and reads stats with"},
+	{Key: "telegram.allowed_users", Env: "TELEGRAM_ALLOWED_USERS", kind: kindList,
+		Description: "Telegram user ids or usernames allowed to use the bot"},
+	{Key: "telegram.webhook_url", Env: "TELEGRAM_WEBHOOK_URL",
+		Description: "public https URL of /api/v1/integrations/telegram/webhook instead of long polling"},
+	{Key: "telegram.api_url", Env: "TELEGRAM_API_URL", Description: "Telegram Bot API server, default https://api.telegram.org"},
+	{Key: "integrations.legacy_api", Env: "LEGACY_API", kind: kindBool,
+		Description: "serve the YOURLS (/yourls-api.php) and Bitly v3 (/v3/shorten) compatible API"},
+
+	// Потоки событий
+	{Key: "kafka.brokers", Env: "KAFKA_BROKERS", kind: kindList,
+		Description: "Kafka brokers click and link events are published to"},
+	{Key: "kafka.clicks_topic", Env: "KAFKA_CLICKS_TOPIC", Default: "urlgen.clicks", Description: "topic of click events"},
+	{Key: "kafka.links_topic", Env: "KAFKA_LINKS_TOPIC", Default: "urlgen.links", Description: "topic of link events"},
+	{Key: "kafka.tls", Env: "KAFKA_TLS", kind: kindBool, Description: "connect to the brokers over TLS"},
+	{Key: "kafka.sasl_mechanism", Env: "KAFKA_SASL_MECHANISM", kind: kindEnum,
+		values: []string{"plain", "scram-sha-256", "scram-sha-512"}, Description: "SASL authentication"},
+	{Key: "kafka.sasl_username", Env: "KAFKA_SASL_USERNAME", Description: "SASL user"},
+	{Key: "kafka.sasl_password", Env: "KAFKA_SASL_PASSWORD", secret: true, Description: "SASL password"},
+	{Key: "nats.url", Env: "NATS_URL", Description: "NATS servers click and link events are published to"},
+	{Key: "nats.clicks_subject", Env: "NATS_CLICKS_SUBJECT", Default: "urlgen.clicks", Description: "subject of click events"},
+	{Key: "nats.links_subject", Env: "NATS_LINKS_SUBJECT", Default: "urlgen.links", Description: "subject of link events"},
+	{Key: "nats.creds_file", Env: "NATS_CREDS_FILE", Description: "NATS user credentials file"},
+	{Key: "rabbitmq.url", Env: "RABBITMQ_URL", secret: true, Description: "RabbitMQ broker click and link events are published to"},
+	{Key: "rabbitmq.exchange", Env: "RABBITMQ_EXCHANGE", Default: "urlgen", Description: "exchange events are published to"},
+	{Key: "rabbitmq.clicks_routing_key", Env: "RABBITMQ_CLICKS_ROUTING_KEY", Default: "urlgen.clicks",
+		Description: "routing key of click events"},
+	{Key: "rabbitmq.links_routing_key", Env: "RABBITMQ_LINKS_ROUTING_KEY", Default: "urlgen.links",
+		Description: "routing key of link events"},
+
+	// Почта
+	{Key: "smtp.addr", Env: "SMTP_ADDR", Description: "SMTP server host:port"},
+	{Key: "smtp.username", Env: "SMTP_USERNAME", Description: "SMTP user"},
+	{Key: "smtp.password", Env: "SMTP_PASSWORD", secret: true, Description: "SMTP password"},
+	{Key: "smtp.from", Env: "SMTP_FROM", Description: "sender of emails"},
+
+	// TLS
+	{Key: "tls.cert_file", Env: "TLS_CERT_FILE", Description: "TLS certificate file"},
+	{Key: "tls.key_file", Env: "TLS_KEY_FILE", Description: "TLS private key file"},
+	{Key: "tls.acme_domains", Env: "ACME_DOMAINS", kind: kindList,
+		Description: "domains to obtain certificates for"},
+	{Key: "tls.acme_email", Env: "ACME_EMAIL", Description: "ACME account email"},
+	{Key: "tls.acme_cache_dir", Env: "ACME_CACHE_DIR", Description: "directory of obtained certificates"},
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"my_project/urlgen/config"
+	"strings"
+	"time"
+)
+
+// AuditEntry - Тип данных, реализующий структуру записи журнала аудита
+type AuditEntry struct {
+	Id          int             // (bigserial, primary_key, not null)
+	CreatedAt   time.Time       // (timestamptz, not null)
+	WorkspaceId int             // (integer) - 0 для действий вне рабочих пространств
+	UserId      int             // (integer) - 0 для действий ключа API
+	Username    string          // (text, not null)
+	ApiKeyId    int             // (integer) - 0 для действий пользователя
+	Action      string          // (text, not null) - например "link.delete"
+	Target      string          // (text, not null) - объект действия (короткая ссылка, домен, идентификатор ключа)
+	Details     json.RawMessage // (jsonb) - подробности действия
+	RequestId   string          // (text, not null)
+}
+
+// AuditFilter - Тип данных, описывающий отбор записей журнала аудита
+type AuditFilter struct {
+	WorkspaceId int        // Рабочее пространство (0 - действия вне рабочих пространств)
+	Action      string     // Действие или группа действий с точкой в конце (например "link.")
+	UserId      int        // Пользователь
+	Target      string     // Объект действия (или одна из ссылок массовой операции)
+	Since       *time.Time // Записи не раньше заданного времени
+	Until       *time.Time // Записи раньше заданного времени
+}
+
+// auditColumns - Столбцы записи журнала аудита в порядке сканирования
+const auditColumns = "id, created_at, COALESCE(workspace_id, 0), COALESCE(user_id, 0), username," +
+	" COALESCE(api_key_id, 0), action, target, details, request_id"
+
+// AddAuditEntries - Метод, позволяющий добавить записи в журнал аудита одним пакетом
+// (журнал только дополняется: изменение и удаление записей запрещены триггером БД)
+func (c *Database) AddAuditEntries(ctx context.Context, entries ...AuditEntry) error {
+
+	sql := "INSERT INTO" + config.AuditLogTableNameDB +
+		" (workspace_id, user_id, username, api_key_id, action, target, details, request_id)" +
+		" VALUES (NULLIF($1, 0), NULLIF($2, 0), $3, NULLIF($4, 0), $5, $6, $7, $8)"
+
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(sql, e.WorkspaceId, e.UserId, e.Username, e.ApiKeyId, e.Action, e.Target, e.Details, e.RequestId)
+	}
+
+	return c.db.SendBatch(ctx, batch).Close()
+}
+
+// ListAuditEntries - Метод, позволяющий получить страницу записей журнала аудита (новые записи первыми)
+func (c *Database) ListAuditEntries(ctx context.Context, filter AuditFilter, page Page) ([]AuditEntry, error) {
+
+	args := []any{filter.WorkspaceId, page.Offset, page.Limit, page.BeforeId}
+	where := []string{config.WorkspaceIdColName + " IS NOT DISTINCT FROM NULLIF($1, 0)", pageCondition(4)}
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if prefix, found := strings.CutSuffix(filter.Action, "."); found {
+		add("starts_with(action, $%d)", prefix+".")
+	} else if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.UserId != 0 {
+		add("user_id = $%d", filter.UserId)
+	}
+	if filter.Target != "" {
+		// Записи массовых операций перечисляют затронутые ссылки в подробностях
+		add("(target = $%[1]d OR details->'short_urls' @> to_jsonb($%[1]d::text))", filter.Target)
+	}
+	if filter.Since != nil {
+		add("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		add("created_at < $%d", *filter.Until)
+	}
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY id DESC OFFSET $2 LIMIT $3",
+		auditColumns, config.AuditLogTableNameDB, strings.Join(where, " AND "))
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []AuditEntry
+
+	for rows.Next() {
+		e := AuditEntry{}
+
+		err = rows.Scan(&e.Id, &e.CreatedAt, &e.WorkspaceId, &e.UserId, &e.Username, &e.ApiKeyId,
+			&e.Action, &e.Target, &e.Details, &e.RequestId)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, e)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"my_project/urlgen/config"
+	"time"
+)
+
+// ClickAggregate - Тип данных, описывающий количество переходов по ссылке за сутки (UTC)
+type ClickAggregate struct {
+	ShortUrl  string    `json:"short_url"`  // Короткая ссылка
+	Day       time.Time `json:"day"`        // Начало суток
+	Clicks    int       `json:"clicks"`     // Количество переходов без переходов ботов
+	BotClicks int       `json:"bot_clicks"` // Количество переходов ботов
+}
+
+// ExportRows - Метод, позволяющий последовательно получить все неудаленные ссылки в порядке идентификаторов
+// (строки передаются fn по мере чтения без накопления в памяти; ошибка fn прерывает выгрузку)
+func (c *Database) ExportRows(ctx context.Context, fn func(RowData) error) error {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE deleted_at IS NULL ORDER BY id", rowColumns, config.TableNameDB)
+
+	rows, err := c.db.Query(ctx, sql)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := RowData{}
+
+		if err = scanRow(rows, &r); err != nil {
+			return err
+		}
+
+		if err = fn(r); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ExportClickAggregates - Метод, позволяющий последовательно получить количество переходов по неудаленным
+// ссылкам за сутки (строки упорядочены по ссылке и суткам)
+func (c *Database) ExportClickAggregates(ctx context.Context, fn func(ClickAggregate) error) error {
+
+	sql := fmt.Sprintf("SELECT c.%[1]s, date_trunc('day', c.clicked_at AT TIME ZONE 'UTC') AS day,"+
+		" count(*) FILTER (WHERE c.%[2]s), count(*) FILTER (WHERE c.is_bot)"+
+		" FROM %[3]s c JOIN %[4]s g ON g.%[1]s = c.%[1]s WHERE g.deleted_at IS NULL"+
+		" GROUP BY c.%[1]s, day ORDER BY c.%[1]s, day",
+		config.ShortUrlColName, notBotCondition, config.ClicksTableNameDB, config.TableNameDB)
+
+	return c.exportClickAggregates(ctx, sql, fn)
+}
+
+// ExportDayClickAggregates - Метод, позволяющий последовательно получить количество переходов по ссылкам
+// за заданные сутки (UTC), включая удаленные ссылки (строки упорядочены по ссылке)
+func (c *Database) ExportDayClickAggregates(ctx context.Context, day time.Time, fn func(ClickAggregate) error) error {
+
+	from := day.UTC().Truncate(24 * time.Hour)
+
+	sql := fmt.Sprintf("SELECT %[1]s, date_trunc('day', $1::timestamptz AT TIME ZONE 'UTC') AS day,"+
+		" count(*) FILTER (WHERE %[2]s), count(*) FILTER (WHERE is_bot)"+
+		" FROM %[3]s WHERE clicked_at >= $1 AND clicked_at < $2 GROUP BY %[1]s ORDER BY %[1]s",
+		config.ShortUrlColName, notBotCondition, config.ClicksTableNameDB)
+
+	return c.exportClickAggregates(ctx, sql, fn, from, from.Add(24*time.Hour))
+}
+
+// exportClickAggregates - Метод, реализующий последовательное чтение количества переходов по ссылкам за сутки
+// (запрос возвращает ссылку, начало суток, количество переходов людей и ботов)
+func (c *Database) exportClickAggregates(ctx context.Context, sql string, fn func(ClickAggregate) error,
+	args ...any) error {
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := ClickAggregate{}
+
+		if err = rows.Scan(&a.ShortUrl, &a.Day, &a.Clicks, &a.BotClicks); err != nil {
+			return err
+		}
+
+		// Сутки без часового пояса читаются как UTC
+		a.Day = time.Date(a.Day.Year(), a.Day.Month(), a.Day.Day(), 0, 0, 0, 0, time.UTC)
+
+		if err = fn(a); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"my_project/urlgen/config"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LinkFilter - Тип данных, описывающий отбор ссылок рабочего пространства для массовой операции
+// (заданные условия объединяются через "И")
+type LinkFilter struct {
+	ShortUrls     []string   // Короткие ссылки
+	UrlPrefix     string     // Начало исходной ссылки
+	Domain        string     // Домен исходной ссылки (без поддоменов)
+	Tag           string     // Метка ссылки
+	CreatedAfter  *time.Time // Ссылки, созданные не раньше заданного времени
+	CreatedBefore *time.Time // Ссылки, созданные раньше заданного времени
+}
+
+// condition - Метод, возвращающий условие SQL отбора ссылок (параметры добавляются к args)
+func (f LinkFilter) condition(args *[]any) string {
+
+	arg := func(v any) string {
+		*args = append(*args, v)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+
+	var conds []string
+
+	if len(f.ShortUrls) != 0 {
+		conds = append(conds, config.ShortUrlColName+" = ANY("+arg(f.ShortUrls)+")")
+	}
+	if f.UrlPrefix != "" {
+		conds = append(conds, "starts_with("+config.UrlColName+", "+arg(f.UrlPrefix)+")")
+	}
+	if f.Domain != "" {
+		pattern := `^[a-z][a-z0-9+.-]*://([^/?#@]*@)?` + regexp.QuoteMeta(strings.ToLower(f.Domain)) + `(:[0-9]+)?([/?#]|$)`
+		conds = append(conds, config.UrlColName+" ~* "+arg(pattern))
+	}
+	if f.Tag != "" {
+		conds = append(conds, arg(f.Tag)+" = ANY(tags)")
+	}
+	if f.CreatedAfter != nil {
+		conds = append(conds, "created_at >= "+arg(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		conds = append(conds, "created_at < "+arg(*f.CreatedBefore))
+	}
+
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+
+	return strings.Join(conds, " AND ")
+}
+
+// BulkChange - Тип функции, изменяющей строку массовой операции (false, если строку изменять не нужно)
+type BulkChange func(row *RowData) bool
+
+// BulkUpdateRows - Метод, позволяющий изменить или удалить ссылки рабочего пространства, отобранные фильтром
+// (строки обрабатываются пачками заданного размера, каждая пачка - в отдельной транзакции;
+// при dryRun транзакции откатываются; возвращает измененные строки)
+func (c *Database) BulkUpdateRows(ctx context.Context, workspaceId int, filter LinkFilter, batchSize int,
+	deleteRows, dryRun bool, change BulkChange) ([]RowData, error) {
+
+	args := []any{workspaceId, 0, batchSize}
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND deleted_at IS NULL AND id > $2 AND %s"+
+		" ORDER BY id LIMIT $3 FOR UPDATE", rowColumns, config.TableNameDB, config.WorkspaceIdColName,
+		filter.condition(&args))
+
+	var changed []RowData
+
+	for {
+		batch, lastId, err := c.bulkUpdateBatch(ctx, sql, args, deleteRows, dryRun, change)
+		if err != nil {
+			return changed, err
+		}
+
+		changed = append(changed, batch...)
+
+		if lastId == 0 {
+			return changed, nil
+		}
+
+		args[1] = lastId
+	}
+}
+
+// bulkUpdateBatch - Метод, реализующий обработку одной пачки массовой операции в транзакции
+// (возвращает идентификатор последней прочитанной строки или 0, если строк больше нет)
+func (c *Database) bulkUpdateBatch(ctx context.Context, sql string, args []any, deleteRows, dryRun bool,
+	change BulkChange) ([]RowData, int, error) {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var batch []RowData
+
+	for rows.Next() {
+		r := RowData{}
+
+		err = scanRow(rows, &r)
+		if err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+
+		batch = append(batch, r)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(batch) == 0 {
+		return nil, 0, nil
+	}
+
+	lastId := batch[len(batch)-1].Id
+
+	// Изменение отобранных строк
+	var changed []RowData
+	queries := &pgx.Batch{}
+
+	for _, r := range batch {
+		if deleteRows {
+			queries.Queue("UPDATE"+config.TableNameDB+" SET deleted_at = now() WHERE id = $1", r.Id)
+		} else {
+			if !change(&r) {
+				continue
+			}
+
+			queries.Queue("UPDATE"+config.TableNameDB+" SET "+config.UrlColName+" = $1, disabled = $2, tags = $3"+
+				" WHERE id = $4", r.Url, r.Disabled, tagsArg(r.Tags), r.Id)
+		}
+
+		changed = append(changed, r)
+	}
+
+	if queries.Len() != 0 {
+		err = tx.SendBatch(ctx, queries).Close()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if dryRun {
+		return changed, lastId, nil
+	}
+
+	return changed, lastId, tx.Commit(ctx)
+}
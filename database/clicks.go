@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/click_pipeline"
+	"strings"
+	"time"
+)
+
+// ClickCount - Тип данных, реализующий структуру количества переходов за интервал времени
+type ClickCount struct {
+	Time   time.Time // Начало интервала
+	Clicks int       // Количество переходов
+}
+
+// WriteClicks - Метод, позволяющий сохранить в БД пачку переходов по коротким ссылкам
+func (c *Database) WriteClicks(ctx context.Context, events []click_pipeline.Event) error {
+
+	_, err := c.db.CopyFrom(ctx, pgx.Identifier{strings.Trim(config.ClicksTableNameDB, " \"")},
+		[]string{config.ShortUrlColName, "clicked_at", "referrer", "user_agent", "country", "region",
+			"browser", "os", "device_class", "is_bot", "variant", "ip"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			e := events[i]
+			return []any{e.ShortUrl, e.Time, nullIfEmpty(e.Referrer), nullIfEmpty(e.UserAgent),
+				nullIfEmpty(e.Country), nullIfEmpty(e.Region), nullIfEmpty(e.Browser), nullIfEmpty(e.OS),
+				nullIfEmpty(e.DeviceClass), e.Bot, nullIfZero(e.Variant), nullIfEmpty(e.StoredIP)}, nil
+		}))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ImportClicks - Метод, позволяющий записать суточное количество переходов по ссылке, перенесенное из другой
+// системы (переходы записываются на начало суток без сведений о клиенте, как при восстановлении)
+func (c *Database) ImportClicks(ctx context.Context, a ClickAggregate) error {
+
+	_, err := c.db.Exec(ctx, clickAggregateSQL, a.ShortUrl, a.Day, a.Clicks, a.BotClicks)
+
+	return err
+}
+
+// DeleteClicksBefore - Метод, позволяющий удалить переходы, записанные раньше заданного времени
+// (возвращает количество удаленных переходов)
+func (c *Database) DeleteClicksBefore(ctx context.Context, before time.Time) (int64, error) {
+
+	tag, err := c.db.Exec(ctx, "DELETE FROM"+config.ClicksTableNameDB+" WHERE clicked_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// notBotCondition - Условие отбора переходов, не помеченных как переходы ботов
+const notBotCondition = "is_bot IS NOT TRUE"
+
+// GetDailyClicks - Метод, позволяющий получить количество переходов по дням за заданное число последних дней
+// (переходы ботов не учитываются)
+func (c *Database) GetDailyClicks(ctx context.Context, shortUrl string, days int) ([]ClickCount, error) {
+
+	sql := fmt.Sprintf("SELECT date_trunc('day', clicked_at) AS day, count(*) FROM %s"+
+		" WHERE %s = $1 AND clicked_at >= now() - make_interval(days => $2) AND %s"+
+		" GROUP BY day ORDER BY day", config.ClicksTableNameDB, config.ShortUrlColName, notBotCondition)
+
+	rows, err := c.db.Query(ctx, sql, shortUrl, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ClickCount
+
+	for rows.Next() {
+		cc := ClickCount{}
+
+		err = rows.Scan(&cc.Time, &cc.Clicks)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, cc)
+	}
+
+	return result, rows.Err()
+}
+
+// nullIfZero - Функция, возвращающая nil для нулевого значения (для записи NULL в БД)
+func nullIfZero(v int) any {
+	if v == 0 {
+		return nil
+	}
+
+	return v
+}
+
+// nullIfEmpty - Функция, возвращающая nil для пустой строки (для записи NULL в БД)
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"my_project/urlgen/config"
+)
+
+// ErrCodePoolEmpty - Ошибка получения кода из пустого запаса
+var ErrCodePoolEmpty = errors.New("error: Code pool is empty")
+
+// FillCodePool - Метод, позволяющий добавить коды в запас заранее созданных кодов
+// (коды, уже занятые ссылками или находящиеся в запасе, пропускаются; возвращает количество добавленных кодов)
+func (c *Database) FillCodePool(ctx context.Context, codes []string) (int, error) {
+
+	sql := "INSERT INTO" + config.CodePoolTableNameDB + " (code) SELECT code FROM unnest($1::text[]) AS code" +
+		" WHERE NOT EXISTS (SELECT 1 FROM" + config.TableNameDB + " g WHERE g." + config.ShortUrlColName +
+		" = $2 || code) ON CONFLICT (code) DO NOTHING"
+
+	tag, err := c.db.Exec(ctx, sql, codes, config.GenUrl)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// TakePoolCode - Метод, позволяющий забрать один код из запаса
+// (код удаляется из запаса в той же операции, поэтому одновременные запросы получают разные коды;
+// если запас пуст, возвращается ErrCodePoolEmpty)
+func (c *Database) TakePoolCode(ctx context.Context) (string, error) {
+
+	sql := "DELETE FROM" + config.CodePoolTableNameDB + " WHERE code = (SELECT code FROM" +
+		config.CodePoolTableNameDB + " LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING code"
+
+	var code string
+
+	err := c.db.QueryRow(ctx, sql).Scan(&code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrCodePoolEmpty
+	}
+
+	return code, err
+}
+
+// CountPoolCodes - Метод, позволяющий получить количество кодов в запасе
+func (c *Database) CountPoolCodes(ctx context.Context) (int, error) {
+
+	var n int
+
+	err := c.db.QueryRow(ctx, "SELECT count(*) FROM"+config.CodePoolTableNameDB).Scan(&n)
+
+	return n, err
+}
@@ -2,92 +2,589 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
+	"math"
 	"my_project/urlgen/config"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // RowData - Тип данных, реализующий структуру для работы с данными в строке БД
 type RowData struct {
-	Id       int    // (serial, not null)
-	Url      string // (text, not null)
-	ShortUrl string // (text, primary_key, not null)
+	Id        int       // (serial, not null)
+	Url       string    // (text, not null)
+	ShortUrl  string    // (text, primary_key, not null)
+	UserId    int       // (integer, null) - 0, если владелец не задан
+	CreatedAt time.Time // (timestamptz, not null)
+
+	WorkspaceId int    // (integer, null) - 0 для ссылок, созданных без рабочего пространства
+	Domain      string // (text, not null) - брендированный домен короткой ссылки (пустая строка - основной домен)
+	ApiKeyId    int    // (integer, null) - ключ API, которым создана ссылка (только запись, 0 - создана пользователем)
+
+	RedirectStatus int        // (smallint, null) - 0, если используется статус по умолчанию
+	ExpiresAt      *time.Time // (timestamptz, null) - nil, если срок действия не ограничен
+	ActiveFrom     *time.Time // (timestamptz, null) - nil, если ссылка действует с момента создания
+	PasswordHash   string     // (text, null) - пустая строка, если пароль не задан
+
+	QueryParams map[string]string // (jsonb, null) - параметры, добавляемые к исходной ссылке при переходе
+
+	Variants       []Variant // (jsonb, null) - варианты исходной ссылки для A/B теста
+	StickyVariants bool      // (boolean, not null) - закреплять ли выбранный вариант за клиентом
+
+	DeviceUrls map[string]string // (jsonb, null) - исходные ссылки для платформ ("ios", "android", "desktop")
+	GeoUrls    map[string]string // (jsonb, null) - исходные ссылки для стран и регионов ("US", "US-CA")
+
+	MaxClicks  int // (integer, null) - 0, если количество переходов не ограничено
+	ClickCount int // (integer, not null) - количество переходов, учтенных в лимите
+
+	Disabled    bool // (boolean, not null) - отключены ли переходы по ссылке
+	NoAnalytics bool // (boolean, not null) - не записываются ли переходы по ссылке в аналитику
+
+	Tags []string // (text[], null) - метки ссылки
+}
+
+// Variant - Тип данных, реализующий структуру варианта исходной ссылки с весом
+type Variant struct {
+	Url    string `json:"url"`    // Исходная ссылка варианта
+	Weight int    `json:"weight"` // Вес варианта (доля переходов пропорциональна весу)
+}
+
+// Expired - Метод, проверяющий, истек ли срок действия ссылки
+func (r *RowData) Expired() bool {
+	return r.ExpiresAt != nil && !time.Now().Before(*r.ExpiresAt)
+}
+
+// NotYetLive - Метод, проверяющий, что срок действия ссылки еще не начался
+func (r *RowData) NotYetLive() bool {
+	return r.ActiveFrom != nil && time.Now().Before(*r.ActiveFrom)
+}
+
+// Exhausted - Метод, проверяющий, исчерпан ли лимит переходов по ссылке
+func (r *RowData) Exhausted() bool {
+	return r.MaxClicks > 0 && r.ClickCount >= r.MaxClicks
+}
+
+// Plain - Метод, проверяющий, что у ссылки нет собственных параметров (срока действия, пароля, лимита переходов,
+// параметров перехода, вариантов, меток и т.п.), поэтому ее можно выдать повторно для той же исходной ссылки
+// (условие совпадает с plainCondition)
+func (r *RowData) Plain() bool {
+	return r.RedirectStatus == 0 && r.ExpiresAt == nil && r.ActiveFrom == nil && r.PasswordHash == "" &&
+		len(r.QueryParams) == 0 && len(r.Variants) == 0 && !r.StickyVariants && len(r.DeviceUrls) == 0 &&
+		len(r.GeoUrls) == 0 && r.MaxClicks == 0 && !r.Disabled && !r.NoAnalytics && len(r.Tags) == 0
+}
+
+// ErrShortUrlExists - Ошибка сохранения строки с уже существующей короткой ссылкой
+var ErrShortUrlExists = errors.New("error: Short url already exists")
+
+// rowColumns - Список столбцов, читаемых в RowData (порядок совпадает с порядком полей в scanRow)
+var rowColumns = fmt.Sprintf("id, %s, %s, COALESCE(%s, 0), created_at, COALESCE(redirect_status, 0), expires_at,"+
+	" COALESCE(password_hash, ''), query_params, variants, sticky_variants, device_urls, geo_urls, active_from,"+
+	" COALESCE(max_clicks, 0), click_count, disabled, COALESCE(%s, 0), no_analytics, tags, domain",
+	config.UrlColName, config.ShortUrlColName, config.UserIdColName, config.WorkspaceIdColName)
+
+// logQueryError - Метод, реализующий запись в журнал ошибки запроса (отсутствие строк ошибкой не считается)
+func (c *Database) logQueryError(ctx context.Context, query string, err error) {
+	if !errors.Is(err, pgx.ErrNoRows) {
+		c.logger.ErrorContext(ctx, "Database query failed", "query", query, "error", err)
+	}
+}
+
+// scanRow - Функция, реализующая чтение столбцов "rowColumns" в заданную структуру
+func scanRow(row pgx.Row, r *RowData) error {
+	return row.Scan(&r.Id, &r.Url, &r.ShortUrl, &r.UserId, &r.CreatedAt, &r.RedirectStatus, &r.ExpiresAt, &r.PasswordHash,
+		&r.QueryParams, &r.Variants, &r.StickyVariants, &r.DeviceUrls, &r.GeoUrls, &r.ActiveFrom,
+		&r.MaxClicks, &r.ClickCount, &r.Disabled, &r.WorkspaceId, &r.NoAnalytics, &r.Tags, &r.Domain)
 }
 
 // Database - Тип данных, реализующий структуру для более удобной работы с БД и подключением в ней
 type Database struct {
-	db *pgx.Conn // База данных
+	db     *pgxpool.Pool // Пул подключений к БД
+	logger *slog.Logger  // Журнал работы с БД
 }
 
 // GetConnection - Функция, позволяющая подключиться к БД
-func GetConnection() (Database, error) {
+// (DATABASE_URL - строка подключения, DATABASE_MAX_CONNS - максимальное количество соединений)
+func GetConnection(logger *slog.Logger) (Database, error) {
+
+	poolConfig, err := pgxpool.ParseConfig(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return Database{}, err
+	}
+
+	if v := os.Getenv("DATABASE_MAX_CONNS"); v != "" {
+		maxConns, err := strconv.Atoi(v)
+		if err != nil || maxConns <= 0 || maxConns > math.MaxInt32 {
+			return Database{}, errors.New("error: DATABASE_MAX_CONNS must be a positive number")
+		}
 
-	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+		poolConfig.MaxConns = int32(maxConns)
+	}
+
+	// Спаны запросов продолжают трассу запроса, в контексте которого выполняются
+	poolConfig.ConnConfig.Tracer = queryTracer{}
+
+	conn, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return Database{}, err
 	}
 
-	return Database{conn}, nil
+	return Database{db: conn, logger: logger}, nil
 }
 
-// GetUrlRow - Метод, позволяющий получить строку из БД по заданной исходной ссылке
-func (c *Database) GetUrlRow(url string) (*RowData, bool) {
+// GetUrlRow - Метод, позволяющий получить строку из БД по заданной исходной ссылке в рабочем пространстве
+// на домене коротких ссылок (0 - ссылки без рабочего пространства, пустой домен - основной домен;
+// только строки без собственных параметров, см. RowData.Plain)
+func (c *Database) GetUrlRow(ctx context.Context, workspaceId int, domain, url string) (*RowData, bool) {
 
 	var row pgx.Row
 
-	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", config.TableNameDB, config.UrlColName)
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND %s AND domain = $3 AND deleted_at IS NULL AND %s",
+		rowColumns, config.TableNameDB, config.UrlColName, workspaceCondition, plainCondition)
 
-	row = c.db.QueryRow(context.Background(), sql, url)
+	row = c.db.QueryRow(ctx, sql, url, workspaceId, domain)
 
 	r := RowData{}
 
-	err := row.Scan(&r.Id, &r.Url, &r.ShortUrl)
+	err := scanRow(row, &r)
 	if err != nil {
+		c.logQueryError(ctx, sql, err)
 		return nil, false
 	}
 
 	return &r, true
 }
 
+// GetUrlRows - Метод, позволяющий получить из БД строки для нескольких исходных ссылок рабочего пространства
+// на домене коротких ссылок за один запрос (результат сопоставлен исходным ссылкам; только строки
+// без собственных параметров)
+func (c *Database) GetUrlRows(ctx context.Context, workspaceId int, domain string, urls []string) (map[string]RowData, error) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1) AND %s AND domain = $3 AND deleted_at IS NULL AND %s",
+		rowColumns, config.TableNameDB, config.UrlColName, workspaceCondition, plainCondition)
+
+	rows, err := c.db.Query(ctx, sql, urls, workspaceId, domain)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]RowData, len(urls))
+
+	for rows.Next() {
+		r := RowData{}
+
+		err = scanRow(rows, &r)
+		if err != nil {
+			return nil, err
+		}
+
+		result[r.Url] = r
+	}
+
+	return result, rows.Err()
+}
+
 // GetShortUrlRow - Метод, позволяющий получить строку из БД по заданной короткой ссылке
-func (c *Database) GetShortUrlRow(shortUrl string) (*RowData, bool) {
+func (c *Database) GetShortUrlRow(ctx context.Context, shortUrl string) (*RowData, bool) {
 
 	var row pgx.Row
 
-	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", config.TableNameDB, config.ShortUrlColName)
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND deleted_at IS NULL", rowColumns, config.TableNameDB, config.ShortUrlColName)
 
-	row = c.db.QueryRow(context.Background(), sql, shortUrl)
+	row = c.db.QueryRow(ctx, sql, shortUrl)
 
 	r := RowData{}
 
-	err := row.Scan(&r.Id, &r.Url, &r.ShortUrl)
+	err := scanRow(row, &r)
 	if err != nil {
+		c.logQueryError(ctx, sql, err)
 		return nil, false
 	}
 
 	return &r, true
 }
 
+// workspaceCondition - Условие отбора строк рабочего пространства, заданного вторым параметром запроса
+var workspaceCondition = config.WorkspaceIdColName + " IS NOT DISTINCT FROM NULLIF($2, 0)"
+
+// notExpiredCondition - Условие отбора строк с неистекшим сроком действия и неисчерпанным лимитом переходов
+const notExpiredCondition = "(expires_at IS NULL OR expires_at > now()) AND (max_clicks IS NULL OR click_count < max_clicks)"
+
+// plainCondition - Условие отбора строк без собственных параметров (совпадает с RowData.Plain; такие строки
+// не истекают и не исчерпывают лимит переходов)
+const plainCondition = "redirect_status IS NULL AND expires_at IS NULL AND active_from IS NULL AND password_hash IS NULL" +
+	" AND query_params IS NULL AND variants IS NULL AND NOT sticky_variants AND device_urls IS NULL AND geo_urls IS NULL" +
+	" AND max_clicks IS NULL AND NOT disabled AND NOT no_analytics AND COALESCE(cardinality(tags), 0) = 0"
+
+// insertRowSQL - Запрос сохранения строки (удаленная, истекшая или исчерпавшая лимит переходов строка
+// с той же короткой ссылкой заменяется новой)
+var insertRowSQL = "INSERT INTO" + config.TableNameDB +
+	" (" + config.UrlColName + ", " + config.ShortUrlColName + ", " + config.UserIdColName + ", redirect_status, expires_at, password_hash," +
+	" query_params, variants, sticky_variants, device_urls, geo_urls, active_from, max_clicks, disabled, " + config.WorkspaceIdColName + ", api_key_id, no_analytics, tags, id, domain)" +
+	" VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12, NULLIF($13, 0), $14, NULLIF($15, 0)," +
+	" NULLIF($16, 0), $17, $18, COALESCE(NULLIF($19, 0), nextval(" + rowIdSequence + ")), $20)" +
+	" ON CONFLICT (" + config.ShortUrlColName + ") DO UPDATE SET " + config.UrlColName + " = EXCLUDED." + config.UrlColName + ", " +
+	config.UserIdColName + " = EXCLUDED." + config.UserIdColName + ", redirect_status = EXCLUDED.redirect_status," +
+	" expires_at = EXCLUDED.expires_at, password_hash = EXCLUDED.password_hash, query_params = EXCLUDED.query_params," +
+	" variants = EXCLUDED.variants, sticky_variants = EXCLUDED.sticky_variants, device_urls = EXCLUDED.device_urls," +
+	" geo_urls = EXCLUDED.geo_urls, active_from = EXCLUDED.active_from, max_clicks = EXCLUDED.max_clicks," +
+	" click_count = 0, disabled = EXCLUDED.disabled, no_analytics = EXCLUDED.no_analytics, tags = EXCLUDED.tags, domain = EXCLUDED.domain," +
+	" " + config.WorkspaceIdColName + " = EXCLUDED." + config.WorkspaceIdColName + ", api_key_id = EXCLUDED.api_key_id," +
+	" created_at = now(), deleted_at = NULL" +
+	" WHERE" + config.TableNameDB + ".deleted_at IS NOT NULL OR" + config.TableNameDB + ".expires_at <= now() OR" +
+	config.TableNameDB + ".click_count >=" + config.TableNameDB + ".max_clicks"
+
+// insertRowArgs - Функция, возвращающая параметры запроса "insertRowSQL" для заданной строки
+func insertRowArgs(row RowData) []any {
+	return []any{row.Url, row.ShortUrl, row.UserId, row.RedirectStatus, row.ExpiresAt, row.PasswordHash,
+		queryParamsArg(row.QueryParams), variantsArg(row.Variants), row.StickyVariants,
+		queryParamsArg(row.DeviceUrls), queryParamsArg(row.GeoUrls), row.ActiveFrom, row.MaxClicks, row.Disabled, row.WorkspaceId,
+		row.ApiKeyId, row.NoAnalytics, tagsArg(row.Tags), row.Id, row.Domain}
+}
+
+// rowIdSequence - Выражение SQL последовательности идентификаторов строк
+var rowIdSequence = "pg_get_serial_sequence('" + strings.TrimSpace(config.TableNameDB) + "', 'id')"
+
+// LastRowId - Метод, позволяющий получить последний выданный идентификатор строк (0, если идентификаторы не выдавались)
+func (c *Database) LastRowId(ctx context.Context) (int, error) {
+
+	var id int
+
+	err := c.db.QueryRow(ctx, "SELECT COALESCE(pg_sequence_last_value("+rowIdSequence+"::regclass), 0)").Scan(&id)
+
+	return id, err
+}
+
+// NextRowIds - Метод, позволяющий заранее получить идентификаторы для n новых строк
+// (идентификаторы уникальны и не выдаются повторно, строки сохраняются с ними через RowData.Id)
+func (c *Database) NextRowIds(ctx context.Context, n int) ([]int, error) {
+
+	rows, err := c.db.Query(ctx, "SELECT nextval("+rowIdSequence+") FROM generate_series(1, $1)", n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0, n)
+
+	for rows.Next() {
+		var id int
+
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// CountShortUrls - Метод, позволяющий получить примерное количество строк в БД
+// (используется оценка статистики таблицы, точный подсчет - только если таблица еще не анализировалась)
+func (c *Database) CountShortUrls(ctx context.Context) (int64, error) {
+
+	var n int64
+
+	err := c.db.QueryRow(ctx, "SELECT reltuples::bigint FROM pg_class WHERE oid = $1::regclass",
+		strings.TrimSpace(config.TableNameDB)).Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+
+	err = c.db.QueryRow(ctx, "SELECT count(*) FROM"+config.TableNameDB).Scan(&n)
+
+	return n, err
+}
+
+// ListShortUrlsSince - Метод, позволяющий получить короткие ссылки строк, созданных начиная с заданного времени
+// (нулевое время - все строки)
+func (c *Database) ListShortUrlsSince(ctx context.Context, since time.Time) ([]string, error) {
+
+	rows, err := c.db.Query(ctx, "SELECT "+config.ShortUrlColName+" FROM"+config.TableNameDB+
+		" WHERE created_at >= $1 AND deleted_at IS NULL", since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shortUrls []string
+
+	for rows.Next() {
+		var shortUrl string
+
+		if err = rows.Scan(&shortUrl); err != nil {
+			return nil, err
+		}
+
+		shortUrls = append(shortUrls, shortUrl)
+	}
+
+	return shortUrls, rows.Err()
+}
+
+// tagsArg - Функция, возвращающая параметр запроса для столбца tags (nil для пустого списка)
+func tagsArg(tags []string) any {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return tags
+}
+
+// variantsArg - Функция, возвращающая параметр запроса для столбца variants (nil для пустого списка)
+func variantsArg(variants []Variant) any {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	return variants
+}
+
+// queryParamsArg - Функция, возвращающая параметр запроса для столбцов query_params, device_urls и geo_urls
+// (nil для пустого набора)
+func queryParamsArg(params map[string]string) any {
+	if len(params) == 0 {
+		return nil
+	}
+
+	return params
+}
+
 // SaveShortUrl - Метод, позволяющий сохранить в БД заданную строку
-func (c *Database) SaveShortUrl(row RowData) error {
+// (удаленная, истекшая или исчерпавшая лимит переходов строка с той же короткой ссылкой заменяется новой)
+func (c *Database) SaveShortUrl(ctx context.Context, row RowData) error {
 
-	_, err := c.db.Exec(context.Background(), "INSERT INTO"+config.TableNameDB+
-		" ("+config.UrlColName+", "+config.ShortUrlColName+") VALUES ($1, $2)", row.Url, row.ShortUrl)
+	tag, err := c.db.Exec(ctx, insertRowSQL, insertRowArgs(row)...)
 	if err != nil {
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return ErrShortUrlExists
+	}
+
 	return nil
 }
 
-// CloseConnection - Метод, реализующий закрытие соединения с БД
-func (c *Database) CloseConnection() error {
+// SaveShortUrls - Метод, позволяющий сохранить в БД несколько строк за один обмен с сервером
+// (возвращает ошибку для каждой строки; после ошибки выполнения запроса оставшиеся строки не сохраняются)
+func (c *Database) SaveShortUrls(ctx context.Context, rows []RowData) []error {
+
+	batch := &pgx.Batch{}
+	for _, row := range rows {
+		batch.Queue(insertRowSQL, insertRowArgs(row)...)
+	}
+
+	results := c.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	errs := make([]error, len(rows))
+
+	for i := range rows {
+		tag, err := results.Exec()
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		if tag.RowsAffected() == 0 {
+			errs[i] = ErrShortUrlExists
+		}
+	}
+
+	return errs
+}
+
+// Page - Тип данных, описывающий страницу списка строк (новые строки первыми)
+type Page struct {
+	Offset   int // Количество пропускаемых строк
+	Limit    int // Размер страницы
+	BeforeId int // Идентификатор, после которого продолжается выборка (0 - с начала списка)
+}
+
+// pageCondition - Условие отбора строк страницы по идентификатору, заданному параметром запроса с номером n
+func pageCondition(n int) string {
+	return fmt.Sprintf("($%d = 0 OR id < $%d)", n, n)
+}
+
+// ListRows - Метод, позволяющий получить страницу строк рабочего пространства из БД (новые ссылки первыми)
+func (c *Database) ListRows(ctx context.Context, workspaceId int, page Page) ([]RowData, error) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND deleted_at IS NULL AND %s ORDER BY id DESC OFFSET $2 LIMIT $3",
+		rowColumns, config.TableNameDB, config.WorkspaceIdColName, pageCondition(4))
 
-	err := c.db.Close(context.Background())
+	rows, err := c.db.Query(ctx, sql, workspaceId, page.Offset, page.Limit, page.BeforeId)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []RowData
+
+	for rows.Next() {
+		r := RowData{}
+
+		err = scanRow(rows, &r)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// ListUserRows - Метод, позволяющий получить страницу строк, созданных пользователем в рабочих пространствах,
+// участником которых он остается (новые ссылки первыми)
+func (c *Database) ListUserRows(ctx context.Context, userId int, page Page) ([]RowData, error) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND (%s IS NULL OR %s IN (SELECT %s FROM %s WHERE %s = $1))"+
+		" AND deleted_at IS NULL AND %s ORDER BY id DESC OFFSET $2 LIMIT $3", rowColumns, config.TableNameDB,
+		config.UserIdColName, config.WorkspaceIdColName, config.WorkspaceIdColName, config.WorkspaceIdColName,
+		config.MembersTableNameDB, config.UserIdColName, pageCondition(4))
+
+	rows, err := c.db.Query(ctx, sql, userId, page.Offset, page.Limit, page.BeforeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []RowData
+
+	for rows.Next() {
+		r := RowData{}
+
+		err = scanRow(rows, &r)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// ListExpiredRows - Метод, позволяющий получить неудаленные строки, срок действия которых истек в заданном интервале
+func (c *Database) ListExpiredRows(ctx context.Context, from, to time.Time) ([]RowData, error) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE deleted_at IS NULL AND expires_at > $1 AND expires_at <= $2 ORDER BY id",
+		rowColumns, config.TableNameDB)
+
+	rows, err := c.db.Query(ctx, sql, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []RowData
+
+	for rows.Next() {
+		r := RowData{}
+
+		err = scanRow(rows, &r)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// UpdateRow - Метод, позволяющий сохранить изменяемые поля заданной строки (поиск по короткой ссылке)
+func (c *Database) UpdateRow(ctx context.Context, row RowData) (bool, error) {
+
+	tag, err := c.db.Exec(ctx, "UPDATE"+config.TableNameDB+
+		" SET "+config.UrlColName+" = $1, redirect_status = NULLIF($2, 0), expires_at = $3, password_hash = NULLIF($4, ''),"+
+		" query_params = $5, variants = $6, sticky_variants = $7, device_urls = $8, geo_urls = $9,"+
+		" active_from = $10, max_clicks = NULLIF($11, 0), disabled = $12, no_analytics = $13,"+
+		" tags = $14 WHERE "+config.ShortUrlColName+" = $15 AND deleted_at IS NULL",
+		row.Url, row.RedirectStatus, row.ExpiresAt, row.PasswordHash, queryParamsArg(row.QueryParams),
+		variantsArg(row.Variants), row.StickyVariants, queryParamsArg(row.DeviceUrls),
+		queryParamsArg(row.GeoUrls), row.ActiveFrom, row.MaxClicks, row.Disabled, row.NoAnalytics, tagsArg(row.Tags),
+		row.ShortUrl)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
+
+// ConsumeClick - Метод, позволяющий атомарно учесть переход по ссылке с ограниченным количеством переходов
+// (возвращает количество учтенных переходов и false, если лимит уже исчерпан)
+func (c *Database) ConsumeClick(ctx context.Context, shortUrl string) (int, bool, error) {
+
+	var count int
+
+	err := c.db.QueryRow(ctx, "UPDATE"+config.TableNameDB+" SET click_count = click_count + 1 WHERE "+
+		config.ShortUrlColName+" = $1 AND deleted_at IS NULL AND click_count < max_clicks RETURNING click_count",
+		shortUrl).Scan(&count)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return count, true, nil
+}
+
+// DeleteRow - Метод, позволяющий пометить строку с заданной короткой ссылкой удаленной
+// (строка и статистика переходов сохраняются в БД)
+func (c *Database) DeleteRow(ctx context.Context, shortUrl string) (bool, error) {
+
+	tag, err := c.db.Exec(ctx, "UPDATE"+config.TableNameDB+
+		" SET deleted_at = now() WHERE "+config.ShortUrlColName+" = $1 AND deleted_at IS NULL", shortUrl)
+	if err != nil {
+		return false, err
 	}
 
+	return tag.RowsAffected() != 0, nil
+}
+
+// IsDeleted - Метод, проверяющий, была ли удалена строка с заданной короткой ссылкой
+func (c *Database) IsDeleted(ctx context.Context, shortUrl string) bool {
+
+	var deleted bool
+
+	err := c.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM"+config.TableNameDB+
+		" WHERE "+config.ShortUrlColName+" = $1 AND deleted_at IS NOT NULL)", shortUrl).Scan(&deleted)
+	if err != nil {
+		c.logQueryError(ctx, "IsDeleted", err)
+		return false
+	}
+
+	return deleted
+}
+
+// Ping - Метод, проверяющий доступность БД
+func (c *Database) Ping(ctx context.Context) error {
+	return c.db.Ping(ctx)
+}
+
+// Stat - Метод, позволяющий получить статистику пула подключений к БД
+func (c *Database) Stat() *pgxpool.Stat {
+	return c.db.Stat()
+}
+
+// CloseConnection - Метод, реализующий закрытие соединений с БД
+func (c *Database) CloseConnection() error {
+
+	c.db.Close()
+
 	return nil
 }
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"my_project/urlgen/config"
+	"time"
+)
+
+// DomainRule - Тип данных, реализующий структуру правила для домена исходных ссылок
+type DomainRule struct {
+	Domain    string    // (text, primary_key, not null)
+	List      string    // (text, primary_key, not null) - "block" или "allow"
+	CreatedAt time.Time // (timestamptz, not null)
+}
+
+// ListDomainRules - Метод, позволяющий получить все правила доменов из БД
+func (c *Database) ListDomainRules(ctx context.Context) ([]DomainRule, error) {
+
+	sql := fmt.Sprintf("SELECT domain, list, created_at FROM %s ORDER BY list, domain", config.DomainRulesTableNameDB)
+
+	rows, err := c.db.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []DomainRule
+
+	for rows.Next() {
+		r := DomainRule{}
+
+		err = rows.Scan(&r.Domain, &r.List, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// AddDomainRule - Метод, позволяющий сохранить в БД правило домена (повторное добавление не считается ошибкой)
+func (c *Database) AddDomainRule(ctx context.Context, list, domain string) (*DomainRule, error) {
+
+	sql := "INSERT INTO" + config.DomainRulesTableNameDB + " (domain, list) VALUES ($1, $2)" +
+		" ON CONFLICT (list, domain) DO UPDATE SET domain = EXCLUDED.domain RETURNING domain, list, created_at"
+
+	r := DomainRule{}
+
+	err := c.db.QueryRow(ctx, sql, domain, list).Scan(&r.Domain, &r.List, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// DeleteDomainRule - Метод, позволяющий удалить правило домена из БД
+func (c *Database) DeleteDomainRule(ctx context.Context, list, domain string) (bool, error) {
+
+	tag, err := c.db.Exec(ctx, "DELETE FROM"+config.DomainRulesTableNameDB+" WHERE list = $1 AND domain = $2", list, domain)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
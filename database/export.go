@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"my_project/urlgen/config"
+	"time"
+)
+
+// ClickExportFilter - Тип данных, описывающий отбор переходов для выгрузки
+type ClickExportFilter struct {
+	WorkspaceId int       // Рабочее пространство ссылок
+	ShortUrl    string    // Короткая ссылка (пустая строка - все ссылки пространства)
+	From        time.Time // Начало периода
+	To          time.Time // Конец периода (не включается)
+	IncludeBots bool      // Выгружать ли переходы ботов
+}
+
+// ClickEvent - Тип данных, реализующий структуру сохраненного перехода по короткой ссылке
+type ClickEvent struct {
+	ShortUrl    string    // Короткая ссылка
+	Time        time.Time // Время перехода
+	Referrer    string    // Источник перехода
+	UserAgent   string    // Заголовок User-Agent клиента
+	Country     string    // Страна клиента
+	Region      string    // Регион клиента
+	Browser     string    // Браузер клиента
+	OS          string    // Операционная система клиента
+	DeviceClass string    // Класс устройства клиента
+	Bot         bool      // Переход бота
+	Variant     int       // Номер варианта исходной ссылки (0 - основная ссылка)
+}
+
+// where - Метод, возвращающий условие SQL отбора переходов и его параметры
+// (переходы удаленных ссылок не выгружаются)
+func (f ClickExportFilter) where() (string, []any) {
+
+	where := fmt.Sprintf("g.%s = $1 AND g.deleted_at IS NULL AND c.clicked_at >= $2 AND c.clicked_at < $3",
+		config.WorkspaceIdColName)
+	args := []any{f.WorkspaceId, f.From, f.To}
+
+	if f.ShortUrl != "" {
+		args = append(args, f.ShortUrl)
+		where += fmt.Sprintf(" AND c.%s = $%d", config.ShortUrlColName, len(args))
+	}
+
+	if !f.IncludeBots {
+		where += " AND c." + notBotCondition
+	}
+
+	return where, args
+}
+
+// ExportClicks - Метод, позволяющий последовательно получить переходы, отобранные фильтром, в порядке времени
+// (строки передаются fn по мере чтения без накопления в памяти; ошибка fn прерывает выгрузку)
+func (c *Database) ExportClicks(ctx context.Context, filter ClickExportFilter, fn func(ClickEvent) error) error {
+
+	where, args := filter.where()
+
+	sql := fmt.Sprintf("SELECT c.%[1]s, c.clicked_at, COALESCE(c.referrer, ''), COALESCE(c.user_agent, ''),"+
+		" COALESCE(c.country, ''), COALESCE(c.region, ''), COALESCE(c.browser, ''), COALESCE(c.os, ''),"+
+		" COALESCE(c.device_class, ''), c.is_bot, COALESCE(c.variant, 0)"+
+		" FROM %[2]s c JOIN %[3]s g ON g.%[1]s = c.%[1]s WHERE %[4]s ORDER BY c.clicked_at, c.id",
+		config.ShortUrlColName, config.ClicksTableNameDB, config.TableNameDB, where)
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := ClickEvent{}
+
+		err = rows.Scan(&e.ShortUrl, &e.Time, &e.Referrer, &e.UserAgent, &e.Country, &e.Region, &e.Browser,
+			&e.OS, &e.DeviceClass, &e.Bot, &e.Variant)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ExportClickCounts - Метод, позволяющий последовательно получить количество переходов по ссылкам и интервалам
+// (bucket - "hour" или "day"; строки упорядочены по ссылке и началу интервала)
+func (c *Database) ExportClickCounts(ctx context.Context, filter ClickExportFilter, bucket string,
+	fn func(shortUrl string, count ClickCount) error) error {
+
+	where, args := filter.where()
+
+	sql := fmt.Sprintf("SELECT c.%[1]s, date_trunc('%[2]s', c.clicked_at) AS bucket, count(*)"+
+		" FROM %[3]s c JOIN %[4]s g ON g.%[1]s = c.%[1]s WHERE %[5]s"+
+		" GROUP BY c.%[1]s, bucket ORDER BY c.%[1]s, bucket",
+		config.ShortUrlColName, bucket, config.ClicksTableNameDB, config.TableNameDB, where)
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shortUrl string
+		cc := ClickCount{}
+
+		if err = rows.Scan(&shortUrl, &cc.Time, &cc.Clicks); err != nil {
+			return err
+		}
+
+		if err = fn(shortUrl, cc); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"my_project/urlgen/config"
+	"time"
+)
+
+// FeatureFlag - Тип данных, реализующий структуру флага функции, измененного через API
+type FeatureFlag struct {
+	Name       string    // (text, primary_key, not null)
+	Percentage int       // (integer, not null) - доля включения от 0 до 100
+	Domains    []string  // (text[], not null) - домены коротких ссылок, на которых действует флаг (пусто - все)
+	UpdatedAt  time.Time // (timestamptz, not null)
+}
+
+// ListFeatureFlags - Метод, позволяющий получить все флаги функций из БД
+func (c *Database) ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error) {
+
+	sql := fmt.Sprintf("SELECT name, percentage, domains, updated_at FROM %s ORDER BY name", config.FeatureFlagsTableNameDB)
+
+	rows, err := c.db.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []FeatureFlag
+
+	for rows.Next() {
+		f := FeatureFlag{}
+
+		err = rows.Scan(&f.Name, &f.Percentage, &f.Domains, &f.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, f)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveFeatureFlag - Метод, позволяющий сохранить в БД флаг функции (прежнее значение флага заменяется)
+func (c *Database) SaveFeatureFlag(ctx context.Context, name string, percentage int, domains []string) (*FeatureFlag, error) {
+
+	if domains == nil {
+		domains = []string{}
+	}
+
+	sql := "INSERT INTO" + config.FeatureFlagsTableNameDB + " (name, percentage, domains) VALUES ($1, $2, $3)" +
+		" ON CONFLICT (name) DO UPDATE SET percentage = EXCLUDED.percentage, domains = EXCLUDED.domains," +
+		" updated_at = now() RETURNING name, percentage, domains, updated_at"
+
+	f := FeatureFlag{}
+
+	err := c.db.QueryRow(ctx, sql, name, percentage, domains).Scan(&f.Name, &f.Percentage, &f.Domains, &f.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// DeleteFeatureFlag - Метод, позволяющий удалить флаг функции из БД (флаг возвращается к значению из настроек)
+func (c *Database) DeleteFeatureFlag(ctx context.Context, name string) (bool, error) {
+
+	tag, err := c.db.Exec(ctx, "DELETE FROM"+config.FeatureFlagsTableNameDB+" WHERE name = $1", name)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
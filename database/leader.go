@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// leaderLockId - Ключ рекомендательной блокировки, которую удерживает ведущий экземпляр сервера
+const leaderLockId = 7461002
+
+// LeaderLock - Тип данных, реализующий удерживаемую блокировку ведущего экземпляра
+// (блокировка уровня сеанса живет, пока открыто отдельное подключение, взятое из пула)
+type LeaderLock struct {
+	conn *pgxpool.Conn // Подключение, удерживающее блокировку
+}
+
+// TryLeaderLock - Метод, позволяющий попытаться стать ведущим экземпляром без ожидания
+// (возвращает nil без ошибки, если блокировку удерживает другой экземпляр)
+func (c *Database) TryLeaderLock(ctx context.Context) (*LeaderLock, error) {
+
+	conn, err := c.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+
+	err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockId).Scan(&locked)
+	if err != nil || !locked {
+		conn.Release()
+		return nil, err
+	}
+
+	return &LeaderLock{conn: conn}, nil
+}
+
+// Check - Метод, проверяющий, что подключение с блокировкой живо
+// (при разрыве подключения БД снимает блокировку, и ведущим может стать другой экземпляр)
+func (l *LeaderLock) Check(ctx context.Context) error {
+	return l.conn.Ping(ctx)
+}
+
+// Release - Метод, реализующий снятие блокировки и возврат подключения
+// (подключение с неудачным снятием закрывается, так как блокировка осталась бы в пуле)
+func (l *LeaderLock) Release(ctx context.Context) {
+
+	if _, err := l.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", leaderLockId); err != nil {
+		_ = l.conn.Conn().Close(ctx)
+	}
+
+	l.conn.Release()
+}
@@ -0,0 +1,249 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"my_project/urlgen/config"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles - Встроенные миграции схемы БД вида "0001_name.up.sql" и "0001_name.down.sql"
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockId - Ключ рекомендательной блокировки, под которой применяются миграции
+// (параллельные запуски выполняются по очереди)
+const migrationLockId = 7461001
+
+// Migration - Тип данных, описывающий миграцию схемы БД
+type Migration struct {
+	Version   int        // Номер миграции
+	Name      string     // Название миграции
+	Up        string     // SQL применения
+	Down      string     // SQL отмены (пустая строка - миграция не отменяется)
+	AppliedAt *time.Time // Время применения (nil - не применена)
+}
+
+// Migrations - Функция, позволяющая получить встроенные миграции по возрастанию номера
+func Migrations() ([]Migration, error) {
+
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		base, direction, found := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		number, title, hasTitle := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !found || !hasTitle || err != nil || version <= 0 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("error: invalid migration file name %q", name)
+		}
+
+		data, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, found := byVersion[version]
+		if !found {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if m.Name != title {
+			return nil, fmt.Errorf("error: migration %d has two names, %q and %q", version, m.Name, title)
+		}
+
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("error: migration %d has no up file", m.Version)
+		}
+
+		result = append(result, *m)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// MigrationStatus - Метод, позволяющий получить встроенные миграции с временем их применения
+func (c *Database) MigrationStatus(ctx context.Context) ([]Migration, error) {
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.Query(ctx, "SELECT version, applied_at FROM"+config.MigrationsTableNameDB)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range migrations {
+		if t, found := applied[migrations[i].Version]; found {
+			migrations[i].AppliedAt = &t
+		}
+	}
+
+	return migrations, nil
+}
+
+// MigrateUp - Метод, позволяющий применить все непримененные миграции по возрастанию номера
+// (каждая миграция применяется в отдельной транзакции; возвращает примененные миграции)
+func (c *Database) MigrateUp(ctx context.Context) ([]Migration, error) {
+
+	migrations, err := c.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+
+	for _, m := range migrations {
+		if m.AppliedAt != nil {
+			continue
+		}
+
+		applied, err := c.applyMigration(ctx, m, true)
+		if err != nil {
+			return done, fmt.Errorf("error: migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+
+		if applied {
+			done = append(done, m)
+		}
+	}
+
+	return done, nil
+}
+
+// MigrateDown - Метод, позволяющий отменить заданное количество последних примененных миграций
+// (возвращает отмененные миграции)
+func (c *Database) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+
+	migrations, err := c.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if m.AppliedAt == nil {
+			continue
+		}
+
+		if m.Down == "" {
+			return done, fmt.Errorf("error: migration %d_%s cannot be reverted", m.Version, m.Name)
+		}
+
+		reverted, err := c.applyMigration(ctx, m, false)
+		if err != nil {
+			return done, fmt.Errorf("error: reverting migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+
+		if reverted {
+			done = append(done, m)
+		}
+	}
+
+	return done, nil
+}
+
+// ensureMigrationsTable - Метод, реализующий создание таблицы примененных миграций
+func (c *Database) ensureMigrationsTable(ctx context.Context) error {
+
+	_, err := c.db.Exec(ctx, "CREATE TABLE IF NOT EXISTS"+config.MigrationsTableNameDB+
+		" (version integer not null primary key, name text not null, applied_at timestamptz not null default now())")
+
+	return err
+}
+
+// applyMigration - Метод, реализующий применение (up = true) или отмену миграции вместе с записью о ней
+// в одной транзакции под рекомендательной блокировкой (возвращает false, если параллельный запуск уже выполнил то же)
+func (c *Database) applyMigration(ctx context.Context, m Migration, up bool) (bool, error) {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockId); err != nil {
+		return false, err
+	}
+
+	var applied bool
+
+	err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM"+config.MigrationsTableNameDB+" WHERE version = $1)",
+		m.Version).Scan(&applied)
+	if err != nil {
+		return false, err
+	}
+
+	if applied == up {
+		return false, nil
+	}
+
+	migration, record, args := m.Up, "INSERT INTO"+config.MigrationsTableNameDB+" (version, name) VALUES ($1, $2)",
+		[]any{m.Version, m.Name}
+	if !up {
+		migration, record, args = m.Down, "DELETE FROM"+config.MigrationsTableNameDB+" WHERE version = $1",
+			[]any{m.Version}
+	}
+
+	// SQL без параметров выполняется простым протоколом, поэтому файл может содержать несколько команд
+	if _, err = tx.Exec(ctx, migration); err != nil {
+		return false, err
+	}
+
+	if _, err = tx.Exec(ctx, record, args...); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
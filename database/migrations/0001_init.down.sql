@@ -0,0 +1,10 @@
+drop table if exists "CodePool";
+drop table if exists "Clicks";
+drop table if exists "Webhooks";
+drop table if exists "GenTable";
+drop table if exists "ApiKeys";
+drop table if exists "WorkspaceMembers";
+drop table if exists "Workspaces";
+drop table if exists "PasswordResets";
+drop table if exists "DomainRules";
+drop table if exists "Users";
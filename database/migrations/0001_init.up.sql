@@ -0,0 +1,174 @@
+create table if not exists "Users"
+(
+    id serial not null primary key,
+    username text not null unique,
+    password_hash text not null
+    );
+
+create table if not exists "GenTable"
+(
+    id serial not null,
+    url text not null,
+    short_url text not null primary key
+    );
+
+alter table "GenTable" add column if not exists user_id integer references "Users" (id);
+
+alter table "Users" add column if not exists external_subject text unique;
+
+alter table "GenTable" add column if not exists created_at timestamptz not null default now();
+
+create table if not exists "Clicks"
+(
+    id bigserial not null primary key,
+    short_url text not null references "GenTable" (short_url) on delete cascade,
+    clicked_at timestamptz not null default now()
+    );
+
+create index if not exists clicks_short_url_clicked_at_idx on "Clicks" (short_url, clicked_at);
+
+alter table "GenTable" add column if not exists redirect_status smallint
+    check (redirect_status in (301, 302, 307, 308));
+
+alter table "GenTable" add column if not exists deleted_at timestamptz;
+
+alter table "GenTable" add column if not exists expires_at timestamptz;
+
+alter table "GenTable" add column if not exists password_hash text;
+
+alter table "Clicks" add column if not exists referrer text;
+alter table "Clicks" add column if not exists country text;
+alter table "Clicks" add column if not exists device_class text;
+
+alter table "Clicks" add column if not exists region text;
+alter table "Clicks" add column if not exists user_agent text;
+alter table "Clicks" add column if not exists browser text;
+alter table "Clicks" add column if not exists os text;
+
+alter table "GenTable" add column if not exists query_params jsonb;
+
+create table if not exists "DomainRules"
+(
+    domain text not null,
+    list text not null check (list in ('block', 'allow')),
+    created_at timestamptz not null default now(),
+    primary key (list, domain)
+    );
+
+alter table "Clicks" add column if not exists is_bot boolean not null default false;
+
+create table if not exists "Webhooks"
+(
+    id serial not null primary key,
+    url text not null,
+    secret text not null,
+    short_url text references "GenTable" (short_url) on delete cascade,
+    events text[] not null default '{click}',
+    user_id integer references "Users" (id),
+    created_at timestamptz not null default now()
+    );
+
+alter table "GenTable" add column if not exists variants jsonb;
+alter table "GenTable" add column if not exists sticky_variants boolean not null default false;
+
+alter table "Clicks" add column if not exists variant smallint;
+
+alter table "GenTable" add column if not exists device_urls jsonb;
+alter table "GenTable" add column if not exists geo_urls jsonb;
+alter table "GenTable" add column if not exists active_from timestamptz;
+
+alter table "GenTable" add column if not exists max_clicks integer check (max_clicks > 0);
+alter table "GenTable" add column if not exists click_count integer not null default 0;
+
+alter table "GenTable" add column if not exists disabled boolean not null default false;
+
+alter table "Users" add column if not exists email text unique;
+alter table "Users" add column if not exists created_at timestamptz not null default now();
+
+create table if not exists "PasswordResets"
+(
+    token_hash text not null primary key,
+    user_id integer not null references "Users" (id) on delete cascade,
+    expires_at timestamptz not null
+    );
+
+create table if not exists "Workspaces"
+(
+    id serial not null primary key,
+    name text not null,
+    created_at timestamptz not null default now()
+    );
+
+create table if not exists "WorkspaceMembers"
+(
+    workspace_id integer not null references "Workspaces" (id) on delete cascade,
+    user_id integer not null references "Users" (id) on delete cascade,
+    role text not null check (role in ('owner', 'admin', 'member')),
+    created_at timestamptz not null default now(),
+    primary key (workspace_id, user_id)
+    );
+
+create index if not exists workspace_members_user_id_idx on "WorkspaceMembers" (user_id);
+
+create table if not exists "ApiKeys"
+(
+    id serial not null primary key,
+    workspace_id integer not null references "Workspaces" (id) on delete cascade,
+    name text not null,
+    key_hash text not null unique,
+    role text not null check (role in ('admin', 'member')),
+    user_id integer references "Users" (id),
+    created_at timestamptz not null default now()
+    );
+
+alter table "GenTable" add column if not exists workspace_id integer references "Workspaces" (id);
+alter table "Webhooks" add column if not exists workspace_id integer references "Workspaces" (id) on delete cascade;
+
+create index if not exists gentable_workspace_id_idx on "GenTable" (workspace_id, id);
+
+-- Existing deployments: links and webhooks created before workspaces move to a shared
+-- workspace with every existing user (the first user owns it)
+do $$
+begin
+    if not exists (select 1 from "Workspaces") and exists (select 1 from "Users") then
+        insert into "Workspaces" (name) values ('Default');
+
+        insert into "WorkspaceMembers" (workspace_id, user_id, role)
+        select (select min(id) from "Workspaces"), id,
+               case when id = (select min(id) from "Users") then 'owner' else 'admin' end
+        from "Users";
+
+        update "GenTable" set workspace_id = (select min(id) from "Workspaces") where workspace_id is null;
+        update "Webhooks" set workspace_id = (select min(id) from "Workspaces") where workspace_id is null;
+    end if;
+end
+$$;
+
+-- Per-key quotas: links remember the api key that created them, keys may be assigned a quota tier
+alter table "ApiKeys" add column if not exists tier text;
+alter table "GenTable" add column if not exists api_key_id integer references "ApiKeys" (id) on delete set null;
+
+create index if not exists gentable_api_key_id_idx on "GenTable" (api_key_id, created_at) where api_key_id is not null;
+
+-- Privacy: clicks may keep an anonymized client IP, links may opt out of analytics,
+-- old clicks are purged by clicked_at
+alter table "Clicks" add column if not exists ip text;
+alter table "GenTable" add column if not exists no_analytics boolean not null default false;
+
+create index if not exists clicks_clicked_at_idx on "Clicks" (clicked_at);
+
+alter table "GenTable" add column if not exists tags text[];
+
+create index if not exists gentable_tags_idx on "GenTable" using gin (tags);
+
+-- Key pool: pre-generated codes not used by any link, handed out one at a time
+create table if not exists "CodePool" (
+    code       text primary key,
+    created_at timestamptz not null default now()
+);
+
+create index if not exists gentable_created_at_idx on "GenTable" (created_at);
+
+-- Branded domains: every domain has its own code namespace, the host is part of short_url
+-- (the primary key), domain names the branded domain for lookups by destination
+alter table "GenTable" add column if not exists domain text not null default '';
@@ -0,0 +1,32 @@
+-- Audit log: administrative actions (links, domain rules, api keys, members, webhooks) with the actor;
+-- entries outlive the workspaces, users and keys they mention, so the columns have no foreign keys
+create table if not exists "AuditLog" (
+    id           bigserial primary key,
+    created_at   timestamptz not null default now(),
+    workspace_id integer,
+    user_id      integer,
+    username     text not null default '',
+    api_key_id   integer,
+    action       text not null,
+    target       text not null default '',
+    details      jsonb,
+    request_id   text not null default ''
+);
+
+create index if not exists auditlog_workspace_id_idx on "AuditLog" (workspace_id, id);
+create index if not exists auditlog_target_idx on "AuditLog" (target, id);
+
+-- The log is append-only: updates, deletes and truncation are rejected (only the down migration drops it)
+create or replace function audit_log_append_only() returns trigger as $$
+begin
+    raise exception 'AuditLog is append-only';
+end
+$$ language plpgsql;
+
+drop trigger if exists auditlog_append_only on "AuditLog";
+create trigger auditlog_append_only before update or delete on "AuditLog"
+    for each row execute function audit_log_append_only();
+
+drop trigger if exists auditlog_no_truncate on "AuditLog";
+create trigger auditlog_no_truncate before truncate on "AuditLog"
+    for each statement execute function audit_log_append_only();
@@ -0,0 +1,8 @@
+-- Feature flags changed at runtime through the admin API; they override the FEATURE_FLAGS defaults
+-- of every instance (instances reread the table periodically)
+create table if not exists "FeatureFlags" (
+    name       text primary key,
+    percentage integer not null check (percentage between 0 and 100),
+    domains    text[] not null default '{}',
+    updated_at timestamptz not null default now()
+);
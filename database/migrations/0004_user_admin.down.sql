@@ -0,0 +1 @@
+alter table "Users" drop column if exists is_admin;
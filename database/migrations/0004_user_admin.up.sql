@@ -0,0 +1,3 @@
+-- Operators of the service: only they may call the /api/v1/admin endpoints that change instance-wide settings
+-- (the user created from ADMIN_USERNAME is marked on start)
+alter table "Users" add column if not exists is_admin boolean not null default false;
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"my_project/urlgen/config"
+	"time"
+)
+
+const (
+	expiredCondition     = "deleted_at IS NULL AND expires_at <= $1" // Ссылки, срок действия которых истек до $1 (без удаленных)
+	softDeletedCondition = "deleted_at <= $1"                        // Ссылки, удаленные до $1
+)
+
+// CountExpiredRows - Метод, позволяющий получить количество ссылок, срок действия которых истек до заданного времени
+func (c *Database) CountExpiredRows(ctx context.Context, before time.Time) (int64, error) {
+	return c.countRows(ctx, expiredCondition, before)
+}
+
+// PurgeExpiredRows - Метод, позволяющий окончательно удалить ссылки, срок действия которых истек до заданного времени,
+// вместе с их переходами (возвращает количество удаленных ссылок)
+func (c *Database) PurgeExpiredRows(ctx context.Context, before time.Time) (int64, error) {
+	return c.purgeRows(ctx, expiredCondition, before)
+}
+
+// CountSoftDeletedRows - Метод, позволяющий получить количество ссылок, удаленных до заданного времени
+func (c *Database) CountSoftDeletedRows(ctx context.Context, before time.Time) (int64, error) {
+	return c.countRows(ctx, softDeletedCondition, before)
+}
+
+// PurgeSoftDeletedRows - Метод, позволяющий окончательно удалить ссылки, удаленные до заданного времени,
+// вместе с их переходами (возвращает количество удаленных ссылок)
+func (c *Database) PurgeSoftDeletedRows(ctx context.Context, before time.Time) (int64, error) {
+	return c.purgeRows(ctx, softDeletedCondition, before)
+}
+
+// countRows - Метод, позволяющий получить количество ссылок, удовлетворяющих условию
+func (c *Database) countRows(ctx context.Context, condition string, before time.Time) (int64, error) {
+
+	var n int64
+
+	err := c.db.QueryRow(ctx, "SELECT count(*) FROM"+config.TableNameDB+" WHERE "+condition, before).Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// purgeRows - Метод, позволяющий удалить ссылки, удовлетворяющие условию, пачками по config.PurgeBatchSize
+// (каждая пачка удаляется отдельным запросом, чтобы не держать блокировки на всю таблицу)
+func (c *Database) purgeRows(ctx context.Context, condition string, before time.Time) (int64, error) {
+
+	sql := fmt.Sprintf("DELETE FROM %s WHERE id IN (SELECT id FROM %s WHERE %s LIMIT %d)",
+		config.TableNameDB, config.TableNameDB, condition, config.PurgeBatchSize)
+
+	var total int64
+
+	for {
+		tag, err := c.db.Exec(ctx, sql, before)
+		if err != nil {
+			return total, err
+		}
+
+		total += tag.RowsAffected()
+
+		if tag.RowsAffected() < config.PurgeBatchSize {
+			return total, nil
+		}
+	}
+}
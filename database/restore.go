@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"my_project/urlgen/config"
+)
+
+// Способы разрешения совпадения восстанавливаемой ссылки с действующей ссылкой в БД
+const (
+	RestoreFail      = "fail"      // Отменить восстановление
+	RestoreSkip      = "skip"      // Оставить ссылку БД (переходы из резервной копии не восстанавливаются)
+	RestoreOverwrite = "overwrite" // Заменить ссылку и ее переходы данными резервной копии
+)
+
+// RestoredRow - Тип данных, описывающий итог восстановления ссылки
+type RestoredRow struct {
+	Restored bool // Записана ли ссылка (false - пропущена из-за совпадения)
+	Replaced bool // Заменена ли существующая строка (удаленная или, при RestoreOverwrite, действующая)
+	Detached bool // Сброшены ли ссылки на пользователя, рабочее пространство или ключ API, которых нет в БД
+}
+
+// restoreRowSQL - Запрос восстановления строки с сохранением идентификатора (если он свободен), времени создания
+// и счетчика переходов; ссылки на отсутствующих пользователя, рабочее пространство и ключ API сбрасываются
+// (%s - условие замены существующей строки с той же короткой ссылкой)
+var restoreRowSQL = "INSERT INTO" + config.TableNameDB +
+	" (" + config.UrlColName + ", " + config.ShortUrlColName + ", " + config.UserIdColName + ", redirect_status, expires_at, password_hash," +
+	" query_params, variants, sticky_variants, device_urls, geo_urls, active_from, max_clicks, disabled, " + config.WorkspaceIdColName + ", api_key_id, no_analytics, tags, id, domain," +
+	" created_at, click_count)" +
+	" VALUES ($1, $2, (SELECT id FROM" + config.UsersTableNameDB + " WHERE id = NULLIF($3::int, 0)), NULLIF($4, 0), $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12," +
+	" NULLIF($13, 0), $14, (SELECT id FROM" + config.WorkspacesTableNameDB + " WHERE id = NULLIF($15::int, 0))," +
+	" (SELECT id FROM" + config.ApiKeysTableNameDB + " WHERE id = NULLIF($16::int, 0)), $17, $18," +
+	" CASE WHEN $19::int = 0 OR EXISTS (SELECT 1 FROM" + config.TableNameDB + " WHERE id = $19::int) THEN nextval(" + rowIdSequence + ") ELSE $19::int END," +
+	" $20, $21, $22)" +
+	" ON CONFLICT (" + config.ShortUrlColName + ") DO UPDATE SET " + config.UrlColName + " = EXCLUDED." + config.UrlColName + ", " +
+	config.UserIdColName + " = EXCLUDED." + config.UserIdColName + ", redirect_status = EXCLUDED.redirect_status," +
+	" expires_at = EXCLUDED.expires_at, password_hash = EXCLUDED.password_hash, query_params = EXCLUDED.query_params," +
+	" variants = EXCLUDED.variants, sticky_variants = EXCLUDED.sticky_variants, device_urls = EXCLUDED.device_urls," +
+	" geo_urls = EXCLUDED.geo_urls, active_from = EXCLUDED.active_from, max_clicks = EXCLUDED.max_clicks," +
+	" click_count = EXCLUDED.click_count, disabled = EXCLUDED.disabled, no_analytics = EXCLUDED.no_analytics, tags = EXCLUDED.tags," +
+	" domain = EXCLUDED.domain, " + config.WorkspaceIdColName + " = EXCLUDED." + config.WorkspaceIdColName + "," +
+	" api_key_id = EXCLUDED.api_key_id, created_at = EXCLUDED.created_at, deleted_at = NULL%s" +
+	" RETURNING NOT (xmax = 0), (" + config.UserIdColName + " IS NULL AND $3::int <> 0) OR (" + config.WorkspaceIdColName +
+	" IS NULL AND $15::int <> 0) OR (api_key_id IS NULL AND $16::int <> 0)"
+
+// Restore - Тип данных, реализующий восстановление ссылок и переходов из резервной копии в одной транзакции
+// (до Commit изменения не видны другим подключениям, при ошибке транзакция откатывается целиком)
+type Restore struct {
+	tx  pgx.Tx // Транзакция восстановления
+	sql string // Запрос восстановления строки с условием замены
+}
+
+// BeginRestore - Метод, позволяющий начать восстановление с заданным способом разрешения совпадений
+// (удаленные ссылки с той же короткой ссылкой заменяются при любом способе)
+func (c *Database) BeginRestore(ctx context.Context, conflict string) (*Restore, error) {
+
+	where := " WHERE" + config.TableNameDB + ".deleted_at IS NOT NULL"
+	if conflict == RestoreOverwrite {
+		where = ""
+	}
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Restore{tx: tx, sql: fmt.Sprintf(restoreRowSQL, where)}, nil
+}
+
+// RestoreRow - Метод, позволяющий восстановить строку (переходы замененной строки удаляются)
+func (r *Restore) RestoreRow(ctx context.Context, row RowData) (RestoredRow, error) {
+
+	args := append(insertRowArgs(row), row.CreatedAt, row.ClickCount)
+	result := RestoredRow{}
+
+	err := r.tx.QueryRow(ctx, r.sql, args...).Scan(&result.Replaced, &result.Detached)
+	if err == pgx.ErrNoRows {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.Restored = true
+
+	if result.Replaced {
+		_, err = r.tx.Exec(ctx, "DELETE FROM"+config.ClicksTableNameDB+" WHERE "+config.ShortUrlColName+" = $1",
+			row.ShortUrl)
+	}
+
+	return result, err
+}
+
+// clickAggregateSQL - Запрос записи суточного количества переходов отдельными переходами на начало суток
+// ($3 переходов и $4 переходов ботов)
+var clickAggregateSQL = "INSERT INTO" + config.ClicksTableNameDB + " (" + config.ShortUrlColName + ", clicked_at, is_bot)" +
+	" SELECT $1, $2, n > $3 FROM generate_series(1, $3 + $4) AS n"
+
+// RestoreClicks - Метод, позволяющий восстановить суточное количество переходов по ссылке
+// (переходы записываются на начало суток без сведений о клиенте)
+func (r *Restore) RestoreClicks(ctx context.Context, a ClickAggregate) error {
+
+	_, err := r.tx.Exec(ctx, clickAggregateSQL, a.ShortUrl, a.Day, a.Clicks, a.BotClicks)
+
+	return err
+}
+
+// Commit - Метод, реализующий завершение восстановления: последовательность идентификаторов поднимается
+// до наибольшего идентификатора строк, а из запаса убираются коды, занятые восстановленными ссылками
+func (r *Restore) Commit(ctx context.Context) error {
+
+	_, err := r.tx.Exec(ctx, "SELECT setval("+rowIdSequence+", max(id)) FROM"+config.TableNameDB+
+		" HAVING max(id) > COALESCE(pg_sequence_last_value("+rowIdSequence+"::regclass), 0)")
+	if err != nil {
+		return err
+	}
+
+	_, err = r.tx.Exec(ctx, "DELETE FROM"+config.CodePoolTableNameDB+" p WHERE EXISTS (SELECT 1 FROM"+
+		config.TableNameDB+" g WHERE g."+config.ShortUrlColName+" = $1 || p.code)", config.GenUrl)
+	if err != nil {
+		return err
+	}
+
+	return r.tx.Commit(ctx)
+}
+
+// Rollback - Метод, реализующий отмену восстановления (после Commit ничего не делает)
+func (r *Restore) Rollback(ctx context.Context) {
+	_ = r.tx.Rollback(ctx)
+}
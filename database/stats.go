@@ -0,0 +1,199 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"my_project/urlgen/config"
+	"time"
+)
+
+// Counter - Тип данных, реализующий структуру количества переходов для значения признака
+type Counter struct {
+	Value  string // Значение признака (источник, страна, браузер, система, класс устройства, короткая ссылка)
+	Clicks int    // Количество переходов
+}
+
+// LinkStats - Тип данных, реализующий структуру статистики переходов по ссылке
+type LinkStats struct {
+	Total     int          // Общее количество переходов за период
+	Bots      int          // Количество переходов ботов за период (не входят в остальные значения, если они исключены)
+	Timeline  []ClickCount // Количество переходов по интервалам
+	Referrers []Counter    // Самые частые источники переходов
+	Countries []Counter    // Самые частые страны
+	Browsers  []Counter    // Самые частые браузеры
+	Systems   []Counter    // Самые частые операционные системы
+	Devices   []Counter    // Распределение по классам устройств
+	Variants  []Counter    // Распределение по вариантам исходной ссылки
+}
+
+// GetLinkStats - Метод, позволяющий получить статистику переходов по короткой ссылке за период
+// (bucket - "hour" или "day", top - размер списков самых частых значений, includeBots - учитывать ли переходы ботов)
+func (c *Database) GetLinkStats(ctx context.Context, shortUrl, bucket string, from, to time.Time, top int,
+	includeBots bool) (*LinkStats, error) {
+
+	stats := LinkStats{}
+
+	where := fmt.Sprintf("%s = $1 AND clicked_at >= $2 AND clicked_at < $3", config.ShortUrlColName)
+
+	// Количество переходов ботов
+	sql := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s AND is_bot", config.ClicksTableNameDB, where)
+
+	err := c.db.QueryRow(ctx, sql, shortUrl, from, to).Scan(&stats.Bots)
+	if err != nil {
+		return nil, err
+	}
+
+	if !includeBots {
+		where += " AND " + notBotCondition
+	}
+
+	// Количество переходов по интервалам
+	sql = fmt.Sprintf("SELECT date_trunc('%s', clicked_at) AS bucket, count(*) FROM %s WHERE %s GROUP BY bucket ORDER BY bucket",
+		bucket, config.ClicksTableNameDB, where)
+
+	rows, err := c.db.Query(ctx, sql, shortUrl, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		cc := ClickCount{}
+
+		if err = rows.Scan(&cc.Time, &cc.Clicks); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		stats.Total += cc.Clicks
+		stats.Timeline = append(stats.Timeline, cc)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Самые частые значения признаков
+	if stats.Referrers, err = c.topValues(ctx, "COALESCE(NULLIF(referrer, ''), '(direct)')", where, top, shortUrl, from, to); err != nil {
+		return nil, err
+	}
+
+	if stats.Countries, err = c.topValues(ctx, "COALESCE(country, 'unknown')", where, top, shortUrl, from, to); err != nil {
+		return nil, err
+	}
+
+	if stats.Browsers, err = c.topValues(ctx, "COALESCE(browser, 'unknown')", where, top, shortUrl, from, to); err != nil {
+		return nil, err
+	}
+
+	if stats.Systems, err = c.topValues(ctx, "COALESCE(os, 'unknown')", where, top, shortUrl, from, to); err != nil {
+		return nil, err
+	}
+
+	if stats.Devices, err = c.topValues(ctx, "COALESCE(device_class, 'unknown')", where, top, shortUrl, from, to); err != nil {
+		return nil, err
+	}
+
+	if stats.Variants, err = c.topValues(ctx, "COALESCE(variant::text, 'default')", where, top, shortUrl, from, to); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// topValues - Метод, позволяющий получить самые частые значения выражения среди переходов, удовлетворяющих условию
+func (c *Database) topValues(ctx context.Context, expr, where string, top int, args ...any) ([]Counter, error) {
+
+	sql := fmt.Sprintf("SELECT %s AS value, count(*) AS clicks FROM %s WHERE %s GROUP BY value ORDER BY clicks DESC, value LIMIT %d",
+		expr, config.ClicksTableNameDB, where, top)
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Counter
+
+	for rows.Next() {
+		cnt := Counter{}
+
+		if err = rows.Scan(&cnt.Value, &cnt.Clicks); err != nil {
+			return nil, err
+		}
+
+		result = append(result, cnt)
+	}
+
+	return result, rows.Err()
+}
+
+// WorkspaceStats - Тип данных, реализующий структуру сводной статистики рабочего пространства
+type WorkspaceStats struct {
+	Links       int       // Количество действующих (не удаленных) ссылок
+	ClicksToday int       // Количество переходов с начала текущих суток
+	ClicksWeek  int       // Количество переходов с начала текущей недели
+	TopLinks    []Counter // Короткие ссылки с наибольшим количеством переходов за неделю
+}
+
+// GetWorkspaceStats - Метод, позволяющий получить сводную статистику рабочего пространства
+// (переходы ботов не учитываются; top - размер списка самых посещаемых ссылок)
+func (c *Database) GetWorkspaceStats(ctx context.Context, workspaceId int, today, week time.Time,
+	top int) (*WorkspaceStats, error) {
+
+	stats := WorkspaceStats{}
+
+	// Количество ссылок
+	links, err := c.CountWorkspaceLinks(ctx, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	stats.Links = links
+
+	// Переходы по ссылкам рабочего пространства с начала недели
+	from := fmt.Sprintf("%s c JOIN %s g ON g.%s = c.%s WHERE g.%s = $1 AND g.deleted_at IS NULL"+
+		" AND c.clicked_at >= $2 AND c.%s", config.ClicksTableNameDB, config.TableNameDB, config.ShortUrlColName,
+		config.ShortUrlColName, config.WorkspaceIdColName, notBotCondition)
+
+	sql := "SELECT count(*) FILTER (WHERE c.clicked_at >= $3), count(*) FROM" + from
+
+	err = c.db.QueryRow(ctx, sql, workspaceId, week, today).Scan(&stats.ClicksToday, &stats.ClicksWeek)
+	if err != nil {
+		return nil, err
+	}
+
+	// Самые посещаемые ссылки
+	sql = fmt.Sprintf("SELECT c.%s, count(*) AS clicks FROM%s GROUP BY c.%s ORDER BY clicks DESC, c.%s LIMIT %d",
+		config.ShortUrlColName, from, config.ShortUrlColName, config.ShortUrlColName, top)
+
+	rows, err := c.db.Query(ctx, sql, workspaceId, week)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		cnt := Counter{}
+
+		if err = rows.Scan(&cnt.Value, &cnt.Clicks); err != nil {
+			return nil, err
+		}
+
+		stats.TopLinks = append(stats.TopLinks, cnt)
+	}
+
+	return &stats, rows.Err()
+}
+
+// CountWorkspaceLinks - Метод, позволяющий получить количество действующих (не удаленных) ссылок рабочего пространства
+func (c *Database) CountWorkspaceLinks(ctx context.Context, workspaceId int) (int, error) {
+
+	sql := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s = $1 AND deleted_at IS NULL",
+		config.TableNameDB, config.WorkspaceIdColName)
+
+	var n int
+
+	err := c.db.QueryRow(ctx, sql, workspaceId).Scan(&n)
+
+	return n, err
+}
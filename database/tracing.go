@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"my_project/urlgen/pkg/tracing"
+	"strings"
+)
+
+// queryTracer - Тип данных, реализующий спан на каждый запрос к БД (pgx.QueryTracer)
+type queryTracer struct{}
+
+// TraceQueryStart - Метод, реализующий начало спана запроса в контексте вызывающего
+// (спан называется по команде SQL, например "db SELECT")
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+
+	operation, _, _ := strings.Cut(strings.TrimSpace(data.SQL), " ")
+	operation = strings.ToUpper(operation)
+
+	ctx, _ = tracing.Tracer().Start(ctx, "db "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(data.SQL),
+		))
+
+	return ctx
+}
+
+// TraceQueryEnd - Метод, реализующий завершение спана запроса (ошибка, кроме отсутствия строк, отмечается в спане)
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+
+	span := trace.SpanFromContext(ctx)
+
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+
+	span.End()
+}
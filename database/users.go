@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"my_project/urlgen/config"
+	"time"
+)
+
+// UserData - Тип данных, реализующий структуру для работы с пользователем в БД
+type UserData struct {
+	Id           int       // (serial, primary_key, not null)
+	Username     string    // (text, unique, not null)
+	PasswordHash string    // (text, not null)
+	Email        string    // (text, unique, null) - пустая строка, если адрес не задан
+	CreatedAt    time.Time // (timestamptz, not null)
+	IsAdmin      bool      // (boolean, not null) - оператор сервиса
+}
+
+// ErrUserExists - Ошибка сохранения пользователя с уже занятым именем или адресом почты
+var ErrUserExists = errors.New("error: User already exists")
+
+// userColumns - Список столбцов, читаемых в UserData
+const userColumns = "id, username, password_hash, COALESCE(email, ''), created_at, is_admin"
+
+// scanUser - Функция, реализующая чтение столбцов "userColumns" в заданную структуру
+func scanUser(row pgx.Row, u *UserData) error {
+	return row.Scan(&u.Id, &u.Username, &u.PasswordHash, &u.Email, &u.CreatedAt, &u.IsAdmin)
+}
+
+// uniqueViolation - Функция, проверяющая, вызвана ли ошибка нарушением уникальности
+func uniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// GetUser - Метод, позволяющий получить пользователя из БД по его имени
+func (c *Database) GetUser(ctx context.Context, username string) (*UserData, bool) {
+
+	var row pgx.Row
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE username = $1", userColumns, config.UsersTableNameDB)
+
+	row = c.db.QueryRow(ctx, sql, username)
+
+	u := UserData{}
+
+	err := scanUser(row, &u)
+	if err != nil {
+		c.logQueryError(ctx, sql, err)
+		return nil, false
+	}
+
+	return &u, true
+}
+
+// GetUserById - Метод, позволяющий получить пользователя из БД по идентификатору
+func (c *Database) GetUserById(ctx context.Context, id int) (*UserData, bool) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", userColumns, config.UsersTableNameDB)
+
+	u := UserData{}
+
+	err := scanUser(c.db.QueryRow(ctx, sql, id), &u)
+	if err != nil {
+		c.logQueryError(ctx, sql, err)
+		return nil, false
+	}
+
+	return &u, true
+}
+
+// GetUserByLogin - Метод, позволяющий получить пользователя из БД по имени или адресу почты
+func (c *Database) GetUserByLogin(ctx context.Context, login string) (*UserData, bool) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE username = $1 OR lower(email) = lower($1) ORDER BY username = $1 DESC LIMIT 1",
+		userColumns, config.UsersTableNameDB)
+
+	u := UserData{}
+
+	err := scanUser(c.db.QueryRow(ctx, sql, login), &u)
+	if err != nil {
+		c.logQueryError(ctx, sql, err)
+		return nil, false
+	}
+
+	return &u, true
+}
+
+// CreateUser - Метод, позволяющий зарегистрировать пользователя (ErrUserExists, если имя или адрес почты заняты)
+func (c *Database) CreateUser(ctx context.Context, username, email, passwordHash string) (*UserData, error) {
+
+	sql := fmt.Sprintf("INSERT INTO %s (username, email, password_hash) VALUES ($1, NULLIF($2, ''), $3) RETURNING %s",
+		config.UsersTableNameDB, userColumns)
+
+	u := UserData{}
+
+	err := scanUser(c.db.QueryRow(ctx, sql, username, email, passwordHash), &u)
+	if uniqueViolation(err) {
+		return nil, ErrUserExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// UpdateUser - Метод, позволяющий сохранить адрес почты и хеш пароля пользователя
+// (ErrUserExists, если адрес почты занят)
+func (c *Database) UpdateUser(ctx context.Context, u UserData) error {
+
+	_, err := c.db.Exec(ctx, "UPDATE"+config.UsersTableNameDB+" SET email = NULLIF($1, ''), password_hash = $2 WHERE id = $3",
+		u.Email, u.PasswordHash, u.Id)
+	if uniqueViolation(err) {
+		return ErrUserExists
+	}
+
+	return err
+}
+
+// CreatePasswordReset - Метод, позволяющий сохранить хеш токена сброса пароля пользователя
+// (прежние запросы сброса этого пользователя отменяются)
+func (c *Database) CreatePasswordReset(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM"+config.ResetsTableNameDB+" WHERE user_id = $1 OR expires_at <= now()", userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO"+config.ResetsTableNameDB+" (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		tokenHash, userId, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ResetPassword - Метод, позволяющий по хешу действующего токена сброса заменить пароль пользователя
+// (токен используется однократно; false, если токен не найден или истек)
+func (c *Database) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (bool, error) {
+
+	tag, err := c.db.Exec(ctx, "WITH reset AS (DELETE FROM"+config.ResetsTableNameDB+
+		" WHERE token_hash = $1 AND expires_at > now() RETURNING user_id) UPDATE"+config.UsersTableNameDB+
+		" SET password_hash = $2 WHERE id IN (SELECT user_id FROM reset)", tokenHash, passwordHash)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
+
+// EnsureAdmin - Метод, позволяющий создать оператора сервиса, если пользователя с таким именем еще нет в БД
+// (существующий пользователь становится оператором, его пароль не изменяется)
+func (c *Database) EnsureAdmin(ctx context.Context, username, passwordHash string) error {
+
+	_, err := c.db.Exec(ctx, "INSERT INTO"+config.UsersTableNameDB+
+		" (username, password_hash, is_admin) VALUES ($1, $2, true)"+
+		" ON CONFLICT (username) DO UPDATE SET is_admin = true", username, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// EnsureExternalUser - Метод, позволяющий получить пользователя по идентификатору внешнего провайдера
+// (пользователь создается при первом входе; ErrUserExists, если имя нового пользователя занято)
+func (c *Database) EnsureExternalUser(ctx context.Context, subject, username string) (*UserData, error) {
+
+	sql := "INSERT INTO" + config.UsersTableNameDB + " (username, password_hash, external_subject) VALUES ($1, '', $2)" +
+		" ON CONFLICT (external_subject) DO UPDATE SET external_subject = EXCLUDED.external_subject" +
+		" RETURNING " + userColumns
+
+	u := UserData{}
+
+	err := scanUser(c.db.QueryRow(ctx, sql, username, subject), &u)
+	if uniqueViolation(err) {
+		return nil, ErrUserExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"my_project/urlgen/config"
+	"time"
+)
+
+// WebhookData - Тип данных, реализующий структуру вебхука в БД
+type WebhookData struct {
+	Id        int       // (serial, primary_key, not null)
+	Url       string    // (text, not null) - адрес получателя
+	Secret    string    // (text, not null) - секрет подписи
+	ShortUrl  string    // (text, null) - пустая строка для вебхука всех ссылок
+	Events    []string  // (text[], not null) - типы событий
+	UserId    int       // (integer, null) - 0, если владелец не задан
+	CreatedAt time.Time // (timestamptz, not null)
+
+	WorkspaceId int // (integer, null) - 0 для вебхуков ссылок без рабочего пространства
+}
+
+// webhookColumns - Список столбцов, читаемых в WebhookData
+var webhookColumns = fmt.Sprintf("id, url, secret, COALESCE(%s, ''), events, COALESCE(%s, 0), created_at,"+
+	" COALESCE(%s, 0)", config.ShortUrlColName, config.UserIdColName, config.WorkspaceIdColName)
+
+// ListWebhooks - Метод, позволяющий получить все вебхуки из БД
+func (c *Database) ListWebhooks(ctx context.Context) ([]WebhookData, error) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s ORDER BY id", webhookColumns, config.WebhooksTableNameDB)
+
+	rows, err := c.db.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []WebhookData
+
+	for rows.Next() {
+		h := WebhookData{}
+
+		err = rows.Scan(&h.Id, &h.Url, &h.Secret, &h.ShortUrl, &h.Events, &h.UserId, &h.CreatedAt, &h.WorkspaceId)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, h)
+	}
+
+	return result, rows.Err()
+}
+
+// CreateWebhook - Метод, позволяющий сохранить вебхук в БД (возвращает сохраненный вебхук)
+func (c *Database) CreateWebhook(ctx context.Context, hook WebhookData) (*WebhookData, error) {
+
+	sql := fmt.Sprintf("INSERT INTO %s (url, secret, %s, events, %s, %s)"+
+		" VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, 0), NULLIF($6, 0)) RETURNING %s", config.WebhooksTableNameDB,
+		config.ShortUrlColName, config.UserIdColName, config.WorkspaceIdColName, webhookColumns)
+
+	h := WebhookData{}
+
+	err := c.db.QueryRow(ctx, sql, hook.Url, hook.Secret, hook.ShortUrl, hook.Events, hook.UserId, hook.WorkspaceId).
+		Scan(&h.Id, &h.Url, &h.Secret, &h.ShortUrl, &h.Events, &h.UserId, &h.CreatedAt, &h.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// DeleteWebhook - Метод, позволяющий удалить вебхук рабочего пространства из БД
+func (c *Database) DeleteWebhook(ctx context.Context, workspaceId, id int) (bool, error) {
+
+	tag, err := c.db.Exec(ctx, "DELETE FROM"+config.WebhooksTableNameDB+" WHERE id = $1 AND "+
+		config.WorkspaceIdColName+" IS NOT DISTINCT FROM NULLIF($2, 0)", id, workspaceId)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
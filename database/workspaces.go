@@ -0,0 +1,382 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"my_project/urlgen/config"
+	"time"
+)
+
+// WorkspaceData - Тип данных, реализующий структуру рабочего пространства в БД
+type WorkspaceData struct {
+	Id        int       // (serial, primary_key, not null)
+	Name      string    // (text, not null)
+	CreatedAt time.Time // (timestamptz, not null)
+	Role      string    // Роль пользователя, для которого получено пространство (не хранится в таблице)
+}
+
+// MemberData - Тип данных, реализующий структуру участника рабочего пространства в БД
+type MemberData struct {
+	WorkspaceId int       // (integer, primary_key, not null)
+	UserId      int       // (integer, primary_key, not null)
+	Username    string    // Имя пользователя (из таблицы пользователей)
+	Role        string    // (text, not null) - "owner", "admin" или "member"
+	CreatedAt   time.Time // (timestamptz, not null)
+}
+
+// ApiKeyData - Тип данных, реализующий структуру ключа API рабочего пространства в БД
+type ApiKeyData struct {
+	Id          int       // (serial, primary_key, not null)
+	WorkspaceId int       // (integer, not null)
+	Name        string    // (text, not null)
+	Role        string    // (text, not null) - "admin" или "member"
+	UserId      int       // (integer, null) - 0, если создатель не задан
+	Tier        string    // (text, null) - пустая строка, если действует уровень квот по умолчанию
+	CreatedAt   time.Time // (timestamptz, not null)
+}
+
+// ErrLastOwner - Ошибка удаления или понижения роли последнего владельца рабочего пространства
+var ErrLastOwner = errors.New("error: Workspace must keep at least one owner")
+
+// apiKeyColumns - Список столбцов, читаемых в ApiKeyData
+var apiKeyColumns = fmt.Sprintf("id, %s, name, role, COALESCE(%s, 0), COALESCE(tier, ''), created_at",
+	config.WorkspaceIdColName, config.UserIdColName)
+
+// ListUserWorkspaces - Метод, позволяющий получить рабочие пространства, участником которых является пользователь
+func (c *Database) ListUserWorkspaces(ctx context.Context, userId int) ([]WorkspaceData, error) {
+
+	sql := fmt.Sprintf("SELECT w.id, w.name, w.created_at, m.role FROM %s w JOIN %s m ON m.%s = w.id"+
+		" WHERE m.%s = $1 ORDER BY w.id", config.WorkspacesTableNameDB, config.MembersTableNameDB,
+		config.WorkspaceIdColName, config.UserIdColName)
+
+	rows, err := c.db.Query(ctx, sql, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []WorkspaceData
+
+	for rows.Next() {
+		ws := WorkspaceData{}
+
+		err = rows.Scan(&ws.Id, &ws.Name, &ws.CreatedAt, &ws.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, ws)
+	}
+
+	return result, rows.Err()
+}
+
+// CreateWorkspace - Метод, позволяющий создать рабочее пространство с заданным пользователем в роли владельца
+func (c *Database) CreateWorkspace(ctx context.Context, name string, ownerId int) (*WorkspaceData, error) {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ws, err := createWorkspace(ctx, tx, name, ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return ws, tx.Commit(ctx)
+}
+
+// createWorkspace - Функция, реализующая создание рабочего пространства и владельца в рамках транзакции
+func createWorkspace(ctx context.Context, tx pgx.Tx, name string, ownerId int) (*WorkspaceData, error) {
+
+	ws := WorkspaceData{Name: name, Role: "owner"}
+
+	err := tx.QueryRow(ctx, "INSERT INTO"+config.WorkspacesTableNameDB+" (name) VALUES ($1) RETURNING id, created_at",
+		name).Scan(&ws.Id, &ws.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO"+config.MembersTableNameDB+" ("+config.WorkspaceIdColName+", "+
+		config.UserIdColName+", role) VALUES ($1, $2, $3)", ws.Id, ownerId, ws.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ws, nil
+}
+
+// EnsureDefaultWorkspace - Метод, позволяющий получить рабочее пространство пользователя по умолчанию
+// (первое по времени создания; если пользователь не состоит ни в одном, создается личное пространство)
+func (c *Database) EnsureDefaultWorkspace(ctx context.Context, userId int, name string) (*WorkspaceData, error) {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокировка строки пользователя исключает создание двух личных пространств одновременными запросами
+	_, err = tx.Exec(ctx, "SELECT 1 FROM"+config.UsersTableNameDB+" WHERE id = $1 FOR UPDATE", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := WorkspaceData{}
+
+	err = tx.QueryRow(ctx, fmt.Sprintf("SELECT w.id, w.name, w.created_at, m.role FROM %s w JOIN %s m ON m.%s = w.id"+
+		" WHERE m.%s = $1 ORDER BY w.id LIMIT 1", config.WorkspacesTableNameDB, config.MembersTableNameDB,
+		config.WorkspaceIdColName, config.UserIdColName), userId).Scan(&ws.Id, &ws.Name, &ws.CreatedAt, &ws.Role)
+	if err == nil {
+		return &ws, tx.Commit(ctx)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	created, err := createWorkspace(ctx, tx, name, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, tx.Commit(ctx)
+}
+
+// GetMemberRole - Метод, позволяющий получить роль пользователя в рабочем пространстве
+// (false, если пользователь не является участником)
+func (c *Database) GetMemberRole(ctx context.Context, workspaceId, userId int) (string, bool, error) {
+
+	var role string
+
+	err := c.db.QueryRow(ctx, "SELECT role FROM"+config.MembersTableNameDB+" WHERE "+config.WorkspaceIdColName+
+		" = $1 AND "+config.UserIdColName+" = $2", workspaceId, userId).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return role, true, nil
+}
+
+// ListMembers - Метод, позволяющий получить участников рабочего пространства
+func (c *Database) ListMembers(ctx context.Context, workspaceId int) ([]MemberData, error) {
+
+	sql := fmt.Sprintf("SELECT m.%s, m.%s, u.username, m.role, m.created_at FROM %s m JOIN %s u ON u.id = m.%s"+
+		" WHERE m.%s = $1 ORDER BY m.created_at", config.WorkspaceIdColName, config.UserIdColName,
+		config.MembersTableNameDB, config.UsersTableNameDB, config.UserIdColName, config.WorkspaceIdColName)
+
+	rows, err := c.db.Query(ctx, sql, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []MemberData
+
+	for rows.Next() {
+		m := MemberData{}
+
+		err = rows.Scan(&m.WorkspaceId, &m.UserId, &m.Username, &m.Role, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, m)
+	}
+
+	return result, rows.Err()
+}
+
+// SetMember - Метод, позволяющий добавить участника рабочего пространства или изменить его роль
+// (ErrLastOwner при понижении роли последнего владельца)
+func (c *Database) SetMember(ctx context.Context, workspaceId, userId int, role string) (*MemberData, error) {
+
+	sql := "INSERT INTO" + config.MembersTableNameDB + " (" + config.WorkspaceIdColName + ", " + config.UserIdColName +
+		", role) VALUES ($1, $2, $3) ON CONFLICT (" + config.WorkspaceIdColName + ", " + config.UserIdColName + ")" +
+		" DO UPDATE SET role = EXCLUDED.role WHERE EXCLUDED.role = 'owner' OR" + config.MembersTableNameDB + ".role <> 'owner'" +
+		" OR (SELECT count(*) FROM" + config.MembersTableNameDB + " WHERE " + config.WorkspaceIdColName + " = $1" +
+		" AND role = 'owner') > 1 RETURNING " + config.WorkspaceIdColName + ", " + config.UserIdColName + ", role, created_at"
+
+	m := MemberData{}
+
+	err := c.db.QueryRow(ctx, sql, workspaceId, userId, role).Scan(&m.WorkspaceId, &m.UserId, &m.Role, &m.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrLastOwner
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// DeleteMember - Метод, позволяющий исключить участника из рабочего пространства
+// (ErrLastOwner при исключении последнего владельца)
+func (c *Database) DeleteMember(ctx context.Context, workspaceId, userId int) (bool, error) {
+
+	role, found, err := c.GetMemberRole(ctx, workspaceId, userId)
+	if err != nil || !found {
+		return false, err
+	}
+
+	tag, err := c.db.Exec(ctx, "DELETE FROM"+config.MembersTableNameDB+" WHERE "+config.WorkspaceIdColName+" = $1 AND "+
+		config.UserIdColName+" = $2 AND (role <> 'owner' OR (SELECT count(*) FROM"+config.MembersTableNameDB+
+		" WHERE "+config.WorkspaceIdColName+" = $1 AND role = 'owner') > 1)", workspaceId, userId)
+	if err != nil {
+		return false, err
+	}
+
+	if tag.RowsAffected() == 0 && role == "owner" {
+		return false, ErrLastOwner
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
+
+// ListApiKeys - Метод, позволяющий получить ключи API рабочего пространства
+func (c *Database) ListApiKeys(ctx context.Context, workspaceId int) ([]ApiKeyData, error) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 ORDER BY id", apiKeyColumns, config.ApiKeysTableNameDB,
+		config.WorkspaceIdColName)
+
+	rows, err := c.db.Query(ctx, sql, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ApiKeyData
+
+	for rows.Next() {
+		k := ApiKeyData{}
+
+		err = rows.Scan(&k.Id, &k.WorkspaceId, &k.Name, &k.Role, &k.UserId, &k.Tier, &k.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, k)
+	}
+
+	return result, rows.Err()
+}
+
+// CreateApiKey - Метод, позволяющий сохранить ключ API по его хешу (сам ключ в БД не хранится)
+func (c *Database) CreateApiKey(ctx context.Context, key ApiKeyData, keyHash string) (*ApiKeyData, error) {
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s, name, key_hash, role, %s, tier) VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, ''))"+
+		" RETURNING %s", config.ApiKeysTableNameDB, config.WorkspaceIdColName, config.UserIdColName, apiKeyColumns)
+
+	k := ApiKeyData{}
+
+	err := c.db.QueryRow(ctx, sql, key.WorkspaceId, key.Name, keyHash, key.Role, key.UserId, key.Tier).
+		Scan(&k.Id, &k.WorkspaceId, &k.Name, &k.Role, &k.UserId, &k.Tier, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
+
+// GetApiKey - Метод, позволяющий получить ключ API по его хешу
+func (c *Database) GetApiKey(ctx context.Context, keyHash string) (*ApiKeyData, bool) {
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE key_hash = $1", apiKeyColumns, config.ApiKeysTableNameDB)
+
+	k := ApiKeyData{}
+
+	err := c.db.QueryRow(ctx, sql, keyHash).Scan(&k.Id, &k.WorkspaceId, &k.Name, &k.Role, &k.UserId, &k.Tier, &k.CreatedAt)
+	if err != nil {
+		c.logQueryError(ctx, sql, err)
+		return nil, false
+	}
+
+	return &k, true
+}
+
+// DeleteApiKey - Метод, позволяющий отозвать ключ API рабочего пространства
+func (c *Database) DeleteApiKey(ctx context.Context, workspaceId, id int) (bool, error) {
+
+	tag, err := c.db.Exec(ctx, "DELETE FROM"+config.ApiKeysTableNameDB+" WHERE id = $1 AND "+
+		config.WorkspaceIdColName+" = $2", id, workspaceId)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
+
+// SetApiKeyTier - Метод, позволяющий назначить ключу API уровень квот (пустая строка - уровень по умолчанию)
+func (c *Database) SetApiKeyTier(ctx context.Context, id int, tier string) (*ApiKeyData, bool, error) {
+
+	sql := fmt.Sprintf("UPDATE %s SET tier = NULLIF($2, '') WHERE id = $1 RETURNING %s", config.ApiKeysTableNameDB,
+		apiKeyColumns)
+
+	k := ApiKeyData{}
+
+	err := c.db.QueryRow(ctx, sql, id, tier).Scan(&k.Id, &k.WorkspaceId, &k.Name, &k.Role, &k.UserId, &k.Tier, &k.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &k, true, nil
+}
+
+// ApiKeyUsage - Тип данных, описывающий использование ключа API
+type ApiKeyUsage struct {
+	CreatesToday int // Количество ссылок, созданных ключом с начала текущих суток (UTC), включая удаленные
+	Links        int // Количество действующих ссылок, созданных ключом
+}
+
+// GetApiKeyUsage - Метод, позволяющий получить использование ключа API по ссылкам, созданным им
+func (c *Database) GetApiKeyUsage(ctx context.Context, keyId int) (ApiKeyUsage, error) {
+
+	sql := fmt.Sprintf("SELECT COUNT(*) FILTER (WHERE created_at >= date_trunc('day', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'),"+
+		" COUNT(*) FILTER (WHERE deleted_at IS NULL AND %s) FROM %s WHERE api_key_id = $1", notExpiredCondition,
+		config.TableNameDB)
+
+	usage := ApiKeyUsage{}
+
+	err := c.db.QueryRow(ctx, sql, keyId).Scan(&usage.CreatesToday, &usage.Links)
+
+	return usage, err
+}
+
+// apiKeyQuotaLockSpace - Пространство ключей рекомендательных блокировок квот ключей API (второй ключ - ключ API)
+const apiKeyQuotaLockSpace = 7461003
+
+// LockApiKeyQuota - Метод, реализующий ожидание блокировки квоты ключа API на всех экземплярах сервера
+// (проверка квоты и создание ссылок одним ключом выполняются по очереди; блокировка уровня сеанса удерживается
+// отдельным подключением из пула до вызова возвращаемой функции снятия)
+func (c *Database) LockApiKeyQuota(ctx context.Context, keyId int) (func(), error) {
+
+	conn, err := c.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1, $2)", apiKeyQuotaLockSpace, keyId); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return func() {
+		// Блокировка снимается и после отмены запроса; подключение с неудачным снятием закрывается
+		ctx := context.WithoutCancel(ctx)
+
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1, $2)", apiKeyQuotaLockSpace, keyId); err != nil {
+			_ = conn.Conn().Close(ctx)
+		}
+
+		conn.Release()
+	}, nil
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"my_project/urlgen/pkg/request_id"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessLogEntry - Тип данных, описывающий запись журнала запросов
+type AccessLogEntry struct {
+	Time      time.Time `json:"time"`                 // Время начала обработки запроса
+	Method    string    `json:"method"`               // Метод запроса
+	Path      string    `json:"path"`                 // Путь запроса (для переходов - код короткой ссылки)
+	Status    int       `json:"status"`               // HTTP статус ответа
+	LatencyMs float64   `json:"latency_ms"`           // Время обработки запроса в миллисекундах
+	Bytes     int       `json:"bytes"`                // Размер тела ответа
+	ClientIP  string    `json:"client_ip"`            // IP адрес клиента
+	RequestID string    `json:"request_id,omitempty"` // Идентификатор запроса
+}
+
+// AccessLogger - Интерфейс получателя записей журнала запросов
+type AccessLogger interface {
+	Log(entry AccessLogEntry)
+}
+
+// jsonAccessLogger - Тип данных, реализующий запись журнала запросов построчно в формате JSON
+type jsonAccessLogger struct {
+	sync.Mutex               // Блокировка для последовательной записи
+	enc        *json.Encoder // Кодировщик записей
+	logger     *slog.Logger  // Журнал ошибок записи
+}
+
+// NewJSONAccessLogger - Функция, реализующая создание журнала запросов в формате JSON
+func NewJSONAccessLogger(w io.Writer, logger *slog.Logger) AccessLogger {
+	return &jsonAccessLogger{enc: json.NewEncoder(w), logger: logger}
+}
+
+// Log - Метод, реализующий запись строки журнала
+func (l *jsonAccessLogger) Log(entry AccessLogEntry) {
+
+	l.Lock()
+	defer l.Unlock()
+
+	if err := l.enc.Encode(entry); err != nil {
+		l.logger.Error("Failed to write access log", "error", err)
+	}
+}
+
+// accessLoggerFromEnv - Функция, позволяющая создать журнал запросов по переменной ACCESS_LOG
+// ("stdout" по умолчанию, "off" для отключения или путь к файлу)
+func accessLoggerFromEnv(logger *slog.Logger) (AccessLogger, error) {
+
+	switch target := os.Getenv("ACCESS_LOG"); target {
+	case "", "stdout":
+		return NewJSONAccessLogger(os.Stdout, logger), nil
+	case "off":
+		return nil, nil
+	default:
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewJSONAccessLogger(f, logger), nil
+	}
+}
+
+// SetAccessLogger - Метод, позволяющий заменить получателя журнала запросов (nil отключает журнал)
+func (s *Server) SetAccessLogger(l AccessLogger) {
+	s.accessLog = l
+}
+
+// accessLogMiddleware - Метод, реализующий промежуточный обработчик записи журнала запросов
+func (s *Server) accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if s.accessLog == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		s.accessLog.Log(AccessLogEntry{
+			Time:      start,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Status:    rec.status,
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			Bytes:     rec.bytes,
+			ClientIP:  s.privacy.anonymize(s.clientIP(r)),
+			RequestID: request_id.FromContext(r.Context()),
+		})
+	})
+}
+
+// proxyHopsFromEnv - Функция, позволяющая получить количество доверенных прокси из переменных окружения
+// (TRUST_PROXY=true - учитывать X-Forwarded-For, TRUST_PROXY_HOPS - количество прокси, по умолчанию 1)
+func proxyHopsFromEnv() (int, error) {
+
+	if os.Getenv("TRUST_PROXY") != "true" {
+		return 0, nil
+	}
+
+	v := os.Getenv("TRUST_PROXY_HOPS")
+	if v == "" {
+		return 1, nil
+	}
+
+	hops, err := strconv.Atoi(v)
+	if err != nil || hops < 1 {
+		return 0, errors.New("error: TRUST_PROXY_HOPS must be a positive integer")
+	}
+
+	return hops, nil
+}
+
+// clientIP - Метод, реализующий определение IP адреса клиента
+// (заголовок X-Forwarded-For учитывается только при TRUST_PROXY=true)
+func (s *Server) clientIP(r *http.Request) string {
+
+	if ip := forwardedIP(r.Header, s.proxyHops); ip != "" {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// forwardedIP - Функция, возвращающая адрес клиента из заголовков X-Forwarded-For за hops доверенными прокси
+// (каждый прокси дописывает в конец адрес, с которого к нему пришел запрос, поэтому начало списка задает клиент
+// и не учитывается; пустая строка, если hops = 0 или адрес не найден)
+func forwardedIP(header http.Header, hops int) string {
+
+	if hops == 0 {
+		return ""
+	}
+
+	var entries []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	if len(entries) == 0 {
+		return ""
+	}
+
+	// При меньшем количестве записей, чем прокси, берется самая дальняя из них
+	ip := entries[max(len(entries)-hops, 0)]
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+
+	return ip
+}
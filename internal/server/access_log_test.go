@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		hops       int
+		remoteAddr string
+		forwarded  []string
+		ip         string
+	}{
+		{"no proxy", 0, "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted header", 0, "203.0.113.7:5000", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"one proxy", 1, "10.0.0.2:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed entry ignored", 1, "10.0.0.2:5000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"two proxies", 2, "10.0.0.2:5000", []string{"1.2.3.4, 198.51.100.1, 10.0.0.3"}, "198.51.100.1"},
+		{"several headers", 2, "10.0.0.2:5000", []string{"1.2.3.4, 198.51.100.1", "10.0.0.3"}, "198.51.100.1"},
+		{"fewer entries than proxies", 3, "10.0.0.2:5000", []string{"198.51.100.1, 10.0.0.3"}, "198.51.100.1"},
+		{"empty entries", 1, "10.0.0.2:5000", []string{" , 198.51.100.1 ,"}, "198.51.100.1"},
+		{"ipv6", 1, "10.0.0.2:5000", []string{"2001:db8::1"}, "2001:db8::1"},
+		{"not an address", 1, "10.0.0.2:5000", []string{"unknown"}, "10.0.0.2"},
+		{"no header", 1, "10.0.0.2:5000", nil, "10.0.0.2"},
+		{"ipv6 remote", 0, "[2001:db8::2]:443", nil, "2001:db8::2"},
+		{"remote without port", 0, "203.0.113.7", nil, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+
+			s := &Server{proxyHops: tt.hops}
+			if got := s.clientIP(r); got != tt.ip {
+				t.Errorf("clientIP = %q, want %q", got, tt.ip)
+			}
+		})
+	}
+}
+
+func TestProxyHopsFromEnv(t *testing.T) {
+
+	tests := []struct {
+		trust string
+		hops  string
+		want  int
+		valid bool
+	}{
+		{"", "", 0, true},
+		{"", "3", 0, true},
+		{"true", "", 1, true},
+		{"true", "2", 2, true},
+		{"true", "0", 0, false},
+		{"true", "-1", 0, false},
+		{"true", "x", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("TRUST_PROXY", tt.trust)
+		t.Setenv("TRUST_PROXY_HOPS", tt.hops)
+
+		hops, err := proxyHopsFromEnv()
+		if (err == nil) != tt.valid || hops != tt.want {
+			t.Errorf("TRUST_PROXY=%q TRUST_PROXY_HOPS=%q: %d, %v, want %d", tt.trust, tt.hops, hops, err, tt.want)
+		}
+	}
+}
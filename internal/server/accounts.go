@@ -0,0 +1,393 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"golang.org/x/crypto/bcrypt"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// usernameRegexp - Регулярное выражение допустимого имени пользователя
+var usernameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+// RegisterRequest - Тип данных, описывающий тело запроса на регистрацию пользователя
+type RegisterRequest struct {
+	Username string `json:"username"`        // Имя пользователя
+	Password string `json:"password"`        // Пароль
+	Email    string `json:"email,omitempty"` // Адрес почты (нужен для сброса пароля)
+}
+
+// PasswordResetRequest - Тип данных, описывающий тело запроса на сброс пароля
+type PasswordResetRequest struct {
+	Login string `json:"login"` // Имя пользователя или адрес почты
+}
+
+// PasswordResetConfirm - Тип данных, описывающий тело запроса на установку нового пароля по токену сброса
+type PasswordResetConfirm struct {
+	Token    string `json:"token"`    // Токен из письма
+	Password string `json:"password"` // Новый пароль
+}
+
+// Profile - Тип данных, описывающий профиль пользователя в API
+type Profile struct {
+	Id        int       `json:"id"`              // Идентификатор
+	Username  string    `json:"username"`        // Имя пользователя
+	Email     string    `json:"email,omitempty"` // Адрес почты
+	CreatedAt time.Time `json:"created_at"`      // Время регистрации
+}
+
+// ProfileUpdate - Тип данных, описывающий тело запроса на изменение профиля (незаданные поля не меняются)
+type ProfileUpdate struct {
+	Email           *string `json:"email"`            // Адрес почты (пустая строка удаляет его)
+	Password        *string `json:"password"`         // Новый пароль
+	CurrentPassword string  `json:"current_password"` // Текущий пароль (обязателен для смены пароля)
+}
+
+// validateCredentials - Функция, реализующая проверку имени пользователя, пароля и адреса почты при регистрации
+func validateCredentials(req RegisterRequest) error {
+
+	if !usernameRegexp.MatchString(req.Username) {
+		return errors.New("username must be 3-32 latin letters, digits, \".\", \"-\" or \"_\"")
+	}
+
+	if err := validatePassword(req.Password); err != nil {
+		return err
+	}
+
+	return validateEmail(req.Email)
+}
+
+// validatePassword - Функция, реализующая проверку длины пароля пользователя
+func validatePassword(password string) error {
+
+	if len(password) < config.PasswordMinLen {
+		return fmt.Errorf("password must be at least %d characters", config.PasswordMinLen)
+	}
+
+	return nil
+}
+
+// validateEmail - Функция, реализующая проверку адреса почты (пустой адрес допустим)
+func validateEmail(email string) error {
+
+	if email == "" {
+		return nil
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return errors.New("invalid email")
+	}
+
+	return nil
+}
+
+// hashResetToken - Функция, возвращающая хеш токена сброса пароля для хранения в БД
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Register - Метод, реализующий обработку "Post" запроса на регистрацию пользователя (возврат JWT)
+func (s *Server) Register(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.signup {
+		http.Error(w, "Error: Registration is disabled (status code: 403)", http.StatusForbidden)
+		s.logger.WarnContext(r.Context(), "Registration is disabled")
+		return
+	}
+
+	req := RegisterRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+
+	err = validateCredentials(req)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid registration", "error", err)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Error: Failed to hash password (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to hash password", "error", err)
+		return
+	}
+
+	user, err := s.db.CreateUser(r.Context(), req.Username, req.Email, string(hash))
+	if errors.Is(err, database.ErrUserExists) {
+		http.Error(w, "Error: Username or email is already taken (status code: 409)", http.StatusConflict)
+		s.logger.WarnContext(r.Context(), "Username or email is already taken", "username", req.Username)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error: Failed to save user (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save user", "error", err)
+		return
+	}
+
+	token, err := s.tokens.Issue(user.Id, user.Username)
+	if err != nil {
+		http.Error(w, "Error: Failed to issue token (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to issue token", "error", err)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "User was registered", "username", user.Username)
+
+	s.writeJSON(w, http.StatusCreated, TokenResponse{
+		Token:     token,
+		ExpiresIn: int(s.tokens.TTL().Seconds()),
+	})
+}
+
+// RequestPasswordReset - Метод, реализующий обработку "Post" запроса на отправку письма для сброса пароля
+// (ответ не зависит от существования пользователя, письмо отправляется в фоне)
+func (s *Server) RequestPasswordReset(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if s.mailer == nil {
+		http.Error(w, "Error: Password reset is not configured (status code: 501)", http.StatusNotImplemented)
+		s.logger.WarnContext(r.Context(), "Password reset is not configured")
+		return
+	}
+
+	req := PasswordResetRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.Login == "" {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	user, isExist := s.db.GetUserByLogin(r.Context(), strings.TrimSpace(req.Login))
+	if isExist && user.Email != "" {
+		secret := make([]byte, 32)
+		if _, err = rand.Read(secret); err != nil {
+			http.Error(w, "Error: Failed to generate reset token (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to generate reset token", "error", err)
+			return
+		}
+
+		token := hex.EncodeToString(secret)
+
+		err = s.db.CreatePasswordReset(r.Context(), user.Id, hashResetToken(token), time.Now().Add(config.PasswordResetTTL))
+		if err != nil {
+			http.Error(w, "Error: Failed to save reset token (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to save reset token", "error", err)
+			return
+		}
+
+		body := "A password reset was requested for " + user.Username + ".\r\n\r\n" +
+			"Open the link below within " + config.PasswordResetTTL.String() + " to set a new password:\r\n" +
+			strings.ReplaceAll(s.mailer.resetUrl, "{token}", token) + "\r\n\r\n" +
+			"If you did not request it, ignore this message.\r\n"
+
+		go func() {
+			if err := s.mailer.send(user.Email, "Password reset", body); err != nil {
+				s.logger.ErrorContext(r.Context(), "Failed to send password reset email", "user_id", user.Id, "error", err)
+			}
+		}()
+
+		s.logger.InfoContext(r.Context(), "Password reset was requested", "user_id", user.Id)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmPasswordReset - Метод, реализующий обработку "Post" запроса на установку нового пароля по токену сброса
+func (s *Server) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	req := PasswordResetConfirm{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.Token == "" {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	err = validatePassword(req.Password)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid password", "error", err)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Error: Failed to hash password (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to hash password", "error", err)
+		return
+	}
+
+	found, err := s.db.ResetPassword(r.Context(), hashResetToken(req.Token), string(hash))
+	if err != nil {
+		http.Error(w, "Error: Failed to reset password (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to reset password", "error", err)
+		return
+	}
+
+	if !found {
+		http.Error(w, "Error: Invalid or expired reset token (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid or expired reset token")
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Password was reset")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProfile - Метод, реализующий обработку "Get" запроса на получение профиля пользователя
+func (s *Server) GetProfile(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	user, isExist := s.db.GetUserById(r.Context(), userIdFromContext(r.Context()))
+	if !isExist {
+		http.Error(w, "Error: User not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "User not found")
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, profileFromUser(*user))
+}
+
+// UpdateProfile - Метод, реализующий обработку "Patch" запроса на изменение адреса почты или пароля пользователя
+func (s *Server) UpdateProfile(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	req := ProfileUpdate{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	user, isExist := s.db.GetUserById(r.Context(), userIdFromContext(r.Context()))
+	if !isExist {
+		http.Error(w, "Error: User not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "User not found")
+		return
+	}
+
+	if req.Email != nil {
+		user.Email = strings.TrimSpace(*req.Email)
+
+		if err = validateEmail(user.Email); err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid email", "error", err)
+			return
+		}
+	}
+
+	if req.Password != nil {
+		// Пользователь внешнего провайдера входа не имеет пароля и задает его без проверки текущего
+		if user.PasswordHash != "" &&
+			bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)) != nil {
+			http.Error(w, "Error: Invalid current password (status code: 403)", http.StatusForbidden)
+			s.logger.WarnContext(r.Context(), "Invalid current password", "user_id", user.Id)
+			return
+		}
+
+		if err = validatePassword(*req.Password); err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid password", "error", err)
+			return
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			http.Error(w, "Error: Failed to hash password (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to hash password", "error", err)
+			return
+		}
+
+		user.PasswordHash = string(hash)
+	}
+
+	err = s.db.UpdateUser(r.Context(), *user)
+	if errors.Is(err, database.ErrUserExists) {
+		http.Error(w, "Error: Email is already taken (status code: 409)", http.StatusConflict)
+		s.logger.WarnContext(r.Context(), "Email is already taken", "user_id", user.Id)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error: Failed to save user (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save user", "error", err)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Profile was updated", "user_id", user.Id)
+
+	s.writeJSON(w, http.StatusOK, profileFromUser(*user))
+}
+
+// ListOwnLinks - Метод, реализующий обработку "Get" запроса на получение страницы ссылок,
+// созданных пользователем во всех его рабочих пространствах
+func (s *Server) ListOwnLinks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	page, err := pageFromRequest(r)
+	if err != nil {
+		http.Error(w, "Error: Invalid cursor (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid cursor")
+		return
+	}
+
+	rows, err := s.db.ListUserRows(r.Context(), userIdFromContext(r.Context()), page)
+	if err != nil {
+		http.Error(w, "Error: Failed to read links (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read links", "error", err)
+		return
+	}
+
+	writeNextCursor(w, r, page, rows)
+
+	links := make([]Link, 0, len(rows))
+	for _, row := range rows {
+		links = append(links, linkFromRow(row))
+	}
+
+	s.writeJSON(w, http.StatusOK, links)
+}
+
+// profileFromUser - Функция, реализующая преобразование пользователя БД в профиль для API
+func profileFromUser(user database.UserData) Profile {
+	return Profile{
+		Id:        user.Id,
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+	}
+}
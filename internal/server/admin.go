@@ -0,0 +1,26 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// adminFiles - Статические файлы веб-интерфейса администратора
+//
+//go:embed admin
+var adminFiles embed.FS
+
+// initAdmin - Метод, инициализирующий раздачу веб-интерфейса администратора
+// (интерфейс обращается к защищенному API с токеном, полученным при входе)
+func (s *Server) initAdmin() {
+
+	static, err := fs.Sub(adminFiles, "admin")
+	if err != nil {
+		panic(err)
+	}
+
+	s.router.Handler(http.MethodGet, "/admin/*filepath",
+		s.instrument("/admin/*filepath", trafficApi, http.StripPrefix("/admin", http.FileServer(http.FS(static)))))
+	s.router.Handler(http.MethodGet, "/admin", http.RedirectHandler("/admin/", http.StatusMovedPermanently))
+}
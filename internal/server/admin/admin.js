@@ -0,0 +1,155 @@
+"use strict";
+
+const pageSize = 50;
+let offset = 0;
+
+const $ = (id) => document.getElementById(id);
+
+function token() {
+    return sessionStorage.getItem("token");
+}
+
+async function api(method, path, body) {
+    const resp = await fetch(path, {
+        method: method,
+        headers: {
+            "Authorization": "Bearer " + token(),
+            "Content-Type": "application/json",
+        },
+        body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    if (resp.status === 401) {
+        logout();
+        throw new Error("unauthorized");
+    }
+    if (!resp.ok) {
+        throw new Error(await resp.text());
+    }
+
+    return resp.status === 204 ? null : resp.json();
+}
+
+function show() {
+    const signedIn = token() !== null;
+    $("login-view").hidden = signedIn;
+    $("links-view").hidden = !signedIn;
+    $("logout").hidden = !signedIn;
+    if (signedIn) {
+        loadLinks();
+    }
+}
+
+function logout() {
+    sessionStorage.removeItem("token");
+    show();
+}
+
+async function loadLinks() {
+    const links = await api("GET", `/api/v1/links?offset=${offset}&limit=${pageSize}`);
+    const body = $("links");
+    body.replaceChildren();
+
+    for (const link of links) {
+        const row = body.insertRow();
+        row.insertCell().textContent = link.short_url;
+        row.insertCell().textContent = link.url;
+        row.insertCell().textContent = new Date(link.created_at).toLocaleString();
+
+        const actions = row.insertCell();
+        actions.append(
+            button("Clicks", () => loadChart(link)),
+            button("Edit", () => editLink(link)),
+            button(link.active ? "Disable" : "Enable", () => toggleLink(link)),
+            button("Delete", () => deleteLink(link)));
+    }
+
+    $("prev").disabled = offset === 0;
+    $("next").disabled = links.length < pageSize;
+}
+
+function button(text, onClick) {
+    const b = document.createElement("button");
+    b.textContent = text;
+    b.addEventListener("click", onClick);
+    return b;
+}
+
+async function editLink(link) {
+    const url = prompt("New destination", link.url);
+    if (url && url !== link.url) {
+        await api("PATCH", `/api/v1/links/${encodeURIComponent(link.code)}`, {url: url});
+        await loadLinks();
+    }
+}
+
+async function toggleLink(link) {
+    await api("PATCH", `/api/v1/links/${encodeURIComponent(link.code)}`, {active: !link.active});
+    await loadLinks();
+}
+
+async function deleteLink(link) {
+    if (confirm(`Delete ${link.short_url}?`)) {
+        await api("DELETE", `/api/v1/links/${encodeURIComponent(link.code)}`);
+        await loadLinks();
+    }
+}
+
+async function loadChart(link) {
+    const points = await api("GET", `/api/v1/links/${encodeURIComponent(link.code)}/clicks?days=30`);
+    const chart = $("chart");
+    chart.replaceChildren();
+
+    const max = Math.max(1, ...points.map((p) => p.clicks));
+    const width = 600 / Math.max(1, points.length);
+
+    points.forEach((p, i) => {
+        const h = 190 * p.clicks / max;
+        const rect = document.createElementNS("http://www.w3.org/2000/svg", "rect");
+        rect.setAttribute("x", String(i * width + 1));
+        rect.setAttribute("y", String(200 - h));
+        rect.setAttribute("width", String(Math.max(1, width - 2)));
+        rect.setAttribute("height", String(h));
+
+        const title = document.createElementNS("http://www.w3.org/2000/svg", "title");
+        title.textContent = `${new Date(p.time).toLocaleDateString()}: ${p.clicks}`;
+        rect.append(title);
+        chart.append(rect);
+    });
+
+    $("chart-title").textContent = `Clicks for ${link.short_url} (last 30 days)`;
+    $("chart-view").hidden = false;
+}
+
+$("login-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const form = new FormData(e.target);
+    const resp = await fetch("/api/v1/auth/login", {
+        method: "POST",
+        headers: {"Content-Type": "application/json"},
+        body: JSON.stringify({username: form.get("username"), password: form.get("password")}),
+    });
+
+    if (!resp.ok) {
+        $("login-error").textContent = "Invalid username or password";
+        return;
+    }
+
+    sessionStorage.setItem("token", (await resp.json()).token);
+    $("login-error").textContent = "";
+    show();
+});
+
+$("create-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    await api("POST", "/api/v1/links", {url: new FormData(e.target).get("url")});
+    e.target.reset();
+    offset = 0;
+    await loadLinks();
+});
+
+$("prev").addEventListener("click", () => { offset = Math.max(0, offset - pageSize); loadLinks(); });
+$("next").addEventListener("click", () => { offset += pageSize; loadLinks(); });
+$("logout").addEventListener("click", logout);
+
+show();
@@ -0,0 +1,288 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Действия массовой операции над ссылками
+const (
+	bulkActionDelete        = "delete"         // Удаление ссылок
+	bulkActionDisable       = "disable"        // Выключение ссылок
+	bulkActionEnable        = "enable"         // Включение ссылок
+	bulkActionTag           = "tag"            // Изменение меток ссылок
+	bulkActionMigrateDomain = "migrate_domain" // Перенос исходных ссылок на другой домен
+)
+
+// BulkFilter - Тип данных, описывающий отбор ссылок для массовой операции в API
+type BulkFilter struct {
+	Codes         []string   `json:"codes,omitempty"`          // Коды коротких ссылок
+	UrlPrefix     string     `json:"url_prefix,omitempty"`     // Начало исходной ссылки
+	Domain        string     `json:"domain,omitempty"`         // Домен исходной ссылки
+	Tag           string     `json:"tag,omitempty"`            // Метка ссылки
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`  // Ссылки, созданные не раньше заданного времени
+	CreatedBefore *time.Time `json:"created_before,omitempty"` // Ссылки, созданные раньше заданного времени
+	All           bool       `json:"all,omitempty"`            // Подтверждение операции над всеми ссылками пространства
+}
+
+// BulkTags - Тип данных, описывающий изменение меток ссылок массовой операцией
+type BulkTags struct {
+	Add    []string `json:"add,omitempty"`    // Добавляемые метки
+	Remove []string `json:"remove,omitempty"` // Удаляемые метки
+}
+
+// BulkDomain - Тип данных, описывающий перенос исходных ссылок массовой операцией
+type BulkDomain struct {
+	From string `json:"from"` // Прежний домен
+	To   string `json:"to"`   // Новый домен
+}
+
+// BulkActionRequest - Тип данных, описывающий тело запроса на массовую операцию над ссылками
+type BulkActionRequest struct {
+	Action string      `json:"action"`           // Действие
+	Filter BulkFilter  `json:"filter"`           // Отбор ссылок
+	Tags   *BulkTags   `json:"tags,omitempty"`   // Изменение меток (для "tag")
+	Domain *BulkDomain `json:"domain,omitempty"` // Перенос домена (для "migrate_domain")
+	DryRun bool        `json:"dry_run"`          // Проверка без сохранения изменений
+}
+
+// BulkActionResponse - Тип данных, описывающий ответ на запрос массовой операции над ссылками
+type BulkActionResponse struct {
+	Action    string   `json:"action"`              // Действие
+	DryRun    bool     `json:"dry_run"`             // Изменения не сохранены
+	Affected  int      `json:"affected"`            // Количество измененных (или изменяемых при проверке) ссылок
+	Codes     []string `json:"codes"`               // Коды измененных ссылок
+	Truncated bool     `json:"truncated,omitempty"` // Список кодов сокращен до максимального размера
+}
+
+// linkFilter - Метод, реализующий преобразование отбора ссылок API в отбор базы данных
+func (f BulkFilter) linkFilter() (database.LinkFilter, error) {
+
+	filter := database.LinkFilter{
+		UrlPrefix:     f.UrlPrefix,
+		Domain:        strings.ToLower(strings.TrimSuffix(strings.TrimSpace(f.Domain), ".")),
+		Tag:           strings.ToLower(strings.TrimSpace(f.Tag)),
+		CreatedAfter:  f.CreatedAfter,
+		CreatedBefore: f.CreatedBefore,
+	}
+
+	for _, code := range f.Codes {
+		filter.ShortUrls = append(filter.ShortUrls, shortUrlFromCode(code))
+	}
+
+	empty := len(filter.ShortUrls) == 0 && filter.UrlPrefix == "" && filter.Domain == "" && filter.Tag == "" &&
+		filter.CreatedAfter == nil && filter.CreatedBefore == nil
+
+	if empty && !f.All {
+		return filter, errors.New("filter is empty, set \"all\" to apply the action to every link")
+	}
+
+	return filter, nil
+}
+
+// bulkChange - Метод, возвращающий изменение строки для массовой операции
+func (s *Server) bulkChange(r *http.Request, req *BulkActionRequest) (database.BulkChange, error) {
+
+	switch req.Action {
+	case bulkActionDelete:
+		return nil, nil
+	case bulkActionDisable, bulkActionEnable:
+		disabled := req.Action == bulkActionDisable
+
+		return func(row *database.RowData) bool {
+			if row.Disabled == disabled {
+				return false
+			}
+
+			row.Disabled = disabled
+			return true
+		}, nil
+	case bulkActionTag:
+		if req.Tags == nil || len(req.Tags.Add)+len(req.Tags.Remove) == 0 {
+			return nil, errors.New("tags to add or remove are required")
+		}
+
+		add, err := normalizeTags(req.Tags.Add)
+		if err != nil {
+			return nil, err
+		}
+
+		remove, err := normalizeTags(req.Tags.Remove)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(row *database.RowData) bool {
+			tags := slices.DeleteFunc(slices.Clone(row.Tags), func(tag string) bool {
+				return slices.Contains(remove, tag)
+			})
+
+			for _, tag := range add {
+				if !slices.Contains(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+
+			if slices.Equal(tags, row.Tags) || len(tags) > maxLinkTags {
+				return false
+			}
+
+			row.Tags = tags
+			return true
+		}, nil
+	case bulkActionMigrateDomain:
+		if req.Domain == nil || req.Domain.From == "" || req.Domain.To == "" {
+			return nil, errors.New("domain \"from\" and \"to\" are required")
+		}
+
+		from := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(req.Domain.From), "."))
+
+		// Новый домен проходит те же проверки, что и исходные ссылки
+		normalized, err := s.urls.normalize(r.Context(), "https://"+strings.TrimSpace(req.Domain.To)+"/")
+		if err != nil {
+			return nil, errors.New("domain \"to\": " + err.Error())
+		}
+
+		target, _ := url.Parse(normalized)
+		to := target.Host
+
+		// Ссылки отбираются только на прежнем домене
+		req.Filter.Domain = from
+
+		return func(row *database.RowData) bool {
+			u, err := url.Parse(row.Url)
+			if err != nil || strings.ToLower(u.Hostname()) != from {
+				return false
+			}
+
+			if port := u.Port(); port != "" && target.Port() == "" {
+				u.Host = net.JoinHostPort(target.Hostname(), port)
+			} else {
+				u.Host = to
+			}
+
+			row.Url = u.String()
+			return true
+		}, nil
+	default:
+		return nil, errors.New("action must be one of delete, disable, enable, tag, migrate_domain")
+	}
+}
+
+// BulkLinkAction - Метод, реализующий обработку "Post" запроса администратора пространства на массовую операцию
+// над ссылками, отобранными фильтром (ссылки обрабатываются пачками в отдельных транзакциях)
+func (s *Server) BulkLinkAction(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	req := BulkActionRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	change, err := s.bulkChange(r, &req)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid bulk action", "action", req.Action, "error", err)
+		return
+	}
+
+	filter, err := req.Filter.linkFilter()
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid bulk filter", "error", err)
+		return
+	}
+
+	workspaceId := workspaceIdFromContext(r.Context())
+
+	// Старые исходные ссылки нужны для очистки кеша после переноса домена
+	oldUrls := map[int]string{}
+	if change != nil {
+		inner := change
+		change = func(row *database.RowData) bool {
+			oldUrl := row.Url
+			if !inner(row) {
+				return false
+			}
+
+			oldUrls[row.Id] = oldUrl
+			return true
+		}
+	}
+
+	rows, err := s.db.BulkUpdateRows(r.Context(), workspaceId, filter, config.AdminBulkBatchSize,
+		req.Action == bulkActionDelete, req.DryRun, change)
+
+	// Изменения уже сохраненных пачек применяются и при ошибке следующей пачки
+	if !req.DryRun {
+		event := eventLinkUpdated
+		if req.Action == bulkActionDelete {
+			event = eventLinkDeleted
+		}
+
+		for _, row := range rows {
+			s.invalidateCache(row.WorkspaceId, row.Domain, row.ShortUrl, row.Url)
+			if oldUrl, found := oldUrls[row.Id]; found && oldUrl != row.Url {
+				s.invalidateCache(row.WorkspaceId, row.Domain, row.ShortUrl, oldUrl)
+			}
+
+			s.emitLinkEvent(event, row)
+		}
+
+		// Одна запись на операцию: затронутые ссылки перечислены в подробностях
+		if len(rows) != 0 {
+			shortUrls := make([]string, 0, len(rows))
+			for _, row := range rows {
+				shortUrls = append(shortUrls, row.ShortUrl)
+			}
+
+			s.audit(r.Context(), auditLinkBulk, "", map[string]any{
+				"action":     req.Action,
+				"filter":     req.Filter,
+				"tags":       req.Tags,
+				"domain":     req.Domain,
+				"short_urls": shortUrls,
+			})
+		}
+	}
+
+	if err != nil {
+		http.Error(w, "Error: Failed to apply bulk action (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to apply bulk action", "action", req.Action,
+			"committed", len(rows), "error", err)
+		return
+	}
+
+	resp := BulkActionResponse{
+		Action:   req.Action,
+		DryRun:   req.DryRun,
+		Affected: len(rows),
+		Codes:    make([]string, 0, min(len(rows), config.AdminBulkMaxCodes)),
+	}
+
+	for _, row := range rows {
+		if len(resp.Codes) == config.AdminBulkMaxCodes {
+			resp.Truncated = true
+			break
+		}
+
+		resp.Codes = append(resp.Codes, codeFromShortUrl(row.ShortUrl))
+	}
+
+	s.logger.InfoContext(r.Context(), "Bulk action was applied", "action", req.Action, "affected", len(rows),
+		"dry_run", req.DryRun, "workspace_id", workspaceId)
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"regexp"
+	"time"
+)
+
+// aliasRegexp - Регулярное выражение допустимых символов пользовательского кода короткой ссылки
+var aliasRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validateAlias - Функция, реализующая проверку длины и набора символов пользовательского кода короткой ссылки
+func validateAlias(alias string) error {
+
+	if len(alias) < config.AliasMinLen || len(alias) > config.AliasMaxLen {
+		return fmt.Errorf("alias length must be from %d to %d characters", config.AliasMinLen, config.AliasMaxLen)
+	}
+
+	if !aliasRegexp.MatchString(alias) {
+		return fmt.Errorf("alias may contain only latin letters, digits, \"-\" and \"_\"")
+	}
+
+	return nil
+}
+
+// saveAlias - Метод, реализующий сохранение ссылки с пользовательским кодом
+// (в отличие от "shorten" для одной исходной ссылки может быть создано несколько кодов;
+// database.ErrShortUrlExists, если код занят)
+func (s *Server) saveAlias(ctx context.Context, newRow database.RowData, alias string) (string, error) {
+
+	if s.live().reserved.contains(alias) {
+		return "", errReservedCode
+	}
+
+	newRow.ShortUrl = shortUrlOnDomain(newRow.Domain, alias)
+
+	err := s.db.SaveShortUrl(ctx, newRow)
+	if err != nil {
+		if !errors.Is(err, database.ErrShortUrlExists) {
+			s.logger.ErrorContext(ctx, "Failed to save url in database", "error", err)
+		}
+		return "", err
+	}
+
+	s.logger.InfoContext(ctx, "Alias was created successfully", "short_url", newRow.ShortUrl, "url", newRow.Url)
+
+	if s.signer != nil {
+		s.signer.remember(newRow.ShortUrl)
+	}
+
+	newRow.CreatedAt = time.Now()
+	s.emitLinkEvent(eventLinkCreated, newRow)
+	s.audit(ctx, auditLinkCreate, newRow.ShortUrl, map[string]any{"url": newRow.Url, "alias": alias})
+
+	// Удаление из кеша значения замененной истекшей ссылки с тем же кодом
+	_ = s.cacheWithShortUrlKey.Delete(newRow.ShortUrl)
+
+	return newRow.ShortUrl, nil
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/request_id"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Действия журнала аудита
+const (
+	auditLinkCreate       = "link.create"        // Создание ссылки
+	auditLinkUpdate       = "link.update"        // Изменение ссылки
+	auditLinkDelete       = "link.delete"        // Удаление ссылки
+	auditLinkBulk         = "link.bulk"          // Массовая операция над ссылками
+	auditDomainRuleAdd    = "domain_rule.add"    // Добавление домена в список
+	auditDomainRuleDelete = "domain_rule.delete" // Удаление домена из списка
+	auditApiKeyCreate     = "api_key.create"     // Выпуск ключа API
+	auditApiKeyDelete     = "api_key.delete"     // Отзыв ключа API
+	auditApiKeyTier       = "api_key.tier"       // Назначение уровня квот ключа API
+	auditMemberSave       = "member.save"        // Добавление участника или изменение его роли
+	auditMemberDelete     = "member.delete"      // Удаление участника
+	auditWebhookCreate    = "webhook.create"     // Создание вебхука
+	auditWebhookDelete    = "webhook.delete"     // Удаление вебхука
+	auditFeatureFlagSet   = "feature_flag.set"   // Изменение флага функции
+	auditFeatureFlagReset = "feature_flag.reset" // Сброс флага функции к значению из настроек
+	auditLogLevelSet      = "log_level.set"      // Временное изменение уровня журнала
+	auditLogLevelReset    = "log_level.reset"    // Восстановление настроенного уровня журнала
+)
+
+// AuditEntry - Тип данных, описывающий запись журнала аудита в API
+type AuditEntry struct {
+	Id          int             `json:"id"`                     // Идентификатор
+	Time        time.Time       `json:"time"`                   // Время действия
+	WorkspaceId int             `json:"workspace_id,omitempty"` // Рабочее пространство (нет для правил доменов, уровней квот и флагов)
+	UserId      int             `json:"user_id,omitempty"`      // Пользователь
+	Username    string          `json:"username,omitempty"`     // Имя пользователя
+	ApiKeyId    int             `json:"api_key_id,omitempty"`   // Ключ API
+	Action      string          `json:"action"`                 // Действие
+	Target      string          `json:"target,omitempty"`       // Объект действия
+	Details     json.RawMessage `json:"details,omitempty"`      // Подробности
+	RequestId   string          `json:"request_id,omitempty"`   // Идентификатор запроса (связывает запись с журналом доступа)
+}
+
+// audit - Метод, реализующий запись действия в журнал аудита от имени пользователя или ключа API запроса
+// (действие к этому моменту уже выполнено, поэтому ошибка записи не отменяет его и только журналируется)
+func (s *Server) audit(ctx context.Context, action, target string, details any) {
+	s.writeAudit(ctx, s.auditEntry(ctx, action, target, details))
+}
+
+// auditEntry - Метод, возвращающий запись журнала аудита о действии пользователя или ключа API запроса
+func (s *Server) auditEntry(ctx context.Context, action, target string, details any) database.AuditEntry {
+
+	entry := database.AuditEntry{
+		WorkspaceId: workspaceIdFromContext(ctx),
+		ApiKeyId:    workspaceFromContext(ctx).KeyId,
+		Action:      action,
+		Target:      target,
+		RequestId:   request_id.FromContext(ctx),
+	}
+
+	if claims, ok := userFromContext(ctx); ok {
+		entry.UserId, entry.Username = claims.UserId, claims.Username
+	}
+
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to encode audit details", "action", action, "error", err)
+		}
+		entry.Details = data
+	}
+
+	return entry
+}
+
+// writeAudit - Метод, реализующий сохранение записей журнала аудита одним пакетом
+func (s *Server) writeAudit(ctx context.Context, entries ...database.AuditEntry) {
+
+	if len(entries) == 0 {
+		return
+	}
+
+	if err := s.db.AddAuditEntries(ctx, entries...); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to write audit entries", "action", entries[0].Action,
+			"count", len(entries), "error", err)
+	}
+}
+
+// auditGlobal - Метод, реализующий запись в журнал аудита действия вне рабочих пространств
+// (правила доменов, уровни квот и флаги функций действуют на весь сервер)
+func (s *Server) auditGlobal(ctx context.Context, action, target string, details any) {
+	s.audit(context.WithValue(ctx, workspaceContextKey{}, &workspaceAccess{KeyId: workspaceFromContext(ctx).KeyId}),
+		action, target, details)
+}
+
+// auditChanges - Функция, возвращающая заданные поля запроса на изменение (поля без значения опускаются)
+func auditChanges(req any) map[string]any {
+
+	changes := map[string]any{}
+
+	data, err := json.Marshal(req)
+	if err == nil {
+		err = json.Unmarshal(data, &changes)
+	}
+	if err != nil {
+		return nil
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(changes, name)
+		}
+	}
+
+	return changes
+}
+
+// ListAuditLog - Метод, реализующий обработку "Get" запроса администратора на получение журнала аудита пространства
+// (параметры: action (группа действий с точкой в конце, например "link."), user_id, target, from, to,
+// offset и limit или курсор cursor из заголовка X-Next-Cursor предыдущей страницы)
+func (s *Server) ListAuditLog(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s.writeAuditLog(w, r, workspaceIdFromContext(r.Context()))
+}
+
+// ListGlobalAuditLog - Метод, реализующий обработку "Get" запроса оператора на получение журнала аудита действий
+// вне рабочих пространств (правила доменов, уровни квот ключей и личные ссылки всех пользователей;
+// параметры как у ListAuditLog)
+func (s *Server) ListGlobalAuditLog(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s.writeAuditLog(w, r, 0)
+}
+
+// writeAuditLog - Метод, реализующий ответ страницей журнала аудита заданного пространства
+func (s *Server) writeAuditLog(w http.ResponseWriter, r *http.Request, workspaceId int) {
+
+	page, err := pageFromRequest(r)
+	if err != nil {
+		http.Error(w, "Error: Invalid cursor (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid cursor")
+		return
+	}
+
+	filter, err := auditFilterFromRequest(r, workspaceId)
+	if err != nil {
+		http.Error(w, "Error: Invalid audit filter (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid audit filter", "error", err)
+		return
+	}
+
+	entries, err := s.db.ListAuditEntries(r.Context(), filter, page)
+	if err != nil {
+		http.Error(w, "Error: Failed to read audit log (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read audit log", "error", err)
+		return
+	}
+
+	if len(entries) == page.Limit {
+		writeCursorAfter(w, r, page, entries[len(entries)-1].Id)
+	}
+
+	resp := make([]AuditEntry, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, AuditEntry{
+			Id:          e.Id,
+			Time:        e.CreatedAt,
+			WorkspaceId: e.WorkspaceId,
+			UserId:      e.UserId,
+			Username:    e.Username,
+			ApiKeyId:    e.ApiKeyId,
+			Action:      e.Action,
+			Target:      e.Target,
+			Details:     e.Details,
+			RequestId:   e.RequestId,
+		})
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// auditFilterFromRequest - Функция, реализующая чтение отбора записей журнала аудита из параметров запроса
+func auditFilterFromRequest(r *http.Request, workspaceId int) (database.AuditFilter, error) {
+
+	query := r.URL.Query()
+
+	filter := database.AuditFilter{
+		WorkspaceId: workspaceId,
+		Action:      query.Get("action"),
+		Target:      query.Get("target"),
+	}
+
+	if v := query.Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.UserId = id
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.Since, "to": &filter.Until} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, err
+			}
+			*dst = &t
+		}
+	}
+
+	return filter, nil
+}
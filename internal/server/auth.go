@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"golang.org/x/crypto/bcrypt"
+	"my_project/urlgen/pkg/token_manager"
+	"net/http"
+	"strings"
+)
+
+// userContextKey - Тип данных, описывающий ключ для хранения пользователя в контексте запроса
+type userContextKey struct{}
+
+// Credentials - Тип данных, описывающий структуру данных для входа пользователя
+type Credentials struct {
+	Username string `json:"username"` // Имя пользователя
+	Password string `json:"password"` // Пароль
+}
+
+// TokenResponse - Тип данных, описывающий структуру ответа с выпущенным токеном
+type TokenResponse struct {
+	Token     string `json:"token"`      // Токен доступа
+	ExpiresIn int    `json:"expires_in"` // Время жизни токена в секундах
+}
+
+// Login - Метод, реализующий обработку "Post" запроса на вход пользователя (возврат JWT)
+func (s *Server) Login(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	creds := Credentials{}
+
+	// Получение данных для входа
+	err := json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	// Проверка пользователя и пароля
+	user, isExist := s.db.GetUser(r.Context(), creds.Username)
+	if !isExist || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(creds.Password)) != nil {
+		http.Error(w, "Error: Invalid username or password (status code: 401)", http.StatusUnauthorized)
+		s.logger.WarnContext(r.Context(), "Invalid username or password", "username", creds.Username)
+		return
+	}
+
+	// Выпуск токена
+	token, err := s.tokens.Issue(user.Id, user.Username)
+	if err != nil {
+		http.Error(w, "Error: Failed to issue token (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to issue token", "error", err)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "User logged in", "username", user.Username)
+
+	s.writeJSON(w, http.StatusOK, TokenResponse{
+		Token:     token,
+		ExpiresIn: int(s.tokens.TTL().Seconds()),
+	})
+}
+
+// IssueToken - Метод, позволяющий выпустить JWT пользователю по имени без проверки пароля
+// (для команд командной строки, которые работают с БД напрямую и вызывают API в процессе)
+func (s *Server) IssueToken(ctx context.Context, username string) (string, error) {
+
+	user, isExist := s.db.GetUser(ctx, username)
+	if !isExist {
+		return "", errors.New("error: user " + username + " not found")
+	}
+
+	return s.tokens.Issue(user.Id, user.Username)
+}
+
+// authenticate - Метод, реализующий промежуточный обработчик проверки JWT или ключа API из заголовка "Authorization"
+// (запрос без токена пропускается анонимно, запрос с недействительным токеном отклоняется)
+func (s *Server) authenticate(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next(w, r, ps)
+			return
+		}
+
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			http.Error(w, "Error: Invalid authorization header (status code: 401)", http.StatusUnauthorized)
+			s.logger.WarnContext(r.Context(), "Invalid authorization header")
+			return
+		}
+
+		if strings.HasPrefix(token, apiKeyPrefix) {
+			keyed, valid := s.authenticateApiKey(r, token)
+			if !valid {
+				http.Error(w, "Error: Invalid api key (status code: 401)", http.StatusUnauthorized)
+				s.logger.WarnContext(r.Context(), "Invalid api key")
+				return
+			}
+
+			next(w, keyed, ps)
+			return
+		}
+
+		claims, err := s.tokens.Parse(token)
+		if err != nil {
+			http.Error(w, "Error: Invalid token (status code: 401)", http.StatusUnauthorized)
+			s.logger.WarnContext(r.Context(), "Invalid token", "error", err)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, claims)), ps)
+	}
+}
+
+// requireAuth - Метод, реализующий промежуточный обработчик, допускающий только аутентифицированные запросы
+// (пользователем или ключом API)
+func (s *Server) requireAuth(next httprouter.Handle) httprouter.Handle {
+	return s.authenticate(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		if _, ok := userFromContext(r.Context()); !ok && workspaceFromContext(r.Context()).KeyId == 0 {
+			http.Error(w, "Error: Authorization required (status code: 401)", http.StatusUnauthorized)
+			s.logger.WarnContext(r.Context(), "Authorization required")
+			return
+		}
+
+		next(w, r, ps)
+	})
+}
+
+// requireOperator - Метод, реализующий промежуточный обработчик, допускающий только операторов сервиса
+// (API администратора изменяет настройки всех рабочих пространств, поэтому ключи API не допускаются)
+func (s *Server) requireOperator(next httprouter.Handle) httprouter.Handle {
+	return s.requireAuth(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		if !s.requireUserToken(w, r) {
+			return
+		}
+
+		user, isExist := s.db.GetUserById(r.Context(), userIdFromContext(r.Context()))
+		if !isExist || !user.IsAdmin {
+			http.Error(w, "Error: Operator access required (status code: 403)", http.StatusForbidden)
+			s.logger.WarnContext(r.Context(), "Operator access required", "user_id", userIdFromContext(r.Context()))
+			return
+		}
+
+		next(w, r, ps)
+	})
+}
+
+// userFromContext - Функция, позволяющая получить аутентифицированного пользователя из контекста запроса
+func userFromContext(ctx context.Context) (*token_manager.Claims, bool) {
+	claims, ok := ctx.Value(userContextKey{}).(*token_manager.Claims)
+	return claims, ok
+}
+
+// userIdFromContext - Функция, позволяющая получить идентификатор пользователя из контекста запроса (0 для анонимного)
+func userIdFromContext(ctx context.Context) int {
+	if claims, ok := userFromContext(ctx); ok {
+		return claims.UserId
+	}
+
+	return 0
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"log/slog"
+	"my_project/urlgen/pkg/token_manager"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testServer - Функция, реализующая создание сервера без БД для проверки промежуточных обработчиков
+func testServer() *Server {
+	return &Server{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		tokens: token_manager.TokenManagerCreate([]byte("secret"), time.Hour),
+	}
+}
+
+// serveHandle - Функция, реализующая вызов обработчика с доступом ключа API в контексте (nil - без ключа)
+// и возвращающая статус ответа и доступ, переданный следующему обработчику
+func serveHandle(handle func(httprouter.Handle) httprouter.Handle, r *http.Request, key *workspaceAccess,
+	ps httprouter.Params) (int, *workspaceAccess) {
+
+	if key != nil {
+		r = r.WithContext(context.WithValue(r.Context(), workspaceContextKey{}, key))
+	}
+
+	var passed *workspaceAccess
+
+	rec := httptest.NewRecorder()
+	handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		passed = workspaceFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})(rec, r, ps)
+
+	return rec.Code, passed
+}
+
+func TestRequireAuth(t *testing.T) {
+
+	s := testServer()
+	key := &workspaceAccess{Id: 7, Role: roleAdmin, KeyId: 1}
+
+	tests := []struct {
+		name          string
+		authorization string
+		key           *workspaceAccess
+		status        int
+	}{
+		{"anonymous", "", nil, http.StatusUnauthorized},
+		{"malformed header", "Basic dXNlcjpwYXNz", nil, http.StatusUnauthorized},
+		{"invalid token", "Bearer not-a-jwt", nil, http.StatusUnauthorized},
+		{"forged token", "Bearer " + mustIssue(t, token_manager.TokenManagerCreate([]byte("other"), time.Hour)),
+			nil, http.StatusUnauthorized},
+		{"api key", "", key, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			if status, _ := serveHandle(s.requireAuth, r, tt.key, nil); status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestRequireOperator(t *testing.T) {
+
+	s := testServer()
+	key := &workspaceAccess{Id: 7, Role: roleAdmin, KeyId: 1}
+
+	tests := []struct {
+		name          string
+		authorization string
+		key           *workspaceAccess
+		status        int
+	}{
+		{"anonymous", "", nil, http.StatusUnauthorized},
+		{"malformed header", "Basic dXNlcjpwYXNz", nil, http.StatusUnauthorized},
+		{"invalid token", "Bearer not-a-jwt", nil, http.StatusUnauthorized},
+		{"forged token", "Bearer " + mustIssue(t, token_manager.TokenManagerCreate([]byte("other"), time.Hour)),
+			nil, http.StatusUnauthorized},
+		{"api key", "", key, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/admin/flags", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			if status, _ := serveHandle(s.requireOperator, r, tt.key, nil); status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestAuthenticateUserToken(t *testing.T) {
+
+	s := testServer()
+
+	var claims *token_manager.Claims
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+mustIssue(t, s.tokens))
+
+	rec := httptest.NewRecorder()
+	s.requireAuth(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		claims, _ = userFromContext(r.Context())
+	})(rec, r, nil)
+
+	if rec.Code != http.StatusOK || claims == nil || claims.UserId != 42 || claims.Username != "alice" {
+		t.Errorf("status = %d, claims = %+v, want user 42", rec.Code, claims)
+	}
+}
+
+// mustIssue - Функция, реализующая выпуск токена пользователю 42 для тестов
+func mustIssue(t *testing.T, tokens *token_manager.TokenManager) string {
+
+	token, err := tokens.Issue(42, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/bigquery"
+	"os"
+	"strconv"
+	"time"
+)
+
+// bigqueryClicksSchema - Столбцы таблицы суточного количества переходов BigQuery (секции по суткам day)
+var bigqueryClicksSchema = []bigquery.Field{
+	{Name: "day", Type: "DATE", Mode: "REQUIRED"},
+	{Name: "short_url", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "code", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "clicks", Type: "INT64", Mode: "REQUIRED"},
+	{Name: "bot_clicks", Type: "INT64", Mode: "REQUIRED"},
+	{Name: "exported_at", Type: "TIMESTAMP", Mode: "REQUIRED"},
+}
+
+// bigqueryLinksSchema - Столбцы таблицы ссылок BigQuery (заменяется при каждой выгрузке)
+var bigqueryLinksSchema = []bigquery.Field{
+	{Name: "short_url", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "code", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "domain", Type: "STRING"},
+	{Name: "url", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "workspace_id", Type: "INT64"},
+	{Name: "created_at", Type: "TIMESTAMP", Mode: "REQUIRED"},
+	{Name: "expires_at", Type: "TIMESTAMP"},
+	{Name: "max_clicks", Type: "INT64"},
+	{Name: "click_count", Type: "INT64", Mode: "REQUIRED"},
+	{Name: "disabled", Type: "BOOL", Mode: "REQUIRED"},
+	{Name: "analytics", Type: "BOOL", Mode: "REQUIRED"},
+	{Name: "tags", Type: "STRING", Mode: "REPEATED"},
+	{Name: "exported_at", Type: "TIMESTAMP", Mode: "REQUIRED"},
+}
+
+// bigqueryClick - Тип данных, описывающий строку таблицы суточного количества переходов BigQuery
+type bigqueryClick struct {
+	Day        string    `json:"day"`
+	ShortUrl   string    `json:"short_url"`
+	Code       string    `json:"code"`
+	Clicks     int       `json:"clicks"`
+	BotClicks  int       `json:"bot_clicks"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// bigqueryLink - Тип данных, описывающий строку таблицы ссылок BigQuery
+type bigqueryLink struct {
+	ShortUrl    string     `json:"short_url"`
+	Code        string     `json:"code"`
+	Domain      string     `json:"domain,omitempty"`
+	Url         string     `json:"url"`
+	WorkspaceId int        `json:"workspace_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxClicks   int        `json:"max_clicks,omitempty"`
+	ClickCount  int        `json:"click_count"`
+	Disabled    bool       `json:"disabled"`
+	Analytics   bool       `json:"analytics"`
+	Tags        []string   `json:"tags,omitempty"`
+	ExportedAt  time.Time  `json:"exported_at"`
+}
+
+// bigqueryExport - Тип данных, описывающий ежедневную выгрузку суточного количества переходов и ссылок в BigQuery
+type bigqueryExport struct {
+	client   *bigquery.Client // Клиент BigQuery
+	dataset  string           // Набор данных с таблицами выгрузки
+	hour     int              // Час (UTC), после которого выгружаются прошедшие сутки
+	exported time.Time        // Начало суток, в которые выгрузка выполнена (используется только задачей выгрузки)
+}
+
+// bigqueryFromEnv - Функция, позволяющая получить выгрузку в BigQuery из переменных окружения
+// (BIGQUERY_DATASET - существующий набор данных, nil, если не задан; BIGQUERY_CREDENTIALS_FILE - ключ
+// сервисного аккаунта в формате JSON, по умолчанию GOOGLE_APPLICATION_CREDENTIALS; BIGQUERY_PROJECT - проект,
+// по умолчанию проект ключа; BIGQUERY_EXPORT_HOUR - час (UTC), после которого выгружаются прошедшие сутки)
+func bigqueryFromEnv() (*bigqueryExport, error) {
+
+	dataset := os.Getenv("BIGQUERY_DATASET")
+	if dataset == "" {
+		return nil, nil
+	}
+
+	path := os.Getenv("BIGQUERY_CREDENTIALS_FILE")
+	if path == "" {
+		path = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if path == "" {
+		return nil, errors.New("error: BIGQUERY_DATASET requires BIGQUERY_CREDENTIALS_FILE")
+	}
+
+	credentials, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error: failed to read BigQuery credentials: %w", err)
+	}
+
+	client, err := bigquery.ClientCreate(credentials, os.Getenv("BIGQUERY_PROJECT"))
+	if err != nil {
+		return nil, err
+	}
+
+	hour := config.BigQueryExportHour
+	if v := os.Getenv("BIGQUERY_EXPORT_HOUR"); v != "" {
+		hour, err = strconv.Atoi(v)
+		if err != nil || hour < 0 || hour > 23 {
+			return nil, errors.New("error: BIGQUERY_EXPORT_HOUR must be an hour from 0 to 23")
+		}
+	}
+
+	return &bigqueryExport{client: client, dataset: dataset, hour: hour}, nil
+}
+
+// exportBigQuery - Метод, реализующий ежедневную выгрузку в BigQuery ведущим экземпляром после заданного часа
+// (до отмены контекста сервера; после ошибки выгрузка повторяется при следующей проверке)
+func (s *Server) exportBigQuery() {
+
+	ticker := time.NewTicker(config.BigQueryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+
+		due := time.Now().UTC().Hour() >= s.bigquery.hour && s.bigquery.exported.Before(today)
+
+		if due && s.isLeader() {
+			if err := s.runBigQueryExport(today); err != nil {
+				s.logger.Error("Failed to export to BigQuery", "error", err)
+			} else {
+				s.bigquery.exported = today
+			}
+		}
+
+		select {
+		case <-s.context.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runBigQueryExport - Метод, реализующий выгрузку ссылок и количества переходов за последние сутки до today
+// (таблица ссылок и секции суток заменяются целиком, поэтому повторная выгрузка не создает дублей,
+// а переходы, записанные с опозданием, попадают в следующую выгрузку)
+func (s *Server) runBigQueryExport(today time.Time) error {
+
+	ctx, cancel := context.WithTimeout(s.context, config.BigQueryExportTimeout)
+	defer cancel()
+
+	export := s.bigquery
+
+	// Время в BigQuery хранится с точностью до микросекунд
+	exportedAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	tables := []bigquery.Table{
+		{Dataset: export.dataset, Table: config.BigQueryClicksTable, Schema: bigqueryClicksSchema,
+			PartitionField: "day", Description: "Daily clicks per short link exported by urlgen"},
+		{Dataset: export.dataset, Table: config.BigQueryLinksTable, Schema: bigqueryLinksSchema,
+			Description: "Short links exported by urlgen"},
+	}
+
+	for _, table := range tables {
+		if err := export.client.EnsureTable(ctx, table); err != nil {
+			return err
+		}
+	}
+
+	links, err := export.client.Load(ctx, export.dataset, config.BigQueryLinksTable, nil, bigquery.WriteTruncate,
+		func(add func(row any) error) error {
+			return s.db.ExportRows(ctx, func(row database.RowData) error {
+				var expiresAt *time.Time
+				if row.ExpiresAt != nil {
+					t := row.ExpiresAt.UTC()
+					expiresAt = &t
+				}
+
+				return add(bigqueryLink{
+					ShortUrl:    row.ShortUrl,
+					Code:        codeFromShortUrl(row.ShortUrl),
+					Domain:      row.Domain,
+					Url:         row.Url,
+					WorkspaceId: row.WorkspaceId,
+					CreatedAt:   row.CreatedAt.UTC(),
+					ExpiresAt:   expiresAt,
+					MaxClicks:   row.MaxClicks,
+					ClickCount:  row.ClickCount,
+					Disabled:    row.Disabled,
+					Analytics:   !row.NoAnalytics,
+					Tags:        row.Tags,
+					ExportedAt:  exportedAt,
+				})
+			})
+		})
+	if err != nil {
+		return fmt.Errorf("error: failed to export links: %w", err)
+	}
+
+	clicks := 0
+
+	for i := config.BigQueryExportDays; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+
+		n, err := export.client.Load(ctx, export.dataset, config.BigQueryClicksTable, &day, bigquery.WriteTruncate,
+			func(add func(row any) error) error {
+				return s.analytics.ExportDayClickAggregates(ctx, day, func(a database.ClickAggregate) error {
+					return add(bigqueryClick{
+						Day:        a.Day.Format(time.DateOnly),
+						ShortUrl:   a.ShortUrl,
+						Code:       codeFromShortUrl(a.ShortUrl),
+						Clicks:     a.Clicks,
+						BotClicks:  a.BotClicks,
+						ExportedAt: exportedAt,
+					})
+				})
+			})
+		if err != nil {
+			return fmt.Errorf("error: failed to export clicks of %s: %w", day.Format(time.DateOnly), err)
+		}
+
+		clicks += n
+	}
+
+	s.logger.Info("Exported to BigQuery", "project", export.client.Project(), "dataset", export.dataset,
+		"links", links, "click_rows", clicks, "days", config.BigQueryExportDays)
+
+	return nil
+}
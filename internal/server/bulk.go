@@ -0,0 +1,366 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// BulkLinkRequest - Тип данных, описывающий тело запроса на массовое создание ссылок
+type BulkLinkRequest struct {
+	Links  []LinkRequest `json:"links"`             // Создаваемые ссылки
+	DryRun bool          `json:"dry_run,omitempty"` // Проверка ссылок без создания
+}
+
+// BulkLinkResult - Тип данных, описывающий результат создания одной ссылки из массового запроса
+type BulkLinkResult struct {
+	Url   string `json:"url"`             // Исходная ссылка из запроса
+	Link  *Link  `json:"link,omitempty"`  // Созданная или уже существующая ссылка
+	Error string `json:"error,omitempty"` // Описание ошибки, если ссылка не создана
+}
+
+// BulkLinkResponse - Тип данных, описывающий ответ на запрос массового создания ссылок
+// (результаты следуют в порядке элементов запроса)
+type BulkLinkResponse struct {
+	Results []BulkLinkResult `json:"results"`
+	DryRun  bool             `json:"dry_run,omitempty"` // Ссылки проверены, но не созданы
+}
+
+// CreateLinksBulk - Метод, реализующий обработку "Post" запроса на массовое создание ссылок
+func (s *Server) CreateLinksBulk(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	req := BulkLinkRequest{}
+
+	// Получение списка ссылок
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || len(req.Links) == 0 {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	if len(req.Links) > config.BulkMaxLinks {
+		http.Error(w, fmt.Sprintf("Error: Too many links, at most %d allowed (status code: 400)", config.BulkMaxLinks),
+			http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Too many links in bulk request", "count", len(req.Links))
+		return
+	}
+
+	results := make([]BulkLinkResult, len(req.Links))
+
+	// Исходные ссылки без пользовательского кода по доменам коротких ссылок (в порядке первого упоминания домена):
+	// ссылки без собственных параметров могут совпасть с существующими, элементы с параметрами получают новые коды
+	var domains []string
+	urls := map[string][]string{}
+	optioned := map[string][]int{}
+
+	// Проверка элементов запроса (пароли заменяются их хешами)
+	for i, item := range req.Links {
+		results[i].Url = item.Url
+
+		normalized, err := s.urls.normalize(r.Context(), item.Url)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		item.Url = normalized
+		req.Links[i].Url = normalized
+
+		if s.checkUrl(r.Context(), normalized).Malicious {
+			results[i].Error = "url is flagged as malicious"
+			continue
+		}
+
+		switch {
+		case item.RedirectStatus != 0 && !isRedirectStatus(item.RedirectStatus):
+			results[i].Error = "invalid redirect status"
+		default:
+			expiresAt, err := item.expiration()
+			if err == nil {
+				err = validateActivation(item.ActiveFrom, expiresAt)
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			if err = validateQueryParams(item.QueryParams); err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			if err = validateMaxClicks(item.MaxClicks); err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			req.Links[i].Tags, err = normalizeTags(item.Tags)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			req.Links[i].Variants, err = s.prepareVariants(r.Context(), item.Variants)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			req.Links[i].DeviceUrls, err = s.prepareDeviceUrls(r.Context(), item.DeviceUrls)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			req.Links[i].GeoUrls, err = s.prepareGeoUrls(r.Context(), item.GeoUrls)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			if item.Alias != "" {
+				if err = validateAlias(item.Alias); err != nil {
+					results[i].Error = err.Error()
+					continue
+				}
+
+				req.Links[i].Alias = s.foldCode(item.Alias)
+			}
+
+			domain, err := s.shortDomain(item.Domain)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			passwordHash, err := hashLinkPassword(item.Password)
+			if err != nil {
+				results[i].Error = "failed to hash password"
+				continue
+			}
+
+			req.Links[i].ExpiresAt = expiresAt
+			req.Links[i].Password = passwordHash
+			req.Links[i].Domain = domain
+			if item.Alias == "" {
+				if !slices.Contains(domains, domain) {
+					domains = append(domains, domain)
+				}
+
+				if row := bulkRow(req.Links[i]); row.Plain() {
+					urls[domain] = append(urls[domain], item.Url)
+				} else {
+					optioned[domain] = append(optioned[domain], i)
+				}
+			}
+		}
+	}
+
+	// При проверке без создания ответ содержит только ошибки проверки элементов
+	if req.DryRun {
+		s.logger.InfoContext(r.Context(), "Bulk links checked", "count", len(req.Links))
+		s.writeJSON(w, http.StatusOK, BulkLinkResponse{Results: results, DryRun: true})
+		return
+	}
+
+	// Поиск уже существующих ссылок рабочего пространства
+	workspaceId := workspaceIdFromContext(r.Context())
+
+	// Найденные и сгенерированные ссылки сопоставлены ключам originalUrlKey, коды элементов с параметрами - их индексам
+	existing := map[string]database.RowData{}
+	generated := map[string]generatedCode{}
+	itemCodes := map[int]generatedCode{}
+
+	for _, domain := range domains {
+		rows, err := s.db.GetUrlRows(r.Context(), workspaceId, domain, urls[domain])
+		if err != nil {
+			http.Error(w, "Error: Failed to read links (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to read links", "error", err)
+			return
+		}
+
+		// Генерация новых ссылок (повторы исходной ссылки без пользовательского кода и собственных параметров
+		// получают одну ссылку, каждый элемент с параметрами - свою)
+		var fresh []string
+		for _, url := range urls[domain] {
+			if _, found := rows[url]; !found && !slices.Contains(fresh, url) {
+				fresh = append(fresh, url)
+			}
+		}
+
+		targets := slices.Clone(fresh)
+		for _, i := range optioned[domain] {
+			targets = append(targets, req.Links[i].Url)
+		}
+
+		codes, err := s.generateCodes(r.Context(), workspaceId, domain, targets)
+		if err != nil {
+			http.Error(w, "Error: Failed to generate links (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to generate short urls", "error", err)
+			return
+		}
+
+		for url, row := range rows {
+			existing[originalUrlKey(workspaceId, domain, url)] = row
+		}
+		for i, url := range fresh {
+			generated[originalUrlKey(workspaceId, domain, url)] = codes[i]
+		}
+		for j, i := range optioned[domain] {
+			itemCodes[i] = codes[len(fresh)+j]
+		}
+	}
+
+	userId := userIdFromContext(r.Context())
+
+	var newRows []database.RowData
+	pending := map[string][]int{}
+
+	for i, item := range req.Links {
+		if results[i].Error != "" {
+			continue
+		}
+
+		var (
+			shortUrl string
+			id       int
+		)
+
+		if item.Alias != "" {
+			shortUrl = shortUrlOnDomain(item.Domain, item.Alias)
+
+			if _, found := pending[shortUrl]; found {
+				results[i].Error = "alias is already taken"
+				continue
+			}
+		} else if code, found := itemCodes[i]; found {
+			shortUrl, id = code.ShortUrl, code.Id
+		} else {
+			key := originalUrlKey(workspaceId, item.Domain, item.Url)
+
+			if row, found := existing[key]; found {
+				link := linkFromRow(row)
+				results[i].Link = &link
+				continue
+			}
+
+			shortUrl, id = generated[key].ShortUrl, generated[key].Id
+		}
+
+		if s.live().reserved.contains(codeFromShortUrl(shortUrl)) {
+			results[i].Error = "code is reserved"
+			continue
+		}
+
+		if _, found := pending[shortUrl]; !found {
+			row := bulkRow(item)
+			row.Id = id
+			row.ShortUrl = shortUrl
+			row.UserId = userId
+			row.WorkspaceId = workspaceId
+			row.ApiKeyId = workspaceFromContext(r.Context()).KeyId
+			row.CreatedAt = time.Now()
+
+			newRows = append(newRows, row)
+		}
+
+		pending[shortUrl] = append(pending[shortUrl], i)
+	}
+
+	// Пакет, превышающий остаток квоты ключа API, отклоняется целиком
+	if remaining, limited := quotaRemaining(r.Context()); limited && len(newRows) > remaining {
+		http.Error(w, "Error: Batch exceeds api key quota (status code: 429)", http.StatusTooManyRequests)
+		s.logger.WarnContext(r.Context(), "Batch exceeds api key quota", "count", len(newRows), "remaining", remaining)
+		return
+	}
+
+	// Сохранение новых ссылок одним пакетом
+	if len(newRows) != 0 {
+		errs := s.db.SaveShortUrls(r.Context(), newRows)
+
+		var audited []database.AuditEntry
+
+		for j, row := range newRows {
+			indices := pending[row.ShortUrl]
+			created := true
+
+			// Занятый сгенерированный код заменяется новым
+			if errors.Is(errs[j], database.ErrShortUrlExists) && req.Links[indices[0]].Alias == "" {
+				row, created, errs[j] = s.saveGenerated(r.Context(), row)
+			}
+
+			for _, i := range indices {
+				switch {
+				case errors.Is(errs[j], database.ErrShortUrlExists) && req.Links[i].Alias != "":
+					results[i].Error = "alias is already taken"
+				case isCollision(errs[j]):
+					results[i].Error = "no free short code"
+				case errs[j] != nil:
+					results[i].Error = "failed to save link"
+				default:
+					link := linkFromRow(row)
+					results[i].Link = &link
+				}
+			}
+
+			if errs[j] != nil {
+				s.logger.ErrorContext(r.Context(), "Failed to save url in database", "url", row.Url, "error", errs[j])
+				continue
+			}
+
+			if created {
+				s.emitLinkEvent(eventLinkCreated, row)
+				s.codesAdded(r.Context(), 1)
+
+				audited = append(audited, s.auditEntry(r.Context(), auditLinkCreate, row.ShortUrl,
+					map[string]any{"url": row.Url, "bulk": true}))
+			}
+
+			if s.signer != nil {
+				s.signer.remember(row.ShortUrl)
+			}
+
+			// Ссылки с пользовательским кодом не заменяют в кеше сгенерированную ссылку для исходной
+			if req.Links[indices[0]].Alias == "" {
+				s.cacheRow(row)
+			} else {
+				_ = s.cacheWithShortUrlKey.Delete(row.ShortUrl)
+			}
+		}
+
+		s.writeAudit(r.Context(), audited...)
+	}
+
+	s.logger.InfoContext(r.Context(), "Bulk links processed", "count", len(req.Links), "created", len(newRows))
+
+	s.writeJSON(w, http.StatusOK, BulkLinkResponse{Results: results})
+}
+
+// bulkRow - Функция, возвращающая строку новой ссылки с параметрами проверенного элемента массового запроса
+// (пароль элемента уже заменен хешем)
+func bulkRow(item LinkRequest) database.RowData {
+	return database.RowData{
+		Url:            item.Url,
+		Domain:         item.Domain,
+		RedirectStatus: item.RedirectStatus,
+		ExpiresAt:      item.ExpiresAt,
+		ActiveFrom:     item.ActiveFrom,
+		MaxClicks:      item.MaxClicks,
+		Disabled:       item.Active != nil && !*item.Active,
+		NoAnalytics:    item.Analytics != nil && !*item.Analytics,
+		Tags:           item.Tags,
+		PasswordHash:   item.Password,
+		QueryParams:    item.QueryParams,
+		Variants:       item.Variants,
+		StickyVariants: item.StickyVariants,
+		DeviceUrls:     item.DeviceUrls,
+		GeoUrls:        item.GeoUrls,
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"my_project/urlgen/database"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// redirectCache - Тип данных, описывающий настройки кеширования ответов перехода по короткой ссылке
+type redirectCache struct {
+	maxAge time.Duration // Максимальное время кеширования ответа (0 - кеширование запрещено)
+	etag   bool          // Добавлять ли заголовок ETag
+}
+
+// redirectCacheFromEnv - Функция, позволяющая получить настройки кеширования из переменных окружения
+// (REDIRECT_CACHE_MAX_AGE - время кеширования, например "1h", REDIRECT_ETAG=true - добавление ETag)
+func redirectCacheFromEnv() (*redirectCache, error) {
+
+	c := redirectCache{etag: os.Getenv("REDIRECT_ETAG") == "true"}
+
+	if v := os.Getenv("REDIRECT_CACHE_MAX_AGE"); v != "" {
+		maxAge, err := time.ParseDuration(v)
+		if err != nil || maxAge < 0 {
+			return nil, errors.New("error: REDIRECT_CACHE_MAX_AGE must be a non-negative duration")
+		}
+
+		c.maxAge = maxAge
+	}
+
+	return &c, nil
+}
+
+// writeHeaders - Метод, реализующий установку заголовков кеширования ответа перехода
+// (время кеширования не превышает срок действия ссылки, защищенные паролем ссылки, ссылки с вариантами
+// и правилами по устройству или местоположению не кешируются;
+// возвращает true, если клиенту отправлен ответ 304 по совпадению ETag)
+func (c *redirectCache) writeHeaders(w http.ResponseWriter, r *http.Request, row *database.RowData, destination string,
+	status int) bool {
+
+	maxAge := c.maxAge
+
+	if row.ExpiresAt != nil {
+		if left := time.Until(*row.ExpiresAt); left < maxAge {
+			maxAge = left
+		}
+	}
+
+	if row.PasswordHash != "" || len(row.Variants) != 0 || len(row.DeviceUrls) != 0 || len(row.GeoUrls) != 0 ||
+		row.MaxClicks != 0 || maxAge < time.Second {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	}
+
+	if !c.etag {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(strconv.Itoa(status) + " " + destination))
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
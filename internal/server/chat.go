@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"my_project/urlgen/database"
+	"strings"
+)
+
+// chatShorten - Метод, реализующий создание короткой ссылки по сообщению из чата ключом API интеграции
+// (с квотами ключа, проверкой и нормализацией ссылки); при отказе вместо ссылки возвращается текст
+// ответа пользователю, так как чаты показывают только текст
+func (s *Server) chatShorten(ctx context.Context, key, rawUrl string) (string, string) {
+
+	ctx, valid := s.apiKeyContext(ctx, key)
+	if !valid {
+		s.logger.WarnContext(ctx, "Invalid api key of chat integration")
+		return "", "The api key of this integration is no longer valid"
+	}
+
+	access := workspaceFromContext(ctx)
+
+	exceeded, release, err := s.linkQuotaExceeded(ctx)
+	if err != nil {
+		return "", "Failed to create the short link, try again later"
+	}
+	if exceeded {
+		return "", "The link quota of this workspace is exceeded"
+	}
+	defer release()
+
+	url, err := s.urls.normalize(ctx, rawUrl)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Invalid url", "url", rawUrl, "error", err)
+		return "", "Invalid url: " + err.Error()
+	}
+
+	if s.checkUrl(ctx, url).Malicious {
+		return "", "The url is flagged as malicious"
+	}
+
+	shortUrl, err := s.shorten(ctx, database.RowData{
+		Url:         url,
+		WorkspaceId: access.Id,
+		ApiKeyId:    access.KeyId,
+	})
+	if isCollision(err) {
+		return "", "No free short code, try again later"
+	}
+	if err != nil {
+		return "", "Failed to create the short link, try again later"
+	}
+
+	return shortUrl, ""
+}
+
+// chatLink - Метод, реализующий поиск ссылки рабочего пространства ключа API интеграции по коду
+// или короткой ссылке из сообщения (при отказе вместо ссылки возвращается текст ответа пользователю)
+func (s *Server) chatLink(ctx context.Context, key, arg string) (*database.RowData, string) {
+
+	ctx, valid := s.apiKeyContext(ctx, key)
+	if !valid {
+		s.logger.WarnContext(ctx, "Invalid api key of chat integration")
+		return nil, "The api key of this integration is no longer valid"
+	}
+
+	row, isExist := s.findWorkspaceLink(ctx, arg)
+	if !isExist {
+		return nil, "Link not found"
+	}
+
+	return row, ""
+}
+
+// findWorkspaceLink - Метод, реализующий поиск ссылки рабочего пространства контекста по коду или короткой ссылке
+// (false, если ссылки нет или она принадлежит другому пространству)
+func (s *Server) findWorkspaceLink(ctx context.Context, arg string) (*database.RowData, bool) {
+
+	shortUrl := arg
+	if !strings.Contains(arg, "://") {
+		shortUrl = shortUrlFromCode(arg)
+	}
+
+	row, isExist := s.resolve(ctx, shortUrl)
+	if arg == "" || !isExist || row.WorkspaceId != workspaceIdFromContext(ctx) {
+		return nil, false
+	}
+
+	// Количество переходов в кеше может отставать
+	if fresh, isExist := s.db.GetShortUrlRow(ctx, row.ShortUrl); isExist {
+		row = fresh
+	}
+
+	return row, true
+}
+
+// chatExpand - Метод, возвращающий ответ с исходной ссылкой для короткой (переход не учитывается)
+func (s *Server) chatExpand(ctx context.Context, key, arg string) (string, string) {
+
+	row, problem := s.chatLink(ctx, key, arg)
+	if problem != "" {
+		return "", problem
+	}
+
+	answer := row.ShortUrl + " → " + row.Url
+
+	switch {
+	case row.Disabled:
+		answer += " (disabled)"
+	case row.Expired() || row.Exhausted():
+		answer += " (expired)"
+	case row.NotYetLive():
+		answer += " (not live yet)"
+	}
+
+	return answer, ""
+}
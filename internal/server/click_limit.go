@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"my_project/urlgen/database"
+)
+
+// maxClicksLimit - Максимальный лимит переходов, задаваемый для ссылки
+const maxClicksLimit = 1 << 30
+
+// validateMaxClicks - Функция, проверяющая лимит переходов по ссылке (0 - без ограничения)
+func validateMaxClicks(maxClicks int) error {
+
+	if maxClicks < 0 || maxClicks > maxClicksLimit {
+		return errors.New("max_clicks must be between 0 and 1073741824")
+	}
+
+	return nil
+}
+
+// consumeClick - Метод, реализующий учет перехода по ссылке с ограниченным количеством переходов
+// (счетчик увеличивается в БД атомарно, поэтому одновременные переходы не превышают лимит;
+// возвращает false, если лимит уже исчерпан)
+func (s *Server) consumeClick(ctx context.Context, row *database.RowData) (bool, error) {
+
+	if row.MaxClicks == 0 {
+		return true, nil
+	}
+
+	count, ok, err := s.db.ConsumeClick(ctx, row.ShortUrl)
+	if err != nil {
+		return false, err
+	}
+
+	// Строка в кеше хранит устаревший счетчик: после исчерпания лимита она удаляется,
+	// чтобы следующие переходы сразу получали ответ 410
+	if !ok || count >= row.MaxClicks {
+		s.invalidateCache(row.WorkspaceId, row.Domain, row.ShortUrl, row.Url)
+	}
+
+	if ok && count >= row.MaxClicks {
+		exhausted := *row
+		exhausted.ClickCount = count
+
+		s.logger.InfoContext(ctx, "Url reached its click limit", "short_url", row.ShortUrl, "max_clicks", row.MaxClicks)
+		s.emitLinkEvent(eventLinkExpired, exhausted)
+	}
+
+	return ok, nil
+}
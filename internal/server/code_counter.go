@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/redis"
+	"os"
+	"strconv"
+)
+
+// codeCounterStrategy - Генератор кодов из номеров счетчика в Redis
+const codeCounterStrategy = "redis"
+
+// redisFromEnv - Функция, позволяющая создать клиент Redis по адресу из переменной REDIS_URL
+// (nil, если адрес не задан)
+func redisFromEnv() (*redis.Client, error) {
+
+	rawUrl := os.Getenv("REDIS_URL")
+	if rawUrl == "" {
+		return nil, nil
+	}
+
+	return redis.ClientCreate(rawUrl)
+}
+
+// counterIds - Метод, реализующий получение n номеров счетчика кодов в Redis одной командой INCRBY
+// (экземпляры сервера получают непересекающиеся пачки номеров)
+func (s *Server) counterIds(ctx context.Context, n int) ([]int, error) {
+
+	last, err := s.redis.IncrBy(ctx, config.CodeCounterKey, int64(n))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = int(last) - n + 1 + i
+	}
+
+	return ids, nil
+}
+
+// seedCodeCounter - Метод, реализующий начальную установку счетчика кодов в Redis, если его нет
+// (счетчик начинается после последнего идентификатора строк БД, чтобы коды не совпадали с кодами "sequence")
+func (s *Server) seedCodeCounter(ctx context.Context) error {
+
+	last, err := s.db.LastRowId(ctx)
+	if err != nil {
+		return fmt.Errorf("error: failed to read the last row id: %w", err)
+	}
+
+	seeded, err := s.redis.SetNX(ctx, config.CodeCounterKey, strconv.Itoa(last))
+	if err != nil {
+		return fmt.Errorf("error: failed to create code counter in Redis: %w", err)
+	}
+
+	if seeded {
+		s.logger.InfoContext(ctx, "Code counter was created in Redis", "key", config.CodeCounterKey, "value", last)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultBlockedWords - Слова, недопустимые в сгенерированных кодах по умолчанию
+var defaultBlockedWords = []string{
+	"anal", "anus", "arse", "ass", "bitch", "boob", "cock", "crap", "cum", "cunt", "damn", "dick", "dildo",
+	"douche", "fag", "fuck", "hell", "homo", "jizz", "kkk", "nazi", "nigg", "penis", "piss", "porn", "pussy",
+	"rape", "sex", "shit", "slut", "tit", "twat", "vagina", "wank", "whore",
+}
+
+// leetReplacer - Замена похожих символов (в том числе подстановок l33t) одним символом
+// (слова и коды приводятся к одному виду, например "a55h0le" и "asshole" - к "asshoie")
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "l", "i", "!", "i", "|", "i", "3", "e", "4", "a", "@", "a", "5", "s", "$", "s",
+	"7", "t", "+", "t", "8", "b", "9", "g", "2", "z", "-", "", "_", "", ".", "",
+)
+
+// codeFilter - Тип данных, описывающий набор слов, кодов с которыми следует избегать при генерации
+type codeFilter []string
+
+// codeFilterFromEnv - Функция, позволяющая получить набор недопустимых в сгенерированных кодах слов
+// (к словам по умолчанию добавляются перечисленные через запятую в CODE_BLOCKED_WORDS)
+func codeFilterFromEnv() codeFilter {
+
+	words := make(codeFilter, 0, len(defaultBlockedWords))
+
+	for _, word := range append(defaultBlockedWords, strings.Split(os.Getenv("CODE_BLOCKED_WORDS"), ",")...) {
+		word = leetReplacer.Replace(strings.ToLower(strings.TrimSpace(word)))
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
+
+// matches - Метод, проверяющий, содержит ли код недопустимое слово (без учета регистра и подстановок l33t)
+func (f codeFilter) matches(code string) bool {
+
+	code = leetReplacer.Replace(strings.ToLower(code))
+
+	for _, word := range f {
+		if strings.Contains(code, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rejectedCode - Метод, проверяющий, что сгенерированный код нельзя выдавать (зарезервирован или оскорбителен)
+func (s *Server) rejectedCode(code string) bool {
+	live := s.live()
+	return live.reserved.contains(code) || live.blocked.matches(code)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"os"
+	"strconv"
+	"time"
+)
+
+// codePoolStrategy - Название генератора, выдающего заранее созданные коды из запаса в БД
+const codePoolStrategy = "pool"
+
+// codePool - Тип данных, реализующий выдачу заранее созданных случайных кодов
+// (запас пополняется в фоне, поэтому создание ссылки не ждет генерации и повторов при совпадении кодов)
+type codePool struct {
+	server *Server
+	size   int           // Размер запаса
+	low    chan struct{} // Сигнал о необходимости пополнить запас
+}
+
+// codePoolFromEnv - Функция, позволяющая создать выдачу кодов из запаса размером из переменной CODE_POOL_SIZE
+// (по умолчанию config.CodePoolSize)
+func codePoolFromEnv(s *Server) (*codePool, error) {
+
+	size := config.CodePoolSize
+
+	if v := os.Getenv("CODE_POOL_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New("error: CODE_POOL_SIZE must be a positive integer")
+		}
+
+		size = n
+	}
+
+	return &codePool{server: s, size: size, low: make(chan struct{}, 1)}, nil
+}
+
+// Next - Метод, возвращающий код из запаса
+// (если запас пуст, код создается на месте, а запас пополняется в фоне)
+func (p *codePool) Next(ctx context.Context, _ string) (string, error) {
+
+	code, err := p.server.db.TakePoolCode(ctx)
+	if err == nil {
+		return code, nil
+	}
+	if !errors.Is(err, database.ErrCodePoolEmpty) {
+		return "", err
+	}
+
+	p.refill()
+	p.server.logger.WarnContext(ctx, "Code pool is empty")
+
+	for {
+		code, err = p.server.codeAlphabet.Random(p.server.codeLength.get())
+		if err != nil || !p.server.rejectedCode(code) {
+			return code, err
+		}
+	}
+}
+
+// refill - Метод, реализующий запрос пополнения запаса без ожидания
+func (p *codePool) refill() {
+	select {
+	case p.low <- struct{}{}:
+	default:
+	}
+}
+
+// watch - Метод, реализующий пополнение запаса кодов в фоне
+// (запас пополняется до полного размера, когда в нем остается меньше половины кодов; пополняет его
+// ведущий экземпляр, остальные только выдают коды)
+func (p *codePool) watch() {
+
+	ticker := time.NewTicker(config.CodePoolCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if p.server.isLeader() {
+			if err := p.fill(p.server.context); err != nil {
+				p.server.logger.Error("Failed to fill code pool", "error", err)
+			}
+		}
+
+		select {
+		case <-p.server.context.Done():
+			return
+		case <-ticker.C:
+		case <-p.low:
+		}
+	}
+}
+
+// fill - Метод, реализующий пополнение запаса кодов текущей длины
+// (недопустимые коды в запас не попадают)
+func (p *codePool) fill(ctx context.Context) error {
+
+	n, err := p.server.db.CountPoolCodes(ctx)
+	if err != nil || n >= p.size/2 {
+		return err
+	}
+
+	added := 0
+
+	for missing := p.size - n; missing > 0; {
+		batch := make([]string, 0, min(missing, config.CodePoolBatchSize))
+
+		for len(batch) < cap(batch) {
+			code, err := p.server.codeAlphabet.Random(p.server.codeLength.get())
+			if err != nil {
+				return err
+			}
+
+			if !p.server.rejectedCode(code) {
+				batch = append(batch, code)
+			}
+		}
+
+		inserted, err := p.server.db.FillCodePool(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		// Совпавшие с занятыми коды не добавляются, недостающие создаются в следующей пачке
+		// (если не добавлено ни одного кода, коды текущей длины почти исчерпаны и пополнение откладывается)
+		if inserted == 0 {
+			break
+		}
+
+		added += inserted
+		missing -= inserted
+	}
+
+	p.server.logger.Info("Code pool was filled", "added", added, "size", p.size)
+
+	return nil
+}
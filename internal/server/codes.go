@@ -0,0 +1,419 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"math"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/generator"
+	"my_project/urlgen/pkg/tracing"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCodeStrategy - Генератор кодов коротких ссылок по умолчанию
+const defaultCodeStrategy = "hash"
+
+// codeLengthCheckInterval - Интервал уточнения количества занятых кодов по БД
+const codeLengthCheckInterval = time.Minute
+
+// codeGeneratorFromEnv - Метод, позволяющий создать генератор кодов, выбранный переменной CODE_STRATEGY
+// ("hash" по умолчанию, "sequence", "snowflake", "pool", "emoji", "nanoid", "redis" (счетчик в Redis из REDIS_URL)
+// или зарегистрированный generator.Register;
+// номер узла для "snowflake" задается переменной CODE_NODE_ID, у экземпляров сервера номера должны различаться,
+// энтропия кодов "nanoid" - переменной CODE_ENTROPY_BITS);
+// возвращает название и генератор
+func (s *Server) codeGeneratorFromEnv() (string, generator.Generator, error) {
+
+	name := os.Getenv("CODE_STRATEGY")
+	if name == "" {
+		name = defaultCodeStrategy
+	}
+
+	return s.newCodeGenerator(name)
+}
+
+// candidateGeneratorFromEnv - Метод, позволяющий создать генератор кодов, выбранный переменной
+// CODE_STRATEGY_CANDIDATE, для постепенного перехода на него флагом candidate_generator
+// (пустое название и nil, если переменная не задана или совпадает с CODE_STRATEGY; запас "pool" недопустим,
+// так как его пополнение рассчитано на один генератор)
+func (s *Server) candidateGeneratorFromEnv() (string, generator.Generator, error) {
+
+	name := os.Getenv("CODE_STRATEGY_CANDIDATE")
+	if name == "" || name == s.codeStrategy {
+		return "", nil, nil
+	}
+
+	if name == codePoolStrategy {
+		return "", nil, errors.New("error: CODE_STRATEGY_CANDIDATE cannot be pool")
+	}
+
+	return s.newCodeGenerator(name)
+}
+
+// newCodeGenerator - Метод, реализующий создание генератора кодов по названию с параметрами из переменных окружения
+func (s *Server) newCodeGenerator(name string) (string, generator.Generator, error) {
+
+	if name == codePoolStrategy {
+		pool, err := codePoolFromEnv(s)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return name, pool, nil
+	}
+
+	node := 0
+
+	if v := os.Getenv("CODE_NODE_ID"); v != "" {
+		var err error
+
+		node, err = strconv.Atoi(v)
+		if err != nil {
+			return "", nil, fmt.Errorf("error: CODE_NODE_ID must be an integer from 0 to %d", generator.SnowflakeMaxNode)
+		}
+	}
+
+	entropy := 0
+
+	if v := os.Getenv("CODE_ENTROPY_BITS"); v != "" {
+		var err error
+
+		entropy, err = strconv.Atoi(v)
+		if err != nil || entropy < config.CodeMinEntropyBits {
+			return "", nil, fmt.Errorf("error: CODE_ENTROPY_BITS must be an integer of at least %d",
+				config.CodeMinEntropyBits)
+		}
+	}
+
+	var counter func(ctx context.Context, n int) ([]int, error)
+	if s.redis != nil {
+		counter = s.counterIds
+	}
+
+	opts := generator.Options{
+		Alphabet:  s.codeAlphabet,
+		MinLength: s.codeLength.min,
+		Length:    s.codeLength.get,
+		Reject:    s.rejectedCode,
+		NextIds:   s.db.NextRowIds,
+		Counter:   counter,
+		Node:      node,
+		Entropy:   entropy,
+	}
+
+	codes, err := generator.New(name, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("error: failed to create code generator %q: %w", name, err)
+	}
+
+	return name, codes, nil
+}
+
+// codeAlphabetFromEnv - Функция, позволяющая получить алфавит кодов из переменной CODE_ALPHABET
+// (по умолчанию generator.DefaultAlphabet; для кодов без учета регистра - строчные символы алфавита,
+// по умолчанию generator.DefaultLowerAlphabet)
+func codeAlphabetFromEnv(caseInsensitive bool) (generator.Alphabet, error) {
+
+	chars := os.Getenv("CODE_ALPHABET")
+	if chars == "" {
+		if caseInsensitive {
+			return generator.DefaultLowerAlphabet, nil
+		}
+		return generator.DefaultAlphabet, nil
+	}
+
+	alphabet, err := generator.NewAlphabet(chars)
+	if err != nil {
+		return "", fmt.Errorf("error: invalid CODE_ALPHABET: %w", err)
+	}
+
+	if caseInsensitive {
+		alphabet = alphabet.Lower()
+		if len(alphabet) < 2 {
+			return "", errors.New("error: invalid CODE_ALPHABET: fewer than 2 characters are left ignoring case")
+		}
+	}
+
+	return alphabet, nil
+}
+
+// foldCode - Метод, реализующий приведение кода к виду, в котором он хранится
+// (коды без учета регистра хранятся строчными)
+func (s *Server) foldCode(code string) string {
+	if s.caseInsensitive {
+		return strings.ToLower(code)
+	}
+
+	return code
+}
+
+// codeLength - Тип данных, реализующий выбор длины генерируемых кодов
+// (длина увеличивается, когда занятые коды составляют заметную долю всех кодов текущей длины)
+type codeLength struct {
+	min     int          // Заданная длина кодов
+	symbols int          // Количество символов алфавита кодов
+	links   atomic.Int64 // Количество занятых кодов
+	current atomic.Int64 // Текущая длина кодов
+}
+
+// codeLengthFromEnv - Функция, позволяющая получить длину кодов из переменной CODE_LENGTH
+// (от 1 до 32 символов, по умолчанию config.ShortUrlLen) для кодов из символов алфавита
+func codeLengthFromEnv(alphabet generator.Alphabet) (*codeLength, error) {
+
+	length := config.ShortUrlLen
+
+	if v := os.Getenv("CODE_LENGTH"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > config.ShortUrlMaxLen {
+			return nil, fmt.Errorf("error: CODE_LENGTH must be an integer from 1 to %d", config.ShortUrlMaxLen)
+		}
+
+		length = n
+	}
+
+	l := &codeLength{min: length, symbols: len(alphabet)}
+	l.current.Store(int64(length))
+
+	return l, nil
+}
+
+// get - Метод, возвращающий текущую длину кодов
+func (l *codeLength) get() int {
+	return int(l.current.Load())
+}
+
+// setLinks - Метод, реализующий пересчет длины кодов по количеству занятых кодов
+// (длина не уменьшается; возвращает новую длину и признак ее увеличения)
+func (l *codeLength) setLinks(n int64) (int, bool) {
+
+	l.links.Store(n)
+
+	want := int64(l.min)
+	for want < config.ShortUrlMaxLen &&
+		float64(n) >= math.Pow(float64(l.symbols), float64(want))*config.CodeSaturation {
+		want++
+	}
+
+	for {
+		current := l.current.Load()
+		if want <= current {
+			return int(current), false
+		}
+
+		if l.current.CompareAndSwap(current, want) {
+			return int(want), true
+		}
+	}
+}
+
+// added - Метод, реализующий учет n новых кодов
+func (l *codeLength) added(n int) (int, bool) {
+	return l.setLinks(l.links.Add(int64(n)))
+}
+
+// generatedCode - Тип данных, описывающий короткую ссылку, сгенерированную для новой строки
+type generatedCode struct {
+	ShortUrl string // Короткая ссылка
+	Id       int    // Идентификатор, с которым сохраняется строка (0 - назначается БД)
+}
+
+// codeGenerator - Метод, возвращающий название и генератор кодов для исходной ссылки рабочего пространства
+// (генератор CODE_STRATEGY_CANDIDATE, если для ссылки включен флаг candidate_generator; выбор постоянен
+// для ссылки, поэтому "hash" дает ей одни и те же коды)
+func (s *Server) codeGenerator(workspaceId int, domain, url string) (string, generator.Generator) {
+
+	if s.candidateCodes != nil && s.flagEnabled(flagCandidateGenerator, domain, originalUrlKey(workspaceId, domain, url)) {
+		return s.candidateName, s.candidateCodes
+	}
+
+	return s.codeStrategy, s.codes
+}
+
+// nextCode - Метод, реализующий получение короткой ссылки на домене коротких ссылок от генератора кодов
+// (возвращает короткую ссылку и идентификатор, с которым сохраняется строка, 0 - назначается БД)
+func (s *Server) nextCode(ctx context.Context, codes generator.Generator, workspaceId int, domain, url string) (string, int, error) {
+
+	code, err := codes.Next(ctx, originalUrlKey(workspaceId, domain, url))
+	if err != nil {
+		return "", 0, err
+	}
+
+	var id int
+
+	if rowIds, ok := codes.(generator.RowIdGenerator); ok {
+		if id, err = rowIds.RowId(code); err != nil {
+			return "", 0, err
+		}
+	}
+
+	if s.signer != nil {
+		code = s.signer.sign(code)
+	}
+
+	return shortUrlOnDomain(domain, code), id, nil
+}
+
+// generateCodes - Метод, реализующий генерацию коротких ссылок для новых исходных ссылок рабочего пространства
+// на домене коротких ссылок (зарезервированные и оскорбительные коды пропускаются; коды различны, даже если
+// исходная ссылка повторяется)
+func (s *Server) generateCodes(ctx context.Context, workspaceId int, domain string, urls []string) ([]generatedCode, error) {
+
+	codes := make([]generatedCode, 0, len(urls))
+	taken := make(map[string]bool, len(urls))
+
+	for _, url := range urls {
+		var (
+			code generatedCode
+			err  error
+		)
+
+		_, gen := s.codeGenerator(workspaceId, domain, url)
+
+		for attempt := 1; code.ShortUrl == ""; attempt++ {
+			if attempt > s.codeAttempts {
+				return nil, &CollisionError{Url: url, Attempts: s.codeAttempts}
+			}
+
+			code.ShortUrl, code.Id, err = s.nextCode(generator.WithAttempt(ctx, attempt), gen, workspaceId, domain, url)
+			if err != nil {
+				return nil, err
+			}
+
+			if s.rejectedCode(codeFromShortUrl(code.ShortUrl)) || taken[code.ShortUrl] {
+				code.ShortUrl = ""
+			}
+		}
+
+		taken[code.ShortUrl] = true
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// CollisionError - Тип данных, описывающий ошибку создания ссылки, все сгенерированные коды которой заняты
+type CollisionError struct {
+	Url      string // Исходная ссылка
+	Attempts int    // Количество попыток сохранения
+}
+
+// Error - Метод, возвращающий описание ошибки
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("error: all %d generated codes of %s are taken", e.Attempts, e.Url)
+}
+
+// isCollision - Функция, проверяющая, является ли ошибка ошибкой *CollisionError
+func isCollision(err error) bool {
+	var collision *CollisionError
+	return errors.As(err, &collision)
+}
+
+// codeAttemptsFromEnv - Функция, позволяющая получить количество попыток сохранения ссылки с новым кодом
+// из переменной CODE_MAX_ATTEMPTS (по умолчанию config.CodeMaxAttempts)
+func codeAttemptsFromEnv() (int, error) {
+
+	v := os.Getenv("CODE_MAX_ATTEMPTS")
+	if v == "" {
+		return config.CodeMaxAttempts, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, errors.New("error: CODE_MAX_ATTEMPTS must be a positive integer")
+	}
+
+	return n, nil
+}
+
+// saveGenerated - Метод, реализующий сохранение новой строки со сгенерированным кодом
+// (при нарушении уникальности кода сохранение повторяется с новым кодом: генератор "hash" дает код
+// на символ длиннее из того же хеша, поэтому одна исходная ссылка всегда получает один и тот же код,
+// "sequence" и "snowflake" - код следующего идентификатора; после исчерпания попыток возвращается
+// *CollisionError; если код уже занят той же ссылкой без собственных параметров параллельным запросом,
+// возвращается существующая строка и признак created = false)
+func (s *Server) saveGenerated(ctx context.Context, row database.RowData) (database.RowData, bool, error) {
+
+	strategy, codes := s.codeGenerator(row.WorkspaceId, row.Domain, row.Url)
+
+	for attempt := 1; attempt <= s.codeAttempts; attempt++ {
+		var err error
+
+		genCtx, span := tracing.Tracer().Start(ctx, "generate code", trace.WithAttributes(
+			attribute.String("code.strategy", strategy), attribute.Int("code.attempt", attempt)))
+
+		row.ShortUrl, row.Id, err = s.nextCode(generator.WithAttempt(genCtx, attempt), codes, row.WorkspaceId,
+			row.Domain, row.Url)
+		span.End()
+		if errors.Is(err, generator.ErrNoCode) {
+			break
+		}
+		if err != nil {
+			return row, false, err
+		}
+
+		if s.rejectedCode(codeFromShortUrl(row.ShortUrl)) {
+			s.logger.DebugContext(ctx, "Generated code is rejected", "short_url", row.ShortUrl, "url", row.Url)
+			continue
+		}
+
+		err = s.db.SaveShortUrl(ctx, row)
+		if err == nil {
+			return row, true, nil
+		}
+		if !errors.Is(err, database.ErrShortUrlExists) {
+			return row, false, err
+		}
+
+		existing, isExist := s.db.GetShortUrlRow(ctx, row.ShortUrl)
+		if isExist && existing.WorkspaceId == row.WorkspaceId && existing.Url == row.Url &&
+			existing.Plain() && row.Plain() {
+			return *existing, false, nil
+		}
+
+		s.metrics.collisions.WithLabelValues(strategy).Inc()
+		trace.SpanFromContext(ctx).AddEvent("code collision", trace.WithAttributes(
+			attribute.String("short_url", row.ShortUrl), attribute.Int("code.attempt", attempt)))
+		s.logger.WarnContext(ctx, "Generated code is taken", "short_url", row.ShortUrl, "url", row.Url,
+			"attempt", attempt)
+	}
+
+	return row, false, &CollisionError{Url: row.Url, Attempts: s.codeAttempts}
+}
+
+// codesAdded - Метод, реализующий учет сохраненных сгенерированных кодов
+func (s *Server) codesAdded(ctx context.Context, n int) {
+	if length, grown := s.codeLength.added(n); grown {
+		s.logger.InfoContext(ctx, "Code length was increased", "length", length)
+	}
+}
+
+// watchCodeLength - Метод, реализующий периодическое уточнение количества занятых кодов по БД
+// (учитывает ссылки, созданные другими экземплярами сервера)
+func (s *Server) watchCodeLength() {
+
+	ticker := time.NewTicker(codeLengthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.db.CountShortUrls(s.context)
+		if err != nil {
+			s.logger.Error("Failed to count links", "error", err)
+		} else if length, grown := s.codeLength.setLinks(n); grown {
+			s.logger.Info("Code length was increased", "length", length, "links", n)
+		}
+
+		select {
+		case <-s.context.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultCompressMinSize - Минимальный размер ответа для сжатия по умолчанию (меньшие ответы не выигрывают от сжатия)
+const defaultCompressMinSize = 1024
+
+// compressibleTypes - Типы содержимого, которые сжимаются (изображения PNG и архивы уже сжаты)
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/",
+}
+
+// compression - Тип данных, описывающий настройки сжатия ответов API
+type compression struct {
+	minSize int       // Минимальный размер тела ответа для сжатия
+	writers sync.Pool // Повторно используемые кодировщики gzip
+}
+
+// compressionFromEnv - Функция, позволяющая получить настройки сжатия из переменных окружения
+// (COMPRESSION=off отключает сжатие, COMPRESSION_MIN_SIZE - минимальный размер ответа в байтах)
+func compressionFromEnv() (*compression, error) {
+
+	if os.Getenv("COMPRESSION") == "off" {
+		return nil, nil
+	}
+
+	c := compression{minSize: defaultCompressMinSize}
+
+	if v := os.Getenv("COMPRESSION_MIN_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			return nil, errors.New("error: COMPRESSION_MIN_SIZE must be a non-negative number of bytes")
+		}
+
+		c.minSize = size
+	}
+
+	c.writers.New = func() any {
+		gz, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return gz
+	}
+
+	return &c, nil
+}
+
+// acceptsGzip - Функция, проверяющая, принимает ли клиент ответ в кодировке gzip
+func acceptsGzip(r *http.Request) bool {
+
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+
+		// Кодировка с весом "q=0" клиентом отвергнута
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			q, err := strconv.ParseFloat(v, 64)
+			return err == nil && q > 0
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// compressible - Функция, проверяющая, что ответ с заданным типом содержимого стоит сжимать
+func compressible(contentType string) bool {
+
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// middleware - Метод, реализующий промежуточный обработчик сжатия ответов
+// (сжимаются ответы с подходящим типом содержимого не меньше минимального размера)
+func (c *compression) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, compression: c, status: http.StatusOK}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter - Тип данных, реализующий отложенное решение о сжатии ответа
+// (начало тела накапливается, пока не станет ясно, достигает ли ответ минимального размера)
+type compressWriter struct {
+	http.ResponseWriter
+	compression *compression // Настройки сжатия
+
+	status      int          // HTTP статус ответа
+	wroteHeader bool         // Вызван ли WriteHeader обработчиком
+	buf         []byte       // Накопленное начало тела ответа
+	decided     bool         // Принято ли решение о сжатии
+	gz          *gzip.Writer // Кодировщик (nil, если ответ не сжимается)
+}
+
+// WriteHeader - Метод, реализующий запоминание статуса ответа до принятия решения о сжатии
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = status
+
+	// Ответы без тела передаются сразу
+	if status == http.StatusNoContent || status == http.StatusNotModified || status < http.StatusOK {
+		w.decided = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write - Метод, реализующий запись тела ответа (сжатого или исходного)
+func (w *compressWriter) Write(b []byte) (int, error) {
+
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.compression.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// decide - Метод, реализующий выбор сжатия по накопленному началу тела и запись заголовков
+func (w *compressWriter) decide() error {
+
+	w.decided = true
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if len(w.buf) >= w.compression.minSize && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+
+		w.gz = w.compression.writers.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+
+	if w.gz != nil {
+		_, err := w.gz.Write(buf)
+		return err
+	}
+
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush - Метод, реализующий отправку накопленной части ответа клиенту
+func (w *compressWriter) Flush() {
+
+	if !w.decided {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		_ = w.decide()
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// close - Метод, реализующий завершение ответа (запись короткого тела без сжатия или завершение потока gzip)
+func (w *compressWriter) close() {
+
+	if !w.decided && (w.wroteHeader || len(w.buf) != 0) {
+		_ = w.decide()
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Close()
+		w.gz.Reset(nil)
+		w.compression.writers.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// Unwrap - Метод, возвращающий исходный ResponseWriter (для http.ResponseController)
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+
+	tests := []struct {
+		header string
+		accept bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip;q=0.5", true},
+		{"br, deflate", false},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"gzip;q=x", false},
+		{"x-gzip", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+
+		if got := acceptsGzip(r); got != tt.accept {
+			t.Errorf("acceptsGzip(%q) = %t, want %t", tt.header, got, tt.accept)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+
+	t.Setenv("COMPRESSION", "")
+	t.Setenv("COMPRESSION_MIN_SIZE", "32")
+
+	c, err := compressionFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	long := strings.Repeat(`{"url": "https://example.com"}`, 10)
+
+	tests := []struct {
+		name        string
+		method      string
+		accept      string
+		contentType string
+		status      int
+		body        []string // Части тела, записываемые по отдельности
+		compressed  bool
+	}{
+		{"long json", http.MethodGet, "gzip", "application/json", http.StatusOK, []string{long}, true},
+		{"long json in parts", http.MethodGet, "gzip", "application/json", http.StatusCreated, []string{long[:10], long[10:]}, true},
+		{"short json", http.MethodGet, "gzip", "application/json", http.StatusOK, []string{"{}"}, false},
+		{"detected text", http.MethodGet, "gzip", "", http.StatusOK, []string{strings.Repeat("text ", 20)}, true},
+		{"png", http.MethodGet, "gzip", "image/png", http.StatusOK, []string{long}, false},
+		{"no gzip", http.MethodGet, "", "application/json", http.StatusOK, []string{long}, false},
+		{"head", http.MethodHead, "gzip", "application/json", http.StatusOK, nil, false},
+		{"no content", http.MethodGet, "gzip", "", http.StatusNoContent, nil, false},
+		{"empty body", http.MethodGet, "gzip", "application/json", http.StatusOK, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			handler := c.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+
+				for _, part := range tt.body {
+					_, _ = io.WriteString(w, part)
+				}
+			}))
+
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q", rec.Header().Get("Vary"))
+			}
+
+			compressed := rec.Header().Get("Content-Encoding") == "gzip"
+			if compressed != tt.compressed {
+				t.Fatalf("compressed = %t, want %t", compressed, tt.compressed)
+			}
+
+			body := rec.Body.String()
+			if compressed {
+				gz, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				data, err := io.ReadAll(gz)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(data)
+			}
+
+			if want := strings.Join(tt.body, ""); body != want {
+				t.Errorf("body = %q, want %q", body, want)
+			}
+		})
+	}
+}
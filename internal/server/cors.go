@@ -0,0 +1,97 @@
+package server
+
+import (
+	"my_project/urlgen/pkg/request_id"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	defaultCORSMethods = "GET, POST, PATCH, DELETE, OPTIONS"                                          // Разрешенные методы по умолчанию
+	defaultCORSHeaders = "Authorization, Content-Type, X-Workspace-Id, Idempotency-Key, X-Request-ID" // Разрешенные заголовки по умолчанию
+	defaultCORSMaxAge  = "600"                                                                        // Время кеширования предварительного запроса по умолчанию (в секундах)
+)
+
+// corsPolicy - Тип данных, описывающий правила CORS для маршрутов API
+type corsPolicy struct {
+	origins  map[string]bool // Разрешенные источники
+	allowAll bool            // Разрешены ли любые источники ("*")
+	methods  string          // Разрешенные методы
+	headers  string          // Разрешенные заголовки
+	maxAge   string          // Время кеширования предварительного запроса
+}
+
+// corsPolicyFromEnv - Функция, позволяющая получить правила CORS из переменных окружения
+// (возвращает nil, если разрешенные источники не заданы)
+func corsPolicyFromEnv() *corsPolicy {
+
+	origins := os.Getenv("CORS_ALLOWED_ORIGINS")
+	if origins == "" {
+		return nil
+	}
+
+	c := corsPolicy{
+		origins: map[string]bool{},
+		methods: envOrDefault("CORS_ALLOWED_METHODS", defaultCORSMethods),
+		headers: envOrDefault("CORS_ALLOWED_HEADERS", defaultCORSHeaders),
+		maxAge:  envOrDefault("CORS_MAX_AGE", defaultCORSMaxAge),
+	}
+
+	for _, o := range strings.Split(origins, ",") {
+		o = strings.TrimSpace(o)
+		if o == "*" {
+			c.allowAll = true
+		} else if o != "" {
+			c.origins[o] = true
+		}
+	}
+
+	return &c
+}
+
+// allowOrigin - Метод, реализующий установку заголовков CORS для разрешенного источника запроса
+func (c *corsPolicy) allowOrigin(w http.ResponseWriter, r *http.Request) bool {
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || (!c.allowAll && !c.origins[origin]) {
+		return false
+	}
+
+	w.Header().Add("Vary", "Origin")
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Expose-Headers", nextCursorHeader+", Link, "+replayedHeader+", "+request_id.Header)
+
+	return true
+}
+
+// middleware - Метод, реализующий промежуточный обработчик добавления заголовков CORS к ответу
+func (c *corsPolicy) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.allowOrigin(w, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// preflight - Метод, реализующий ответ на предварительный запрос CORS ("Options")
+func (c *corsPolicy) preflight(w http.ResponseWriter, r *http.Request) {
+
+	if r.Header.Get("Access-Control-Request-Method") == "" || !c.allowOrigin(w, r) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", c.methods)
+	w.Header().Set("Access-Control-Allow-Headers", c.headers)
+	w.Header().Set("Access-Control-Max-Age", c.maxAge)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// envOrDefault - Функция, возвращающая значение переменной окружения или значение по умолчанию
+func envOrDefault(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+
+	return def
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"fmt"
+	"my_project/urlgen/pkg/version"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// publishVarsOnce - Однократная публикация переменных expvar сервера (повторная публикация недопустима)
+var publishVarsOnce sync.Once
+
+// DebugSettings - Тип данных, описывающий настройки отладочного сервера (pprof и expvar)
+type DebugSettings struct {
+	Addr  string // Адрес отладочного сервера (пустая строка - сервер не запускается)
+	Token string // Токен доступа (пустая строка - без проверки)
+}
+
+// DebugSettingsFromEnv - Функция, позволяющая получить настройки отладочного сервера
+// из переменных DEBUG_ADDR и DEBUG_TOKEN
+func DebugSettingsFromEnv() DebugSettings {
+	return DebugSettings{Addr: os.Getenv("DEBUG_ADDR"), Token: os.Getenv("DEBUG_TOKEN")}
+}
+
+// Handler - Метод, возвращающий обработчик отладочного сервера: профили /debug/pprof/ и переменные /debug/vars
+// (командная строка процесса не выводится, так как флаги могут содержать секреты)
+func (d DebugSettings) Handler() http.Handler {
+
+	publishVarsOnce.Do(func() {
+		expvar.Publish("build", expvar.Func(func() any { return version.Get() }))
+		expvar.Publish("goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/vars", debugVars)
+
+	if d.Token == "" {
+		return mux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="urlgen debug"`)
+			http.Error(w, "Error: Unauthorized (status code: 401)", http.StatusUnauthorized)
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// authorized - Метод, проверяющий токен запроса: "Authorization: Bearer <token>" или пароль Basic
+// (go tool pprof передает его из адреса вида http://user:<token>@host/debug/pprof/heap)
+func (d DebugSettings) authorized(r *http.Request) bool {
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		_, token, found = r.BasicAuth()
+	}
+
+	return found && subtle.ConstantTimeCompare([]byte(token), []byte(d.Token)) == 1
+}
+
+// debugVars - Функция, реализующая вывод переменных expvar в JSON без командной строки процесса
+func debugVars(w http.ResponseWriter, _ *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	fmt.Fprint(w, "{\n")
+
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if kv.Key == "cmdline" {
+			return
+		}
+
+		if !first {
+			fmt.Fprint(w, ",\n")
+		}
+		first = false
+
+		fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
+	})
+
+	fmt.Fprint(w, "\n}\n")
+}
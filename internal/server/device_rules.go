@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/useragent"
+	"net/http"
+)
+
+// Платформы правил перехода по устройству
+const (
+	platformIOS     = "ios"     // iPhone, iPad и iPod
+	platformAndroid = "android" // Устройства Android
+	platformDesktop = "desktop" // Настольные компьютеры
+)
+
+// devicePlatforms - Допустимые платформы правил перехода по устройству
+var devicePlatforms = map[string]bool{platformIOS: true, platformAndroid: true, platformDesktop: true}
+
+// prepareDestinations - Метод, реализующий проверку и нормализацию исходных ссылок правил перехода
+// (valid - проверка ключа правила)
+func (s *Server) prepareDestinations(ctx context.Context, urls map[string]string,
+	valid func(key string) bool) (map[string]string, error) {
+
+	result := make(map[string]string, len(urls))
+
+	for key, raw := range urls {
+		if !valid(key) {
+			return nil, errors.New("unknown rule key " + key)
+		}
+
+		url, err := s.urls.normalize(ctx, raw)
+		if err != nil {
+			return nil, errors.New(key + " " + err.Error())
+		}
+
+		if s.checkUrl(ctx, url).Malicious {
+			return nil, errors.New(key + " url is flagged as malicious")
+		}
+
+		result[key] = url
+	}
+
+	return result, nil
+}
+
+// prepareDeviceUrls - Метод, реализующий проверку исходных ссылок для платформ ("ios", "android", "desktop")
+func (s *Server) prepareDeviceUrls(ctx context.Context, urls map[string]string) (map[string]string, error) {
+	return s.prepareDestinations(ctx, urls, func(key string) bool { return devicePlatforms[key] })
+}
+
+// devicePlatform - Функция, реализующая определение платформы клиента по заголовку User-Agent
+// (пустая строка, если платформа не относится к правилам)
+func devicePlatform(r *http.Request) string {
+
+	info := useragent.Parse(r.UserAgent())
+
+	switch {
+	case info.OS == "iOS":
+		return platformIOS
+	case info.OS == "Android":
+		return platformAndroid
+	case info.DeviceClass == useragent.DeviceDesktop:
+		return platformDesktop
+	}
+
+	return ""
+}
+
+// selectTarget - Метод, реализующий выбор исходной ссылки для перехода
+// (сначала правила по устройству, затем по местоположению, затем варианты A/B теста; возвращает ссылку
+// и номер варианта)
+func (s *Server) selectTarget(w http.ResponseWriter, r *http.Request, row *database.RowData, code string) (string, int) {
+
+	if len(row.DeviceUrls) != 0 {
+		if url, found := row.DeviceUrls[devicePlatform(r)]; found {
+			return url, 0
+		}
+	}
+
+	if url := s.geoTarget(r, row.GeoUrls); url != "" {
+		return url, 0
+	}
+
+	variant := s.pickVariant(w, r, row, code)
+
+	return variantUrl(row, variant), variant
+}
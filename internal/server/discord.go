@@ -0,0 +1,192 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	discordSignatureHeader = "X-Signature-Ed25519"   // Заголовок подписи запроса Discord
+	discordTimestampHeader = "X-Signature-Timestamp" // Заголовок времени запроса Discord
+	discordMaxBody         = 64 << 10                // Максимальный размер тела запроса взаимодействия
+
+	discordPing           = 1  // Вид взаимодействия: проверка адреса при настройке приложения
+	discordCommand        = 2  // Вид взаимодействия: команда приложения
+	discordPong           = 1  // Вид ответа на проверку адреса
+	discordMessage        = 4  // Вид ответа сообщением
+	discordFlagsEphemeral = 64 // Флаг сообщения, видимого только вызвавшему команду
+)
+
+// discordCommands - Тип данных, описывающий обработку команд приложения Discord "/shorten" и "/expand"
+type discordCommands struct {
+	publicKey ed25519.PublicKey // Открытый ключ подписи запросов приложения Discord
+	keys      map[string]string // Ключи API серверов Discord по идентификатору сервера (guild_id)
+}
+
+// discordInteraction - Тип данных, описывающий запрос взаимодействия Discord
+type discordInteraction struct {
+	Type    int    `json:"type"`     // Вид взаимодействия
+	GuildId string `json:"guild_id"` // Сервер Discord (пустой в личных сообщениях)
+	Data    struct {
+		Name    string `json:"name"` // Название команды
+		Options []struct {
+			Name  string `json:"name"`  // Название параметра
+			Value any    `json:"value"` // Значение параметра
+		} `json:"options"` // Параметры команды
+	} `json:"data"` // Команда
+}
+
+// option - Метод, возвращающий строковый параметр команды (пустая строка, если параметра нет)
+func (i *discordInteraction) option(name string) string {
+
+	for _, o := range i.Data.Options {
+		if v, ok := o.Value.(string); ok && o.Name == name {
+			return strings.TrimSpace(v)
+		}
+	}
+
+	return ""
+}
+
+// discordResponse - Тип данных, описывающий ответ на взаимодействие Discord
+type discordResponse struct {
+	Type int                 `json:"type"`           // Вид ответа
+	Data *discordMessageData `json:"data,omitempty"` // Сообщение
+}
+
+// discordMessageData - Тип данных, описывающий сообщение ответа Discord
+type discordMessageData struct {
+	Content string `json:"content"`         // Текст сообщения
+	Flags   int    `json:"flags,omitempty"` // Флаги сообщения
+}
+
+// discordFromEnv - Функция, позволяющая получить обработку команд Discord из переменных окружения
+// (DISCORD_PUBLIC_KEY - открытый ключ приложения Discord в шестнадцатеричной записи, nil, если не задан;
+// DISCORD_GUILD_KEYS - ключи API серверов через запятую в виде "guild_id:ключ")
+func discordFromEnv() (*discordCommands, error) {
+
+	v := os.Getenv("DISCORD_PUBLIC_KEY")
+	if v == "" {
+		return nil, nil
+	}
+
+	publicKey, err := hex.DecodeString(v)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("error: DISCORD_PUBLIC_KEY must be a hex-encoded Ed25519 public key")
+	}
+
+	c := discordCommands{publicKey: publicKey, keys: map[string]string{}}
+
+	for _, item := range strings.Split(os.Getenv("DISCORD_GUILD_KEYS"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		guild, key, found := strings.Cut(item, ":")
+		if !found || guild == "" || !strings.HasPrefix(key, apiKeyPrefix) {
+			return nil, errors.New("error: DISCORD_GUILD_KEYS must be a list of guild_id:" + apiKeyPrefix + "key")
+		}
+
+		c.keys[guild] = key
+	}
+
+	if len(c.keys) == 0 {
+		return nil, errors.New("error: DISCORD_GUILD_KEYS is required with DISCORD_PUBLIC_KEY")
+	}
+
+	return &c, nil
+}
+
+// verify - Метод, проверяющий подпись запроса Discord (Ed25519 времени запроса и тела)
+func (c *discordCommands) verify(header http.Header, body []byte) bool {
+
+	signature, err := hex.DecodeString(header.Get(discordSignatureHeader))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+
+	return ed25519.Verify(c.publicKey, append([]byte(header.Get(discordTimestampHeader)), body...), signature)
+}
+
+// DiscordInteraction - Метод, реализующий обработку "Post" запроса взаимодействия Discord: команд
+// "/shorten url:https://..." и "/expand link:<код или короткая ссылка>" (ссылки создаются и раскрываются
+// ключом API, сопоставленным серверу Discord; ошибки видны только вызвавшему команду)
+func (s *Server) DiscordInteraction(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, discordMaxBody))
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	// Discord проверяет, что запросы с неверной подписью отклоняются, до сохранения адреса
+	if !s.discord.verify(r.Header, body) {
+		http.Error(w, "Error: Invalid Discord signature (status code: 401)", http.StatusUnauthorized)
+		s.logger.WarnContext(r.Context(), "Invalid Discord signature")
+		return
+	}
+
+	interaction := discordInteraction{}
+
+	err = json.Unmarshal(body, &interaction)
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	if interaction.Type == discordPing {
+		s.writeJSON(w, http.StatusOK, discordResponse{Type: discordPong})
+		return
+	}
+
+	reply := func(content string, flags int) {
+		s.writeJSON(w, http.StatusOK, discordResponse{Type: discordMessage,
+			Data: &discordMessageData{Content: content, Flags: flags}})
+	}
+
+	if interaction.Type != discordCommand {
+		reply("Unsupported interaction", discordFlagsEphemeral)
+		return
+	}
+
+	key, found := s.discord.keys[interaction.GuildId]
+	if !found {
+		reply("This Discord server is not connected to the link shortener", discordFlagsEphemeral)
+		s.logger.WarnContext(r.Context(), "Discord guild is not connected", "guild_id", interaction.GuildId)
+		return
+	}
+
+	switch interaction.Data.Name {
+	case "shorten":
+		shortUrl, problem := s.chatShorten(r.Context(), key, interaction.option("url"))
+		if problem != "" {
+			reply(problem, discordFlagsEphemeral)
+			return
+		}
+
+		s.logger.InfoContext(r.Context(), "Discord command served", "guild_id", interaction.GuildId,
+			"short_url", shortUrl)
+
+		reply(shortUrl, 0)
+	case "expand":
+		url, problem := s.chatExpand(r.Context(), key, interaction.option("link"))
+		if problem != "" {
+			reply(problem, discordFlagsEphemeral)
+			return
+		}
+
+		reply(url, 0)
+	default:
+		reply("Unknown command "+interaction.Data.Name, discordFlagsEphemeral)
+	}
+}
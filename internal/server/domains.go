@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"golang.org/x/net/idna"
+	"my_project/urlgen/database"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	domainBlockList = "block" // Список запрещенных доменов
+	domainAllowList = "allow" // Список разрешенных доменов
+)
+
+// errDomainNotAllowed - Ошибка создания ссылки на домен, запрещенный правилами
+var errDomainNotAllowed = errors.New("url domain is not allowed")
+
+// domainPolicy - Тип данных, описывающий правила доменов исходных ссылок
+// (правило для домена действует и на все его поддомены)
+type domainPolicy struct {
+	sync.RWMutex
+	strict bool            // Режим списка разрешенных доменов (ссылки на прочие домены запрещены)
+	block  map[string]bool // Запрещенные домены
+	allow  map[string]bool // Разрешенные домены
+}
+
+// DomainRule - Тип данных, описывающий правило домена в API
+type DomainRule struct {
+	Domain    string    `json:"domain"`               // Домен
+	List      string    `json:"list"`                 // Список ("block" или "allow")
+	CreatedAt time.Time `json:"created_at,omitempty"` // Время добавления
+}
+
+// DomainRulesResponse - Тип данных, описывающий ответ на запрос правил доменов
+type DomainRulesResponse struct {
+	Mode  string       `json:"mode"`  // Режим ("block" или "allow")
+	Rules []DomainRule `json:"rules"` // Правила
+}
+
+// domainPolicyFromEnv - Функция, позволяющая получить режим правил доменов из переменной DOMAIN_POLICY
+// ("block" по умолчанию или "allow"); правила загружаются из БД
+func domainPolicyFromEnv() (*domainPolicy, error) {
+
+	strict, err := domainModeFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	p := domainPolicy{
+		strict: strict,
+		block:  map[string]bool{},
+		allow:  map[string]bool{},
+	}
+
+	return &p, nil
+}
+
+// domainModeFromEnv - Функция, позволяющая получить режим правил доменов из переменной DOMAIN_POLICY
+// (true для режима списка разрешенных доменов)
+func domainModeFromEnv() (bool, error) {
+
+	switch os.Getenv("DOMAIN_POLICY") {
+	case "", domainBlockList:
+		return false, nil
+	case domainAllowList:
+		return true, nil
+	default:
+		return false, errors.New("error: DOMAIN_POLICY must be block or allow")
+	}
+}
+
+// load - Метод, реализующий загрузку правил доменов из БД (загруженные правила заменяют прежние)
+func (p *domainPolicy) load(ctx context.Context, db *database.Database) error {
+
+	rules, err := db.ListDomainRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	block, allow := map[string]bool{}, map[string]bool{}
+	for _, rule := range rules {
+		if rule.List == domainAllowList {
+			allow[rule.Domain] = true
+		} else {
+			block[rule.Domain] = true
+		}
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.block, p.allow = block, allow
+
+	return nil
+}
+
+// setMode - Метод, реализующий изменение режима правил доменов
+func (p *domainPolicy) setMode(strict bool) {
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.strict = strict
+}
+
+// list - Метод, возвращающий набор доменов заданного списка (вызывается под блокировкой)
+func (p *domainPolicy) list(name string) map[string]bool {
+	if name == domainAllowList {
+		return p.allow
+	}
+
+	return p.block
+}
+
+// mode - Метод, возвращающий название действующего режима
+func (p *domainPolicy) mode() string {
+
+	p.RLock()
+	defer p.RUnlock()
+
+	if p.strict {
+		return domainAllowList
+	}
+
+	return domainBlockList
+}
+
+// allows - Метод, проверяющий, разрешены ли ссылки на заданный узел
+func (p *domainPolicy) allows(host string) bool {
+
+	p.RLock()
+	defer p.RUnlock()
+
+	blocked := matchDomain(p.block, host)
+	if p.strict {
+		return !blocked && matchDomain(p.allow, host)
+	}
+
+	return !blocked
+}
+
+// set - Метод, реализующий добавление или удаление домена в списке
+func (p *domainPolicy) set(list, domain string, present bool) {
+
+	p.Lock()
+	defer p.Unlock()
+
+	if present {
+		p.list(list)[domain] = true
+	} else {
+		delete(p.list(list), domain)
+	}
+}
+
+// matchDomain - Функция, проверяющая, входит ли узел или один из родительских доменов в набор
+func matchDomain(domains map[string]bool, host string) bool {
+
+	for {
+		if domains[host] {
+			return true
+		}
+
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			return false
+		}
+
+		host = parent
+	}
+}
+
+// normalizeDomain - Функция, реализующая приведение домена правила к виду узлов исходных ссылок
+func normalizeDomain(domain string) (string, error) {
+
+	domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return "", errors.New("domain is empty")
+	}
+
+	return idna.Lookup.ToASCII(domain)
+}
+
+// ListDomainRules - Метод, реализующий обработку "Get" запроса оператора на получение правил доменов
+func (s *Server) ListDomainRules(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	rules, err := s.db.ListDomainRules(r.Context())
+	if err != nil {
+		http.Error(w, "Error: Failed to read domain rules (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read domain rules", "error", err)
+		return
+	}
+
+	resp := DomainRulesResponse{
+		Mode:  s.domains.mode(),
+		Rules: make([]DomainRule, 0, len(rules)),
+	}
+
+	for _, rule := range rules {
+		resp.Rules = append(resp.Rules, DomainRule{Domain: rule.Domain, List: rule.List, CreatedAt: rule.CreatedAt})
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// AddDomainRule - Метод, реализующий обработку "Post" запроса оператора на добавление правила домена
+func (s *Server) AddDomainRule(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	req := DomainRule{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || (req.List != domainBlockList && req.List != domainAllowList) {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	domain, err := normalizeDomain(req.Domain)
+	if err != nil {
+		http.Error(w, "Error: Invalid domain (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid domain", "domain", req.Domain, "error", err)
+		return
+	}
+
+	rule, err := s.db.AddDomainRule(r.Context(), req.List, domain)
+	if err != nil {
+		http.Error(w, "Error: Failed to save domain rule (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save domain rule", "error", err)
+		return
+	}
+
+	s.domains.set(rule.List, rule.Domain, true)
+
+	s.logger.InfoContext(r.Context(), "Domain rule was added", "list", rule.List, "domain", rule.Domain)
+	s.auditGlobal(r.Context(), auditDomainRuleAdd, rule.Domain, map[string]any{"list": rule.List})
+
+	s.writeJSON(w, http.StatusCreated, DomainRule{Domain: rule.Domain, List: rule.List, CreatedAt: rule.CreatedAt})
+}
+
+// DeleteDomainRule - Метод, реализующий обработку "Delete" запроса оператора на удаление правила домена
+func (s *Server) DeleteDomainRule(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	list := ps.ByName("list")
+
+	domain, err := normalizeDomain(ps.ByName("domain"))
+	if err != nil || (list != domainBlockList && list != domainAllowList) {
+		http.Error(w, "Error: Domain rule not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Domain rule not found")
+		return
+	}
+
+	found, err := s.db.DeleteDomainRule(r.Context(), list, domain)
+	if err != nil {
+		http.Error(w, "Error: Failed to delete domain rule (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to delete domain rule", "error", err)
+		return
+	}
+
+	if !found {
+		http.Error(w, "Error: Domain rule not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Domain rule not found", "list", list, "domain", domain)
+		return
+	}
+
+	s.domains.set(list, domain, false)
+
+	s.logger.InfoContext(r.Context(), "Domain rule was deleted", "list", list, "domain", domain)
+	s.auditGlobal(r.Context(), auditDomainRuleDelete, domain, map[string]any{"list": list})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/error_reporter"
+	"net/http"
+	"strconv"
+)
+
+// reportingLogger - Функция, возвращающая журнал, записи уровня Error которого отправляются как сообщения об ошибках
+// (так сообщаются ошибки обработчиков с ответом 5xx и сбои фоновых задач, которые всегда журналируются)
+func reportingLogger(logger *slog.Logger, reporter error_reporter.Reporter) *slog.Logger {
+
+	if _, off := reporter.(error_reporter.Nop); off {
+		return logger
+	}
+
+	return slog.New(error_reporter.NewHandler(logger.Handler(), reporter, enrichErrorEvent))
+}
+
+// enrichErrorEvent - Функция, реализующая добавление в сообщение об ошибке пользователя, пространства и ключа API запроса
+func enrichErrorEvent(ctx context.Context, e *error_reporter.Event) {
+
+	if id := userIdFromContext(ctx); id != 0 {
+		e.UserId = strconv.Itoa(id)
+	}
+
+	if access := workspaceFromContext(ctx); access.Id != 0 {
+		e.Tags["workspace_id"] = strconv.Itoa(access.Id)
+		if access.KeyId != 0 {
+			e.Tags["api_key_id"] = strconv.Itoa(access.KeyId)
+		}
+	}
+}
+
+// errorReportingMiddleware - Метод, реализующий промежуточный обработчик, прикладывающий запрос к сообщениям
+// об ошибках (о панике обработчика сообщает recoverMiddleware)
+func (s *Server) errorReportingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(error_reporter.NewContext(r.Context(), r)))
+	})
+}
+
+// background - Метод, реализующий запуск фоновой задачи с сообщением о ее панике
+// (после отправки сообщения паника продолжается и завершает процесс, как и без отправителя)
+func (s *Server) background(job string, run func()) {
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				s.reporter.Report(s.context, error_reporter.Event{Message: "Background job panicked", Panic: v,
+					Tags: map[string]string{"job": job}})
+				s.reporter.Flush(config.ErrorReportFlushTimeout)
+				panic(v)
+			}
+		}()
+
+		run()
+	}()
+}
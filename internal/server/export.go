@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/database"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultExportPeriod = 30 * 24 * time.Hour // Период выгрузки по умолчанию
+	exportFlushRows     = 1000                // Количество строк, после которого выгрузка отправляется клиенту
+)
+
+// csvExport - Тип данных, реализующий потоковую запись выгрузки CSV в ответ
+type csvExport struct {
+	w       http.ResponseWriter
+	csv     *csv.Writer
+	rows    int  // Количество записанных строк
+	started bool // Передана ли часть выгрузки в ответ
+}
+
+// newCSVExport - Функция, позволяющая начать выгрузку CSV с заданным именем файла и строкой заголовков
+func newCSVExport(w http.ResponseWriter, filename string, header []string) *csvExport {
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	e := &csvExport{w: w}
+	e.csv = csv.NewWriter(e)
+	_ = e.csv.Write(header)
+
+	return e
+}
+
+// Write - Метод, реализующий передачу буферизованной части выгрузки в ответ
+func (e *csvExport) Write(b []byte) (int, error) {
+	e.started = true
+	return e.w.Write(b)
+}
+
+// write - Метод, реализующий запись строки выгрузки (накопленные строки периодически отправляются клиенту)
+func (e *csvExport) write(record []string) error {
+
+	if err := e.csv.Write(record); err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushRows == 0 {
+		e.flush()
+	}
+
+	return e.csv.Error()
+}
+
+// flush - Метод, реализующий отправку записанных строк клиенту
+func (e *csvExport) flush() {
+	e.csv.Flush()
+	_ = http.NewResponseController(e.w).Flush()
+}
+
+// csvSafe - Функция, реализующая защиту значения от выполнения как формулы в табличных редакторах
+// (значения клиентов - источник, User-Agent - могут начинаться с "=", "+", "-" или "@")
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+
+	return v
+}
+
+// ExportLinkStats - Метод, реализующий обработку "Get" запроса на выгрузку переходов по ссылке в CSV
+func (s *Server) ExportLinkStats(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	code := ps.ByName("code")
+	shortUrl := shortUrlOnDomain(linkDomain(r), code)
+
+	if _, isExist := s.workspaceLink(w, r, shortUrl); !isExist {
+		return
+	}
+
+	s.exportStats(w, r, shortUrl, code)
+}
+
+// ExportStats - Метод, реализующий обработку "Get" запроса на выгрузку переходов по всем ссылкам пространства в CSV
+func (s *Server) ExportStats(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s.exportStats(w, r, "", "links")
+}
+
+// exportStats - Метод, реализующий выгрузку переходов или их количества по интервалам в CSV
+// (параметры: from и to в формате RFC 3339, bucket=hour|day - выгрузить количество переходов
+// по интервалам вместо отдельных переходов, include_bots=true - выгружать переходы ботов)
+func (s *Server) exportStats(w http.ResponseWriter, r *http.Request, shortUrl, name string) {
+
+	bucket := r.URL.Query().Get("bucket")
+	if _, found := statsPeriods[bucket]; bucket != "" && !found {
+		http.Error(w, "Error: Invalid bucket (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid stats bucket", "bucket", bucket)
+		return
+	}
+
+	from, to, err := parsePeriod(r, defaultExportPeriod)
+	if err != nil || !from.Before(to) {
+		http.Error(w, "Error: Invalid period (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid stats period", "error", err)
+		return
+	}
+
+	filter := database.ClickExportFilter{
+		WorkspaceId: workspaceIdFromContext(r.Context()),
+		ShortUrl:    shortUrl,
+		From:        from,
+		To:          to,
+		IncludeBots: r.URL.Query().Get("include_bots") == "true",
+	}
+
+	filename := fmt.Sprintf("%s-clicks-%s-%s.csv", name, from.UTC().Format("20060102"), to.UTC().Format("20060102"))
+
+	var export *csvExport
+
+	// Выгрузка передается клиенту по мере чтения из БД
+	if bucket == "" {
+		export = newCSVExport(w, filename, []string{"code", "clicked_at", "referrer", "user_agent", "country",
+			"region", "browser", "os", "device_class", "is_bot", "variant"})
+
+		err = s.analytics.ExportClicks(r.Context(), filter, func(e database.ClickEvent) error {
+			return export.write([]string{codeFromShortUrl(e.ShortUrl), e.Time.UTC().Format(time.RFC3339),
+				csvSafe(e.Referrer), csvSafe(e.UserAgent), e.Country, e.Region, e.Browser, e.OS, e.DeviceClass,
+				strconv.FormatBool(e.Bot), strconv.Itoa(e.Variant)})
+		})
+	} else {
+		export = newCSVExport(w, filename, []string{"code", bucket, "clicks"})
+
+		err = s.analytics.ExportClickCounts(r.Context(), filter, bucket, func(shortUrl string, cc database.ClickCount) error {
+			return export.write([]string{codeFromShortUrl(shortUrl), cc.Time.UTC().Format(time.RFC3339),
+				strconv.Itoa(cc.Clicks)})
+		})
+	}
+
+	if err != nil {
+		// После отправки части выгрузки статус ответа изменить нельзя, выгрузка обрывается
+		if !export.started {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "Error: Failed to export stats (status code: 500)", http.StatusInternalServerError)
+		}
+		s.logger.ErrorContext(r.Context(), "Failed to export stats", "short_url", shortUrl, "rows", export.rows,
+			"error", err)
+		return
+	}
+
+	export.flush()
+
+	s.logger.InfoContext(r.Context(), "Stats were exported", "short_url", shortUrl, "rows", export.rows,
+		"bucket", bucket)
+}
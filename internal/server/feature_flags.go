@@ -0,0 +1,350 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"hash/fnv"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Флаги функций, включаемых постепенно
+const (
+	flagCandidateGenerator = "candidate_generator" // Генерация кодов генератором CODE_STRATEGY_CANDIDATE
+	flagInterstitial       = "interstitial"        // Промежуточная страница с назначением перед переходом
+	flagClickAnalytics     = "click_analytics"     // Запись переходов в конвейер аналитики
+)
+
+// flagDefaultDomain - Название основного домена коротких ссылок в списках доменов флагов
+const flagDefaultDomain = "default"
+
+const (
+	flagSourceConfig  = "config"  // Значение флага из настроек
+	flagSourceRuntime = "runtime" // Значение флага, измененное через API
+)
+
+// knownFlags - Флаги функций и их доли включения по умолчанию
+var knownFlags = map[string]int{
+	flagCandidateGenerator: 0,
+	flagInterstitial:       0,
+	flagClickAnalytics:     100,
+}
+
+// flagRule - Тип данных, описывающий правило включения флага функции
+type flagRule struct {
+	percentage int      // Доля включения от 0 (выключен) до 100 (включен для всех)
+	domains    []string // Домены коротких ссылок, на которых действует флаг (пусто - все домены)
+}
+
+// flagRules - Тип данных, описывающий правила флагов функций по названиям
+type flagRules map[string]flagRule
+
+// FeatureFlag - Тип данных, описывающий флаг функции в API
+type FeatureFlag struct {
+	Name       string     `json:"name"`                 // Название
+	Percentage int        `json:"percentage"`           // Доля включения от 0 до 100
+	Domains    []string   `json:"domains,omitempty"`    // Домены коротких ссылок ("default" - основной домен)
+	Source     string     `json:"source"`               // Источник значения ("config" или "runtime")
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"` // Время изменения через API
+}
+
+// FeatureFlagRequest - Тип данных, описывающий запрос на изменение флага функции
+type FeatureFlagRequest struct {
+	Percentage *int     `json:"percentage"` // Доля включения от 0 до 100
+	Domains    []string `json:"domains"`    // Домены коротких ссылок (пусто - все домены)
+}
+
+// featureFlagsFromEnv - Функция, позволяющая получить правила флагов функций из переменной FEATURE_FLAGS
+// (через запятую вида "название=доля[@домен|домен]", например "interstitial=10@go.example.com|default";
+// флаги, не указанные в переменной, получают значения по умолчанию)
+func featureFlagsFromEnv() (flagRules, error) {
+
+	rules := flagRules{}
+	for name, percentage := range knownFlags {
+		rules[name] = flagRule{percentage: percentage}
+	}
+
+	for _, v := range strings.Split(os.Getenv("FEATURE_FLAGS"), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(v, "=")
+		if _, known := knownFlags[name]; !found || !known {
+			return nil, fmt.Errorf("error: invalid FEATURE_FLAGS entry %q: unknown flag", v)
+		}
+
+		value, list, _ := strings.Cut(value, "@")
+
+		percentage, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil || percentage < 0 || percentage > 100 {
+			return nil, fmt.Errorf("error: invalid FEATURE_FLAGS entry %q: percentage must be from 0 to 100", v)
+		}
+
+		var domains []string
+		if list != "" {
+			domains, err = normalizeFlagDomains(strings.Split(list, "|"))
+			if err != nil {
+				return nil, fmt.Errorf("error: invalid FEATURE_FLAGS entry %q: %w", v, err)
+			}
+		}
+
+		rules[name] = flagRule{percentage: percentage, domains: domains}
+	}
+
+	return rules, nil
+}
+
+// normalizeFlagDomains - Функция, реализующая приведение доменов флага к виду доменов коротких ссылок
+func normalizeFlagDomains(list []string) ([]string, error) {
+
+	var domains []string
+
+	for _, v := range list {
+		domain := strings.ToLower(strings.TrimSpace(v))
+
+		if domain != flagDefaultDomain {
+			var err error
+
+			domain, err = normalizeDomain(domain)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains, nil
+}
+
+// flagOverrides - Тип данных, описывающий флаги функций, измененные через API
+// (хранятся в БД и заменяют значения из настроек на всех экземплярах сервера)
+type flagOverrides struct {
+	sync.RWMutex
+	flags map[string]database.FeatureFlag // Измененные флаги по названиям
+}
+
+// load - Метод, реализующий загрузку измененных флагов из БД (загруженные флаги заменяют прежние)
+func (o *flagOverrides) load(ctx context.Context, db *database.Database) error {
+
+	list, err := db.ListFeatureFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	flags := map[string]database.FeatureFlag{}
+	for _, f := range list {
+		flags[f.Name] = f
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	o.flags = flags
+
+	return nil
+}
+
+// get - Метод, возвращающий измененный флаг по названию
+func (o *flagOverrides) get(name string) (database.FeatureFlag, bool) {
+
+	o.RLock()
+	defer o.RUnlock()
+
+	f, found := o.flags[name]
+
+	return f, found
+}
+
+// set - Метод, реализующий сохранение измененного флага или его удаление (nil)
+func (o *flagOverrides) set(name string, f *database.FeatureFlag) {
+
+	o.Lock()
+	defer o.Unlock()
+
+	if o.flags == nil {
+		o.flags = map[string]database.FeatureFlag{}
+	}
+
+	if f != nil {
+		o.flags[name] = *f
+	} else {
+		delete(o.flags, name)
+	}
+}
+
+// featureFlag - Метод, возвращающий действующее значение флага функции (измененное через API или из настроек)
+func (s *Server) featureFlag(name string) FeatureFlag {
+
+	if f, found := s.flags.get(name); found {
+		updatedAt := f.UpdatedAt
+		return FeatureFlag{Name: name, Percentage: f.Percentage, Domains: f.Domains, Source: flagSourceRuntime,
+			UpdatedAt: &updatedAt}
+	}
+
+	rule := s.live().flags[name]
+
+	return FeatureFlag{Name: name, Percentage: rule.percentage, Domains: rule.domains, Source: flagSourceConfig}
+}
+
+// flagEnabled - Метод, проверяющий, включен ли флаг функции для объекта на домене коротких ссылок
+// (объект - например, короткая ссылка - попадает в долю включения по хешу, поэтому решение для него постоянно,
+// пока доля не уменьшена)
+func (s *Server) flagEnabled(name, domain, subject string) bool {
+
+	f := s.featureFlag(name)
+
+	if f.Percentage <= 0 {
+		return false
+	}
+
+	if domain == "" {
+		domain = flagDefaultDomain
+	}
+	if len(f.Domains) != 0 && !slices.Contains(f.Domains, domain) {
+		return false
+	}
+
+	return f.Percentage >= 100 || flagBucket(name, subject) < f.Percentage
+}
+
+// flagBucket - Функция, возвращающая номер от 0 до 99 объекта для флага (у разных флагов доли не совпадают)
+func flagBucket(name, subject string) int {
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(subject))
+
+	return int(h.Sum32() % 100)
+}
+
+// watchFeatureFlags - Метод, реализующий периодическое перечитывание флагов, измененных через API
+// на других экземплярах сервера
+func (s *Server) watchFeatureFlags() {
+
+	ticker := time.NewTicker(config.FeatureFlagsRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.context.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.flags.load(s.context, s.db); err != nil {
+			s.logger.Error("Failed to read feature flags", "error", err)
+		}
+	}
+}
+
+// ListFeatureFlags - Метод, реализующий обработку "Get" запроса оператора на получение действующих флагов функций
+func (s *Server) ListFeatureFlags(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	names := make([]string, 0, len(knownFlags))
+	for name := range knownFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resp := make([]FeatureFlag, 0, len(names))
+	for _, name := range names {
+		resp = append(resp, s.featureFlag(name))
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// SetFeatureFlag - Метод, реализующий обработку "Put" запроса оператора на изменение флага функции
+// (значение сохраняется в БД и действует на всех экземплярах сервера, пока не будет сброшено)
+func (s *Server) SetFeatureFlag(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	name := ps.ByName("name")
+	if _, known := knownFlags[name]; !known {
+		http.Error(w, "Error: Feature flag not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Feature flag not found", "flag", name)
+		return
+	}
+
+	req := FeatureFlagRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.Percentage == nil || *req.Percentage < 0 || *req.Percentage > 100 {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	domains, err := normalizeFlagDomains(req.Domains)
+	if err == nil {
+		for _, domain := range domains {
+			if domain != flagDefaultDomain && !slices.Contains(s.shortDomains, domain) {
+				err = errUnknownDomain
+			}
+		}
+	}
+	if err != nil {
+		http.Error(w, "Error: Invalid domain (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid feature flag domain", "flag", name, "error", err)
+		return
+	}
+
+	f, err := s.db.SaveFeatureFlag(r.Context(), name, *req.Percentage, domains)
+	if err != nil {
+		http.Error(w, "Error: Failed to save feature flag (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save feature flag", "error", err)
+		return
+	}
+
+	s.flags.set(name, f)
+
+	s.logger.InfoContext(r.Context(), "Feature flag was changed", "flag", name, "percentage", f.Percentage,
+		"domains", f.Domains)
+	s.auditGlobal(r.Context(), auditFeatureFlagSet, name,
+		map[string]any{"percentage": f.Percentage, "domains": f.Domains})
+
+	s.writeJSON(w, http.StatusOK, s.featureFlag(name))
+}
+
+// ResetFeatureFlag - Метод, реализующий обработку "Delete" запроса оператора на сброс флага функции
+// к значению из настроек
+func (s *Server) ResetFeatureFlag(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	name := ps.ByName("name")
+	if _, known := knownFlags[name]; !known {
+		http.Error(w, "Error: Feature flag not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Feature flag not found", "flag", name)
+		return
+	}
+
+	found, err := s.db.DeleteFeatureFlag(r.Context(), name)
+	if err != nil {
+		http.Error(w, "Error: Failed to reset feature flag (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to reset feature flag", "error", err)
+		return
+	}
+
+	s.flags.set(name, nil)
+
+	if found {
+		s.logger.InfoContext(r.Context(), "Feature flag was reset", "flag", name)
+		s.auditGlobal(r.Context(), auditFeatureFlagReset, name, nil)
+	}
+
+	s.writeJSON(w, http.StatusOK, s.featureFlag(name))
+}
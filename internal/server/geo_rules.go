@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// geoKeyRegexp - Регулярное выражение ключа правила перехода по местоположению ("US" или "US-CA")
+var geoKeyRegexp = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// prepareGeoUrls - Метод, реализующий проверку исходных ссылок для стран и регионов
+// (ключи - коды стран ISO 3166-1 или регионов ISO 3166-2, приводятся к верхнему регистру)
+func (s *Server) prepareGeoUrls(ctx context.Context, urls map[string]string) (map[string]string, error) {
+
+	upper := make(map[string]string, len(urls))
+	for key, url := range urls {
+		upper[strings.ToUpper(key)] = url
+	}
+
+	return s.prepareDestinations(ctx, upper, geoKeyRegexp.MatchString)
+}
+
+// geoTarget - Метод, реализующий выбор исходной ссылки по местоположению клиента
+// (сначала по региону, затем по стране; пустая строка, если правило не найдено или база GeoIP не задана)
+func (s *Server) geoTarget(r *http.Request, urls map[string]string) string {
+
+	if s.geo == nil || len(urls) == 0 {
+		return ""
+	}
+
+	country, region := s.geo.Lookup(s.clientIP(r))
+	if country == "" {
+		return ""
+	}
+
+	if region != "" {
+		if url, found := urls[country+"-"+region]; found {
+			return url
+		}
+	}
+
+	return urls[country]
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/version"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// readinessTimeout - Время ожидания проверки зависимостей при проверке готовности
+const readinessTimeout = 2 * time.Second
+
+// Состояния зависимостей подробной проверки
+const (
+	dependencyOk       = "ok"       // Зависимость работает
+	dependencyDegraded = "degraded" // Зависимость работает медленно, устарела или перегружена
+	dependencyDown     = "down"     // Зависимость недоступна
+)
+
+// HealthStatus - Тип данных, описывающий ответ проверки состояния сервера
+type HealthStatus struct {
+	Status       string                      `json:"status"`                 // Общее состояние ("ok", "degraded" или "unavailable")
+	Checks       map[string]string           `json:"checks,omitempty"`       // Состояние отдельных зависимостей
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"` // Подробное состояние зависимостей
+}
+
+// DependencyStatus - Тип данных, описывающий состояние зависимости в подробной проверке
+type DependencyStatus struct {
+	Status    string         `json:"status"`            // Состояние ("ok", "degraded" или "down")
+	LatencyMs float64        `json:"latency_ms"`        // Время проверки в миллисекундах
+	Error     string         `json:"error,omitempty"`   // Ошибка или причина деградации
+	Details   map[string]any `json:"details,omitempty"` // Показатели зависимости
+}
+
+// Healthz - Метод, реализующий обработку "Get" запроса на проверку жизнеспособности сервера
+// (с параметром detail=1 также проверяются зависимости: ответ 503, если одна из них недоступна)
+func (s *Server) Healthz(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if detail, _ := strconv.ParseBool(r.URL.Query().Get("detail")); !detail {
+		s.writeJSON(w, http.StatusOK, HealthStatus{Status: "ok"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	result := HealthStatus{Status: "ok", Dependencies: s.checkDependencies(ctx)}
+
+	for name, dep := range result.Dependencies {
+		switch {
+		case dep.Status == dependencyDown:
+			result.Status = "unavailable"
+			s.logger.ErrorContext(r.Context(), "Dependency is down", "dependency", name, "error", dep.Error)
+		case dep.Status == dependencyDegraded && result.Status == "ok":
+			result.Status = "degraded"
+		}
+	}
+
+	status := http.StatusOK
+	if result.Status == "unavailable" {
+		status = http.StatusServiceUnavailable
+	}
+
+	s.writeJSON(w, status, result)
+}
+
+// checkDependencies - Метод, реализующий одновременную проверку зависимостей сервера
+// (Redis, ClickHouse и GeoIP проверяются, только если они настроены)
+func (s *Server) checkDependencies(ctx context.Context) map[string]DependencyStatus {
+
+	checks := map[string]func(context.Context) DependencyStatus{
+		"database": s.checkDatabase,
+		"webhooks": s.checkWebhookQueue,
+	}
+	if s.redis != nil {
+		checks["redis"] = s.checkRedis
+	}
+	if s.clickhouse != nil {
+		checks["clickhouse"] = s.checkClickHouse
+	}
+	if s.geo != nil {
+		checks["geoip"] = s.checkGeoIP
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	result := make(map[string]DependencyStatus, len(checks))
+
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) DependencyStatus) {
+			defer wg.Done()
+
+			start := time.Now()
+			dep := check(ctx)
+			dep.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+
+			if dep.Status == dependencyOk && time.Since(start) > config.HealthSlowLatency {
+				dep.Status, dep.Error = dependencyDegraded, "slow response"
+			}
+
+			mu.Lock()
+			result[name] = dep
+			mu.Unlock()
+		}(name, check)
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+// checkDatabase - Метод, реализующий проверку БД (деградация, если заняты все подключения пула;
+// подробности показывают и то, является ли экземпляр ведущим)
+func (s *Server) checkDatabase(ctx context.Context) DependencyStatus {
+
+	if err := s.db.Ping(ctx); err != nil {
+		return DependencyStatus{Status: dependencyDown, Error: err.Error()}
+	}
+
+	stat := s.db.Stat()
+	dep := DependencyStatus{Status: dependencyOk, Details: map[string]any{
+		"total_conns":    stat.TotalConns(),
+		"acquired_conns": stat.AcquiredConns(),
+		"max_conns":      stat.MaxConns(),
+		"leader":         s.isLeader(),
+	}}
+
+	if stat.AcquiredConns() >= stat.MaxConns() {
+		dep.Status, dep.Error = dependencyDegraded, "connection pool is exhausted"
+	}
+
+	return dep
+}
+
+// checkRedis - Метод, реализующий проверку Redis
+func (s *Server) checkRedis(ctx context.Context) DependencyStatus {
+
+	if err := s.redis.Ping(ctx); err != nil {
+		return DependencyStatus{Status: dependencyDown, Error: err.Error()}
+	}
+
+	return DependencyStatus{Status: dependencyOk}
+}
+
+// checkClickHouse - Метод, реализующий проверку доступности ClickHouse
+func (s *Server) checkClickHouse(ctx context.Context) DependencyStatus {
+
+	if err := s.clickhouse.client.Ping(ctx); err != nil {
+		return DependencyStatus{Status: dependencyDown, Error: err.Error()}
+	}
+
+	return DependencyStatus{Status: dependencyOk}
+}
+
+// checkGeoIP - Метод, реализующий проверку свежести базы GeoIP
+func (s *Server) checkGeoIP(context.Context) DependencyStatus {
+
+	built := s.geo.BuildTime()
+	dep := DependencyStatus{Status: dependencyOk, Details: map[string]any{
+		"build_time": built.UTC(),
+		"age_hours":  int(time.Since(built).Hours()),
+	}}
+
+	if time.Since(built) > config.HealthGeoIPMaxAge {
+		dep.Status, dep.Error = dependencyDegraded, "database is older than "+config.HealthGeoIPMaxAge.String()
+	}
+
+	return dep
+}
+
+// checkWebhookQueue - Метод, реализующий проверку заполненности очереди доставки вебхуков
+func (s *Server) checkWebhookQueue(context.Context) DependencyStatus {
+
+	queued, capacity := s.webhooks.Queued()
+	dep := DependencyStatus{Status: dependencyOk, Details: map[string]any{
+		"queued":   queued,
+		"capacity": capacity,
+	}}
+
+	switch {
+	case queued >= capacity:
+		dep.Status, dep.Error = dependencyDown, "queue is full, deliveries are dropped"
+	case float64(queued) >= float64(capacity)*config.HealthQueueDegraded:
+		dep.Status, dep.Error = dependencyDegraded, "queue is almost full"
+	}
+
+	return dep
+}
+
+// Version - Метод, реализующий обработку "Get" запроса на получение сведений о сборке сервера
+func (s *Server) Version(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	s.writeJSON(w, http.StatusOK, version.Get())
+}
+
+// Readyz - Метод, реализующий обработку "Get" запроса на проверку готовности сервера к приему запросов
+func (s *Server) Readyz(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	result := HealthStatus{
+		Status: "ok",
+		Checks: map[string]string{},
+	}
+
+	// Проверка подключения к БД
+	if err := s.db.Ping(ctx); err != nil {
+		result.Status = "unavailable"
+		result.Checks["database"] = err.Error()
+		s.logger.ErrorContext(r.Context(), "Database is not ready", "error", err)
+	} else {
+		result.Checks["database"] = "ok"
+	}
+
+	// Проверка доступности кеша (захват блокировок кеша)
+	if s.cacheWithShortUrlKey == nil || s.cacheWithOriginalUrlKey == nil {
+		result.Status = "unavailable"
+		result.Checks["cache"] = "not initialized"
+	} else {
+		s.cacheWithShortUrlKey.Len()
+		s.cacheWithOriginalUrlKey.Len()
+		result.Checks["cache"] = "ok"
+	}
+
+	status := http.StatusOK
+	if result.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+
+	s.writeJSON(w, status, result)
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"log/slog"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/cache_manager"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	idempotencyHeader  = "Idempotency-Key"     // Заголовок ключа идемпотентности запроса
+	replayedHeader     = "Idempotent-Replayed" // Заголовок ответа, повторенного по ключу идемпотентности
+	idempotencyMaxBody = 4 << 20               // Максимальный размер тела запроса с ключом идемпотентности
+)
+
+// idempotentResponse - Тип данных, описывающий сохраненный ответ на запрос с ключом идемпотентности
+type idempotentResponse struct {
+	fingerprint string        // Хеш метода, пути и тела запроса
+	done        chan struct{} // Закрывается по завершении обработки запроса
+	status      int           // HTTP статус ответа
+	contentType string        // Тип содержимого ответа
+	body        []byte        // Тело ответа
+}
+
+// idempotencyStore - Тип данных, описывающий хранилище ответов на запросы с ключом идемпотентности
+type idempotencyStore struct {
+	sync.Mutex                                           // Атомарная проверка и резервирование ключа
+	responses  *cache_manager.Cache[*idempotentResponse] // Ответы по ключам вида "пространство пользователь ключ_API ключ"
+}
+
+// newIdempotencyStore - Функция, позволяющая создать хранилище ответов на запросы с ключом идемпотентности
+func newIdempotencyStore(logger *slog.Logger) *idempotencyStore {
+	return &idempotencyStore{
+		responses: cache_manager.CacheCreate[*idempotentResponse](config.IdempotencyTTL, config.CacheCleanupTime, logger),
+	}
+}
+
+// reserve - Метод, реализующий поиск ответа по ключу или резервирование ключа для нового запроса
+// (true, если ключ зарезервирован и запрос нужно обработать)
+func (st *idempotencyStore) reserve(key, fingerprint string) (*idempotentResponse, bool) {
+
+	st.Lock()
+	defer st.Unlock()
+
+	if resp, found := st.responses.Get(key); found {
+		return resp, false
+	}
+
+	resp := &idempotentResponse{fingerprint: fingerprint, done: make(chan struct{})}
+	st.responses.Set(key, resp, 0)
+
+	return resp, true
+}
+
+// idempotencyRecorder - Тип данных, реализующий запоминание ответа для повтора по ключу идемпотентности
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int          // HTTP статус ответа
+	body   bytes.Buffer // Тело ответа
+}
+
+// WriteHeader - Метод, реализующий запись и запоминание статуса ответа
+func (r *idempotencyRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write - Метод, реализующий запись и запоминание тела ответа
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap - Метод, возвращающий исходный ResponseWriter (для http.ResponseController)
+func (r *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// withIdempotency - Метод, реализующий промежуточный обработчик заголовка Idempotency-Key
+// (повтор запроса с тем же ключом получает сохраненный ответ вместо создания новой ссылки;
+// ключи действуют в пределах пространства и пользователя или ключа API, ответы 5xx и 429 не сохраняются)
+func (s *Server) withIdempotency(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" {
+			next(w, r, ps)
+			return
+		}
+
+		if len(key) > config.IdempotencyKeyMaxLen {
+			http.Error(w, "Error: Idempotency-Key is too long (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, idempotencyMaxBody))
+		if err != nil {
+			http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Failed to read request")
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		access := workspaceFromContext(r.Context())
+		scope := strconv.Itoa(access.Id) + " " + strconv.Itoa(userIdFromContext(r.Context())) + " " +
+			strconv.Itoa(access.KeyId) + " " + key
+
+		resp, reserved := s.idempotency.reserve(scope, fingerprint)
+
+		if !reserved {
+			switch {
+			case resp.fingerprint != fingerprint:
+				http.Error(w, "Error: Idempotency-Key was used with another request (status code: 422)",
+					http.StatusUnprocessableEntity)
+				s.logger.WarnContext(r.Context(), "Idempotency-Key was used with another request")
+			case !isClosed(resp.done):
+				http.Error(w, "Error: Request with this Idempotency-Key is in progress (status code: 409)",
+					http.StatusConflict)
+				s.logger.WarnContext(r.Context(), "Request with this Idempotency-Key is in progress")
+			default:
+				w.Header().Set(replayedHeader, "true")
+				if resp.contentType != "" {
+					w.Header().Set("Content-Type", resp.contentType)
+				}
+				w.WriteHeader(resp.status)
+				_, _ = w.Write(resp.body)
+
+				s.logger.InfoContext(r.Context(), "Response was replayed by Idempotency-Key", "status", resp.status)
+			}
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		// Ключ освобождается, если обработка завершилась ошибкой сервера, превышением квоты или паникой:
+		// повтор выполнится заново
+		completed := false
+
+		defer func() {
+			if !completed || rec.status >= http.StatusInternalServerError || rec.status == http.StatusTooManyRequests {
+				_ = s.idempotency.responses.Delete(scope)
+			}
+			close(resp.done)
+		}()
+
+		next(rec, r, ps)
+
+		resp.status = rec.status
+		resp.contentType = rec.Header().Get("Content-Type")
+		resp.body = rec.body.Bytes()
+		completed = true
+	}
+}
+
+// isClosed - Функция, проверяющая, закрыт ли канал
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"my_project/urlgen/config"
+	"os"
+	"strings"
+)
+
+// kafkaPublisher - Тип данных, реализующий отправку сообщений потока событий в темы Kafka
+type kafkaPublisher struct {
+	writer *kafka.Writer                 // Асинхронная запись сообщений
+	topics map[string]string             // Темы по потоку событий
+	result func(messages int, err error) // Учет результата отправки
+}
+
+// kafkaFromEnv - Функция, позволяющая получить отправку событий в Kafka из переменных окружения
+// (KAFKA_BROKERS - адреса брокеров через запятую, nil, если не заданы; KAFKA_CLICKS_TOPIC и KAFKA_LINKS_TOPIC -
+// темы переходов и событий ссылок; KAFKA_TLS=true - подключение по TLS; KAFKA_SASL_MECHANISM - "plain",
+// "scram-sha-256" или "scram-sha-512" с KAFKA_SASL_USERNAME и KAFKA_SASL_PASSWORD)
+func kafkaFromEnv(result func(messages int, err error)) (*kafkaPublisher, error) {
+
+	var brokers []string
+	for _, broker := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+
+	if len(brokers) == 0 {
+		return nil, nil
+	}
+
+	transport := &kafka.Transport{ClientID: "urlgen"}
+
+	if os.Getenv("KAFKA_TLS") == "true" {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	mechanism, err := kafkaSASLFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	transport.SASL = mechanism
+
+	p := kafkaPublisher{result: result, topics: map[string]string{
+		streamClicks: os.Getenv("KAFKA_CLICKS_TOPIC"),
+		streamLinks:  os.Getenv("KAFKA_LINKS_TOPIC"),
+	}}
+
+	for stream, topic := range p.topics {
+		if topic == "" {
+			p.topics[stream] = config.StreamTopicPrefix + stream
+		}
+	}
+
+	p.writer = &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: config.StreamBatchTimeout,
+		Async:        true,
+		Transport:    transport,
+		Completion: func(messages []kafka.Message, err error) {
+			result(len(messages), err)
+		},
+	}
+
+	return &p, nil
+}
+
+// kafkaSASLFromEnv - Функция, позволяющая получить механизм аутентификации SASL из переменных окружения
+// (nil, если KAFKA_SASL_MECHANISM не задан)
+func kafkaSASLFromEnv() (sasl.Mechanism, error) {
+
+	username, password := os.Getenv("KAFKA_SASL_USERNAME"), os.Getenv("KAFKA_SASL_PASSWORD")
+
+	switch os.Getenv("KAFKA_SASL_MECHANISM") {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, errors.New("error: KAFKA_SASL_MECHANISM must be plain, scram-sha-256 or scram-sha-512")
+	}
+}
+
+// Publish - Метод, реализующий добавление сообщений в очередь записи в Kafka
+// (сообщения одной ссылки попадают в один раздел темы; заголовок "event" содержит тип события)
+func (p *kafkaPublisher) Publish(messages []EventMessage) {
+
+	batch := make([]kafka.Message, 0, len(messages))
+
+	for _, m := range messages {
+		batch = append(batch, kafka.Message{
+			Topic:   p.topics[m.Stream],
+			Key:     []byte(m.Key),
+			Value:   m.Value,
+			Headers: []kafka.Header{{Key: "event", Value: []byte(m.Event)}},
+		})
+	}
+
+	// В асинхронном режиме ошибки записи передаются в Completion, здесь - только отказ закрытой записи
+	if err := p.writer.WriteMessages(context.Background(), batch...); err != nil {
+		p.result(len(batch), err)
+	}
+}
+
+// Close - Метод, реализующий запись оставшихся сообщений и закрытие подключений к брокерам
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"sync/atomic"
+	"time"
+)
+
+// leader - Тип данных, описывающий выбор ведущего экземпляра сервера
+// (фоновые задачи, которые должны выполняться одним экземпляром, - очистка старых переходов, пополнение
+// запаса кодов и рассылка вебхуков об истечении ссылок - выполняются только ведущим)
+type leader struct {
+	held atomic.Bool          // Является ли экземпляр ведущим
+	lock *database.LeaderLock // Удерживаемая блокировка (используется только задачей выбора)
+}
+
+// isLeader - Метод, проверяющий, является ли экземпляр ведущим
+func (s *Server) isLeader() bool {
+	return s.leader.held.Load()
+}
+
+// electLeader - Метод, реализующий периодическую попытку стать ведущим экземпляром и проверку удерживаемой
+// блокировки (после разрыва подключения с блокировкой ведущим становится один из других экземпляров)
+func (s *Server) electLeader() {
+
+	ticker := time.NewTicker(config.LeaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.leader.step(s)
+
+		select {
+		case <-s.context.Done():
+			if s.leader.lock != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), config.LeaderCheckInterval)
+				s.leader.lock.Release(ctx)
+				cancel()
+
+				s.leader.held.Store(false)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// step - Метод, реализующий одну проверку блокировки ведущего или попытку ее получить
+func (l *leader) step(s *Server) {
+
+	if l.lock != nil {
+		if err := l.lock.Check(s.context); err != nil {
+			l.held.Store(false)
+			l.lock.Release(s.context)
+			l.lock = nil
+
+			s.logger.Warn("Leadership was lost", "error", err)
+		}
+		return
+	}
+
+	lock, err := s.db.TryLeaderLock(s.context)
+	if err != nil {
+		s.logger.Error("Failed to take leader lock", "error", err)
+		return
+	}
+
+	if lock != nil {
+		l.lock = lock
+		l.held.Store(true)
+
+		s.logger.Info("Instance became the leader for background jobs")
+	}
+}
@@ -0,0 +1,446 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/version"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// yourlsTimeLayout - Формат времени в ответах API YOURLS
+const yourlsTimeLayout = "2006-01-02 15:04:05"
+
+// jsonpCallbackRegexp - Допустимое имя функции ответа JSONP
+var jsonpCallbackRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$.]*$`)
+
+// yourlsLink - Тип данных, описывающий ссылку в ответе API YOURLS
+type yourlsLink struct {
+	Keyword   string `json:"keyword,omitempty" xml:"keyword,omitempty"`     // Код короткой ссылки
+	ShortUrl  string `json:"shorturl,omitempty" xml:"shorturl,omitempty"`   // Короткая ссылка (в "url-stats")
+	Url       string `json:"url" xml:"url"`                                 // Исходная ссылка
+	Title     string `json:"title" xml:"title"`                             // Название (ссылки urlgen названий не имеют)
+	Date      string `json:"date,omitempty" xml:"date,omitempty"`           // Время создания (в "shorturl")
+	Timestamp string `json:"timestamp,omitempty" xml:"timestamp,omitempty"` // Время создания (в "url-stats")
+	Ip        string `json:"ip" xml:"ip"`                                   // Адрес клиента, создавшего ссылку
+	Clicks    string `json:"clicks,omitempty" xml:"clicks,omitempty"`       // Количество переходов (в "url-stats")
+}
+
+// yourlsResponse - Тип данных, описывающий ответ API YOURLS (поля заполняются по действию)
+type yourlsResponse struct {
+	XMLName    xml.Name    `json:"-" xml:"result"`
+	Status     string      `json:"status,omitempty" xml:"status,omitempty"`       // "success" или "fail"
+	Code       string      `json:"code,omitempty" xml:"code,omitempty"`           // Код ошибки ("error:url", ...)
+	Url        *yourlsLink `json:"url,omitempty" xml:"url,omitempty"`             // Созданная ссылка
+	Link       *yourlsLink `json:"link,omitempty" xml:"link,omitempty"`           // Ссылка в "url-stats"
+	Message    string      `json:"message,omitempty" xml:"message,omitempty"`     // Сообщение
+	Title      *string     `json:"title,omitempty" xml:"title,omitempty"`         // Название ссылки
+	ShortUrl   string      `json:"shorturl,omitempty" xml:"shorturl,omitempty"`   // Короткая ссылка
+	Keyword    string      `json:"keyword,omitempty" xml:"keyword,omitempty"`     // Код ссылки в "expand"
+	LongUrl    string      `json:"longurl,omitempty" xml:"longurl,omitempty"`     // Исходная ссылка в "expand"
+	Version    string      `json:"version,omitempty" xml:"version,omitempty"`     // Версия в "version"
+	ErrorCode  int         `json:"errorCode,omitempty" xml:"errorCode,omitempty"` // Статус ошибки
+	StatusCode int         `json:"statusCode" xml:"statusCode"`                   // Статус ответа
+	simple     string      // Ответ в формате "simple"
+}
+
+// bitlyLink - Тип данных, описывающий созданную ссылку в ответе API Bitly v3
+type bitlyLink struct {
+	Url        string `json:"url"`         // Короткая ссылка
+	Hash       string `json:"hash"`        // Код короткой ссылки
+	GlobalHash string `json:"global_hash"` // Код короткой ссылки (ссылки urlgen общего кода не имеют)
+	LongUrl    string `json:"long_url"`    // Исходная ссылка
+	NewHash    int    `json:"new_hash"`    // 1, если ссылка создана запросом (urlgen не различает, всегда 0)
+}
+
+// bitlyExpanded - Тип данных, описывающий раскрытую ссылку в ответе API Bitly v3
+type bitlyExpanded struct {
+	ShortUrl   string `json:"short_url,omitempty"`   // Запрошенная короткая ссылка
+	Hash       string `json:"hash,omitempty"`        // Запрошенный код
+	LongUrl    string `json:"long_url,omitempty"`    // Исходная ссылка
+	UserHash   string `json:"user_hash,omitempty"`   // Код ссылки
+	GlobalHash string `json:"global_hash,omitempty"` // Код ссылки
+	Error      string `json:"error,omitempty"`       // "NOT_FOUND", если ссылки нет
+}
+
+// bitlyResponse - Тип данных, описывающий ответ API Bitly v3
+type bitlyResponse struct {
+	StatusCode int    `json:"status_code"` // Статус ответа
+	StatusTxt  string `json:"status_txt"`  // "OK" или код ошибки ("INVALID_URI", ...)
+	Data       any    `json:"data"`        // Результат (пустой массив при ошибке)
+}
+
+// legacyKey - Функция, возвращающая ключ API запроса совместимого API из первого непустого параметра params
+// или из заголовка "Authorization: Bearer"
+func legacyKey(r *http.Request, params ...string) string {
+
+	for _, name := range params {
+		if key := strings.TrimSpace(r.FormValue(name)); key != "" {
+			return key
+		}
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+// legacyShorten - Метод, реализующий создание короткой ссылки запросом совместимого API ключом API
+// (с квотами ключа, проверкой и нормализацией ссылки; keyword - пользовательский код); при отказе
+// возвращаются статус HTTP и текст ошибки
+func (s *Server) legacyShorten(ctx context.Context, key, rawUrl, keyword string) (*database.RowData, int, string) {
+
+	ctx, valid := s.apiKeyContext(ctx, key)
+	if key == "" || !valid {
+		s.logger.WarnContext(ctx, "Invalid api key of legacy api request")
+		return nil, http.StatusUnauthorized, "Invalid or missing api key"
+	}
+
+	if strings.TrimSpace(rawUrl) == "" {
+		s.logger.WarnContext(ctx, "Missing url in legacy api request")
+		return nil, http.StatusBadRequest, "Missing url"
+	}
+
+	exceeded, release, err := s.linkQuotaExceeded(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to read api key usage"
+	}
+	if exceeded {
+		return nil, http.StatusTooManyRequests, "Link quota exceeded"
+	}
+	defer release()
+
+	url, err := s.urls.normalize(ctx, rawUrl)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Invalid url", "url", rawUrl, "error", err)
+		return nil, http.StatusBadRequest, "Invalid url: " + err.Error()
+	}
+
+	if s.checkUrl(ctx, url).Malicious {
+		s.logger.WarnContext(ctx, "Url is flagged as malicious", "url", url)
+		return nil, http.StatusUnprocessableEntity, "Url is flagged as malicious"
+	}
+
+	access := workspaceFromContext(ctx)
+
+	newRow := database.RowData{
+		Url:         url,
+		WorkspaceId: access.Id,
+		ApiKeyId:    access.KeyId,
+	}
+
+	var shortUrl string
+
+	if keyword != "" {
+		keyword = s.foldCode(keyword)
+
+		if err = validateAlias(keyword); err != nil {
+			s.logger.WarnContext(ctx, "Invalid alias", "alias", keyword, "error", err)
+			return nil, http.StatusBadRequest, "Invalid keyword: " + err.Error()
+		}
+
+		shortUrl, err = s.saveAlias(ctx, newRow, keyword)
+	} else {
+		shortUrl, err = s.shorten(ctx, newRow)
+	}
+
+	switch {
+	case errors.Is(err, errReservedCode):
+		s.logger.WarnContext(ctx, "Alias is reserved", "alias", keyword)
+		return nil, http.StatusConflict, "Keyword is reserved"
+	case errors.Is(err, database.ErrShortUrlExists):
+		s.logger.WarnContext(ctx, "Alias is already taken", "alias", keyword)
+		return nil, http.StatusConflict, "Keyword is already taken"
+	case isCollision(err):
+		return nil, http.StatusServiceUnavailable, "No free short code"
+	case err != nil:
+		return nil, http.StatusInternalServerError, "Failed to save url in database"
+	}
+
+	row, isExist := s.db.GetShortUrlRow(ctx, shortUrl)
+	if !isExist {
+		s.logger.ErrorContext(ctx, "Failed to read created link", "short_url", shortUrl)
+		return nil, http.StatusInternalServerError, "Failed to read link"
+	}
+
+	return row, http.StatusOK, ""
+}
+
+// legacyLink - Метод, реализующий поиск ссылки рабочего пространства ключа API по коду или короткой ссылке
+// для совместимого API (при отказе возвращаются статус HTTP и текст ошибки)
+func (s *Server) legacyLink(ctx context.Context, key, arg string) (*database.RowData, int, string) {
+
+	ctx, valid := s.apiKeyContext(ctx, key)
+	if key == "" || !valid {
+		s.logger.WarnContext(ctx, "Invalid api key of legacy api request")
+		return nil, http.StatusUnauthorized, "Invalid or missing api key"
+	}
+
+	row, isExist := s.findWorkspaceLink(ctx, strings.TrimSpace(arg))
+	if !isExist {
+		s.logger.WarnContext(ctx, "Url not found", "short_url", arg)
+		return nil, http.StatusNotFound, "Link not found"
+	}
+
+	return row, http.StatusOK, ""
+}
+
+// YourlsApi - Метод, реализующий обработку "Get" и "Post" запроса к API, совместимому с YOURLS
+// ("/yourls-api.php": действия "shorturl", "expand", "url-stats" и "version"; ключ API передается
+// в параметре "signature"; форматы ответа "xml" (по умолчанию, как в YOURLS), "json", "jsonp" и "simple")
+func (s *Server) YourlsApi(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	key := legacyKey(r, "signature")
+
+	var resp yourlsResponse
+
+	switch action := r.FormValue("action"); action {
+	case "shorturl":
+		row, status, problem := s.legacyShorten(r.Context(), key, r.FormValue("url"), r.FormValue("keyword"))
+		if problem != "" {
+			resp = yourlsError(status, problem)
+			break
+		}
+
+		title := ""
+		resp = yourlsResponse{
+			Status: "success",
+			Url: &yourlsLink{
+				Keyword: codeFromShortUrl(row.ShortUrl),
+				Url:     row.Url,
+				Date:    row.CreatedAt.UTC().Format(yourlsTimeLayout),
+				Ip:      s.clientIP(r),
+			},
+			Message:    row.Url + " added to database",
+			Title:      &title,
+			ShortUrl:   row.ShortUrl,
+			StatusCode: http.StatusOK,
+			simple:     row.ShortUrl,
+		}
+
+		s.logger.InfoContext(r.Context(), "Legacy api link served", "api", "yourls", "short_url", row.ShortUrl)
+	case "expand", "url-stats":
+		arg := r.FormValue("shorturl")
+
+		row, status, problem := s.legacyLink(r.Context(), key, arg)
+		if problem != "" {
+			resp = yourlsError(status, problem)
+			break
+		}
+
+		if action == "expand" {
+			resp = yourlsResponse{
+				Keyword:    codeFromShortUrl(row.ShortUrl),
+				ShortUrl:   row.ShortUrl,
+				LongUrl:    row.Url,
+				Message:    "success",
+				StatusCode: http.StatusOK,
+				simple:     row.Url,
+			}
+			break
+		}
+
+		resp = yourlsResponse{
+			Link: &yourlsLink{
+				ShortUrl:  row.ShortUrl,
+				Url:       row.Url,
+				Timestamp: row.CreatedAt.UTC().Format(yourlsTimeLayout),
+				Clicks:    strconv.Itoa(row.ClickCount),
+			},
+			Message:    "success",
+			StatusCode: http.StatusOK,
+			simple:     strconv.Itoa(row.ClickCount),
+		}
+	case "version":
+		v := version.Get().Version
+		resp = yourlsResponse{Version: v, Message: "success", StatusCode: http.StatusOK, simple: v}
+	default:
+		resp = yourlsError(http.StatusBadRequest, "Unknown or missing \"action\" parameter")
+		s.logger.WarnContext(r.Context(), "Unknown yourls api action", "action", action)
+	}
+
+	s.writeYourls(w, r, resp)
+}
+
+// yourlsError - Функция, возвращающая ответ API YOURLS с ошибкой
+func yourlsError(status int, message string) yourlsResponse {
+
+	code := "error:url"
+	switch status {
+	case http.StatusUnauthorized:
+		code = "error:auth"
+	case http.StatusConflict:
+		code = "error:keyword"
+	case http.StatusNotFound:
+		code = "error:notfound"
+	}
+
+	return yourlsResponse{
+		Status:     "fail",
+		Code:       code,
+		Message:    message,
+		ErrorCode:  status,
+		StatusCode: status,
+		simple:     message,
+	}
+}
+
+// writeYourls - Метод, реализующий запись ответа API YOURLS в формате параметра "format"
+func (s *Server) writeYourls(w http.ResponseWriter, r *http.Request, resp yourlsResponse) {
+
+	var (
+		body        []byte
+		err         error
+		contentType string
+	)
+
+	switch format := r.FormValue("format"); format {
+	case "json", "jsonp":
+		contentType = "application/json"
+		body, err = json.Marshal(resp)
+
+		if callback := r.FormValue("callback"); format == "jsonp" && jsonpCallbackRegexp.MatchString(callback) {
+			contentType = "application/javascript"
+			body = append(append([]byte(callback+"("), body...), ')')
+		}
+	case "simple":
+		contentType = "text/plain; charset=utf-8"
+		body = []byte(resp.simple)
+	default:
+		contentType = "application/xml"
+		body, err = xml.Marshal(resp)
+		body = append([]byte(xml.Header), body...)
+	}
+
+	if err != nil {
+		http.Error(w, "Error: Failed to write response (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to write response", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err = w.Write(body); err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to write response", "error", err)
+	}
+}
+
+// BitlyShorten - Метод, реализующий обработку "Get" запроса к API, совместимому с Bitly v3 ("/v3/shorten":
+// параметр "longUrl", ключ API в "access_token" или "apiKey"; форматы ответа "json" и "txt")
+func (s *Server) BitlyShorten(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	row, status, problem := s.legacyShorten(r.Context(), legacyKey(r, "access_token", "apiKey"),
+		r.FormValue("longUrl"), "")
+	if problem != "" {
+		s.writeBitlyError(w, r, status, problem)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Legacy api link served", "api", "bitly", "short_url", row.ShortUrl)
+
+	if r.FormValue("format") == "txt" {
+		s.writeBitlyTxt(w, r, http.StatusOK, row.ShortUrl)
+		return
+	}
+
+	code := codeFromShortUrl(row.ShortUrl)
+
+	s.writeJSON(w, http.StatusOK, bitlyResponse{StatusCode: http.StatusOK, StatusTxt: "OK",
+		Data: bitlyLink{Url: row.ShortUrl, Hash: code, GlobalHash: code, LongUrl: row.Url}})
+}
+
+// BitlyExpand - Метод, реализующий обработку "Get" запроса к API, совместимому с Bitly v3 ("/v3/expand":
+// параметры "shortUrl" и "hash" можно повторять; ненайденные ссылки возвращаются с ошибкой "NOT_FOUND")
+func (s *Server) BitlyExpand(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	key := legacyKey(r, "access_token", "apiKey")
+
+	if err := r.ParseForm(); err != nil {
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		s.writeBitlyError(w, r, http.StatusBadRequest, "Failed to read request")
+		return
+	}
+
+	var expanded []bitlyExpanded
+
+	requested := append(r.Form["shortUrl"], r.Form["hash"]...)
+	if len(requested) == 0 {
+		s.logger.WarnContext(r.Context(), "Missing short url in legacy api request")
+		s.writeBitlyError(w, r, http.StatusBadRequest, "Missing shortUrl or hash")
+		return
+	}
+
+	for i, arg := range requested {
+		item := bitlyExpanded{ShortUrl: arg}
+		if i >= len(r.Form["shortUrl"]) {
+			item = bitlyExpanded{Hash: arg}
+		}
+
+		row, status, problem := s.legacyLink(r.Context(), key, arg)
+		switch {
+		case status == http.StatusNotFound:
+			item.Error = "NOT_FOUND"
+		case problem != "":
+			s.writeBitlyError(w, r, status, problem)
+			return
+		default:
+			code := codeFromShortUrl(row.ShortUrl)
+			item.LongUrl, item.UserHash, item.GlobalHash = row.Url, code, code
+		}
+
+		expanded = append(expanded, item)
+	}
+
+	if r.FormValue("format") == "txt" {
+		s.writeBitlyTxt(w, r, http.StatusOK, expanded[0].LongUrl)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, bitlyResponse{StatusCode: http.StatusOK, StatusTxt: "OK",
+		Data: map[string]any{"expand": expanded}})
+}
+
+// writeBitlyError - Метод, реализующий запись ответа API Bitly v3 с ошибкой (код ошибки по статусу и тексту)
+func (s *Server) writeBitlyError(w http.ResponseWriter, r *http.Request, status int, message string) {
+
+	statusTxt := "UNKNOWN_ERROR"
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		statusTxt = "INVALID_URI"
+		switch message {
+		case "Missing url":
+			statusTxt = "MISSING_ARG_LONGURL"
+		case "Missing shortUrl or hash":
+			statusTxt = "MISSING_ARG_SHORTURL_OR_HASH"
+		}
+	case http.StatusUnauthorized:
+		statusTxt = "INVALID_ARG_ACCESS_TOKEN"
+	case http.StatusNotFound:
+		statusTxt = "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		statusTxt = "RATE_LIMIT_EXCEEDED"
+	}
+
+	if r.FormValue("format") == "txt" {
+		s.writeBitlyTxt(w, r, status, statusTxt)
+		return
+	}
+
+	s.writeJSON(w, status, bitlyResponse{StatusCode: status, StatusTxt: statusTxt, Data: []any{}})
+}
+
+// writeBitlyTxt - Метод, реализующий запись ответа API Bitly v3 в формате "txt"
+func (s *Server) writeBitlyTxt(w http.ResponseWriter, r *http.Request, status int, text string) {
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+
+	if _, err := w.Write([]byte(text + "\n")); err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,724 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/click_pipeline"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 50  // Размер страницы списка ссылок по умолчанию
+	maxPageLimit     = 500 // Максимальный размер страницы списка ссылок
+	defaultClickDays = 30  // Количество дней статистики переходов по умолчанию
+	maxClickDays     = 365 // Максимальное количество дней статистики переходов
+)
+
+// Link - Тип данных, описывающий представление ссылки в API
+type Link struct {
+	Code        string    `json:"code"`                   // Код короткой ссылки
+	ShortUrl    string    `json:"short_url"`              // Короткая ссылка
+	Domain      string    `json:"domain,omitempty"`       // Брендированный домен (пустая строка - основной домен)
+	Url         string    `json:"url"`                    // Исходная ссылка
+	UserId      int       `json:"user_id,omitempty"`      // Идентификатор владельца
+	WorkspaceId int       `json:"workspace_id,omitempty"` // Идентификатор рабочего пространства
+	CreatedAt   time.Time `json:"created_at"`             // Время создания
+
+	RedirectStatus int        `json:"redirect_status,omitempty"` // Статус перехода (0 - статус по умолчанию)
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`      // Время окончания срока действия
+	ActiveFrom     *time.Time `json:"active_from,omitempty"`     // Время начала срока действия
+
+	MaxClicks  int `json:"max_clicks,omitempty"`  // Лимит переходов (0 - без ограничения)
+	ClickCount int `json:"click_count,omitempty"` // Количество переходов, учтенных в лимите
+
+	Active    bool `json:"active"`    // Действуют ли переходы по ссылке
+	Analytics bool `json:"analytics"` // Записываются ли переходы по ссылке в аналитику
+
+	Tags []string `json:"tags,omitempty"` // Метки ссылки
+
+	PasswordProtected bool `json:"password_protected,omitempty"` // Защищена ли ссылка паролем
+
+	QueryParams map[string]string `json:"query_params,omitempty"` // Параметры, добавляемые к исходной ссылке при переходе
+
+	Variants       []database.Variant `json:"variants,omitempty"`        // Варианты исходной ссылки для A/B теста
+	StickyVariants bool               `json:"sticky_variants,omitempty"` // Закреплять ли выбранный вариант за клиентом
+
+	DeviceUrls map[string]string `json:"device_urls,omitempty"` // Исходные ссылки для платформ ("ios", "android", "desktop")
+	GeoUrls    map[string]string `json:"geo_urls,omitempty"`    // Исходные ссылки для стран и регионов ("US", "US-CA")
+}
+
+// LinkRequest - Тип данных, описывающий тело запроса на создание ссылки
+type LinkRequest struct {
+	Url            string     `json:"url"`                       // Исходная ссылка
+	RedirectStatus int        `json:"redirect_status,omitempty"` // Статус перехода (0 - статус по умолчанию)
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`      // Время окончания срока действия (RFC 3339)
+	TTL            string     `json:"ttl,omitempty"`             // Срок действия от момента создания (например, "72h")
+	ActiveFrom     *time.Time `json:"active_from,omitempty"`     // Время начала срока действия (RFC 3339)
+	ActiveUntil    *time.Time `json:"active_until,omitempty"`    // Время окончания срока действия (синоним "expires_at")
+	Password       string     `json:"password,omitempty"`        // Пароль для перехода по ссылке
+	Alias          string     `json:"alias,omitempty"`           // Пользовательский код короткой ссылки
+	Domain         string     `json:"domain,omitempty"`          // Брендированный домен из SHORT_DOMAINS (по умолчанию основной)
+	MaxClicks      int        `json:"max_clicks,omitempty"`      // Лимит переходов, после которого ссылка перестает действовать
+	Active         *bool      `json:"active,omitempty"`          // Действуют ли переходы по ссылке (по умолчанию true)
+	Analytics      *bool      `json:"analytics,omitempty"`       // Записывать ли переходы в аналитику (по умолчанию true)
+	Tags           []string   `json:"tags,omitempty"`            // Метки ссылки
+
+	QueryParams map[string]string `json:"query_params,omitempty"` // Параметры, добавляемые к исходной ссылке при переходе
+
+	Variants       []database.Variant `json:"variants,omitempty"`        // Варианты исходной ссылки для A/B теста
+	StickyVariants bool               `json:"sticky_variants,omitempty"` // Закреплять ли выбранный вариант за клиентом
+
+	DeviceUrls map[string]string `json:"device_urls,omitempty"` // Исходные ссылки для платформ ("ios", "android", "desktop")
+	GeoUrls    map[string]string `json:"geo_urls,omitempty"`    // Исходные ссылки для стран и регионов ("US", "US-CA")
+}
+
+// expiration - Метод, реализующий вычисление времени окончания срока действия из "expires_at", "active_until" или "ttl"
+func (req *LinkRequest) expiration() (*time.Time, error) {
+
+	if req.ActiveUntil != nil {
+		if req.ExpiresAt != nil {
+			return nil, errors.New("only one of expires_at and active_until may be set")
+		}
+
+		req.ExpiresAt = req.ActiveUntil
+	}
+
+	if req.ExpiresAt != nil && req.TTL != "" {
+		return nil, errors.New("only one of expires_at and ttl may be set")
+	}
+
+	if req.TTL != "" {
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			return nil, errors.New("invalid ttl")
+		}
+
+		expiresAt := time.Now().Add(ttl)
+		return &expiresAt, nil
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at is in the past")
+	}
+
+	return req.ExpiresAt, nil
+}
+
+// validateActivation - Функция, проверяющая, что начало срока действия ссылки раньше его окончания
+func validateActivation(activeFrom, expiresAt *time.Time) error {
+
+	if activeFrom != nil && expiresAt != nil && !activeFrom.Before(*expiresAt) {
+		return errors.New("active_from must be before the end of the active period")
+	}
+
+	return nil
+}
+
+// nullableTime - Тип данных, описывающий время в теле запроса на изменение, различающий отсутствующее поле
+// и явный null, который снимает значение
+type nullableTime struct {
+	Set  bool       // Задано ли поле в запросе
+	Time *time.Time // Новое значение (nil - значение снимается)
+}
+
+// UnmarshalJSON - Метод, реализующий чтение времени RFC 3339 или null из тела запроса
+// (вызывается только для полей, заданных в запросе)
+func (t *nullableTime) UnmarshalJSON(data []byte) error {
+
+	t.Set = true
+	t.Time = nil
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	return json.Unmarshal(data, &t.Time)
+}
+
+// MarshalJSON - Метод, реализующий запись времени для журнала аудита
+// (отсутствующее поле - null, снятое значение - пустая строка, как у пароля)
+func (t nullableTime) MarshalJSON() ([]byte, error) {
+
+	if t.Set && t.Time == nil {
+		return []byte(`""`), nil
+	}
+
+	return json.Marshal(t.Time)
+}
+
+// apply - Метод, реализующий замену значения заданным в запросе (поле, отсутствующее в запросе, не меняет его)
+func (t nullableTime) apply(dst **time.Time) {
+	if t.Set {
+		*dst = t.Time
+	}
+}
+
+// LinkUpdate - Тип данных, описывающий тело запроса на изменение ссылки (изменяются только заданные поля)
+type LinkUpdate struct {
+	Url            *string      `json:"url"`             // Исходная ссылка
+	RedirectStatus *int         `json:"redirect_status"` // Статус перехода (0 - статус по умолчанию)
+	ExpiresAt      nullableTime `json:"expires_at"`      // Время окончания срока действия (null снимает ограничение)
+	ActiveFrom     nullableTime `json:"active_from"`     // Время начала срока действия (null снимает ограничение)
+	ActiveUntil    nullableTime `json:"active_until"`    // Время окончания срока действия (синоним "expires_at")
+	Password       *string      `json:"password"`        // Пароль для перехода (пустая строка снимает защиту)
+	MaxClicks      *int         `json:"max_clicks"`      // Лимит переходов (0 снимает ограничение)
+	Active         *bool        `json:"active"`          // Действуют ли переходы по ссылке
+	Analytics      *bool        `json:"analytics"`       // Записывать ли переходы в аналитику
+	Tags           *[]string    `json:"tags"`            // Метки ссылки (пустой список удаляет их)
+
+	QueryParams *map[string]string `json:"query_params"` // Параметры, добавляемые при переходе (пустой объект удаляет их)
+
+	Variants       *[]database.Variant `json:"variants"`        // Варианты исходной ссылки (пустой список удаляет их)
+	StickyVariants *bool               `json:"sticky_variants"` // Закреплять ли выбранный вариант за клиентом
+
+	DeviceUrls *map[string]string `json:"device_urls"` // Исходные ссылки для платформ (пустой объект удаляет их)
+	GeoUrls    *map[string]string `json:"geo_urls"`    // Исходные ссылки для стран и регионов (пустой объект удаляет их)
+}
+
+// ClickPoint - Тип данных, описывающий количество переходов за интервал времени в API
+type ClickPoint struct {
+	Time   time.Time `json:"time"`   // Начало интервала
+	Clicks int       `json:"clicks"` // Количество переходов
+}
+
+// ListLinks - Метод, реализующий обработку "Get" запроса на получение страницы ссылок
+// (параметры: offset и limit или курсор cursor из заголовка X-Next-Cursor предыдущей страницы)
+func (s *Server) ListLinks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	page, err := pageFromRequest(r)
+	if err != nil {
+		http.Error(w, "Error: Invalid cursor (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid cursor")
+		return
+	}
+
+	rows, err := s.db.ListRows(r.Context(), workspaceIdFromContext(r.Context()), page)
+	if err != nil {
+		http.Error(w, "Error: Failed to read links (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read links", "error", err)
+		return
+	}
+
+	writeNextCursor(w, r, page, rows)
+
+	links := make([]Link, 0, len(rows))
+	for _, row := range rows {
+		links = append(links, linkFromRow(row))
+	}
+
+	s.writeJSON(w, http.StatusOK, links)
+}
+
+// CreateLink - Метод, реализующий обработку "Post" запроса на создание ссылки
+func (s *Server) CreateLink(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	req := LinkRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.Url == "" {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	req.Url, err = s.urls.normalize(r.Context(), req.Url)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid url", "error", err)
+		return
+	}
+
+	if s.rejectMalicious(w, r, req.Url) {
+		return
+	}
+
+	if req.RedirectStatus != 0 && !isRedirectStatus(req.RedirectStatus) {
+		http.Error(w, "Error: Invalid redirect status (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid redirect status", "redirect_status", req.RedirectStatus)
+		return
+	}
+
+	expiresAt, err := req.expiration()
+	if err == nil {
+		err = validateActivation(req.ActiveFrom, expiresAt)
+	}
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid expiration", "error", err)
+		return
+	}
+
+	err = validateQueryParams(req.QueryParams)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid query params", "error", err)
+		return
+	}
+
+	err = validateMaxClicks(req.MaxClicks)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid click limit", "error", err)
+		return
+	}
+
+	req.Tags, err = normalizeTags(req.Tags)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid tags", "error", err)
+		return
+	}
+
+	req.Variants, err = s.prepareVariants(r.Context(), req.Variants)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid variants", "error", err)
+		return
+	}
+
+	req.DeviceUrls, err = s.prepareDeviceUrls(r.Context(), req.DeviceUrls)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid device urls", "error", err)
+		return
+	}
+
+	req.GeoUrls, err = s.prepareGeoUrls(r.Context(), req.GeoUrls)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid geo urls", "error", err)
+		return
+	}
+
+	req.Domain, err = s.shortDomain(req.Domain)
+	if err != nil {
+		http.Error(w, "Error: Unknown domain (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Unknown domain", "domain", req.Domain)
+		return
+	}
+
+	if req.Alias != "" {
+		req.Alias = s.foldCode(req.Alias)
+
+		err = validateAlias(req.Alias)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid alias", "alias", req.Alias, "error", err)
+			return
+		}
+	}
+
+	passwordHash, err := hashLinkPassword(req.Password)
+	if err != nil {
+		http.Error(w, "Error: Failed to hash password (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to hash link password", "error", err)
+		return
+	}
+
+	newRow := database.RowData{
+		Url:            req.Url,
+		UserId:         userIdFromContext(r.Context()),
+		WorkspaceId:    workspaceIdFromContext(r.Context()),
+		Domain:         req.Domain,
+		ApiKeyId:       workspaceFromContext(r.Context()).KeyId,
+		RedirectStatus: req.RedirectStatus,
+		ExpiresAt:      expiresAt,
+		ActiveFrom:     req.ActiveFrom,
+		MaxClicks:      req.MaxClicks,
+		Disabled:       req.Active != nil && !*req.Active,
+		NoAnalytics:    req.Analytics != nil && !*req.Analytics,
+		Tags:           req.Tags,
+		PasswordHash:   passwordHash,
+		QueryParams:    req.QueryParams,
+		Variants:       req.Variants,
+		StickyVariants: req.StickyVariants,
+		DeviceUrls:     req.DeviceUrls,
+		GeoUrls:        req.GeoUrls,
+	}
+
+	var shortUrl string
+
+	if req.Alias != "" {
+		shortUrl, err = s.saveAlias(r.Context(), newRow, req.Alias)
+	} else {
+		shortUrl, err = s.shorten(r.Context(), newRow)
+	}
+
+	if errors.Is(err, errReservedCode) {
+		http.Error(w, "Error: Alias is reserved (status code: 409)", http.StatusConflict)
+		s.logger.WarnContext(r.Context(), "Alias is reserved", "alias", req.Alias)
+		return
+	}
+	if errors.Is(err, database.ErrShortUrlExists) {
+		http.Error(w, "Error: Alias is already taken (status code: 409)", http.StatusConflict)
+		s.logger.WarnContext(r.Context(), "Alias is already taken", "alias", req.Alias)
+		return
+	}
+	if isCollision(err) {
+		http.Error(w, "Error: No free short code (status code: 503)", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error: Failed to save url in database (status code: 500)", http.StatusInternalServerError)
+		return
+	}
+
+	row, isExist := s.db.GetShortUrlRow(r.Context(), shortUrl)
+	if !isExist {
+		http.Error(w, "Error: Failed to read link (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read created link")
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, linkFromRow(*row))
+}
+
+// GetLink - Метод, реализующий обработку "Get" запроса на получение ссылки по коду
+func (s *Server) GetLink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	row, isExist := s.workspaceLink(w, r, shortUrlOnDomain(linkDomain(r), ps.ByName("code")))
+	if !isExist {
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, linkFromRow(*row))
+}
+
+// UpdateLink - Метод, реализующий обработку "Patch" запроса на изменение параметров ссылки
+func (s *Server) UpdateLink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	req := LinkUpdate{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || (req.Url != nil && *req.Url == "") {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	if req.Url != nil {
+		*req.Url, err = s.urls.normalize(r.Context(), *req.Url)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid url", "error", err)
+			return
+		}
+
+		if s.rejectMalicious(w, r, *req.Url) {
+			return
+		}
+	}
+
+	if req.RedirectStatus != nil && *req.RedirectStatus != 0 && !isRedirectStatus(*req.RedirectStatus) {
+		http.Error(w, "Error: Invalid redirect status (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid redirect status", "redirect_status", *req.RedirectStatus)
+		return
+	}
+
+	if req.QueryParams != nil {
+		err = validateQueryParams(*req.QueryParams)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid query params", "error", err)
+			return
+		}
+	}
+
+	if req.MaxClicks != nil {
+		err = validateMaxClicks(*req.MaxClicks)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid click limit", "error", err)
+			return
+		}
+	}
+
+	if req.Tags != nil {
+		*req.Tags, err = normalizeTags(*req.Tags)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid tags", "error", err)
+			return
+		}
+	}
+
+	if req.Variants != nil {
+		*req.Variants, err = s.prepareVariants(r.Context(), *req.Variants)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid variants", "error", err)
+			return
+		}
+	}
+
+	if req.DeviceUrls != nil {
+		*req.DeviceUrls, err = s.prepareDeviceUrls(r.Context(), *req.DeviceUrls)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid device urls", "error", err)
+			return
+		}
+	}
+
+	if req.GeoUrls != nil {
+		*req.GeoUrls, err = s.prepareGeoUrls(r.Context(), *req.GeoUrls)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid geo urls", "error", err)
+			return
+		}
+	}
+
+	shortUrl := shortUrlOnDomain(linkDomain(r), ps.ByName("code"))
+
+	row, isExist := s.workspaceLink(w, r, shortUrl)
+	if !isExist {
+		return
+	}
+
+	oldUrl := row.Url
+
+	if req.Url != nil {
+		row.Url = *req.Url
+	}
+	if req.RedirectStatus != nil {
+		row.RedirectStatus = *req.RedirectStatus
+	}
+	req.ExpiresAt.apply(&row.ExpiresAt)
+	req.ActiveUntil.apply(&row.ExpiresAt)
+	req.ActiveFrom.apply(&row.ActiveFrom)
+
+	err = validateActivation(row.ActiveFrom, row.ExpiresAt)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid expiration", "error", err)
+		return
+	}
+
+	if req.QueryParams != nil {
+		row.QueryParams = *req.QueryParams
+	}
+	if req.Variants != nil {
+		row.Variants = *req.Variants
+	}
+	if req.StickyVariants != nil {
+		row.StickyVariants = *req.StickyVariants
+	}
+	if req.DeviceUrls != nil {
+		row.DeviceUrls = *req.DeviceUrls
+	}
+	if req.GeoUrls != nil {
+		row.GeoUrls = *req.GeoUrls
+	}
+	if req.MaxClicks != nil {
+		row.MaxClicks = *req.MaxClicks
+	}
+	if req.Active != nil {
+		row.Disabled = !*req.Active
+	}
+	if req.Analytics != nil {
+		row.NoAnalytics = !*req.Analytics
+	}
+	if req.Tags != nil {
+		row.Tags = *req.Tags
+	}
+	if req.Password != nil {
+		row.PasswordHash, err = hashLinkPassword(*req.Password)
+		if err != nil {
+			http.Error(w, "Error: Failed to hash password (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to hash link password", "error", err)
+			return
+		}
+	}
+
+	_, err = s.db.UpdateRow(r.Context(), *row)
+	if err != nil {
+		http.Error(w, "Error: Failed to update url in database (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to update url in database", "error", err)
+		return
+	}
+
+	s.invalidateCache(row.WorkspaceId, row.Domain, shortUrl, oldUrl)
+
+	s.logger.InfoContext(r.Context(), "Url was updated", "short_url", shortUrl, "url", row.Url)
+
+	s.emitLinkEvent(eventLinkUpdated, *row)
+
+	// Новый пароль ссылки не попадает в журнал аудита
+	if req.Password != nil {
+		redacted := "[redacted]"
+		req.Password = &redacted
+	}
+	s.audit(r.Context(), auditLinkUpdate, shortUrl, map[string]any{"old_url": oldUrl, "changes": auditChanges(req)})
+
+	s.writeJSON(w, http.StatusOK, linkFromRow(*row))
+}
+
+// DeleteLink - Метод, реализующий обработку "Delete" запроса на удаление ссылки
+func (s *Server) DeleteLink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	shortUrl := shortUrlOnDomain(linkDomain(r), ps.ByName("code"))
+
+	row, isExist := s.workspaceLink(w, r, shortUrl)
+	if !isExist {
+		return
+	}
+
+	_, err := s.db.DeleteRow(r.Context(), shortUrl)
+	if err != nil {
+		http.Error(w, "Error: Failed to delete url from database (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to delete url from database", "error", err)
+		return
+	}
+
+	s.invalidateCache(row.WorkspaceId, row.Domain, shortUrl, row.Url)
+
+	s.logger.InfoContext(r.Context(), "Url was deleted", "short_url", shortUrl)
+
+	s.emitLinkEvent(eventLinkDeleted, *row)
+	s.audit(r.Context(), auditLinkDelete, shortUrl, map[string]any{"url": row.Url})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLinkClicks - Метод, реализующий обработку "Get" запроса на получение количества переходов по дням
+func (s *Server) GetLinkClicks(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	days := queryInt(r, "days", defaultClickDays, 1, maxClickDays)
+
+	row, isExist := s.workspaceLink(w, r, shortUrlOnDomain(linkDomain(r), ps.ByName("code")))
+	if !isExist {
+		return
+	}
+
+	counts, err := s.analytics.GetDailyClicks(r.Context(), row.ShortUrl, days)
+	if err != nil {
+		http.Error(w, "Error: Failed to read clicks (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read clicks", "error", err)
+		return
+	}
+
+	points := make([]ClickPoint, 0, len(counts))
+	for _, c := range counts {
+		points = append(points, ClickPoint{Time: c.Time, Clicks: c.Clicks})
+	}
+
+	s.writeJSON(w, http.StatusOK, points)
+}
+
+// recordClick - Метод, реализующий асинхронное сохранение перехода по короткой ссылке
+// (variant - номер выбранного варианта исходной ссылки, 0 - основная ссылка; переходы ссылок без аналитики
+// и ссылок вне доли флага click_analytics не записываются)
+func (s *Server) recordClick(r *http.Request, row *database.RowData, variant int) {
+
+	if row.NoAnalytics || !s.flagEnabled(flagClickAnalytics, row.Domain, row.ShortUrl) {
+		return
+	}
+
+	ip := s.clientIP(r)
+
+	err := s.clicks.Push(click_pipeline.Event{
+		ShortUrl:    row.ShortUrl,
+		WorkspaceId: row.WorkspaceId,
+		Time:        time.Now(),
+		IP:          ip,
+		StoredIP:    s.privacy.storedIP(ip),
+		Referrer:    r.Referer(),
+		UserAgent:   r.UserAgent(),
+		Bot:         r.Method == http.MethodHead || isPrefetch(r),
+		Variant:     variant,
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to record click", "error", err)
+	}
+}
+
+// isPrefetch - Функция, проверяющая, является ли запрос предварительной загрузкой страницы браузером
+func isPrefetch(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Sec-Purpose"), "prefetch") ||
+		r.Header.Get("Purpose") == "prefetch" || r.Header.Get("X-Moz") == "prefetch"
+}
+
+// invalidateCache - Метод, реализующий удаление из кеша значений для заданной пары ссылок рабочего пространства
+// на домене коротких ссылок
+func (s *Server) invalidateCache(workspaceId int, domain, shortUrl, url string) {
+	_ = s.cacheWithShortUrlKey.Delete(shortUrl)
+	_ = s.cacheWithOriginalUrlKey.Delete(originalUrlKey(workspaceId, domain, url))
+}
+
+// linkFromRow - Функция, реализующая преобразование строки БД в представление ссылки для API
+func linkFromRow(row database.RowData) Link {
+	return Link{
+		Code:        codeFromShortUrl(row.ShortUrl),
+		ShortUrl:    row.ShortUrl,
+		Domain:      row.Domain,
+		Url:         row.Url,
+		UserId:      row.UserId,
+		WorkspaceId: row.WorkspaceId,
+		CreatedAt:   row.CreatedAt,
+
+		RedirectStatus: row.RedirectStatus,
+		ExpiresAt:      row.ExpiresAt,
+		ActiveFrom:     row.ActiveFrom,
+		MaxClicks:      row.MaxClicks,
+		ClickCount:     row.ClickCount,
+		Active:         !row.Disabled,
+		Analytics:      !row.NoAnalytics,
+
+		Tags: row.Tags,
+
+		PasswordProtected: row.PasswordHash != "",
+
+		QueryParams: row.QueryParams,
+
+		Variants:       row.Variants,
+		StickyVariants: row.StickyVariants,
+
+		DeviceUrls: row.DeviceUrls,
+		GeoUrls:    row.GeoUrls,
+	}
+}
+
+// shortUrlFromCode - Функция, реализующая получение короткой ссылки по ее коду на основном домене
+func shortUrlFromCode(code string) string {
+	return shortUrlOnDomain("", code)
+}
+
+// codeFromShortUrl - Функция, реализующая получение кода из короткой ссылки любого домена
+// (процентная запись раскрывается)
+func codeFromShortUrl(shortUrl string) string {
+
+	_, code := splitShortUrl(shortUrl)
+
+	if decoded, err := url.PathUnescape(code); err == nil {
+		return decoded
+	}
+
+	return code
+}
+
+// queryInt - Функция, реализующая чтение целочисленного параметра запроса с ограничением значения
+// (max < 0 означает отсутствие верхней границы)
+func queryInt(r *http.Request, name string, def, min, max int) int {
+
+	v, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return def
+	}
+
+	if v < min {
+		return min
+	}
+
+	if max >= 0 && v > max {
+		return max
+	}
+
+	return v
+}
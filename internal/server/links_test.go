@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"my_project/urlgen/database"
+	"testing"
+	"time"
+)
+
+func TestLinkUpdateExpiresAt(t *testing.T) {
+
+	old := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		body  string
+		want  *time.Time
+		audit any
+	}{
+		{"absent", `{"url": "https://example.com"}`, &old, nil},
+		{"null", `{"expires_at": null}`, nil, ""},
+		{"new value", `{"expires_at": "2031-01-01T00:00:00Z"}`, &later, "2031-01-01T00:00:00Z"},
+		{"null synonym", `{"active_until": null}`, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			req := LinkUpdate{}
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			expiresAt := old
+			row := database.RowData{ExpiresAt: &expiresAt}
+
+			req.ExpiresAt.apply(&row.ExpiresAt)
+			req.ActiveUntil.apply(&row.ExpiresAt)
+
+			if (row.ExpiresAt == nil) != (tt.want == nil) || (row.ExpiresAt != nil && !row.ExpiresAt.Equal(*tt.want)) {
+				t.Errorf("ExpiresAt = %v, want %v", row.ExpiresAt, tt.want)
+			}
+
+			if got := auditChanges(req)["expires_at"]; got != tt.audit {
+				t.Errorf("audit expires_at = %#v, want %#v", got, tt.audit)
+			}
+		})
+	}
+}
+
+func TestLinkUpdateInvalidTime(t *testing.T) {
+
+	req := LinkUpdate{}
+	if err := json.Unmarshal([]byte(`{"expires_at": "tomorrow"}`), &req); err == nil {
+		t.Errorf("Unmarshal() of an invalid time succeeded")
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/config"
+	applog "my_project/urlgen/pkg/logger"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LogLevelResponse - Тип данных, описывающий ответ на запрос уровня журнала
+type LogLevelResponse struct {
+	Level           string     `json:"level"`            // Действующий уровень
+	ConfiguredLevel string     `json:"configured_level"` // Настроенный уровень (LOG_LEVEL)
+	Until           *time.Time `json:"until,omitempty"`  // Окончание временного уровня
+}
+
+// LogLevelRequest - Тип данных, описывающий запрос на временное изменение уровня журнала
+type LogLevelRequest struct {
+	Level    string `json:"level"`    // Уровень ("debug", "info", "warn", "error"; по умолчанию "debug")
+	Duration string `json:"duration"` // Время действия, например "30m" (по умолчанию config.LogLevelOverrideDuration)
+}
+
+// UseLogLevel - Метод, позволяющий передать серверу управление уровнем журнала для API администратора
+// (без него запросы уровня журнала получают ответ 404)
+func (s *Server) UseLogLevel(level *applog.Level) {
+	s.logLevel = level
+}
+
+// logLevelResponse - Функция, реализующая преобразование состояния уровня журнала в ответ API
+func logLevelResponse(state applog.LevelState) LogLevelResponse {
+
+	resp := LogLevelResponse{
+		Level:           strings.ToLower(state.Level.String()),
+		ConfiguredLevel: strings.ToLower(state.Base.String()),
+	}
+
+	if !state.Until.IsZero() {
+		resp.Until = &state.Until
+	}
+
+	return resp
+}
+
+// requireLogLevel - Метод, проверяющий, доступно ли управление уровнем журнала (иначе записывает ответ 404)
+func (s *Server) requireLogLevel(w http.ResponseWriter, r *http.Request) bool {
+
+	if s.logLevel == nil {
+		http.Error(w, "Error: Log level control is not available (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Log level control is not available")
+		return false
+	}
+
+	return true
+}
+
+// GetLogLevel - Метод, реализующий обработку "Get" запроса оператора на получение уровня журнала
+func (s *Server) GetLogLevel(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireLogLevel(w, r) {
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, logLevelResponse(s.logLevel.State()))
+}
+
+// SetLogLevel - Метод, реализующий обработку "Put" запроса оператора на временное изменение уровня журнала
+// (по истечении времени, не больше config.LogLevelOverrideMaxDuration, восстанавливается настроенный уровень)
+func (s *Server) SetLogLevel(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireLogLevel(w, r) {
+		return
+	}
+
+	req := LogLevelRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	if req.Level == "" {
+		req.Level = "debug"
+	}
+
+	level, err := applog.ParseLevel(req.Level)
+	if err != nil {
+		http.Error(w, "Error: Unknown log level (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Unknown log level", "level", req.Level)
+		return
+	}
+
+	duration := config.LogLevelOverrideDuration
+	if req.Duration != "" {
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 || duration > config.LogLevelOverrideMaxDuration {
+			http.Error(w, "Error: Invalid duration (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid log level duration", "duration", req.Duration)
+			return
+		}
+	}
+
+	resp := logLevelResponse(s.logLevel.Override(level, duration))
+
+	s.logger.InfoContext(r.Context(), "Log level was changed", "level", resp.Level, "until", resp.Until)
+	s.auditGlobal(r.Context(), auditLogLevelSet, resp.Level, map[string]any{"duration": duration.String()})
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// ResetLogLevel - Метод, реализующий обработку "Delete" запроса оператора на восстановление настроенного
+// уровня журнала
+func (s *Server) ResetLogLevel(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireLogLevel(w, r) {
+		return
+	}
+
+	if s.logLevel.Restore() {
+		s.logger.InfoContext(r.Context(), "Log level was restored", "level", s.logLevel.State().Level)
+		s.auditGlobal(r.Context(), auditLogLevelReset, "", nil)
+	}
+
+	s.writeJSON(w, http.StatusOK, logLevelResponse(s.logLevel.State()))
+}
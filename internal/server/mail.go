@@ -0,0 +1,65 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// mailer - Тип данных, описывающий отправку писем пользователям через SMTP
+type mailer struct {
+	addr     string    // Адрес SMTP сервера (host:port)
+	from     string    // Адрес отправителя
+	auth     smtp.Auth // Аутентификация на SMTP сервере (nil, если не требуется)
+	resetUrl string    // Шаблон ссылки сброса пароля ("{token}" заменяется токеном)
+}
+
+// mailerFromEnv - Функция, позволяющая получить настройки почты из переменных SMTP_ADDR, SMTP_FROM,
+// SMTP_USERNAME, SMTP_PASSWORD и PASSWORD_RESET_URL (nil, если SMTP_ADDR не задан)
+func mailerFromEnv() (*mailer, error) {
+
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return nil, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, errors.New("error: SMTP_ADDR must be host:port")
+	}
+
+	m := mailer{
+		addr:     addr,
+		from:     os.Getenv("SMTP_FROM"),
+		resetUrl: os.Getenv("PASSWORD_RESET_URL"),
+	}
+
+	if m.from == "" {
+		return nil, errors.New("error: SMTP_FROM is not set")
+	}
+
+	if !strings.Contains(m.resetUrl, "{token}") {
+		return nil, errors.New("error: PASSWORD_RESET_URL must contain {token}")
+	}
+
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		m.auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	return &m, nil
+}
+
+// send - Метод, реализующий отправку текстового письма
+func (m *mailer) send(to, subject, body string) error {
+
+	msg := "From: " + m.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + body
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg))
+}
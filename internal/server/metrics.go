@@ -0,0 +1,177 @@
+package server
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/version"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	trafficApi      = "api"      // Тип трафика для запросов к API
+	trafficRedirect = "redirect" // Тип трафика для переходов по коротким ссылкам
+)
+
+// metrics - Тип данных, описывающий метрики сервера
+type metrics struct {
+	registry *prometheus.Registry // Реестр метрик сервера
+
+	requests      *prometheus.CounterVec   // Количество запросов
+	latency       *prometheus.HistogramVec // Время обработки запросов
+	cacheRequests *prometheus.CounterVec   // Количество обращений к кешу
+	collisions    *prometheus.CounterVec   // Количество совпадений сгенерированных кодов с занятыми
+	panics        prometheus.Counter       // Количество паник обработчиков запросов
+
+	streamMessages *prometheus.CounterVec // Количество сообщений потоков событий по результату отправки
+}
+
+// newMetrics - Функция, реализующая создание и регистрацию метрик сервера
+func newMetrics(db *database.Database) *metrics {
+
+	m := metrics{
+		registry: prometheus.NewRegistry(),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "urlgen_http_requests_total",
+			Help: "Number of HTTP requests by route, method, status and traffic type.",
+		}, []string{"route", "method", "status", "traffic"}),
+
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "urlgen_http_request_duration_seconds",
+			Help:    "HTTP request latency by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "traffic"}),
+
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "urlgen_cache_requests_total",
+			Help: "Number of cache lookups by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+
+		collisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "urlgen_code_collisions_total",
+			Help: "Number of generated codes that were already taken, by code strategy.",
+		}, []string{"strategy"}),
+
+		panics: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "urlgen_http_panics_total",
+			Help: "Number of requests whose handler panicked and got a 500 response.",
+		}),
+
+		streamMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "urlgen_stream_messages_total",
+			Help: "Number of click and link events sent to event streams, by backend and result (published or failed).",
+		}, []string{"backend", "result"}),
+	}
+
+	build := version.Get()
+
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "urlgen_build_info",
+			Help:        "Build information of the running server (always 1).",
+			ConstLabels: prometheus.Labels{"version": build.Version, "commit": build.Commit, "go_version": build.GoVersion},
+		}, func() float64 { return 1 }),
+		m.requests,
+		m.latency,
+		m.cacheRequests,
+		m.collisions,
+		m.panics,
+		m.streamMessages,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	if db != nil {
+		m.registerPoolStats(db)
+	}
+
+	return &m
+}
+
+// registerPoolStats - Метод, реализующий регистрацию метрик пула подключений к БД
+func (m *metrics) registerPoolStats(db *database.Database) {
+
+	gauge := func(name, help string, value func() float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, value)
+	}
+
+	m.registry.MustRegister(
+		gauge("urlgen_db_pool_total_conns", "Total number of connections in the pool.",
+			func() float64 { return float64(db.Stat().TotalConns()) }),
+		gauge("urlgen_db_pool_idle_conns", "Number of idle connections in the pool.",
+			func() float64 { return float64(db.Stat().IdleConns()) }),
+		gauge("urlgen_db_pool_acquired_conns", "Number of connections currently in use.",
+			func() float64 { return float64(db.Stat().AcquiredConns()) }),
+		gauge("urlgen_db_pool_max_conns", "Maximum size of the pool.",
+			func() float64 { return float64(db.Stat().MaxConns()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "urlgen_db_pool_acquires_total",
+			Help: "Number of successful connection acquires from the pool.",
+		}, func() float64 { return float64(db.Stat().AcquireCount()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "urlgen_db_pool_acquire_wait_seconds_total",
+			Help: "Total time spent waiting for a connection from the pool.",
+		}, func() float64 { return db.Stat().AcquireDuration().Seconds() }),
+	)
+}
+
+// handler - Метод, возвращающий обработчик запросов на получение метрик
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// cacheLookup - Метод, реализующий учет обращения к кешу
+func (m *metrics) cacheLookup(cache string, hit bool) {
+
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	m.cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// instrument - Метод, реализующий промежуточный обработчик учета запросов к заданному маршруту
+func (m *metrics) instrument(route, traffic string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(rec.status), traffic).Inc()
+		m.latency.WithLabelValues(route, traffic).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder - Тип данных, реализующий запоминание статуса и размера ответа
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int  // HTTP статус ответа
+	bytes   int  // Количество записанных байт тела ответа
+	written bool // Отправлен ли заголовок ответа
+}
+
+// WriteHeader - Метод, реализующий запись и запоминание статуса ответа
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status, r.written = status, true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write - Метод, реализующий запись тела ответа с подсчетом размера
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.written = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap - Метод, возвращающий исходный ResponseWriter (для http.ResponseController)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"github.com/nats-io/nats.go"
+	"my_project/urlgen/config"
+	"os"
+)
+
+// natsPublisher - Тип данных, реализующий отправку сообщений потока событий в темы NATS
+type natsPublisher struct {
+	conn     *nats.Conn                    // Подключение к NATS
+	subjects map[string]string             // Темы по потоку событий
+	result   func(messages int, err error) // Учет результата отправки
+}
+
+// natsFromEnv - Функция, позволяющая получить отправку событий в NATS из переменных окружения
+// (NATS_URL - адреса серверов через запятую, nil, если не заданы; NATS_CLICKS_SUBJECT и NATS_LINKS_SUBJECT -
+// темы переходов и событий ссылок; NATS_CREDS_FILE - файл учетных данных пользователя; при недоступности
+// сервера подключение повторяется в фоне, а сообщения копятся в буфере клиента)
+func natsFromEnv(result func(messages int, err error)) (*natsPublisher, error) {
+
+	addr := os.Getenv("NATS_URL")
+	if addr == "" {
+		return nil, nil
+	}
+
+	options := []nats.Option{
+		nats.Name("urlgen"),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+		nats.ReconnectBufSize(config.NATSReconnectBuffer),
+	}
+
+	if creds := os.Getenv("NATS_CREDS_FILE"); creds != "" {
+		options = append(options, nats.UserCredentials(creds))
+	}
+
+	conn, err := nats.Connect(addr, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := natsPublisher{conn: conn, result: result, subjects: map[string]string{
+		streamClicks: os.Getenv("NATS_CLICKS_SUBJECT"),
+		streamLinks:  os.Getenv("NATS_LINKS_SUBJECT"),
+	}}
+
+	for stream, subject := range p.subjects {
+		if subject == "" {
+			p.subjects[stream] = config.StreamTopicPrefix + stream
+		}
+	}
+
+	return &p, nil
+}
+
+// Publish - Метод, реализующий отправку сообщений в NATS без ожидания подтверждения
+// (заголовки "event" и "key" содержат тип события и короткую ссылку)
+func (p *natsPublisher) Publish(messages []EventMessage) {
+
+	var (
+		published int
+		failed    int
+		lastErr   error
+	)
+
+	for _, m := range messages {
+		msg := nats.NewMsg(p.subjects[m.Stream])
+		msg.Header.Set("event", m.Event)
+		msg.Header.Set("key", m.Key)
+		msg.Data = m.Value
+
+		if err := p.conn.PublishMsg(msg); err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		published++
+	}
+
+	if published != 0 {
+		p.result(published, nil)
+	}
+	if failed != 0 {
+		p.result(failed, lastErr)
+	}
+}
+
+// Close - Метод, реализующий отправку оставшихся сообщений и закрытие подключения
+func (p *natsPublisher) Close() error {
+
+	err := p.conn.FlushTimeout(config.NATSFlushTimeout)
+	p.conn.Close()
+
+	return err
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/julienschmidt/httprouter"
+	"golang.org/x/oauth2"
+	"my_project/urlgen/database"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// oidcStateCookie - Название cookie, хранящего состояние входа через внешнего провайдера
+const oidcStateCookie = "oidc_state"
+
+// oidcProvider - Тип данных, описывающий подключение к внешнему провайдеру OpenID Connect
+type oidcProvider struct {
+	issuer   string                // Адрес провайдера
+	verifier *oidc.IDTokenVerifier // Проверка ID токенов
+	oauth    oauth2.Config         // Настройки OAuth2 клиента
+}
+
+// oidcClaims - Тип данных, описывающий используемые поля ID токена
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// username - Метод, возвращающий имя нового локального пользователя по полям ID токена
+// (адрес почты используется, только если провайдер его подтвердил)
+func (c oidcClaims) username(subject string) string {
+
+	if c.Email != "" && c.EmailVerified {
+		return c.Email
+	}
+	if c.PreferredUsername != "" {
+		return c.PreferredUsername
+	}
+
+	return subject
+}
+
+// newOIDCProvider - Функция, позволяющая подключиться к провайдеру, заданному переменными окружения
+// (возвращает nil, если провайдер не настроен)
+func newOIDCProvider(ctx context.Context) (*oidcProvider, error) {
+
+	issuer := os.Getenv("OIDC_ISSUER_URL")
+	if issuer == "" {
+		return nil, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []string{oidc.ScopeOpenID, "email", "profile"}
+	if s := os.Getenv("OIDC_SCOPES"); s != "" {
+		scopes = strings.Split(s, ",")
+	}
+
+	clientId := os.Getenv("OIDC_CLIENT_ID")
+
+	return &oidcProvider{
+		issuer:   issuer,
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientId}),
+		oauth: oauth2.Config{
+			ClientID:     clientId,
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+	}, nil
+}
+
+// OIDCLogin - Метод, реализующий перенаправление пользователя на страницу входа внешнего провайдера
+func (s *Server) OIDCLogin(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		http.Error(w, "Error: Failed to create state (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to create state", "error", err)
+		return
+	}
+
+	state := base64.RawURLEncoding.EncodeToString(buf)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/v1/auth/oidc",
+		Expires:  time.Now().Add(10 * time.Minute),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, s.oidc.oauth.AuthCodeURL(state), http.StatusFound)
+}
+
+// OIDCCallback - Метод, реализующий обработку ответа внешнего провайдера (возврат JWT)
+func (s *Server) OIDCCallback(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	// Проверка состояния
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || cookie.Value == "" || cookie.Value != r.URL.Query().Get("state") {
+		http.Error(w, "Error: Invalid state (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid OIDC state")
+		return
+	}
+
+	// Получение и проверка ID токена
+	oauthToken, err := s.oidc.oauth.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		http.Error(w, "Error: Failed to exchange code (status code: 401)", http.StatusUnauthorized)
+		s.logger.ErrorContext(r.Context(), "Failed to exchange OIDC code", "error", err)
+		return
+	}
+
+	rawIdToken, ok := oauthToken.Extra("id_token").(string)
+	if !ok {
+		http.Error(w, "Error: Id token is missing (status code: 401)", http.StatusUnauthorized)
+		s.logger.WarnContext(r.Context(), "OIDC id token is missing")
+		return
+	}
+
+	idToken, err := s.oidc.verifier.Verify(r.Context(), rawIdToken)
+	if err != nil {
+		http.Error(w, "Error: Invalid id token (status code: 401)", http.StatusUnauthorized)
+		s.logger.WarnContext(r.Context(), "Invalid OIDC id token", "error", err)
+		return
+	}
+
+	claims := oidcClaims{}
+	if err = idToken.Claims(&claims); err != nil {
+		http.Error(w, "Error: Invalid id token (status code: 401)", http.StatusUnauthorized)
+		s.logger.ErrorContext(r.Context(), "Failed to read OIDC claims", "error", err)
+		return
+	}
+
+	// Сопоставление субъекта с локальным пользователем
+	username := claims.username(idToken.Subject)
+
+	user, err := s.db.EnsureExternalUser(r.Context(), s.oidc.issuer+"|"+idToken.Subject, username)
+	if errors.Is(err, database.ErrUserExists) {
+		http.Error(w, "Error: Username is already taken (status code: 409)", http.StatusConflict)
+		s.logger.WarnContext(r.Context(), "OIDC username is already taken", "username", username)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error: Failed to map user (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to map OIDC user", "error", err)
+		return
+	}
+
+	// Выпуск токена
+	token, err := s.tokens.Issue(user.Id, user.Username)
+	if err != nil {
+		http.Error(w, "Error: Failed to issue token (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to issue token", "error", err)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "User logged in via OIDC", "username", user.Username)
+
+	s.writeJSON(w, http.StatusOK, TokenResponse{
+		Token:     token,
+		ExpiresIn: int(s.tokens.TTL().Seconds()),
+	})
+}
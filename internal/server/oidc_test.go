@@ -0,0 +1,27 @@
+package server
+
+import "testing"
+
+func TestOIDCClaimsUsername(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		claims oidcClaims
+		want   string
+	}{
+		{"verified email", oidcClaims{Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"},
+			"alice@example.com"},
+		{"unverified email", oidcClaims{Email: "admin@example.com", PreferredUsername: "mallory"}, "mallory"},
+		{"unverified email only", oidcClaims{Email: "admin@example.com"}, "sub-1"},
+		{"preferred username", oidcClaims{PreferredUsername: "bob"}, "bob"},
+		{"no claims", oidcClaims{}, "sub-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.claims.username("sub-1"); got != tt.want {
+				t.Errorf("username() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
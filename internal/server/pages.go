@@ -0,0 +1,176 @@
+package server
+
+import (
+	"html/template"
+	"my_project/urlgen/pkg/request_id"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultErrorTemplate - Шаблон страницы ошибки по умолчанию
+const defaultErrorTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    <meta name="robots" content="noindex">
+    <title>{{.Status}} - {{.Title}}</title>
+    <style>
+        body { font-family: sans-serif; max-width: 640px; margin: 96px auto; padding: 0 16px; color: #222; text-align: center; }
+        h1 { color: #8c8ad8; font-size: 4em; margin: 0; }
+    </style>
+</head>
+<body>
+<h1>{{.Status}}</h1>
+<h2>{{.Title}}</h2>
+<p>{{.Message}}</p>
+{{if .RequestId}}<p><small>Request ID: {{.RequestId}}</small></p>{{end}}
+</body>
+</html>
+`
+
+// ErrorPage - Тип данных, описывающий данные страницы ошибки перехода по короткой ссылке
+// (доступны в пользовательских шаблонах)
+type ErrorPage struct {
+	Status   int    `json:"status"`    // HTTP статус
+	Title    string `json:"error"`     // Краткое описание ошибки
+	Message  string `json:"message"`   // Подробное описание ошибки
+	Code     string `json:"code"`      // Запрошенный код короткой ссылки
+	ShortUrl string `json:"short_url"` // Запрошенная короткая ссылка
+
+	RequestId string `json:"request_id,omitempty"` // Идентификатор запроса (для обращения в поддержку)
+
+	ActiveFrom *time.Time `json:"active_from,omitempty"` // Время начала действия ссылки (для еще не действующей ссылки)
+}
+
+// errorPages - Тип данных, описывающий шаблоны страниц ошибок перехода по короткой ссылке
+type errorPages struct {
+	notFound   *template.Template // Шаблон для неизвестной ссылки (404)
+	gone       *template.Template // Шаблон для удаленной или истекшей ссылки (410)
+	notYetLive *template.Template // Шаблон для ссылки, срок действия которой еще не начался (404)
+}
+
+// loadErrorPages - Функция, реализующая загрузку шаблонов страниц ошибок
+// (пути к пользовательским шаблонам задаются переменными NOT_FOUND_TEMPLATE, GONE_TEMPLATE и NOT_YET_LIVE_TEMPLATE)
+func loadErrorPages() (*errorPages, error) {
+
+	notFound, err := loadTemplate("not_found", os.Getenv("NOT_FOUND_TEMPLATE"))
+	if err != nil {
+		return nil, err
+	}
+
+	gone, err := loadTemplate("gone", os.Getenv("GONE_TEMPLATE"))
+	if err != nil {
+		return nil, err
+	}
+
+	notYetLive, err := loadTemplate("not_yet_live", os.Getenv("NOT_YET_LIVE_TEMPLATE"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &errorPages{
+		notFound:   notFound,
+		gone:       gone,
+		notYetLive: notYetLive,
+	}, nil
+}
+
+// loadTemplate - Функция, реализующая загрузку шаблона из файла (шаблон по умолчанию, если путь не задан)
+func loadTemplate(name, path string) (*template.Template, error) {
+
+	if path == "" {
+		return template.New(name).Parse(defaultErrorTemplate)
+	}
+
+	return template.ParseFiles(path)
+}
+
+// writeNotFound - Метод, реализующий ответ на переход по неизвестной короткой ссылке
+func (s *Server) writeNotFound(w http.ResponseWriter, r *http.Request, code string) {
+	s.writeErrorPage(w, r, s.pages.notFound, ErrorPage{
+		Status:   http.StatusNotFound,
+		Title:    "Link not found",
+		Message:  "This short link does not exist.",
+		Code:     code,
+		ShortUrl: shortUrlOnDomain(s.requestDomain(r), code),
+	})
+}
+
+// writeInvalidCode - Метод, реализующий ответ на переход по коду с неверным контрольным символом
+// (скорее всего код набран с опечаткой)
+func (s *Server) writeInvalidCode(w http.ResponseWriter, r *http.Request, code string) {
+	s.writeErrorPage(w, r, s.pages.notFound, ErrorPage{
+		Status:   http.StatusBadRequest,
+		Title:    "Invalid link",
+		Message:  "This short link contains a typo, please check it.",
+		Code:     code,
+		ShortUrl: shortUrlOnDomain(s.requestDomain(r), code),
+	})
+}
+
+// writeGone - Метод, реализующий ответ на переход по удаленной или истекшей короткой ссылке
+func (s *Server) writeGone(w http.ResponseWriter, r *http.Request, code string) {
+	s.writeErrorPage(w, r, s.pages.gone, ErrorPage{
+		Status:   http.StatusGone,
+		Title:    "Link is no longer available",
+		Message:  "This short link has been removed or has expired.",
+		Code:     code,
+		ShortUrl: shortUrlOnDomain(s.requestDomain(r), code),
+	})
+}
+
+// writeDisabled - Метод, реализующий ответ на переход по отключенной короткой ссылке
+// (ответ не кешируется, так как ссылка может быть снова включена)
+func (s *Server) writeDisabled(w http.ResponseWriter, r *http.Request, code string) {
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	s.writeErrorPage(w, r, s.pages.gone, ErrorPage{
+		Status:   http.StatusForbidden,
+		Title:    "Link is disabled",
+		Message:  "This short link has been disabled by its owner.",
+		Code:     code,
+		ShortUrl: shortUrlOnDomain(s.requestDomain(r), code),
+	})
+}
+
+// writeNotYetLive - Метод, реализующий ответ на переход по ссылке до начала срока ее действия
+// (существование ссылки не раскрывается статусом, ответ не кешируется)
+func (s *Server) writeNotYetLive(w http.ResponseWriter, r *http.Request, code string, activeFrom *time.Time) {
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	s.writeErrorPage(w, r, s.pages.notYetLive, ErrorPage{
+		Status:     http.StatusNotFound,
+		Title:      "Link is not live yet",
+		Message:    "This short link will become available later.",
+		Code:       code,
+		ShortUrl:   shortUrlOnDomain(s.requestDomain(r), code),
+		ActiveFrom: activeFrom,
+	})
+}
+
+// writeErrorPage - Метод, реализующий запись страницы ошибки (в формате JSON, если клиент его запрашивает)
+func (s *Server) writeErrorPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template, page ErrorPage) {
+
+	page.RequestId = request_id.FromContext(r.Context())
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		s.writeJSON(w, page.Status, page)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(page.Status)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	err := tmpl.Execute(w, page)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to write error page", "error", err)
+	}
+}
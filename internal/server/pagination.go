@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"my_project/urlgen/database"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	cursorParam      = "cursor"        // Параметр запроса с курсором страницы
+	cursorPrefix     = "id:"           // Префикс содержимого курсора (до кодирования)
+	nextCursorHeader = "X-Next-Cursor" // Заголовок ответа с курсором следующей страницы
+)
+
+// errInvalidCursor - Ошибка разбора курсора страницы
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageFromRequest - Функция, реализующая чтение параметров страницы списка ссылок
+// (курсор из параметра "cursor" заменяет "offset": выборка продолжается после последней полученной строки)
+func pageFromRequest(r *http.Request) (database.Page, error) {
+
+	page := database.Page{
+		Offset: queryInt(r, "offset", 0, 0, -1),
+		Limit:  queryInt(r, "limit", defaultPageLimit, 1, maxPageLimit),
+	}
+
+	cursor := r.URL.Query().Get(cursorParam)
+	if cursor == "" {
+		return page, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return page, errInvalidCursor
+	}
+
+	idStr, found := strings.CutPrefix(string(raw), cursorPrefix)
+	if !found {
+		return page, errInvalidCursor
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return page, errInvalidCursor
+	}
+
+	page.Offset = 0
+	page.BeforeId = id
+
+	return page, nil
+}
+
+// encodeCursor - Функция, возвращающая непрозрачный курсор страницы, следующей за строкой с заданным идентификатором
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(id)))
+}
+
+// writeNextCursor - Функция, реализующая запись курсора следующей страницы в заголовки "X-Next-Cursor" и "Link"
+// (для неполной страницы следующей страницы нет и заголовки не добавляются)
+func writeNextCursor(w http.ResponseWriter, r *http.Request, page database.Page, rows []database.RowData) {
+
+	if len(rows) < page.Limit {
+		return
+	}
+
+	writeCursorAfter(w, r, page, rows[len(rows)-1].Id)
+}
+
+// writeCursorAfter - Функция, реализующая запись курсора страницы, следующей за строкой с заданным идентификатором
+// (полноту страницы проверяет вызывающий; параметры отбора запроса сохраняются в ссылке "Link")
+func writeCursorAfter(w http.ResponseWriter, r *http.Request, page database.Page, lastId int) {
+
+	cursor := encodeCursor(lastId)
+
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set(cursorParam, cursor)
+	query.Set("limit", strconv.Itoa(page.Limit))
+
+	w.Header().Set(nextCursorHeader, cursor)
+	w.Header().Set("Link", "<"+r.URL.Path+"?"+query.Encode()+`>; rel="next"`)
+}
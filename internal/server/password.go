@@ -0,0 +1,181 @@
+package server
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"html/template"
+	"math"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// linkPasswordHeader - Заголовок для передачи пароля ссылки при обращении из API клиентов
+const linkPasswordHeader = "X-Link-Password"
+
+// passwordTemplate - Шаблон страницы ввода пароля ссылки
+var passwordTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    <meta name="robots" content="noindex">
+    <title>Password required</title>
+    <style>
+        body { font-family: sans-serif; max-width: 360px; margin: 96px auto; padding: 0 16px; color: #222; }
+        form { display: flex; flex-direction: column; gap: 8px; }
+        .error { color: #c33; }
+    </style>
+</head>
+<body>
+<h1>Password required</h1>
+<p>{{.ShortUrl}} is protected by a password.</p>
+<form method="post">
+    <input name="password" type="password" placeholder="Password" autofocus required>
+    <button type="submit">Continue</button>
+</form>
+{{if .Invalid}}<p class="error">Invalid password</p>{{end}}
+</body>
+</html>
+`))
+
+// passwordPage - Тип данных, описывающий данные страницы ввода пароля
+type passwordPage struct {
+	ShortUrl string // Короткая ссылка
+	Invalid  bool   // Был ли введен неверный пароль
+}
+
+// passwordAttempts - Тип данных, реализующий учет неверных паролей защищенных ссылок в памяти экземпляра сервера
+// (после config.LinkPasswordMaxFailures неверных паролей ссылки ввод пароля приостанавливается
+// до конца окна config.LinkPasswordFailureWindow, отсчитываемого от первого неверного пароля)
+type passwordAttempts struct {
+	sync.Mutex
+	failures map[string]*passwordFailures // Неверные пароли по короткой ссылке
+	swept    time.Time                    // Время последнего удаления истекших окон
+}
+
+// passwordFailures - Тип данных, описывающий неверные пароли ссылки в текущем окне
+type passwordFailures struct {
+	count int       // Количество неверных паролей
+	since time.Time // Начало окна
+}
+
+// blocked - Метод, возвращающий время до возобновления ввода пароля ссылки (0, если ввод разрешен)
+func (p *passwordAttempts) blocked(key string, now time.Time) time.Duration {
+
+	p.Lock()
+	defer p.Unlock()
+
+	f, found := p.failures[key]
+	if !found || f.count < config.LinkPasswordMaxFailures {
+		return 0
+	}
+
+	return max(f.since.Add(config.LinkPasswordFailureWindow).Sub(now), 0)
+}
+
+// fail - Метод, реализующий учет неверного пароля ссылки
+func (p *passwordAttempts) fail(key string, now time.Time) {
+
+	p.Lock()
+	defer p.Unlock()
+
+	if p.failures == nil {
+		p.failures = map[string]*passwordFailures{}
+	}
+
+	// Истекшие окна не отличаются от отсутствующих и удаляются
+	if now.Sub(p.swept) >= config.LinkPasswordFailureWindow {
+		for k, f := range p.failures {
+			if now.Sub(f.since) >= config.LinkPasswordFailureWindow {
+				delete(p.failures, k)
+			}
+		}
+		p.swept = now
+	}
+
+	f, found := p.failures[key]
+	if !found || now.Sub(f.since) >= config.LinkPasswordFailureWindow {
+		f = &passwordFailures{since: now}
+		p.failures[key] = f
+	}
+
+	f.count++
+}
+
+// hashLinkPassword - Функция, реализующая хеширование пароля ссылки (пустой пароль не хешируется)
+func hashLinkPassword(password string) (string, error) {
+
+	if password == "" {
+		return "", nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// checkLinkPassword - Метод, реализующий проверку пароля защищенной ссылки
+// (пароль принимается из заголовка X-Link-Password или из формы; при его отсутствии или ошибке
+// показывается форма ввода и возвращается false; после серии неверных паролей ссылки пароли
+// не проверяются до конца окна и возвращается 429)
+func (s *Server) checkLinkPassword(w http.ResponseWriter, r *http.Request, row *database.RowData) bool {
+
+	if row.PasswordHash == "" {
+		return true
+	}
+
+	password := r.Header.Get(linkPasswordHeader)
+	fromHeader := password != ""
+
+	if !fromHeader && r.Method == http.MethodPost {
+		password = r.PostFormValue("password")
+	}
+
+	if password != "" {
+		if wait := s.passwordAttempts.blocked(row.ShortUrl, time.Now()); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Error: Too many invalid link passwords (status code: 429)", http.StatusTooManyRequests)
+			s.logger.WarnContext(r.Context(), "Too many invalid link passwords", "short_url", row.ShortUrl)
+			return false
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(row.PasswordHash), []byte(password)) == nil {
+			return true
+		}
+
+		s.passwordAttempts.fail(row.ShortUrl, time.Now())
+	}
+
+	if fromHeader {
+		http.Error(w, "Error: Invalid link password (status code: 401)", http.StatusUnauthorized)
+		s.logger.WarnContext(r.Context(), "Invalid link password", "short_url", row.ShortUrl)
+		return false
+	}
+
+	if password != "" {
+		s.logger.WarnContext(r.Context(), "Invalid link password", "short_url", row.ShortUrl)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusUnauthorized)
+
+	if r.Method == http.MethodHead {
+		return false
+	}
+
+	err := passwordTemplate.Execute(w, passwordPage{
+		ShortUrl: row.ShortUrl,
+		Invalid:  password != "",
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to write password page", "error", err)
+	}
+
+	return false
+}
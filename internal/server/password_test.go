@@ -0,0 +1,74 @@
+package server
+
+import (
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckLinkPasswordFailures(t *testing.T) {
+
+	hash, err := hashLinkPassword("correct horse")
+	if err != nil {
+		t.Fatalf("hashLinkPassword() error = %v", err)
+	}
+
+	s := testServer()
+	row := &database.RowData{ShortUrl: "abc", PasswordHash: hash}
+	other := &database.RowData{ShortUrl: "xyz", PasswordHash: hash}
+
+	check := func(row *database.RowData, password string) int {
+		r := httptest.NewRequest(http.MethodGet, "/"+row.ShortUrl, nil)
+		r.Header.Set(linkPasswordHeader, password)
+
+		rec := httptest.NewRecorder()
+		if s.checkLinkPassword(rec, r, row) {
+			return http.StatusOK
+		}
+
+		return rec.Code
+	}
+
+	for i := 0; i < config.LinkPasswordMaxFailures; i++ {
+		if code := check(row, "wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d status = %d, want %d", i+1, code, http.StatusUnauthorized)
+		}
+	}
+
+	if code := check(row, "wrong"); code != http.StatusTooManyRequests {
+		t.Errorf("invalid password after failures status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := check(row, "correct horse"); code != http.StatusTooManyRequests {
+		t.Errorf("valid password after failures status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := check(other, "correct horse"); code != http.StatusOK {
+		t.Errorf("other link status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestPasswordAttemptsWindow(t *testing.T) {
+
+	var p passwordAttempts
+	now := time.Now()
+
+	for i := 0; i < config.LinkPasswordMaxFailures; i++ {
+		p.fail("abc", now)
+	}
+
+	if wait := p.blocked("abc", now.Add(time.Minute)); wait != config.LinkPasswordFailureWindow-time.Minute {
+		t.Errorf("blocked() = %v, want %v", wait, config.LinkPasswordFailureWindow-time.Minute)
+	}
+
+	later := now.Add(config.LinkPasswordFailureWindow)
+	if wait := p.blocked("abc", later); wait != 0 {
+		t.Errorf("blocked() after window = %v, want 0", wait)
+	}
+
+	p.fail("abc", later)
+	if wait := p.blocked("abc", later); wait != 0 {
+		t.Errorf("blocked() after new failure = %v, want 0", wait)
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"my_project/urlgen/config"
+	"net"
+	"os"
+	"time"
+)
+
+// Режимы обработки IP адресов клиентов
+const (
+	ipPrivacyOff      = "off"      // IP не сохраняется в переходах, журнал запросов получает полный адрес
+	ipPrivacyTruncate = "truncate" // Сохраняется сеть адреса (IPv4 /24, IPv6 /48)
+	ipPrivacyHash     = "hash"     // Сохраняется HMAC адреса с секретной солью
+)
+
+// clickPurgeInterval - Интервал удаления переходов старше срока хранения
+const clickPurgeInterval = time.Hour
+
+// ipPrivacy - Тип данных, описывающий обезличивание IP адресов клиентов и срок хранения переходов
+type ipPrivacy struct {
+	mode      string        // Режим обработки IP адресов
+	salt      []byte        // Соль HMAC для режима "hash"
+	retention time.Duration // Срок хранения переходов (0 - без ограничения)
+}
+
+// ipPrivacyFromEnv - Функция, позволяющая получить настройки обработки IP адресов из переменных окружения
+// (IP_PRIVACY - "off", "truncate" или "hash", IP_HASH_SALT - соль для "hash" (по умолчанию случайная
+// при каждом запуске), CLICK_RETENTION - срок хранения переходов, например "720h" или "90d")
+func ipPrivacyFromEnv() (*ipPrivacy, error) {
+
+	p := ipPrivacy{mode: os.Getenv("IP_PRIVACY")}
+
+	switch p.mode {
+	case "", ipPrivacyOff:
+		p.mode = ipPrivacyOff
+	case ipPrivacyTruncate:
+	case ipPrivacyHash:
+		p.salt = []byte(os.Getenv("IP_HASH_SALT"))
+		if len(p.salt) == 0 {
+			p.salt = make([]byte, 32)
+			if _, err := rand.Read(p.salt); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, errors.New("error: IP_PRIVACY must be one of off, truncate, hash")
+	}
+
+	if v := os.Getenv("CLICK_RETENTION"); v != "" {
+		retention, err := config.ParseRetention(v)
+		if err != nil || retention <= 0 {
+			return nil, errors.New("error: CLICK_RETENTION must be a positive duration (e.g. \"720h\" or \"90d\")")
+		}
+
+		p.retention = retention
+	}
+
+	return &p, nil
+}
+
+// enabled - Метод, проверяющий, включено ли обезличивание IP адресов
+func (p *ipPrivacy) enabled() bool {
+	return p.mode != ipPrivacyOff
+}
+
+// anonymize - Метод, возвращающий IP адрес клиента в виде, допустимом для хранения
+// (в режиме "off" адрес не изменяется)
+func (p *ipPrivacy) anonymize(ip string) string {
+
+	switch p.mode {
+	case ipPrivacyTruncate:
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return ""
+		}
+
+		if v4 := parsed.To4(); v4 != nil {
+			return v4.Mask(net.CIDRMask(24, 32)).String()
+		}
+
+		return parsed.Mask(net.CIDRMask(48, 128)).String()
+	case ipPrivacyHash:
+		mac := hmac.New(sha256.New, p.salt)
+		mac.Write([]byte(ip))
+
+		return hex.EncodeToString(mac.Sum(nil)[:16])
+	default:
+		return ip
+	}
+}
+
+// storedIP - Метод, возвращающий IP адрес, сохраняемый с переходом (пустая строка, если обезличивание выключено)
+func (p *ipPrivacy) storedIP(ip string) string {
+
+	if !p.enabled() {
+		return ""
+	}
+
+	return p.anonymize(ip)
+}
+
+// purgeClicks - Метод, реализующий периодическое удаление переходов старше срока хранения
+// (ведущим экземпляром, до отмены контекста сервера; из БД и из ClickHouse, если он настроен)
+func (s *Server) purgeClicks() {
+
+	ticker := time.NewTicker(clickPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.isLeader() {
+			before := time.Now().Add(-s.privacy.retention)
+
+			deleted, err := s.db.DeleteClicksBefore(s.context, before)
+			if err != nil {
+				s.logger.Error("Failed to purge old clicks", "error", err)
+			} else if deleted != 0 {
+				s.logger.Info("Old clicks were purged", "count", deleted, "retention", s.privacy.retention.String())
+			}
+
+			if s.clickhouse != nil {
+				if err = s.clickhouse.DeleteClicksBefore(s.context, before); err != nil {
+					s.logger.Error("Failed to purge old ClickHouse clicks", "error", err)
+				}
+			}
+		}
+
+		select {
+		case <-s.context.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
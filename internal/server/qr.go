@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"github.com/skip2/go-qrcode"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultQRSize = 256  // Размер QR кода по умолчанию (в пикселях)
+	minQRSize     = 64   // Минимальный размер QR кода
+	maxQRSize     = 2048 // Максимальный размер QR кода
+)
+
+// qrLevels - Соответствие параметра запроса уровню коррекции ошибок QR кода
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// GetLinkQR - Метод, реализующий обработку "Get" запроса на получение QR кода короткой ссылки
+// (параметры: format=png|svg, size - размер в пикселях, level=L|M|Q|H - уровень коррекции ошибок)
+func (s *Server) GetLinkQR(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	row, isExist := s.workspaceLink(w, r, shortUrlOnDomain(linkDomain(r), ps.ByName("code")))
+	if !isExist {
+		return
+	}
+
+	level := qrcode.Medium
+	if l := r.URL.Query().Get("level"); l != "" {
+		var found bool
+		if level, found = qrLevels[strings.ToUpper(l)]; !found {
+			http.Error(w, "Error: Invalid error correction level (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Invalid QR error correction level", "level", l)
+			return
+		}
+	}
+
+	size := queryInt(r, "size", defaultQRSize, minQRSize, maxQRSize)
+
+	qr, err := qrcode.New(row.ShortUrl, level)
+	if err != nil {
+		http.Error(w, "Error: Failed to create QR code (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to create QR code", "error", err)
+		return
+	}
+
+	var (
+		contentType string
+		body        []byte
+	)
+
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "", "png":
+		contentType = "image/png"
+		body, err = qr.PNG(size)
+	case "svg":
+		contentType = "image/svg+xml"
+		body = qrSVG(qr.Bitmap(), size)
+	default:
+		http.Error(w, "Error: Invalid format (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid QR format")
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error: Failed to create QR code (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to encode QR code", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, err = w.Write(body)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to write response", "error", err)
+	}
+}
+
+// qrSVG - Функция, реализующая отрисовку матрицы QR кода в формате SVG
+func qrSVG(bitmap [][]bool, size int) []byte {
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, len(bitmap), len(bitmap))
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+
+	for y, line := range bitmap {
+		for x, dark := range line {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	b.WriteString(`"/></svg>`)
+
+	return []byte(b.String())
+}
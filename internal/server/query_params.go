@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"my_project/urlgen/database"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const maxQueryParams = 20 // Максимальное количество параметров, добавляемых к исходной ссылке
+
+// destinationUrl - Функция, реализующая получение адреса перехода на заданную исходную ссылку с добавленными
+// параметрами ссылки (в значениях подставляются {code}, {short_url} и {date}; параметры, уже заданные
+// в исходной ссылке, не заменяются)
+func destinationUrl(row *database.RowData, target, code string) string {
+
+	if len(row.QueryParams) == 0 {
+		return target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+
+	replacer := strings.NewReplacer(
+		"{code}", code,
+		"{short_url}", row.ShortUrl,
+		"{date}", time.Now().UTC().Format(time.DateOnly),
+	)
+
+	query := u.Query()
+	for name, value := range row.QueryParams {
+		if !query.Has(name) {
+			query.Set(name, replacer.Replace(value))
+		}
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// validateQueryParams - Функция, реализующая проверку параметров, добавляемых к исходной ссылке
+func validateQueryParams(params map[string]string) error {
+
+	if len(params) > maxQueryParams {
+		return errors.New("too many query params")
+	}
+
+	for name := range params {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("query param name is empty")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,408 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/database"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quotaTierRegexp - Регулярное выражение описания уровня квот ("имя:создания_в_сутки/всего_ссылок")
+var quotaTierRegexp = regexp.MustCompile(`^([A-Za-z0-9_-]+):(\d+)/(\d+)$`)
+
+// quotaTier - Тип данных, описывающий уровень квот ключей API (0 - без ограничения)
+type quotaTier struct {
+	Name         string // Название уровня
+	DailyCreates int    // Количество ссылок, создаваемых ключом за сутки (UTC)
+	Links        int    // Количество действующих ссылок, созданных ключом
+}
+
+// unlimited - Метод, проверяющий, что уровень не ограничивает ключ
+func (t quotaTier) unlimited() bool {
+	return t.DailyCreates == 0 && t.Links == 0
+}
+
+// exceeded - Метод, проверяющий, исчерпал ли ключ API с заданным использованием одну из квот уровня
+func (t quotaTier) exceeded(usage database.ApiKeyUsage) bool {
+	return (t.Links != 0 && usage.Links >= t.Links) || (t.DailyCreates != 0 && usage.CreatesToday >= t.DailyCreates)
+}
+
+// quotaPolicy - Тип данных, описывающий уровни квот ключей API
+type quotaPolicy struct {
+	tiers       map[string]quotaTier // Уровни по названию
+	defaultTier string               // Уровень ключей без назначенного уровня (пустая строка - без ограничения)
+}
+
+// quotaPolicyFromEnv - Функция, позволяющая получить уровни квот из переменных окружения
+// (API_KEY_TIERS - список уровней через запятую, например "free:100/1000,pro:10000/0",
+// API_KEY_DEFAULT_TIER - уровень ключей, которым уровень не назначен)
+func quotaPolicyFromEnv() (*quotaPolicy, error) {
+
+	p := quotaPolicy{tiers: map[string]quotaTier{}, defaultTier: os.Getenv("API_KEY_DEFAULT_TIER")}
+
+	for _, item := range strings.Split(os.Getenv("API_KEY_TIERS"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		m := quotaTierRegexp.FindStringSubmatch(item)
+		if m == nil {
+			return nil, errors.New("error: API_KEY_TIERS must be a list of name:daily_creates/links")
+		}
+
+		daily, err1 := strconv.Atoi(m[2])
+		links, err2 := strconv.Atoi(m[3])
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("error: API_KEY_TIERS limits are too large")
+		}
+
+		p.tiers[m[1]] = quotaTier{Name: m[1], DailyCreates: daily, Links: links}
+	}
+
+	if _, found := p.tiers[p.defaultTier]; p.defaultTier != "" && !found {
+		return nil, errors.New("error: API_KEY_DEFAULT_TIER is not listed in API_KEY_TIERS")
+	}
+
+	return &p, nil
+}
+
+// tier - Метод, возвращающий уровень квот ключа API по названию назначенного ему уровня
+// (уровень, удаленный из настроек, заменяется уровнем по умолчанию)
+func (p *quotaPolicy) tier(name string) quotaTier {
+
+	if t, found := p.tiers[name]; found {
+		return t
+	}
+
+	return p.tiers[p.defaultTier]
+}
+
+// quotaStore - Интерфейс хранилища использования ключей API
+type quotaStore interface {
+	LockApiKeyQuota(ctx context.Context, keyId int) (func(), error)              // Блокировка квоты ключа
+	GetApiKeyUsage(ctx context.Context, keyId int) (database.ApiKeyUsage, error) // Использование ключа
+}
+
+// quotaLocks - Тип данных, реализующий очередь запросов одного ключа API на экземпляре сервера
+// (ожидающие запросы не занимают подключения к БД, пока ключ заблокирован другим запросом этого экземпляра)
+type quotaLocks struct {
+	mu    sync.Mutex
+	slots map[int]*quotaSlot // Очереди по ключу API
+}
+
+// quotaSlot - Тип данных, описывающий очередь запросов ключа API
+type quotaSlot struct {
+	held    chan struct{} // Занятость ключа (емкость 1)
+	waiters int           // Количество запросов в очереди, включая выполняющийся
+}
+
+// lock - Метод, реализующий ожидание очереди ключа API до отмены контекста (возвращает функцию освобождения)
+func (q *quotaLocks) lock(ctx context.Context, keyId int) (func(), error) {
+
+	q.mu.Lock()
+	if q.slots == nil {
+		q.slots = map[int]*quotaSlot{}
+	}
+	slot, found := q.slots[keyId]
+	if !found {
+		slot = &quotaSlot{held: make(chan struct{}, 1)}
+		q.slots[keyId] = slot
+	}
+	slot.waiters++
+	q.mu.Unlock()
+
+	leave := func() {
+		q.mu.Lock()
+		if slot.waiters--; slot.waiters == 0 {
+			delete(q.slots, keyId)
+		}
+		q.mu.Unlock()
+	}
+
+	select {
+	case slot.held <- struct{}{}:
+	case <-ctx.Done():
+		leave()
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-slot.held
+		leave()
+	}, nil
+}
+
+// lockKeyQuota - Метод, реализующий блокировку квоты ключа API на время проверки квоты и создания ссылок
+// (сначала на экземпляре, затем в БД для всех экземпляров)
+func (s *Server) lockKeyQuota(ctx context.Context, keyId int) (func(), error) {
+
+	unlock, err := s.quotaLocks.lock(ctx, keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.quota.LockApiKeyQuota(ctx, keyId)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+
+	return func() {
+		release()
+		unlock()
+	}, nil
+}
+
+// quotaContextKey - Тип данных, описывающий ключ для хранения остатка квоты в контексте запроса
+type quotaContextKey struct{}
+
+// nextQuotaReset - Функция, возвращающая время сброса суточной квоты (начало следующих суток UTC)
+func nextQuotaReset() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// linkQuotaExceeded - Метод, проверяющий, исчерпал ли ключ API контекста квоту создания ссылок
+// (запрос без ключа API не ограничен; если квота не исчерпана, квота ключа остается заблокированной
+// до вызова возвращаемой функции, которая вызывается после создания ссылки)
+func (s *Server) linkQuotaExceeded(ctx context.Context) (bool, func(), error) {
+
+	access := workspaceFromContext(ctx)
+
+	tier := s.live().quotas.tier(access.Tier)
+	if access.KeyId == 0 || tier.unlimited() {
+		return false, func() {}, nil
+	}
+
+	release, err := s.lockKeyQuota(ctx, access.KeyId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to lock api key quota", "key_id", access.KeyId, "error", err)
+		return false, nil, err
+	}
+
+	usage, err := s.quota.GetApiKeyUsage(ctx, access.KeyId)
+	if err != nil {
+		release()
+		s.logger.ErrorContext(ctx, "Failed to read api key usage", "key_id", access.KeyId, "error", err)
+		return false, nil, err
+	}
+
+	if tier.exceeded(usage) {
+		release()
+		s.logger.WarnContext(ctx, "Link quota exceeded", "key_id", access.KeyId, "tier", tier.Name)
+		return true, nil, nil
+	}
+
+	return false, release, nil
+}
+
+// withQuota - Метод, реализующий промежуточный обработчик квот создания ссылок ключами API
+// (запрос пользователя пропускается без ограничения, остаток квоты передается в контексте
+// для пакетного создания; запросы одного ключа выполняются по очереди, чтобы не превысить квоту вместе)
+func (s *Server) withQuota(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		access := workspaceFromContext(r.Context())
+
+		tier := s.live().quotas.tier(access.Tier)
+		if access.KeyId == 0 || tier.unlimited() {
+			next(w, r, ps)
+			return
+		}
+
+		release, err := s.lockKeyQuota(r.Context(), access.KeyId)
+		if err != nil {
+			http.Error(w, "Error: Failed to lock api key quota (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to lock api key quota", "key_id", access.KeyId, "error", err)
+			return
+		}
+		defer release()
+
+		usage, err := s.quota.GetApiKeyUsage(r.Context(), access.KeyId)
+		if err != nil {
+			http.Error(w, "Error: Failed to read api key usage (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to read api key usage", "key_id", access.KeyId, "error", err)
+			return
+		}
+
+		// Остаток квоты - наименьший из остатков суточной квоты и квоты действующих ссылок (-1 - без ограничения)
+		remaining := -1
+
+		if tier.Links != 0 {
+			remaining = max(tier.Links-usage.Links, 0)
+			if remaining == 0 {
+				http.Error(w, "Error: Link quota exceeded (status code: 429)", http.StatusTooManyRequests)
+				s.logger.WarnContext(r.Context(), "Link quota exceeded", "key_id", access.KeyId, "tier", tier.Name)
+				return
+			}
+		}
+
+		if tier.DailyCreates != 0 {
+			daily := max(tier.DailyCreates-usage.CreatesToday, 0)
+			if daily == 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(nextQuotaReset()).Seconds())+1))
+				http.Error(w, "Error: Daily create quota exceeded (status code: 429)", http.StatusTooManyRequests)
+				s.logger.WarnContext(r.Context(), "Daily create quota exceeded", "key_id", access.KeyId, "tier", tier.Name)
+				return
+			}
+
+			if remaining == -1 || daily < remaining {
+				remaining = daily
+			}
+		}
+
+		w.Header().Set("X-Quota-Remaining", strconv.Itoa(remaining))
+
+		next(w, r.WithContext(context.WithValue(r.Context(), quotaContextKey{}, remaining)), ps)
+	}
+}
+
+// quotaRemaining - Функция, позволяющая получить остаток квоты ключа API из контекста запроса
+// (false, если запрос не ограничен квотой)
+func quotaRemaining(ctx context.Context) (int, bool) {
+	remaining, ok := ctx.Value(quotaContextKey{}).(int)
+	return remaining, ok
+}
+
+// KeyUsage - Тип данных, описывающий использование ключа API и его квоты в API (0 - без ограничения)
+type KeyUsage struct {
+	KeyId        int       `json:"key_id"`         // Идентификатор ключа
+	Tier         string    `json:"tier,omitempty"` // Уровень квот
+	CreatesToday int       `json:"creates_today"`  // Ссылки, созданные с начала суток (UTC)
+	DailyLimit   int       `json:"daily_limit"`    // Суточная квота создания ссылок
+	Links        int       `json:"links"`          // Действующие ссылки, созданные ключом
+	LinkLimit    int       `json:"link_limit"`     // Квота действующих ссылок
+	ResetsAt     time.Time `json:"resets_at"`      // Время сброса суточной квоты
+}
+
+// TierRequest - Тип данных, описывающий тело запроса на назначение ключу API уровня квот
+type TierRequest struct {
+	Tier string `json:"tier"` // Уровень квот (пустая строка - уровень по умолчанию)
+}
+
+// writeKeyUsage - Метод, реализующий запись ответа с использованием ключа API
+func (s *Server) writeKeyUsage(w http.ResponseWriter, r *http.Request, keyId int, tierName string) {
+
+	usage, err := s.db.GetApiKeyUsage(r.Context(), keyId)
+	if err != nil {
+		http.Error(w, "Error: Failed to read api key usage (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read api key usage", "key_id", keyId, "error", err)
+		return
+	}
+
+	tier := s.live().quotas.tier(tierName)
+
+	s.writeJSON(w, http.StatusOK, KeyUsage{
+		KeyId:        keyId,
+		Tier:         tier.Name,
+		CreatesToday: usage.CreatesToday,
+		DailyLimit:   tier.DailyCreates,
+		Links:        usage.Links,
+		LinkLimit:    tier.Links,
+		ResetsAt:     nextQuotaReset(),
+	})
+}
+
+// GetOwnKeyUsage - Метод, реализующий обработку "Get" запроса ключа API на получение своего использования
+func (s *Server) GetOwnKeyUsage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	access := workspaceFromContext(r.Context())
+	if access.KeyId == 0 {
+		http.Error(w, "Error: Api key required (status code: 403)", http.StatusForbidden)
+		s.logger.WarnContext(r.Context(), "Api key required")
+		return
+	}
+
+	s.writeKeyUsage(w, r, access.KeyId, access.Tier)
+}
+
+// GetKeyUsage - Метод, реализующий обработку "Get" запроса на получение использования ключа API рабочего пространства
+func (s *Server) GetKeyUsage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	workspaceId := workspaceIdFromContext(r.Context())
+
+	key, found := s.workspaceApiKey(r.Context(), workspaceId, ps.ByName("id"))
+	if !found {
+		http.Error(w, "Error: Api key not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Api key not found", "workspace_id", workspaceId, "key_id", ps.ByName("id"))
+		return
+	}
+
+	s.writeKeyUsage(w, r, key.Id, key.Tier)
+}
+
+// workspaceApiKey - Метод, реализующий поиск ключа API рабочего пространства по идентификатору из пути
+func (s *Server) workspaceApiKey(ctx context.Context, workspaceId int, idStr string) (*database.ApiKeyData, bool) {
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, false
+	}
+
+	keys, err := s.db.ListApiKeys(ctx, workspaceId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to read api keys", "error", err)
+		return nil, false
+	}
+
+	for _, k := range keys {
+		if k.Id == id {
+			return &k, true
+		}
+	}
+
+	return nil, false
+}
+
+// SetKeyTier - Метод, реализующий обработку "Patch" запроса оператора на назначение ключу API уровня квот
+func (s *Server) SetKeyTier(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	req := TierRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	if _, found := s.live().quotas.tiers[req.Tier]; req.Tier != "" && !found {
+		http.Error(w, "Error: Unknown quota tier (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Unknown quota tier", "tier", req.Tier)
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.Error(w, "Error: Api key not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Api key not found")
+		return
+	}
+
+	key, found, err := s.db.SetApiKeyTier(r.Context(), id, req.Tier)
+	if err != nil {
+		http.Error(w, "Error: Failed to save api key (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save api key", "error", err)
+		return
+	}
+
+	if !found {
+		http.Error(w, "Error: Api key not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Api key not found", "key_id", id)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Api key tier was changed", "key_id", key.Id, "tier", req.Tier)
+	s.auditGlobal(r.Context(), auditApiKeyTier, strconv.Itoa(key.Id),
+		map[string]any{"workspace_id": key.WorkspaceId, "tier": req.Tier})
+
+	s.writeJSON(w, http.StatusOK, apiKeyFromData(*key))
+}
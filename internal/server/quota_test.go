@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/database"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeQuotaStore - Тип данных, реализующий хранилище использования ключей API в памяти
+// (блокировка ключа общая для всех серверов, как рекомендательная блокировка в БД)
+type fakeQuotaStore struct {
+	lock  sync.Mutex
+	mu    sync.Mutex
+	links int
+}
+
+// LockApiKeyQuota - Метод, реализующий блокировку квоты ключа API
+func (f *fakeQuotaStore) LockApiKeyQuota(_ context.Context, _ int) (func(), error) {
+	f.lock.Lock()
+	return f.lock.Unlock, nil
+}
+
+// GetApiKeyUsage - Метод, возвращающий использование ключа API
+func (f *fakeQuotaStore) GetApiKeyUsage(_ context.Context, _ int) (database.ApiKeyUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return database.ApiKeyUsage{Links: f.links, CreatesToday: f.links}, nil
+}
+
+// create - Метод, реализующий создание ссылки после задержки проверки и сохранения
+func (f *fakeQuotaStore) create() {
+	time.Sleep(time.Millisecond)
+
+	f.mu.Lock()
+	f.links++
+	f.mu.Unlock()
+}
+
+// quotaTestServers - Функция, реализующая создание серверов без БД с общим хранилищем квот
+// и уровнем квот по умолчанию с заданным количеством ссылок
+func quotaTestServers(store quotaStore, links, count int) []*Server {
+
+	servers := make([]*Server, count)
+
+	for i := range servers {
+		s := testServer()
+		s.quota = store
+		s.settings.Store(&liveSettings{quotas: &quotaPolicy{
+			tiers:       map[string]quotaTier{"free": {Name: "free", Links: links}},
+			defaultTier: "free",
+		}})
+
+		servers[i] = s
+	}
+
+	return servers
+}
+
+func TestWithQuotaConcurrent(t *testing.T) {
+
+	const limit, requests = 5, 40
+
+	store := &fakeQuotaStore{}
+	servers := quotaTestServers(store, limit, 2)
+	key := &workspaceAccess{Id: 7, Role: roleMember, KeyId: 1}
+
+	var wg sync.WaitGroup
+	codes := make(chan int, requests)
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(s *Server) {
+			defer wg.Done()
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/links", nil)
+			r = r.WithContext(context.WithValue(r.Context(), workspaceContextKey{}, key))
+
+			rec := httptest.NewRecorder()
+			s.withQuota(func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+				store.create()
+				w.WriteHeader(http.StatusCreated)
+			})(rec, r, nil)
+
+			codes <- rec.Code
+		}(servers[i%len(servers)])
+	}
+
+	wg.Wait()
+	close(codes)
+
+	created := 0
+	for code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusTooManyRequests:
+		default:
+			t.Errorf("withQuota() status = %d", code)
+		}
+	}
+
+	if created != limit || store.links != limit {
+		t.Errorf("withQuota() created %d links (%d stored), want %d", created, store.links, limit)
+	}
+}
+
+func TestLinkQuotaExceededConcurrent(t *testing.T) {
+
+	const limit, requests = 5, 40
+
+	store := &fakeQuotaStore{}
+	servers := quotaTestServers(store, limit, 2)
+	ctx := context.WithValue(context.Background(), workspaceContextKey{},
+		&workspaceAccess{Id: 7, Role: roleMember, KeyId: 1})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(s *Server) {
+			defer wg.Done()
+
+			exceeded, release, err := s.linkQuotaExceeded(ctx)
+			if err != nil {
+				t.Errorf("linkQuotaExceeded() error = %v", err)
+				return
+			}
+			if exceeded {
+				return
+			}
+			defer release()
+
+			store.create()
+		}(servers[i%len(servers)])
+	}
+
+	wg.Wait()
+
+	if store.links != limit {
+		t.Errorf("linkQuotaExceeded() allowed %d links, want %d", store.links, limit)
+	}
+}
+
+func TestQuotaLocksCanceled(t *testing.T) {
+
+	var locks quotaLocks
+
+	unlock, err := locks.lock(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("lock() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err = locks.lock(ctx, 1); err == nil {
+		t.Errorf("lock() of a held key succeeded")
+	}
+
+	unlock()
+
+	if len(locks.slots) != 0 {
+		t.Errorf("lock() left %d queues after release", len(locks.slots))
+	}
+}
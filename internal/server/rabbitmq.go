@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"my_project/urlgen/config"
+	"os"
+	"sync"
+	"time"
+)
+
+// rabbitmqPublisher - Тип данных, реализующий отправку сообщений потока событий в обменник RabbitMQ
+// с подтверждением публикации брокером
+type rabbitmqPublisher struct {
+	url         string                        // Адрес брокера
+	exchange    string                        // Обменник (пустой - обменник по умолчанию)
+	routingKeys map[string]string             // Ключи маршрутизации по потоку событий
+	result      func(messages int, err error) // Учет результата отправки
+
+	mu     sync.Mutex          // Защита признака закрытия при добавлении в очередь
+	closed bool                // Признак закрытия отправки
+	queue  chan []EventMessage // Очередь пачек сообщений на отправку
+	done   chan struct{}       // Закрывается после отправки оставшихся сообщений
+
+	// Подключение используется только горутиной отправки
+	conn    *amqp.Connection // Подключение к брокеру
+	channel *amqp.Channel    // Канал в режиме подтверждения публикаций
+	retryAt time.Time        // Время следующей попытки подключения после ошибки
+	lastErr error            // Ошибка последней попытки подключения
+}
+
+// rabbitmqFromEnv - Функция, позволяющая получить отправку событий в RabbitMQ из переменных окружения
+// (RABBITMQ_URL - адрес брокера "amqp://" или "amqps://", nil, если не задан; RABBITMQ_EXCHANGE - обменник,
+// создаваемый как устойчивый обменник вида "topic", если его нет; RABBITMQ_CLICKS_ROUTING_KEY и
+// RABBITMQ_LINKS_ROUTING_KEY - ключи маршрутизации переходов и событий ссылок; подключение выполняется
+// и восстанавливается в фоне)
+func rabbitmqFromEnv(result func(messages int, err error)) (*rabbitmqPublisher, error) {
+
+	addr := os.Getenv("RABBITMQ_URL")
+	if addr == "" {
+		return nil, nil
+	}
+
+	if _, err := amqp.ParseURI(addr); err != nil {
+		return nil, errors.New("error: RABBITMQ_URL must be an amqp:// or amqps:// url")
+	}
+
+	exchange, found := os.LookupEnv("RABBITMQ_EXCHANGE")
+	if !found {
+		exchange = config.RabbitMQExchange
+	}
+
+	p := rabbitmqPublisher{
+		url:      addr,
+		exchange: exchange,
+		result:   result,
+		queue:    make(chan []EventMessage, config.RabbitMQQueueSize),
+		done:     make(chan struct{}),
+		routingKeys: map[string]string{
+			streamClicks: os.Getenv("RABBITMQ_CLICKS_ROUTING_KEY"),
+			streamLinks:  os.Getenv("RABBITMQ_LINKS_ROUTING_KEY"),
+		},
+	}
+
+	for stream, key := range p.routingKeys {
+		if key == "" {
+			p.routingKeys[stream] = config.StreamTopicPrefix + stream
+		}
+	}
+
+	go p.run()
+
+	return &p, nil
+}
+
+// Publish - Метод, реализующий добавление сообщений в очередь отправки в RabbitMQ
+// (при заполненной очереди сообщения отбрасываются и учитываются как неотправленные)
+func (p *rabbitmqPublisher) Publish(messages []EventMessage) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		p.result(len(messages), errors.New("error: RabbitMQ publisher is closed"))
+		return
+	}
+
+	select {
+	case p.queue <- messages:
+	default:
+		p.result(len(messages), errors.New("error: RabbitMQ publish queue is full"))
+	}
+}
+
+// Close - Метод, реализующий отправку оставшихся сообщений и закрытие подключения к брокеру
+func (p *rabbitmqPublisher) Close() error {
+
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(config.RabbitMQCloseTimeout):
+		return errors.New("error: timed out waiting for RabbitMQ confirms")
+	}
+}
+
+// run - Метод, реализующий отправку пачек сообщений из очереди до закрытия отправки
+func (p *rabbitmqPublisher) run() {
+
+	defer close(p.done)
+	defer p.disconnect()
+
+	for messages := range p.queue {
+		p.send(messages)
+	}
+}
+
+// send - Метод, реализующий публикацию пачки сообщений и ожидание их подтверждения брокером
+// (заголовки "event" и "key" содержат тип события и короткую ссылку; сообщения сохраняются на диске брокера)
+func (p *rabbitmqPublisher) send(messages []EventMessage) {
+
+	channel, err := p.connect()
+	if err != nil {
+		p.result(len(messages), err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.RabbitMQConfirmTimeout)
+	defer cancel()
+
+	confirms := make([]*amqp.DeferredConfirmation, 0, len(messages))
+
+	for i, m := range messages {
+		confirm, err := channel.PublishWithDeferredConfirmWithContext(ctx, p.exchange, p.routingKeys[m.Stream], false, false,
+			amqp.Publishing{
+				Headers:      amqp.Table{"event": m.Event, "key": m.Key},
+				ContentType:  "application/json",
+				DeliveryMode: amqp.Persistent,
+				Timestamp:    time.Now(),
+				Type:         m.Event,
+				AppId:        "urlgen",
+				Body:         m.Value,
+			})
+		if err != nil {
+			p.result(len(messages)-i, err)
+			p.disconnect()
+			break
+		}
+
+		confirms = append(confirms, confirm)
+	}
+
+	var (
+		published int
+		failed    int
+		lastErr   error
+	)
+
+	// При закрытии канала неподтвержденные сообщения считаются отклоненными
+	for _, confirm := range confirms {
+		acked, err := confirm.WaitContext(ctx)
+		switch {
+		case err != nil:
+			failed++
+			lastErr = errors.New("error: timed out waiting for RabbitMQ confirm")
+		case !acked:
+			failed++
+			lastErr = errors.New("error: message rejected by RabbitMQ")
+		default:
+			published++
+		}
+	}
+
+	if published != 0 {
+		p.result(published, nil)
+	}
+	if failed != 0 {
+		p.result(failed, lastErr)
+	}
+}
+
+// connect - Метод, возвращающий открытый канал к брокеру с подключением при необходимости
+// (после ошибки подключения повторная попытка выполняется не раньше, чем через RabbitMQRetryDelay)
+func (p *rabbitmqPublisher) connect() (*amqp.Channel, error) {
+
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel, nil
+	}
+
+	if time.Now().Before(p.retryAt) {
+		return nil, p.lastErr
+	}
+
+	p.disconnect()
+
+	err := p.dial()
+	if err != nil {
+		p.disconnect()
+		p.retryAt = time.Now().Add(config.RabbitMQRetryDelay)
+		p.lastErr = err
+		return nil, err
+	}
+
+	return p.channel, nil
+}
+
+// dial - Метод, реализующий подключение к брокеру, проверку обменника и включение подтверждений публикаций
+func (p *rabbitmqPublisher) dial() error {
+
+	properties := amqp.NewConnectionProperties()
+	properties.SetClientConnectionName("urlgen")
+
+	conn, err := amqp.DialConfig(p.url, amqp.Config{Locale: "en_US", Properties: properties})
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+
+	if p.channel, err = conn.Channel(); err != nil {
+		return err
+	}
+
+	if p.exchange != "" {
+		// Неудачная пассивная проверка закрывает канал, поэтому обменник создается в новом канале
+		if err = p.channel.ExchangeDeclarePassive(p.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+			if p.channel, err = conn.Channel(); err != nil {
+				return err
+			}
+			if err = p.channel.ExchangeDeclare(p.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return p.channel.Confirm(false)
+}
+
+// disconnect - Метод, реализующий закрытие подключения к брокеру
+func (p *rabbitmqPublisher) disconnect() {
+
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+
+	p.conn, p.channel = nil, nil
+}
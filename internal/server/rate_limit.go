@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/redis"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitMemory = "memory" // Ограничение частоты в памяти экземпляра сервера
+	rateLimitRedis  = "redis"  // Ограничение частоты, общее для экземпляров сервера (Redis из REDIS_URL)
+)
+
+// rateLimitExempt - Маршруты, к которым ограничение частоты не применяется (проверки балансировщика)
+var rateLimitExempt = map[string]bool{"/healthz": true, "/readyz": true, "/version": true}
+
+// rateDecision - Тип данных, описывающий решение об очередном запросе клиента
+type rateDecision struct {
+	allowed    bool          // Разрешен ли запрос
+	remaining  int           // Количество запросов, оставшихся клиенту
+	retryAfter time.Duration // Время до следующего разрешенного запроса (для отклоненного запроса)
+}
+
+// rateLimiter - Интерфейс учета запросов клиентов (хранилище счетчиков ограничения частоты)
+type rateLimiter interface {
+	allow(ctx context.Context, key string, now time.Time) (rateDecision, error) // Учет запроса клиента
+}
+
+// rateLimit - Тип данных, описывающий ограничение частоты запросов к API с одного IP адреса
+type rateLimit struct {
+	limit   int           // Количество запросов за окно
+	window  time.Duration // Окно
+	limiter rateLimiter   // Хранилище счетчиков
+	logger  *slog.Logger  // Журнал
+}
+
+// rateLimitFromEnv - Функция, позволяющая получить ограничение частоты запросов из переменной RATE_LIMIT
+// (вида "количество/окно", например "100/1m"; nil, если не задано) и хранилище счетчиков
+// из RATE_LIMIT_BACKEND ("memory" по умолчанию или "redis" - общие счетчики всех экземпляров в Redis)
+func rateLimitFromEnv(client *redis.Client, logger *slog.Logger) (*rateLimit, error) {
+
+	v := os.Getenv("RATE_LIMIT")
+	if v == "" {
+		return nil, nil
+	}
+
+	count, period, found := strings.Cut(v, "/")
+	limit, err := strconv.Atoi(count)
+	if !found || err != nil || limit < 1 {
+		return nil, errors.New("error: RATE_LIMIT must look like requests/window, e.g. 100/1m")
+	}
+
+	window, err := time.ParseDuration(period)
+	if err != nil || window < time.Second {
+		return nil, errors.New("error: RATE_LIMIT window must be a duration of at least 1s")
+	}
+
+	l := rateLimit{limit: limit, window: window, logger: logger}
+
+	switch os.Getenv("RATE_LIMIT_BACKEND") {
+	case "", rateLimitMemory:
+		l.limiter = newMemoryRateLimiter(limit, window)
+	case rateLimitRedis:
+		if client == nil {
+			return nil, errors.New("error: RATE_LIMIT_BACKEND=redis requires REDIS_URL")
+		}
+		l.limiter = &redisRateLimiter{client: client, limit: limit, window: window}
+	default:
+		return nil, errors.New("error: RATE_LIMIT_BACKEND must be memory or redis")
+	}
+
+	return &l, nil
+}
+
+// middleware - Метод, реализующий промежуточный обработчик ограничения частоты запросов клиента
+// (сбой хранилища счетчиков или ответ дольше config.RateLimitTimeout не останавливает API:
+// запрос пропускается, ошибка журналируется)
+func (l *rateLimit) middleware(key func(r *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ctx, cancel := context.WithTimeout(r.Context(), config.RateLimitTimeout)
+		decision, err := l.limiter.allow(ctx, key(r), time.Now())
+		cancel()
+		if err != nil {
+			l.logger.ErrorContext(r.Context(), "Failed to check rate limit", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(decision.remaining, 0)))
+
+		if !decision.allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.retryAfter.Seconds()))))
+			http.Error(w, "Error: Too many requests (status code: 429)", http.StatusTooManyRequests)
+			l.logger.WarnContext(r.Context(), "Rate limit exceeded", "path", r.URL.Path)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// memoryRateLimiter - Тип данных, реализующий ограничение частоты "маркерной корзиной" в памяти
+// (корзина вмещает limit запросов и наполняется за окно; у каждого экземпляра сервера свои корзины)
+type memoryRateLimiter struct {
+	sync.Mutex
+	limit   int                     // Вместимость корзины
+	window  time.Duration           // Время наполнения пустой корзины
+	buckets map[string]*tokenBucket // Корзины клиентов
+	swept   time.Time               // Время последнего удаления полных корзин
+}
+
+// tokenBucket - Тип данных, описывающий корзину клиента
+type tokenBucket struct {
+	tokens  float64   // Доступные запросы
+	updated time.Time // Время последнего пересчета
+}
+
+// newMemoryRateLimiter - Функция, реализующая создание ограничения частоты в памяти
+func newMemoryRateLimiter(limit int, window time.Duration) *memoryRateLimiter {
+	return &memoryRateLimiter{limit: limit, window: window, buckets: map[string]*tokenBucket{}}
+}
+
+// allow - Метод, реализующий учет запроса клиента
+func (m *memoryRateLimiter) allow(_ context.Context, key string, now time.Time) (rateDecision, error) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	rate := float64(m.limit) / m.window.Seconds()
+
+	// Корзины, наполнившиеся с последнего запроса, не отличаются от новых и удаляются
+	if now.Sub(m.swept) >= m.window {
+		for k, b := range m.buckets {
+			if b.tokens+now.Sub(b.updated).Seconds()*rate >= float64(m.limit) {
+				delete(m.buckets, k)
+			}
+		}
+		m.swept = now
+	}
+
+	b, found := m.buckets[key]
+	if !found {
+		b = &tokenBucket{tokens: float64(m.limit), updated: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(m.limit), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return rateDecision{retryAfter: wait}, nil
+	}
+
+	b.tokens--
+
+	return rateDecision{allowed: true, remaining: int(b.tokens)}, nil
+}
+
+// redisRateLimitScript - Скрипт Redis, реализующий "скользящее окно": запросы прошлого окна учитываются
+// с весом оставшейся в текущем окне доли прошлого (KEYS - счетчики прошлого и текущего окна;
+// ARGV - лимит, окно и прошедшее время текущего окна в миллисекундах; ответ - признак разрешения и остаток)
+const redisRateLimitScript = `
+local previous = tonumber(redis.call('GET', KEYS[1]) or '0')
+local current = tonumber(redis.call('GET', KEYS[2]) or '0')
+local limit, window, elapsed = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local count = math.floor(previous * (window - elapsed) / window) + current
+if count >= limit then
+  return {0, 0}
+end
+redis.call('INCR', KEYS[2])
+redis.call('PEXPIRE', KEYS[2], window * 2)
+return {1, limit - count - 1}
+`
+
+// redisRateLimiter - Тип данных, реализующий ограничение частоты скользящим окном в Redis
+// (счетчики общие для всех экземпляров сервера; окна отсчитываются по часам экземпляров)
+type redisRateLimiter struct {
+	client *redis.Client // Клиент Redis
+	limit  int           // Количество запросов за окно
+	window time.Duration // Окно
+}
+
+// allow - Метод, реализующий учет запроса клиента одним вызовом скрипта
+func (l *redisRateLimiter) allow(ctx context.Context, key string, now time.Time) (rateDecision, error) {
+
+	window := l.window.Milliseconds()
+	index := now.UnixMilli() / window
+	elapsed := now.UnixMilli() - index*window
+
+	// Оба счетчика клиента в одном слоте кластера Redis
+	prefix := config.RateLimitKeyPrefix + "{" + key + "}:"
+
+	reply, err := l.client.Do(ctx, "EVAL", redisRateLimitScript, "2",
+		prefix+strconv.FormatInt(index-1, 10), prefix+strconv.FormatInt(index, 10),
+		strconv.Itoa(l.limit), strconv.FormatInt(window, 10), strconv.FormatInt(elapsed, 10))
+	if err != nil {
+		return rateDecision{}, err
+	}
+
+	items, ok := reply.([]any)
+	if !ok || len(items) != 2 {
+		return rateDecision{}, fmt.Errorf("error: unexpected Redis reply %v", reply)
+	}
+
+	allowed, _ := items[0].(int64)
+	remaining, _ := items[1].(int64)
+
+	if allowed == 0 {
+		// Запросы прошлого окна теряют вес к концу текущего окна
+		return rateDecision{retryAfter: time.Duration(window-elapsed) * time.Millisecond}, nil
+	}
+
+	return rateDecision{allowed: true, remaining: int(remaining)}, nil
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/redis"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimiter(t *testing.T) {
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		after time.Duration // Время запроса от начала
+		key   string
+		allow bool
+		left  int
+	}{
+		{"first", 0, "a", true, 2},
+		{"second", 0, "a", true, 1},
+		{"third", 0, "a", true, 0},
+		{"over limit", 0, "a", false, 0},
+		{"other client", 0, "b", true, 2},
+		{"partly refilled", 10 * time.Second, "a", false, 0},
+		{"one token refilled", 20 * time.Second, "a", true, 0},
+		{"full after window", 2 * time.Minute, "a", true, 2},
+	}
+
+	m := newMemoryRateLimiter(3, time.Minute)
+
+	for _, tt := range tests {
+		decision, err := m.allow(context.Background(), tt.key, start.Add(tt.after))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decision.allowed != tt.allow || decision.remaining != tt.left {
+			t.Errorf("%s: allow = %t, %d left, want %t, %d left", tt.name, decision.allowed, decision.remaining, tt.allow, tt.left)
+		}
+
+		if !decision.allowed && decision.retryAfter <= 0 {
+			t.Errorf("%s: retry after %s", tt.name, decision.retryAfter)
+		}
+	}
+}
+
+func TestMemoryRateLimiterRetryAfter(t *testing.T) {
+
+	m := newMemoryRateLimiter(2, 10*time.Second)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		_, _ = m.allow(context.Background(), "a", now)
+	}
+
+	decision, _ := m.allow(context.Background(), "a", now)
+	if decision.allowed || decision.retryAfter != 5*time.Second {
+		t.Errorf("decision = %+v, want retry after 5s", decision)
+	}
+
+	// Полные корзины удаляются по прошествии окна
+	_, _ = m.allow(context.Background(), "b", now.Add(time.Minute))
+	if _, found := m.buckets["a"]; found {
+		t.Error("full bucket was not swept")
+	}
+}
+
+// fakeRateRedis - Функция, реализующая сервер, который выполняет скрипт redisRateLimitScript над счетчиками в памяти
+func fakeRateRedis(t *testing.T) (*redis.Client, map[string]int) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	var mu sync.Mutex
+	counters := map[string]int{}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+
+					if len(args) != 8 || args[0] != "EVAL" || args[1] != redisRateLimitScript || args[2] != "2" {
+						_, _ = io.WriteString(conn, "-ERR unexpected command\r\n")
+						continue
+					}
+
+					limit, _ := strconv.Atoi(args[5])
+					window, _ := strconv.Atoi(args[6])
+					elapsed, _ := strconv.Atoi(args[7])
+
+					mu.Lock()
+					count := counters[args[3]]*(window-elapsed)/window + counters[args[4]]
+					reply := "*2\r\n:0\r\n:0\r\n"
+					if count < limit {
+						counters[args[4]]++
+						reply = fmt.Sprintf("*2\r\n:1\r\n:%d\r\n", limit-count-1)
+					}
+					mu.Unlock()
+
+					_, _ = io.WriteString(conn, reply)
+				}
+			}()
+		}
+	}()
+
+	client, err := redis.ClientCreate("redis://" + listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, counters
+}
+
+// readCommand - Функция, реализующая чтение команды RESP (массива строк)
+func readCommand(r *bufio.Reader) ([]string, error) {
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+
+	return args, nil
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+
+	client, counters := fakeRateRedis(t)
+	l := &redisRateLimiter{client: client, limit: 2, window: time.Minute}
+
+	start := time.UnixMilli(100 * time.Minute.Milliseconds())
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		allow bool
+		left  int
+	}{
+		{"first", 0, true, 1},
+		{"second", time.Second, true, 0},
+		{"over limit", 2 * time.Second, false, 0},
+		{"previous window weighs in", time.Minute + 10*time.Second, true, 0},
+		{"previous and current window", time.Minute + 15*time.Second, false, 0},
+		{"previous window faded", time.Minute + 40*time.Second, true, 0},
+		{"two windows later", 3 * time.Minute, true, 1},
+	}
+
+	for _, tt := range tests {
+		now := start.Add(tt.after)
+
+		decision, err := l.allow(context.Background(), "10.0.0.1", now)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if decision.allowed != tt.allow || decision.remaining != tt.left {
+			t.Errorf("%s: allow = %t, %d left, want %t, %d left", tt.name, decision.allowed, decision.remaining, tt.allow, tt.left)
+		}
+
+		if !decision.allowed {
+			elapsed := time.Duration(now.UnixMilli()%time.Minute.Milliseconds()) * time.Millisecond
+			if decision.retryAfter != time.Minute-elapsed {
+				t.Errorf("%s: retry after %s, want %s", tt.name, decision.retryAfter, time.Minute-elapsed)
+			}
+		}
+	}
+
+	// Счетчики клиента хранятся по окнам в одном слоте кластера
+	key := config.RateLimitKeyPrefix + "{10.0.0.1}:100"
+	if counters[key] != 2 {
+		t.Errorf("counters = %v, want 2 requests in %s", counters, key)
+	}
+}
+
+// stubLimiter - Тип данных, реализующий хранилище счетчиков с заданным ответом
+type stubLimiter struct {
+	decision rateDecision
+	err      error
+	hang     bool // Ждать ли отмены контекста
+}
+
+// allow - Метод, возвращающий заданный ответ
+func (s stubLimiter) allow(ctx context.Context, _ string, _ time.Time) (rateDecision, error) {
+
+	if s.hang {
+		<-ctx.Done()
+		return rateDecision{}, ctx.Err()
+	}
+
+	return s.decision, s.err
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		limiter    stubLimiter
+		status     int
+		remaining  string
+		retryAfter string
+	}{
+		{"allowed", stubLimiter{decision: rateDecision{allowed: true, remaining: 4}}, http.StatusOK, "4", ""},
+		{"rejected", stubLimiter{decision: rateDecision{retryAfter: 1500 * time.Millisecond}}, http.StatusTooManyRequests, "0", "2"},
+		{"store failure", stubLimiter{err: errors.New("error: down")}, http.StatusOK, "", ""},
+		{"store timeout", stubLimiter{hang: true}, http.StatusOK, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			l := &rateLimit{limit: 5, window: time.Minute, limiter: tt.limiter, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+			handler := l.middleware(func(r *http.Request) string { return "client" },
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/links", nil))
+
+			if elapsed := time.Since(start); elapsed > config.RateLimitTimeout+time.Second {
+				t.Errorf("request took %s", elapsed)
+			}
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if got := rec.Header().Get("X-RateLimit-Remaining"); got != tt.remaining {
+				t.Errorf("X-RateLimit-Remaining = %q, want %q", got, tt.remaining)
+			}
+
+			if got := rec.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.retryAfter)
+			}
+		})
+	}
+}
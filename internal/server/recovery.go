@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"my_project/urlgen/pkg/error_reporter"
+	"my_project/urlgen/pkg/request_id"
+	"net/http"
+	"runtime/debug"
+)
+
+// InternalErrorResponse - Тип данных, описывающий ответ на запрос, обработчик которого завершился паникой
+type InternalErrorResponse struct {
+	Status    int    `json:"status"`               // HTTP статус
+	Error     string `json:"error"`                // Описание ошибки
+	RequestId string `json:"request_id,omitempty"` // Идентификатор запроса (связывает ответ с журналом)
+}
+
+// recoverMiddleware - Метод, реализующий промежуточный обработчик, восстанавливающий работу после паники
+// обработчика запроса: паника журналируется со стеком вызовов, учитывается метрикой и отправляется
+// как сообщение об ошибке, а клиент получает ответ 500 в формате JSON
+// (если ответ уже начат, соединение разрывается, чтобы клиент не принял его за полный)
+func (s *Server) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			s.metrics.panics.Inc()
+			s.reporter.Report(r.Context(), error_reporter.Event{Message: "Handler panicked", Panic: v, Request: r})
+			s.logger.ErrorContext(r.Context(), "Handler panicked", "method", r.Method, "path", r.URL.Path,
+				"panic", fmt.Sprint(v), "stack", string(debug.Stack()), error_reporter.Reported())
+
+			if rec.written {
+				panic(http.ErrAbortHandler)
+			}
+
+			s.writeJSON(w, http.StatusInternalServerError, InternalErrorResponse{
+				Status:    http.StatusInternalServerError,
+				Error:     "Internal server error",
+				RequestId: request_id.FromContext(r.Context()),
+			})
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"my_project/urlgen/config"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	previewSuffix       = "+"                        // Суффикс короткой ссылки для показа страницы предпросмотра
+	previewFetchTimeout = 3 * time.Second            // Время ожидания загрузки страницы назначения для предпросмотра
+	previewFetchLimit   = 64 << 10                   // Максимальный объем читаемой страницы назначения
+	previewMaxRedirects = 3                          // Максимальное количество перенаправлений при загрузке страницы
+	redirectMethods     = "GET, HEAD, POST, OPTIONS" // Методы, допустимые для коротких ссылок
+)
+
+// previewClient - Клиент HTTP загрузки страниц назначения для предпросмотра (адрес проверяется при каждом
+// соединении, поэтому ни ссылка, ни перенаправление не приводят сервер к узлам частных сетей)
+var previewClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: previewFetchTimeout, Control: publicDialControl}).DialContext,
+		TLSHandshakeTimeout:   previewFetchTimeout,
+		ResponseHeaderTimeout: previewFetchTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       time.Minute,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= previewMaxRedirects {
+			return errors.New("too many redirects")
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return errors.New("redirect to an unsupported scheme")
+		}
+
+		return nil
+	},
+}
+
+// titleRegexp - Регулярное выражение для поиска заголовка страницы
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// previewTemplate - Шаблон страницы предпросмотра ссылки
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    <meta name="robots" content="noindex">
+    {{if .Refresh}}<meta http-equiv="refresh" content="{{.Refresh}}; url={{.Url}}">{{end}}
+    <title>Link preview</title>
+    <style>
+        body { font-family: sans-serif; max-width: 640px; margin: 48px auto; padding: 0 16px; color: #222; }
+        .url { word-break: break-all; font-size: 1.1em; }
+        .warn { color: #c33; }
+        a.button { display: inline-block; padding: 8px 16px; background: #8c8ad8; color: #fff; text-decoration: none; }
+    </style>
+</head>
+<body>
+<h1>Link preview</h1>
+<p>{{.ShortUrl}} leads to:</p>
+<p class="url">{{.Url}}</p>
+{{if .Title}}<p>Page title: <b>{{.Title}}</b></p>{{end}}
+<ul>
+    <li>Domain: <b>{{.Host}}</b></li>
+    {{if .Secure}}<li>The connection is encrypted (HTTPS)</li>{{else}}<li class="warn">The connection is not encrypted</li>{{end}}
+</ul>
+{{if .Refresh}}<p>You will be redirected in {{.Refresh}} seconds.</p>{{end}}
+<p><a class="button" href="{{.Url}}" rel="noopener noreferrer">Continue to the site</a></p>
+</body>
+</html>
+`))
+
+// previewData - Тип данных, описывающий данные страницы предпросмотра
+type previewData struct {
+	ShortUrl string // Короткая ссылка
+	Url      string // Исходная ссылка
+	Title    string // Заголовок страницы назначения
+	Host     string // Домен назначения
+	Secure   bool   // Используется ли HTTPS
+	Refresh  int    // Время до автоматического перехода, в секундах (0 - без перехода)
+}
+
+// Redirect - Метод, реализующий переход по короткой ссылке вида "/{code}"
+// (с суффиксом "+" или параметром preview=1 вместо перехода показывается страница предпросмотра,
+// с флагом interstitial переход выполняется промежуточной страницей,
+// "Post" запрос используется формой ввода пароля защищенной ссылки, "Head" запрос возвращает те же заголовки
+// без тела и по умолчанию не учитывается как переход, "Options" запрос возвращает допустимые методы)
+func (s *Server) Redirect(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+	case http.MethodOptions:
+		w.Header().Set("Allow", redirectMethods)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", redirectMethods)
+		http.Error(w, "Error: Method not allowed (status code: 405)", http.StatusMethodNotAllowed)
+		return
+	}
+
+	code := strings.TrimPrefix(r.URL.Path, "/")
+
+	preview := r.URL.Query().Get("preview") == "1"
+	if strings.HasSuffix(code, previewSuffix) {
+		code = strings.TrimSuffix(code, previewSuffix)
+		preview = true
+	}
+
+	if code == "" || strings.Contains(code, "/") {
+		s.writeNotFound(w, r, code)
+		return
+	}
+
+	shortUrl := shortUrlOnDomain(s.requestDomain(r), code)
+
+	// Коды без верной подписи отклоняются без обращения к БД, коды с неверным контрольным символом
+	// получают ответ об ошибке в коде вместо ответа о ненайденной ссылке
+	if !s.signedCodeAllowed(r.Context(), code, shortUrl) {
+		if s.signer.checksum && !s.signer.checksumValid(code) {
+			s.writeInvalidCode(w, r, code)
+			s.logger.WarnContext(r.Context(), "Invalid code checksum", "short_url", shortUrl)
+			return
+		}
+
+		s.writeNotFound(w, r, code)
+		s.logger.WarnContext(r.Context(), "Invalid code signature", "short_url", shortUrl)
+		return
+	}
+
+	row, isExist := s.resolve(r.Context(), shortUrl)
+	if !isExist {
+		if s.db.IsDeleted(r.Context(), shortUrl) {
+			s.writeGone(w, r, code)
+			s.logger.WarnContext(r.Context(), "Url was deleted", "short_url", shortUrl)
+			return
+		}
+
+		s.writeNotFound(w, r, code)
+		s.logger.WarnContext(r.Context(), "Url not found", "short_url", shortUrl)
+		return
+	}
+
+	if row.Expired() || row.Exhausted() {
+		s.writeGone(w, r, code)
+		s.logger.WarnContext(r.Context(), "Url expired", "short_url", shortUrl)
+		return
+	}
+
+	if row.Disabled {
+		s.writeDisabled(w, r, code)
+		s.logger.WarnContext(r.Context(), "Url is disabled", "short_url", shortUrl)
+		return
+	}
+
+	if row.NotYetLive() {
+		s.writeNotYetLive(w, r, code, row.ActiveFrom)
+		s.logger.WarnContext(r.Context(), "Url is not live yet", "short_url", shortUrl)
+		return
+	}
+
+	if !s.checkLinkPassword(w, r, row) {
+		return
+	}
+
+	if preview {
+		if s.warnMalicious(w, r, shortUrl, row.Url) {
+			return
+		}
+
+		s.writePreview(w, r, shortUrl, destinationUrl(row, row.Url, code), false)
+		return
+	}
+
+	target, variant := s.selectTarget(w, r, row, code)
+	destination := destinationUrl(row, target, code)
+
+	if s.warnMalicious(w, r, shortUrl, target) {
+		return
+	}
+
+	if r.Method != http.MethodHead || s.countHeadClicks {
+		ok, err := s.consumeClick(r.Context(), row)
+		if err != nil {
+			http.Error(w, "Error: Failed to count click (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to count click", "short_url", shortUrl, "error", err)
+			return
+		}
+
+		if !ok {
+			s.writeGone(w, r, code)
+			s.logger.WarnContext(r.Context(), "Url reached its click limit", "short_url", shortUrl)
+			return
+		}
+
+		s.recordClick(r, row, variant)
+	}
+
+	if s.flagEnabled(flagInterstitial, row.Domain, row.ShortUrl) {
+		s.writePreview(w, r, shortUrl, destination, true)
+		return
+	}
+
+	status := row.RedirectStatus
+	if status == 0 {
+		status = s.redirectStatus
+	}
+
+	if s.redirectCache.writeHeaders(w, r, row, destination, status) {
+		return
+	}
+
+	http.Redirect(w, r, destination, status)
+}
+
+// isRedirectStatus - Функция, проверяющая, допустим ли заданный статус перехода по короткой ссылке
+func isRedirectStatus(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+}
+
+// writePreview - Метод, реализующий запись страницы предпросмотра ссылки
+// (промежуточная страница флага interstitial переходит по ссылке сама, не загружая заголовок назначения,
+// и не кешируется, так как каждый ее показ учтен как переход)
+func (s *Server) writePreview(w http.ResponseWriter, r *http.Request, shortUrl, origUrl string, interstitial bool) {
+
+	data := previewData{
+		ShortUrl: shortUrl,
+		Url:      origUrl,
+	}
+
+	if u, err := url.Parse(origUrl); err == nil {
+		data.Host = u.Hostname()
+		data.Secure = u.Scheme == "https"
+
+		if !interstitial && (u.Scheme == "http" || u.Scheme == "https") {
+			data.Title = fetchTitle(r.Context(), origUrl)
+		}
+	}
+
+	if interstitial {
+		data.Refresh = config.InterstitialDelaySeconds
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := previewTemplate.Execute(w, data)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to write preview", "error", err)
+	}
+}
+
+// fetchTitle - Функция, реализующая получение заголовка страницы назначения (пустая строка при ошибке;
+// страницы частных сетей не загружаются, читается не больше previewFetchLimit байт)
+func fetchTitle(ctx context.Context, pageUrl string) string {
+
+	ctx, cancel := context.WithTimeout(ctx, previewFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageUrl, nil)
+	if err != nil {
+		return ""
+	}
+
+	resp, err := previewClient.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, previewFetchLimit))
+	if err != nil {
+		return ""
+	}
+
+	if m := titleRegexp.FindSubmatch(body); m != nil {
+		return strings.TrimSpace(string(m[1]))
+	}
+
+	return ""
+}
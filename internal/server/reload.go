@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"my_project/urlgen/config"
+	"os"
+	"time"
+)
+
+// liveSettings - Тип данных, описывающий настройки сервера, изменяемые без перезапуска
+// (набор заменяется целиком, поэтому запрос видит либо прежние, либо новые значения)
+type liveSettings struct {
+	quotas   *quotaPolicy  // Уровни квот ключей API
+	reserved reservedCodes // Коды, недоступные для коротких ссылок
+	blocked  codeFilter    // Слова, недопустимые в сгенерированных кодах
+	cacheTTL time.Duration // Время жизни значений кеша ссылок
+	strict   bool          // Режим списка разрешенных доменов
+	flags    flagRules     // Флаги функций по умолчанию (без изменений через API)
+}
+
+// liveSettingsFromEnv - Функция, позволяющая получить изменяемые без перезапуска настройки из переменных окружения
+// (API_KEY_TIERS, API_KEY_DEFAULT_TIER, RESERVED_CODES, CODE_BLOCKED_WORDS, CACHE_TTL, DOMAIN_POLICY, FEATURE_FLAGS)
+func liveSettingsFromEnv() (*liveSettings, error) {
+
+	quotas, err := quotaPolicyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheTTL := config.CacheDefaultExpiration
+	if v := os.Getenv("CACHE_TTL"); v != "" {
+		cacheTTL, err = time.ParseDuration(v)
+		if err != nil || cacheTTL <= 0 {
+			return nil, errors.New("error: CACHE_TTL must be a positive duration")
+		}
+	}
+
+	strict, err := domainModeFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := featureFlagsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return &liveSettings{
+		quotas:   quotas,
+		reserved: reservedCodesFromEnv(),
+		blocked:  codeFilterFromEnv(),
+		cacheTTL: cacheTTL,
+		strict:   strict,
+		flags:    flags,
+	}, nil
+}
+
+// live - Метод, возвращающий действующие изменяемые настройки сервера
+func (s *Server) live() *liveSettings {
+	return s.settings.Load()
+}
+
+// Reload - Метод, позволяющий применить изменяемые настройки из переменных окружения без перезапуска
+// (правила доменов и флаги функций перечитываются из БД; при ошибке действуют прежние настройки)
+func (s *Server) Reload() error {
+
+	live, err := liveSettingsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	if s.db != nil {
+		if err = s.domains.load(s.context, s.db); err != nil {
+			return err
+		}
+
+		if err = s.flags.load(s.context, s.db); err != nil {
+			return err
+		}
+	}
+
+	s.settings.Store(live)
+
+	s.domains.setMode(live.strict)
+	s.cacheWithShortUrlKey.SetDefaultExpiration(live.cacheTTL)
+	s.cacheWithOriginalUrlKey.SetDefaultExpiration(live.cacheTTL)
+
+	return nil
+}
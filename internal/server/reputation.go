@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/cache_manager"
+	"my_project/urlgen/pkg/reputation"
+	"net/http"
+	"os"
+)
+
+// confirmParam - Параметр запроса, подтверждающий переход по ссылке, признанной опасной
+const confirmParam = "confirm"
+
+// warningTemplate - Шаблон страницы предупреждения о переходе по опасной ссылке
+var warningTemplate = template.Must(template.New("warning").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    <meta name="robots" content="noindex">
+    <title>Warning: unsafe link</title>
+    <style>
+        body { font-family: sans-serif; max-width: 640px; margin: 48px auto; padding: 0 16px; color: #222; }
+        h1 { color: #c33; }
+        .url { word-break: break-all; }
+    </style>
+</head>
+<body>
+<h1>Warning: unsafe link</h1>
+<p>{{.ShortUrl}} leads to a site reported as <b>{{.Threat}}</b>:</p>
+<p class="url">{{.Url}}</p>
+<p>Visiting it may harm your computer or steal your personal information.</p>
+<p><a href="?{{.Confirm}}=1" rel="noopener noreferrer">Continue at your own risk</a></p>
+</body>
+</html>
+`))
+
+// warningPage - Тип данных, описывающий данные страницы предупреждения
+type warningPage struct {
+	ShortUrl string // Короткая ссылка
+	Url      string // Исходная ссылка
+	Threat   string // Тип угрозы
+	Confirm  string // Параметр подтверждения перехода
+}
+
+// urlReputation - Тип данных, описывающий проверку репутации исходных ссылок
+type urlReputation struct {
+	checker        reputation.Checker                       // Сервис проверки репутации
+	warnOnRedirect bool                                     // Показывать ли предупреждение при переходе по опасной ссылке
+	verdicts       *cache_manager.Cache[reputation.Verdict] // Кеш результатов проверки
+}
+
+// urlReputationFromEnv - Функция, позволяющая получить настройки проверки репутации из переменных окружения
+// (возвращает nil, если SAFE_BROWSING_API_KEY не задан)
+func urlReputationFromEnv(logger *slog.Logger) *urlReputation {
+
+	apiKey := os.Getenv("SAFE_BROWSING_API_KEY")
+	if apiKey == "" {
+		return nil
+	}
+
+	return &urlReputation{
+		checker:        reputation.SafeBrowsingCreate(apiKey),
+		warnOnRedirect: os.Getenv("SAFE_BROWSING_WARN") == "true",
+		verdicts:       cache_manager.CacheCreate[reputation.Verdict](config.CacheDefaultExpiration, config.CacheCleanupTime, logger),
+	}
+}
+
+// checkUrl - Метод, реализующий проверку репутации исходной ссылки с учетом кеша
+// (при ошибке сервиса ссылка считается безопасной)
+func (s *Server) checkUrl(ctx context.Context, url string) reputation.Verdict {
+
+	if s.reputation == nil {
+		return reputation.Verdict{}
+	}
+
+	// Поиск в кеше
+	if verdict, isExist := s.reputation.verdicts.Get(url); isExist {
+		return verdict
+	}
+
+	verdict, err := s.reputation.checker.Check(ctx, url)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to check url reputation", "url", url, "error", err)
+		return reputation.Verdict{}
+	}
+
+	if verdict.Malicious {
+		s.logger.WarnContext(ctx, "Url is flagged as malicious", "url", url, "threat", verdict.Threat)
+	}
+
+	s.reputation.verdicts.Set(url, verdict, 0)
+
+	return verdict
+}
+
+// rejectMalicious - Метод, реализующий отказ в создании ссылки на опасную страницу
+// (возвращает true, если ответ с ошибкой уже записан)
+func (s *Server) rejectMalicious(w http.ResponseWriter, r *http.Request, url string) bool {
+
+	verdict := s.checkUrl(r.Context(), url)
+	if !verdict.Malicious {
+		return false
+	}
+
+	http.Error(w, "Error: Url is flagged as malicious (status code: 422)", http.StatusUnprocessableEntity)
+
+	return true
+}
+
+// warnMalicious - Метод, реализующий показ предупреждения перед переходом по опасной ссылке
+// (возвращает true, если вместо перехода записана страница предупреждения)
+func (s *Server) warnMalicious(w http.ResponseWriter, r *http.Request, shortUrl, url string) bool {
+
+	if s.reputation == nil || !s.reputation.warnOnRedirect || r.URL.Query().Get(confirmParam) == "1" {
+		return false
+	}
+
+	verdict := s.checkUrl(r.Context(), url)
+	if !verdict.Malicious {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	err := warningTemplate.Execute(w, warningPage{
+		ShortUrl: shortUrl,
+		Url:      url,
+		Threat:   verdict.Threat,
+		Confirm:  confirmParam,
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Failed to write warning page", "error", err)
+	}
+
+	return true
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"my_project/urlgen/pkg/request_id"
+	"net/http"
+)
+
+// requestIdMiddleware - Метод, реализующий промежуточный обработчик идентификатора запроса
+// (идентификатор из заголовка X-Request-ID принимается, если он допустим, иначе генерируется новый;
+// он возвращается в ответе и передается в контексте журналу, обработчикам и запросам к БД)
+func (s *Server) requestIdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		id := r.Header.Get(request_id.Header)
+		if !request_id.Valid(id) {
+			id = request_id.Generate()
+		}
+
+		w.Header().Set(request_id.Header, id)
+
+		next.ServeHTTP(w, r.WithContext(request_id.NewContext(r.Context(), id)))
+	})
+}
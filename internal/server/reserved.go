@@ -0,0 +1,46 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// errReservedCode - Ошибка создания ссылки с зарезервированным кодом
+var errReservedCode = errors.New("error: Code is reserved")
+
+// defaultReservedCodes - Коды, зарезервированные по умолчанию (пути сервиса и нецензурные слова)
+var defaultReservedCodes = []string{
+	"api", "admin", "metrics", "healthz", "readyz", "get-short", "get-original",
+	"login", "logout", "static", "assets", "favicon.ico", "robots.txt",
+	"fuck", "shit", "cunt", "bitch", "dick", "cock", "pussy", "porn", "sex", "nigger", "faggot",
+}
+
+// reservedCodes - Тип данных, описывающий набор кодов, недоступных для коротких ссылок (без учета регистра)
+type reservedCodes map[string]struct{}
+
+// reservedCodesFromEnv - Функция, позволяющая получить набор зарезервированных кодов
+// (к кодам по умолчанию добавляются перечисленные через запятую в RESERVED_CODES)
+func reservedCodesFromEnv() reservedCodes {
+
+	codes := reservedCodes{}
+
+	for _, code := range defaultReservedCodes {
+		codes[code] = struct{}{}
+	}
+
+	for _, code := range strings.Split(os.Getenv("RESERVED_CODES"), ",") {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code != "" {
+			codes[code] = struct{}{}
+		}
+	}
+
+	return codes
+}
+
+// contains - Метод, проверяющий, зарезервирован ли заданный код
+func (c reservedCodes) contains(code string) bool {
+	_, found := c[strings.ToLower(code)]
+	return found
+}
@@ -1,18 +1,134 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/julienschmidt/httprouter"
-	"log"
+	"golang.org/x/crypto/bcrypt"
 	"my_project/urlgen/database"
-	"my_project/urlgen/pkg/generator"
 	"net/http"
+	"time"
 )
 
 // InitRoutes - Метод, инициализирующий обработчики запросов
 func (s *Server) initRoutes() {
-	s.router.POST("/get-short", s.GetShortUrl)
-	s.router.GET("/get-original", s.GetOriginalUrl)
+	s.handle(http.MethodPost, "/get-short", s.authenticate(s.withWorkspace(roleMember, s.withQuota(s.GetShortUrl))))
+	s.handle(http.MethodGet, "/get-original", s.GetOriginalUrl)
+
+	s.handle(http.MethodGet, "/healthz", s.Healthz)
+	s.handle(http.MethodGet, "/readyz", s.Readyz)
+	s.handle(http.MethodGet, "/version", s.Version)
+	s.router.Handler(http.MethodGet, "/metrics", s.metrics.handler())
+
+	s.handle(http.MethodPost, "/api/v1/auth/login", s.Login)
+	s.handle(http.MethodPost, "/api/v1/auth/register", s.Register)
+	s.handle(http.MethodPost, "/api/v1/auth/password-reset", s.RequestPasswordReset)
+	s.handle(http.MethodPost, "/api/v1/auth/password-reset/confirm", s.ConfirmPasswordReset)
+
+	s.handle(http.MethodGet, "/api/v1/me", s.requireAuth(s.GetProfile))
+	s.handle(http.MethodPatch, "/api/v1/me", s.requireAuth(s.UpdateProfile))
+	s.handle(http.MethodGet, "/api/v1/me/links", s.requireAuth(s.ListOwnLinks))
+
+	if s.oidc != nil {
+		s.handle(http.MethodGet, "/api/v1/auth/oidc/login", s.OIDCLogin)
+		s.handle(http.MethodGet, "/api/v1/auth/oidc/callback", s.OIDCCallback)
+	}
+
+	s.handle(http.MethodGet, "/api/v1/links", s.requireWorkspace(roleMember, s.ListLinks))
+	s.handle(http.MethodPost, "/api/v1/links", s.requireWorkspace(roleMember, s.withIdempotency(s.withQuota(s.CreateLink))))
+	s.handle(http.MethodPost, "/api/v1/links/bulk", s.requireWorkspace(roleMember, s.withIdempotency(s.withQuota(s.CreateLinksBulk))))
+	s.handle(http.MethodGet, "/api/v1/links/:code", s.requireWorkspace(roleMember, s.GetLink))
+	s.handle(http.MethodPatch, "/api/v1/links/:code", s.requireWorkspace(roleMember, s.UpdateLink))
+	s.handle(http.MethodDelete, "/api/v1/links/:code", s.requireWorkspace(roleMember, s.DeleteLink))
+	s.handle(http.MethodGet, "/api/v1/links/:code/clicks", s.requireWorkspace(roleMember, s.GetLinkClicks))
+	s.handle(http.MethodGet, "/api/v1/links/:code/qr", s.requireWorkspace(roleMember, s.GetLinkQR))
+	s.handle(http.MethodGet, "/api/v1/links/:code/stats", s.requireWorkspace(roleMember, s.GetLinkStats))
+	s.handle(http.MethodGet, "/api/v1/links/:code/stats/export", s.requireWorkspace(roleMember, s.ExportLinkStats))
+	s.handle(http.MethodGet, "/api/v1/stats", s.requireWorkspace(roleMember, s.GetWorkspaceStats))
+	s.handle(http.MethodGet, "/api/v1/stats/export", s.requireWorkspace(roleMember, s.ExportStats))
+
+	s.handle(http.MethodGet, "/api/v1/workspaces", s.requireAuth(s.ListWorkspaces))
+	s.handle(http.MethodPost, "/api/v1/workspaces", s.requireAuth(s.CreateWorkspace))
+	s.handle(http.MethodGet, "/api/v1/workspaces/:workspace/members", s.requireWorkspace(roleMember, s.ListMembers))
+	s.handle(http.MethodPost, "/api/v1/workspaces/:workspace/members", s.requireWorkspace(roleAdmin, s.AddMember))
+	s.handle(http.MethodDelete, "/api/v1/workspaces/:workspace/members/:user", s.requireWorkspace(roleAdmin, s.DeleteMember))
+	s.handle(http.MethodGet, "/api/v1/workspaces/:workspace/keys", s.requireWorkspace(roleAdmin, s.ListApiKeys))
+	s.handle(http.MethodPost, "/api/v1/workspaces/:workspace/keys", s.requireWorkspace(roleAdmin, s.CreateApiKey))
+	s.handle(http.MethodDelete, "/api/v1/workspaces/:workspace/keys/:id", s.requireWorkspace(roleAdmin, s.DeleteApiKey))
+	s.handle(http.MethodGet, "/api/v1/workspaces/:workspace/keys/:id/usage", s.requireWorkspace(roleAdmin, s.GetKeyUsage))
+	s.handle(http.MethodGet, "/api/v1/usage", s.requireAuth(s.GetOwnKeyUsage))
+
+	s.handle(http.MethodGet, "/api/v1/admin/domains", s.requireOperator(s.ListDomainRules))
+	s.handle(http.MethodPost, "/api/v1/admin/domains", s.requireOperator(s.AddDomainRule))
+	s.handle(http.MethodDelete, "/api/v1/admin/domains/:list/:domain", s.requireOperator(s.DeleteDomainRule))
+	s.handle(http.MethodPatch, "/api/v1/admin/keys/:id", s.requireOperator(s.SetKeyTier))
+	s.handle(http.MethodPost, "/api/v1/admin/links/bulk", s.requireWorkspace(roleAdmin, s.BulkLinkAction))
+	s.handle(http.MethodGet, "/api/v1/admin/audit", s.requireOperator(s.ListGlobalAuditLog))
+	s.handle(http.MethodGet, "/api/v1/admin/flags", s.requireOperator(s.ListFeatureFlags))
+	s.handle(http.MethodPut, "/api/v1/admin/flags/:name", s.requireOperator(s.SetFeatureFlag))
+	s.handle(http.MethodDelete, "/api/v1/admin/flags/:name", s.requireOperator(s.ResetFeatureFlag))
+	s.handle(http.MethodGet, "/api/v1/admin/log-level", s.requireOperator(s.GetLogLevel))
+	s.handle(http.MethodPut, "/api/v1/admin/log-level", s.requireOperator(s.SetLogLevel))
+	s.handle(http.MethodDelete, "/api/v1/admin/log-level", s.requireOperator(s.ResetLogLevel))
+	s.handle(http.MethodGet, "/api/v1/audit", s.requireWorkspace(roleAdmin, s.ListAuditLog))
+
+	s.handle(http.MethodGet, "/api/v1/webhooks", s.requireWorkspace(roleAdmin, s.ListWebhooks))
+	s.handle(http.MethodPost, "/api/v1/webhooks", s.requireWorkspace(roleAdmin, s.CreateWebhook))
+	s.handle(http.MethodDelete, "/api/v1/webhooks/:id", s.requireWorkspace(roleAdmin, s.DeleteWebhook))
+
+	if s.slack != nil {
+		s.handle(http.MethodPost, "/api/v1/integrations/slack/command", s.SlackCommand)
+	}
+
+	if s.discord != nil {
+		s.handle(http.MethodPost, "/api/v1/integrations/discord/interactions", s.DiscordInteraction)
+	}
+
+	if s.telegram != nil && s.telegram.webhookUrl != "" {
+		s.handle(http.MethodPost, "/api/v1/integrations/telegram/webhook", s.TelegramWebhook)
+	}
+
+	if s.legacyApi {
+		s.handle(http.MethodGet, "/yourls-api.php", s.YourlsApi)
+		s.handle(http.MethodPost, "/yourls-api.php", s.YourlsApi)
+		s.handle(http.MethodGet, "/v3/shorten", s.BitlyShorten)
+		s.handle(http.MethodGet, "/v3/expand", s.BitlyExpand)
+	}
+
+	s.initAdmin()
+
+	if s.cors != nil {
+		s.router.GlobalOPTIONS = http.HandlerFunc(s.cors.preflight)
+	}
+
+	// Переход по коротким ссылкам вида "/{code}" обрабатывается как ненайденный маршрут,
+	// так как маршрутизатор не допускает параметр в корне наряду со статическими маршрутами
+	s.router.NotFound = s.instrument("/{code}", trafficRedirect, http.HandlerFunc(s.Redirect))
+}
+
+// handle - Метод, реализующий регистрацию обработчика маршрута API (с учетом метрик, ограничения частоты
+// и правил CORS)
+func (s *Server) handle(method, path string, h httprouter.Handle) {
+	s.router.Handle(method, path, func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		var next http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h(w, r, ps)
+		})
+
+		if s.compression != nil {
+			next = s.compression.middleware(next)
+		}
+
+		if s.rateLimit != nil && !rateLimitExempt[path] {
+			next = s.rateLimit.middleware(s.clientIP, next)
+		}
+
+		if s.cors != nil {
+			next = s.cors.middleware(next)
+		}
+
+		s.instrument(path, trafficApi, next).ServeHTTP(w, r)
+	})
 }
 
 // GetShortUrl - Метод, реализующий обработку "Post" запроса на сервер (возврат сокращенной ссылки)
@@ -24,61 +140,110 @@ func (s *Server) GetShortUrl(w http.ResponseWriter, r *http.Request, _ httproute
 	err := json.NewDecoder(r.Body).Decode(&inUrl)
 	if err != nil {
 		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
-		log.Println("[ERROR] Failed to read request")
+		s.logger.WarnContext(r.Context(), "Failed to read request")
 		return
 	}
 
-	var answer []byte
+	url, err := s.urls.normalize(r.Context(), inUrl.Data)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid url", "url", inUrl.Data, "error", err)
+		return
+	}
 
-	// Поиск в кеше
-	if shrUrl, isExist := s.cacheWithOriginalUrlKey.Get(inUrl.Data); isExist {
-		answer = []byte(shrUrl)
+	if s.rejectMalicious(w, r, url) {
+		return
+	}
 
-		log.Println("[SUCCESS] Url found in cache: ", shrUrl, "(In URL: ", inUrl.Data, ")")
+	shortUrl, err := s.shorten(r.Context(), database.RowData{
+		Url:         url,
+		UserId:      userIdFromContext(r.Context()),
+		WorkspaceId: workspaceIdFromContext(r.Context()),
+		ApiKeyId:    workspaceFromContext(r.Context()).KeyId,
+	})
+	if isCollision(err) {
+		http.Error(w, "Error: No free short code (status code: 503)", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error: Failed to save url in database (status code: 500)", http.StatusInternalServerError)
+		return
+	}
 
-		_, err := w.Write(answer)
-		if err != nil {
-			http.Error(w, "Error: Failed to write response (status code: 500)", http.StatusInternalServerError)
-			log.Println("[ERROR] Failed to write response")
+	// Запись ответа
+	_, err = w.Write([]byte(shortUrl))
+	if err != nil {
+		http.Error(w, "Error: Failed to write response (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to write response", "error", err)
+	}
+}
+
+// shorten - Метод, реализующий получение короткой ссылки для заданной исходной (поиск в кеше, в БД или генерация новой)
+// (параметры новой ссылки берутся из заданной строки; существующая ссылка ее рабочего пространства
+// возвращается, только если ни у нее, ни у заданной строки нет собственных параметров, иначе создается новая)
+func (s *Server) shorten(ctx context.Context, newRow database.RowData) (string, error) {
+
+	url := newRow.Url
+
+	var (
+		row     *database.RowData
+		isExist bool
+	)
+
+	if newRow.Plain() {
+
+		// Поиск в кеше
+		shrUrl, cached := s.cacheWithOriginalUrlKey.Get(originalUrlKey(newRow.WorkspaceId, newRow.Domain, url))
+		s.cacheLookup(ctx, "original_url", cached)
+		if cached {
+			s.logger.DebugContext(ctx, "Url found in cache", "short_url", shrUrl, "url", url)
+			return shrUrl, nil
 		}
 
-		return
+		// Поиск в БД
+		row, isExist = s.db.GetUrlRow(ctx, newRow.WorkspaceId, newRow.Domain, url)
 	}
 
-	// Поиск в БД
-	row, isExist := s.db.GetUrlRow(inUrl.Data)
+	var answer string
+
 	if isExist {
-		answer = []byte(row.ShortUrl)
+		answer = row.ShortUrl
 
-		log.Println("[SUCCESS] Url found in database: ", string(answer), "(In URL: ", inUrl.Data, ")")
+		s.logger.DebugContext(ctx, "Url found in database", "short_url", answer, "url", url)
 	} else {
 
 		// Генерация новой ссылки с последующим добавлением в БД, если значение не найдено
-		answer = []byte(generator.GenerateShortUrl(inUrl.Data))
-		err = s.db.SaveShortUrl(database.RowData{
-			Id:       0,
-			Url:      inUrl.Data,
-			ShortUrl: string(answer),
-		})
+		var (
+			created bool
+			err     error
+		)
+
+		newRow, created, err = s.saveGenerated(ctx, newRow)
 		if err != nil {
-			http.Error(w, "Error: Failed to save url in database (status code: 500)", http.StatusInternalServerError)
-			log.Println("[ERROR] Failed to save url in database")
-			return
+			s.logger.ErrorContext(ctx, "Failed to save url in database", "error", err)
+			return "", err
 		}
 
-		log.Println("[SUCCESS] Url was generated successfully: ", string(answer), "(In URL: ", inUrl.Data, ")")
+		answer = newRow.ShortUrl
+		row = &newRow
+
+		if created {
+			s.logger.InfoContext(ctx, "Url was generated successfully", "short_url", answer, "url", url)
+			s.codesAdded(ctx, 1)
+
+			newRow.CreatedAt = time.Now()
+
+			s.emitLinkEvent(eventLinkCreated, newRow)
+			s.audit(ctx, auditLinkCreate, answer, map[string]any{"url": url})
+		} else {
+			s.logger.DebugContext(ctx, "Url was saved by another request", "short_url", answer, "url", url)
+		}
 	}
 
 	// Добавление новых значений в кеш
-	s.cacheWithShortUrlKey.Set(string(answer), inUrl.Data, 0)
-	s.cacheWithOriginalUrlKey.Set(inUrl.Data, string(answer), 0)
+	s.cacheRow(*row)
 
-	// Запись ответа
-	_, err = w.Write(answer)
-	if err != nil {
-		http.Error(w, "Error: Failed to write response (status code: 500)", http.StatusInternalServerError)
-		log.Println("[ERROR] Failed to write response")
-	}
+	return answer, nil
 }
 
 // GetOriginalUrl - Метод, реализующий обработку "Get" запроса на сервер (возврат исходной ссылки, если она есть)
@@ -90,48 +255,130 @@ func (s *Server) GetOriginalUrl(w http.ResponseWriter, r *http.Request, _ httpro
 	err := json.NewDecoder(r.Body).Decode(&inShortUrl)
 	if err != nil {
 		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
-		log.Println("[ERROR] Failed to read request")
+		s.logger.WarnContext(r.Context(), "Failed to read request")
 		return
 	}
 
-	var answer []byte
+	// Поиск исходной ссылки
+	row, isExist := s.resolve(r.Context(), inShortUrl.Data)
+	if !isExist {
 
-	// Поиск в кеше
-	if origUrl, isExist := s.cacheWithShortUrlKey.Get(inShortUrl.Data); isExist {
-		answer = []byte(origUrl)
+		// Возврат ошибки, если значение не найдено
+		http.Error(w, "Error: Url not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Url not found")
+		return
+	}
 
-		log.Println("[SUCCESS] Url found in cache: ", origUrl, "(Short URL: ", inShortUrl.Data, ")")
+	if row.Expired() || row.Exhausted() {
+		http.Error(w, "Error: Url expired (status code: 410)", http.StatusGone)
+		s.logger.WarnContext(r.Context(), "Url expired", "short_url", inShortUrl.Data)
+		return
+	}
 
-		_, err := w.Write(answer)
-		if err != nil {
-			http.Error(w, "Error: Failed to write response (status code: 500)", http.StatusInternalServerError)
-			log.Println("[ERROR] Failed to write response")
-		}
+	if row.Disabled {
+		http.Error(w, "Error: Url is disabled (status code: 403)", http.StatusForbidden)
+		s.logger.WarnContext(r.Context(), "Url is disabled", "short_url", inShortUrl.Data)
+		return
+	}
 
+	if row.NotYetLive() {
+		http.Error(w, "Error: Url not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Url is not live yet", "short_url", inShortUrl.Data)
 		return
 	}
 
-	// Поиск в БД
-	row, isExist := s.db.GetShortUrlRow(inShortUrl.Data)
+	if row.PasswordHash != "" &&
+		bcrypt.CompareHashAndPassword([]byte(row.PasswordHash), []byte(r.Header.Get(linkPasswordHeader))) != nil {
+		http.Error(w, "Error: Invalid link password (status code: 401)", http.StatusUnauthorized)
+		s.logger.WarnContext(r.Context(), "Invalid link password", "short_url", inShortUrl.Data)
+		return
+	}
+
+	ok, err := s.consumeClick(r.Context(), row)
+	if err != nil {
+		http.Error(w, "Error: Failed to count click (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to count click", "short_url", inShortUrl.Data, "error", err)
+		return
+	}
+
+	if !ok {
+		http.Error(w, "Error: Url expired (status code: 410)", http.StatusGone)
+		s.logger.WarnContext(r.Context(), "Url reached its click limit", "short_url", inShortUrl.Data)
+		return
+	}
+
+	s.recordClick(r, row, 0)
+
+	// Запись ответа
+	_, err = w.Write([]byte(row.Url))
+	if err != nil {
+		http.Error(w, "Error: Failed to write response (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to write response", "error", err)
+	}
+}
+
+// resolve - Метод, реализующий получение строки ссылки по короткой ссылке (поиск в кеше, затем в БД)
+func (s *Server) resolve(ctx context.Context, shortUrl string) (*database.RowData, bool) {
+
+	// Короткая ссылка ищется в принятой записи (процентная запись кода, строчные символы для кодов
+	// без учета регистра), затем в написании запроса (ссылки, созданные до включения режима)
+	base, _ := splitShortUrl(shortUrl)
+
+	if folded := base + escapeCode(s.foldCode(codeFromShortUrl(shortUrl))); folded != shortUrl {
+		if row, isExist := s.resolveExact(ctx, folded); isExist {
+			return row, true
+		}
+	}
+
+	return s.resolveExact(ctx, shortUrl)
+}
+
+// resolveExact - Метод, реализующий поиск строки по короткой ссылке в заданном написании в кеше или в БД
+func (s *Server) resolveExact(ctx context.Context, shortUrl string) (*database.RowData, bool) {
+
+	// Поиск в кеше
+	cached, isExist := s.cacheWithShortUrlKey.Get(shortUrl)
+	s.cacheLookup(ctx, "short_url", isExist)
 	if isExist {
-		answer = []byte(row.Url)
+		s.logger.DebugContext(ctx, "Url found in cache", "short_url", shortUrl, "url", cached.Url)
+		return &cached, true
+	}
 
-		log.Println("[SUCCESS] Url found in database: ", string(answer), "(Short URL: ", inShortUrl.Data, ")")
+	// Поиск в БД
+	row, isExist := s.db.GetShortUrlRow(ctx, shortUrl)
+	if !isExist {
+		return nil, false
+	}
 
-		// Добавление значений в кеш
-		s.cacheWithShortUrlKey.Set(inShortUrl.Data, string(answer), 0)
-		s.cacheWithOriginalUrlKey.Set(string(answer), inShortUrl.Data, 0)
+	s.logger.DebugContext(ctx, "Url found in database", "short_url", shortUrl, "url", row.Url)
 
-		// Запись ответа
-		_, err := w.Write(answer)
-		if err != nil {
-			http.Error(w, "Error: Failed to write response (status code: 500)", http.StatusInternalServerError)
-			log.Println("[ERROR] Failed to write response")
+	// Добавление значений в кеш
+	s.cacheRow(*row)
+
+	return row, true
+}
+
+// cacheRow - Метод, реализующий добавление строки в кеш
+// (время жизни значений не превышает оставшийся срок действия ссылки)
+func (s *Server) cacheRow(row database.RowData) {
+
+	var duration time.Duration
+
+	if row.ExpiresAt != nil {
+		duration = time.Until(*row.ExpiresAt)
+		if duration <= 0 {
+			return
 		}
-	} else {
 
-		// Возврат ошибки, если значение не найдено
-		http.Error(w, "Error: Url not found (status code: 404)", http.StatusNotFound)
-		log.Println("[ERROR] Url not found")
+		if duration > s.live().cacheTTL {
+			duration = 0
+		}
+	}
+
+	s.cacheWithShortUrlKey.Set(row.ShortUrl, row, duration)
+
+	// Повторно выдаются только ссылки без собственных параметров
+	if row.Plain() {
+		s.cacheWithOriginalUrlKey.Set(originalUrlKey(row.WorkspaceId, row.Domain, row.Url), row.ShortUrl, duration)
 	}
 }
@@ -2,10 +2,27 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/julienschmidt/httprouter"
+	"log/slog"
 	"my_project/urlgen/config"
 	"my_project/urlgen/database"
 	"my_project/urlgen/pkg/cache_manager"
+	"my_project/urlgen/pkg/click_pipeline"
+	"my_project/urlgen/pkg/error_reporter"
+	"my_project/urlgen/pkg/generator"
+	"my_project/urlgen/pkg/geoip"
+	applog "my_project/urlgen/pkg/logger"
+	"my_project/urlgen/pkg/redis"
+	"my_project/urlgen/pkg/token_manager"
+	"my_project/urlgen/pkg/useragent"
+	"my_project/urlgen/pkg/webhook"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
 )
 
 // Url - Тип данных, описывающий структуру для представления ссылки
@@ -18,13 +35,262 @@ type Server struct {
 	context context.Context    // Контекст сервера
 	router  *httprouter.Router // Маршрутизатор
 
-	db                      *database.Database   // Подключение к БД
-	cacheWithShortUrlKey    *cache_manager.Cache // Кеш с ключами вида "короткая ссылка"
-	cacheWithOriginalUrlKey *cache_manager.Cache // Кеш с ключами вида "оригинальная ссылка"
+	db                      *database.Database                     // Подключение к БД
+	logger                  *slog.Logger                           // Журнал сервера
+	cacheWithShortUrlKey    *cache_manager.Cache[database.RowData] // Кеш с ключами вида "короткая ссылка"
+	cacheWithOriginalUrlKey *cache_manager.Cache[string]           // Кеш с ключами вида "оригинальная ссылка"
+
+	settings atomic.Pointer[liveSettings] // Настройки, изменяемые без перезапуска
+
+	tokens *token_manager.TokenManager // Менеджер JWT
+	oidc   *oidcProvider               // Внешний провайдер входа (nil, если не настроен)
+	signup bool                        // Разрешена ли регистрация пользователей
+	mailer *mailer                     // Отправка писем пользователям (nil, если SMTP не настроен)
+
+	idempotency *idempotencyStore // Ответы на запросы создания с заголовком Idempotency-Key
+
+	redirectStatus  int                 // Статус перехода по короткой ссылке по умолчанию
+	countHeadClicks bool                // Учитывать ли "Head" запросы коротких ссылок как переходы
+	redirectCache   *redirectCache      // Настройки кеширования ответов перехода
+	pages           *errorPages         // Шаблоны страниц ошибок перехода
+	urls            *urlPolicy          // Правила проверки и нормализации исходных ссылок
+	domains         *domainPolicy       // Правила доменов исходных ссылок
+	flags           *flagOverrides      // Флаги функций, измененные через API
+	codeStrategy    string              // Название генератора кодов коротких ссылок
+	codes           generator.Generator // Генератор кодов коротких ссылок
+	candidateCodes  generator.Generator // Генератор, на который коды переводятся флагом (nil, если не задан)
+	candidateName   string              // Название генератора candidateCodes
+	codeAlphabet    generator.Alphabet  // Алфавит генерируемых кодов
+	codeLength      *codeLength         // Длина генерируемых кодов
+	codeAttempts    int                 // Количество попыток сохранения ссылки с новым кодом
+	signer          *codeSigner         // Подпись и контрольный символ сгенерированных кодов (nil, если отключены)
+	caseInsensitive bool                // Не различать ли регистр символов кодов
+	shortDomains    []string            // Брендированные домены коротких ссылок
+	redis           *redis.Client       // Клиент Redis (nil, если REDIS_URL не задан)
+	reputation      *urlReputation      // Проверка репутации исходных ссылок (nil, если не настроена)
+
+	metrics  *metrics                 // Метрики сервера
+	statsd   *statsdPush              // Отправка метрик в StatsD (nil, если STATSD_ADDR не задан)
+	reporter error_reporter.Reporter  // Отправка сообщений об ошибках (error_reporter.Nop, если не настроена)
+	clicks   *click_pipeline.Pipeline // Конвейер записи переходов
+	leader   leader                   // Выбор ведущего экземпляра для фоновых задач
+	geo      *geoip.Locator           // Определение местоположения клиентов (nil, если база GeoIP не задана)
+
+	analytics  clickAnalytics       // Хранилище, из которого читается статистика переходов (БД или ClickHouse)
+	quota      quotaStore           // Хранилище использования ключей API (БД)
+	quotaLocks quotaLocks           // Очередь проверок квоты ключей API на этом экземпляре
+	clickhouse *clickhouseAnalytics // Хранение переходов в ClickHouse вместо БД (nil, если CLICKHOUSE_URL не задан)
+	bigquery   *bigqueryExport      // Ежедневная выгрузка в BigQuery (nil, если BIGQUERY_DATASET не задан)
+
+	webhooks        *webhook.Dispatcher // Доставка вебхуков
+	webhookRegistry *webhookRegistry    // Подписанные вебхуки
+	cors            *corsPolicy         // Правила CORS для API (nil, если CORS отключен)
+	slack           *slackCommand       // Команда Slack "/shorten" (nil, если не настроена)
+	telegram        *telegramBot        // Бот Telegram (nil, если не настроен)
+	discord         *discordCommands    // Команды приложения Discord (nil, если не настроены)
+	rateLimit       *rateLimit          // Ограничение частоты запросов к API (nil, если не задано)
+	compression     *compression        // Сжатие ответов API (nil, если сжатие отключено)
+	legacyApi       bool                // Включен ли API, совместимый с YOURLS и Bitly v3
+	streams         []EventPublisher    // Потоки событий переходов и ссылок (Kafka, NATS, RabbitMQ)
+
+	passwordAttempts passwordAttempts // Учет неверных паролей защищенных ссылок
+
+	accessLog AccessLogger  // Журнал запросов (nil, если журнал отключен)
+	logLevel  *applog.Level // Управление уровнем журнала (nil, если не передано UseLogLevel)
+	privacy   *ipPrivacy    // Обезличивание IP адресов клиентов и срок хранения переходов
+	proxyHops int           // Количество доверенных прокси перед сервером (0 - X-Forwarded-For не учитывается)
 }
 
 // NewServer - Функция, позволяющая создать новый сервер
-func NewServer(db *database.Database, ctx context.Context) (*Server, error) {
+func NewServer(db *database.Database, logger *slog.Logger, ctx context.Context) (*Server, error) {
+
+	var (
+		err          error
+		oidcProvider *oidcProvider
+	)
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, errors.New("error: JWT_SECRET is not set")
+	}
+
+	// Ошибки, журналируемые сервером и его компонентами, отправляются во внешнюю систему
+	reporter, err := error_reporter.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	logger = reportingLogger(logger, reporter)
+
+	redirectStatus := config.DefaultRedirectStatus
+	if v := os.Getenv("REDIRECT_STATUS"); v != "" {
+		redirectStatus, err = strconv.Atoi(v)
+		if err != nil || !isRedirectStatus(redirectStatus) {
+			return nil, errors.New("error: REDIRECT_STATUS must be one of 301, 302, 307, 308")
+		}
+	}
+
+	live, err := liveSettingsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	redirectCache, err := redirectCacheFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := loadErrorPages()
+	if err != nil {
+		return nil, err
+	}
+
+	accessLog, err := accessLoggerFromEnv(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyHops, err := proxyHopsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	domains, err := domainPolicyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	webhookRegistry := &webhookRegistry{}
+	flags := &flagOverrides{}
+
+	if db != nil {
+		err = domains.load(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+
+		err = flags.load(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+
+		err = webhookRegistry.load(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	oidcProvider, err = newOIDCProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mailer, err := mailerFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := compressionFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	privacy, err := ipPrivacyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	redisClient, err := redisFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit, err := rateLimitFromEnv(redisClient, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	slack, err := slackFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	telegramBot, err := telegramFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	discord, err := discordFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	shortDomains, err := shortDomainsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	caseInsensitive := os.Getenv("CODE_CASE_INSENSITIVE") == "true"
+
+	codeAlphabet, err := codeAlphabetFromEnv(caseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+
+	codeLength, err := codeLengthFromEnv(codeAlphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	codeAttempts, err := codeAttemptsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := codeSignerFromEnv(codeAlphabet, caseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+
+	if signer != nil && db != nil {
+		err = signer.refresh(ctx, db, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Статистика читается из хранилища, в которое записываются переходы
+	var analytics clickAnalytics = db
+
+	clickhouse, err := clickhouseFromEnv(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if clickhouse != nil {
+		analytics = clickhouse
+	}
+
+	bigqueryExport, err := bigqueryFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	// Открытие базы GeoIP (определение местоположения и разбор User-Agent выполняются в конвейере записи переходов)
+	var geo *geoip.Locator
+
+	enrichers := []click_pipeline.Enricher{useragent.Enricher{}}
+
+	if path := os.Getenv("GEOIP_DB_PATH"); path != "" {
+		geo, err = geoip.LocatorCreate(path, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		enrichers = append(enrichers, geo)
+	}
 
 	// Создание сервера
 	s := Server{
@@ -32,8 +298,145 @@ func NewServer(db *database.Database, ctx context.Context) (*Server, error) {
 		router:  httprouter.New(),
 
 		db:                      db,
-		cacheWithShortUrlKey:    cache_manager.CacheCreate(config.CacheDefaultExpiration, config.CacheCleanupTime),
-		cacheWithOriginalUrlKey: cache_manager.CacheCreate(config.CacheDefaultExpiration, config.CacheCleanupTime),
+		logger:                  logger,
+		cacheWithShortUrlKey:    cache_manager.CacheCreate[database.RowData](live.cacheTTL, config.CacheCleanupTime, logger),
+		cacheWithOriginalUrlKey: cache_manager.CacheCreate[string](live.cacheTTL, config.CacheCleanupTime, logger),
+
+		tokens: token_manager.TokenManagerCreate([]byte(secret), config.TokenTTL),
+		oidc:   oidcProvider,
+		signup: os.Getenv("SIGNUP_ENABLED") == "true",
+		mailer: mailer,
+
+		idempotency: newIdempotencyStore(logger),
+
+		redirectStatus:  redirectStatus,
+		countHeadClicks: os.Getenv("COUNT_HEAD_CLICKS") == "true",
+		redirectCache:   redirectCache,
+		pages:           pages,
+		urls:            urlPolicyFromEnv(domains),
+		domains:         domains,
+		flags:           flags,
+		codeAlphabet:    codeAlphabet,
+		codeLength:      codeLength,
+		codeAttempts:    codeAttempts,
+		signer:          signer,
+		caseInsensitive: caseInsensitive,
+		shortDomains:    shortDomains,
+		redis:           redisClient,
+		reputation:      urlReputationFromEnv(logger),
+
+		metrics:  newMetrics(db),
+		reporter: reporter,
+		geo:      geo,
+		cors:     corsPolicyFromEnv(),
+
+		analytics:  analytics,
+		quota:      db,
+		clickhouse: clickhouse,
+		bigquery:   bigqueryExport,
+
+		compression: compression,
+
+		webhooks: webhook.DispatcherCreate(config.WebhookWorkers, config.WebhookQueueSize, config.WebhookMaxAttempts,
+			config.WebhookBackoff, webhookClient(config.WebhookTimeout), logger),
+		webhookRegistry: webhookRegistry,
+		slack:           slack,
+		telegram:        telegramBot,
+		discord:         discord,
+		rateLimit:       rateLimit,
+		legacyApi:       os.Getenv("LEGACY_API") == "true",
+
+		accessLog: accessLog,
+		privacy:   privacy,
+		proxyHops: proxyHops,
+	}
+
+	s.settings.Store(live)
+
+	s.statsd, err = statsdFromEnv(s.metrics.registry)
+	if err != nil {
+		return nil, err
+	}
+
+	s.codeStrategy, s.codes, err = s.codeGeneratorFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	s.candidateName, s.candidateCodes, err = s.candidateGeneratorFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if (s.codeStrategy == codeCounterStrategy || s.candidateName == codeCounterStrategy) && db != nil {
+		if err = s.seedCodeCounter(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Запуск конвейера записи переходов (с рассылкой вебхуков после записи в БД; ClickHouse получает
+	// более крупные и редкие пачки)
+	batchSize, flushInterval := config.ClickBatchSize, config.ClickFlushInterval
+	if clickhouse != nil {
+		batchSize, flushInterval = config.ClickHouseBatchSize, config.ClickHouseFlushInterval
+	}
+
+	s.clicks = click_pipeline.PipelineCreate(clickSink{server: &s}, config.ClickBufferSize, batchSize,
+		flushInterval, logger, enrichers...)
+
+	if s.statsd != nil {
+		s.background("statsd", s.pushMetrics)
+	}
+
+	kafkaStream, err := kafkaFromEnv(func(messages int, err error) { s.streamResult("kafka", messages, err) })
+	if err != nil {
+		return nil, err
+	}
+	if kafkaStream != nil {
+		s.streams = append(s.streams, kafkaStream)
+	}
+
+	natsStream, err := natsFromEnv(func(messages int, err error) { s.streamResult("nats", messages, err) })
+	if err != nil {
+		return nil, err
+	}
+	if natsStream != nil {
+		s.streams = append(s.streams, natsStream)
+	}
+
+	rabbitmqStream, err := rabbitmqFromEnv(func(messages int, err error) { s.streamResult("rabbitmq", messages, err) })
+	if err != nil {
+		return nil, err
+	}
+	if rabbitmqStream != nil {
+		s.streams = append(s.streams, rabbitmqStream)
+	}
+
+	if db != nil {
+		// Первая попытка выполняется сразу, чтобы задачи ведущего начали работу без ожидания проверки
+		s.leader.step(&s)
+
+		s.background("leader election", s.electLeader)
+		s.background("expirations", s.watchExpirations)
+		s.background("code length", s.watchCodeLength)
+		s.background("feature flags", s.watchFeatureFlags)
+		s.background("webhooks", s.watchWebhooks)
+
+		if pool, ok := s.codes.(*codePool); ok {
+			s.background("code pool", pool.watch)
+		}
+
+		if privacy.retention > 0 {
+			s.background("clicks purge", s.purgeClicks)
+		}
+
+		if s.telegram != nil {
+			s.background("telegram", s.runTelegram)
+		}
+
+		if s.bigquery != nil {
+			s.background("bigquery export", s.exportBigQuery)
+		}
 	}
 
 	// Инициализация маршрутов
@@ -46,3 +449,63 @@ func NewServer(db *database.Database, ctx context.Context) (*Server, error) {
 func (s *Server) GetRouter() *httprouter.Router {
 	return s.router
 }
+
+// Handler - Метод, позволяющий получить обработчик всех запросов сервера (маршрутизатор с промежуточными обработчиками)
+func (s *Server) Handler() http.Handler {
+	return s.requestIdMiddleware(s.tracingMiddleware(s.errorReportingMiddleware(s.accessLogMiddleware(
+		s.recoverMiddleware(s.router)))))
+}
+
+// Close - Метод, реализующий освобождение ресурсов сервера (запись буферизованных переходов и остановка очистки кеша)
+func (s *Server) Close(ctx context.Context) error {
+
+	err := s.clicks.Close(ctx)
+
+	// Вебхуки останавливаются после записи оставшихся переходов, чтобы доставить и их
+	if webhookErr := s.webhooks.Close(ctx); err == nil {
+		err = webhookErr
+	}
+
+	// Потоки событий закрываются после записи оставшихся переходов, чтобы отправить и их
+	for _, stream := range s.streams {
+		if streamErr := stream.Close(); err == nil {
+			err = streamErr
+		}
+	}
+
+	if s.geo != nil {
+		_ = s.geo.Close()
+	}
+
+	s.cacheWithShortUrlKey.Close()
+	s.cacheWithOriginalUrlKey.Close()
+
+	if s.reputation != nil {
+		s.reputation.verdicts.Close()
+	}
+
+	if s.redis != nil {
+		_ = s.redis.Close()
+	}
+
+	// Отправка оставшихся сообщений об ошибках
+	timeout := config.ErrorReportFlushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	s.reporter.Flush(timeout)
+
+	return err
+}
+
+// writeJSON - Метод, реализующий запись ответа в формате JSON
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		s.logger.Error("Failed to write response", "error", err)
+	}
+}
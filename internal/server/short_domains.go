@@ -0,0 +1,118 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"my_project/urlgen/config"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// errUnknownDomain - Ошибка выбора домена коротких ссылок, который не обслуживается сервером
+var errUnknownDomain = errors.New("unknown domain")
+
+// shortDomainsFromEnv - Функция, позволяющая получить брендированные домены коротких ссылок
+// из переменной SHORT_DOMAINS (через запятую; коды на каждом домене независимы)
+func shortDomainsFromEnv() ([]string, error) {
+
+	var domains []string
+
+	for _, v := range strings.Split(os.Getenv("SHORT_DOMAINS"), ",") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+
+		domain, err := normalizeDomain(v)
+		if err != nil {
+			return nil, fmt.Errorf("error: invalid SHORT_DOMAINS entry %q: %w", v, err)
+		}
+
+		if !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains, nil
+}
+
+// shortDomain - Метод, проверяющий домен коротких ссылок, выбранный при создании ссылки
+// (возвращает нормализованный домен; пустая строка - основной домен)
+func (s *Server) shortDomain(domain string) (string, error) {
+
+	if domain == "" {
+		return "", nil
+	}
+
+	domain, err := normalizeDomain(domain)
+	if err != nil || !slices.Contains(s.shortDomains, domain) {
+		return "", errUnknownDomain
+	}
+
+	return domain, nil
+}
+
+// requestDomain - Метод, возвращающий брендированный домен, на который пришел переход
+// (пустая строка - основной домен или домен, не указанный в SHORT_DOMAINS)
+func (s *Server) requestDomain(r *http.Request) string {
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if slices.Contains(s.shortDomains, host) {
+		return host
+	}
+
+	return ""
+}
+
+// linkDomain - Функция, возвращающая домен ссылок, выбранный параметром запроса domain API управления ссылками
+// (пустая строка - основной домен)
+func linkDomain(r *http.Request) string {
+	return strings.ToLower(r.URL.Query().Get("domain"))
+}
+
+// shortUrlBase - Функция, возвращающая основу коротких ссылок домена (пустой домен - config.GenUrl)
+func shortUrlBase(domain string) string {
+	if domain == "" {
+		return config.GenUrl
+	}
+
+	return "https://" + domain + "/"
+}
+
+// shortUrlOnDomain - Функция, реализующая получение короткой ссылки по коду на домене коротких ссылок
+// (символы вне латиницы, например эмодзи, кодируются в процентной записи, селекторы варианта U+FE0F,
+// которые клиенты добавляют к эмодзи, отбрасываются)
+func shortUrlOnDomain(domain, code string) string {
+	return shortUrlBase(domain) + escapeCode(code)
+}
+
+// escapeCode - Функция, возвращающая код в записи, в которой он хранится в короткой ссылке
+func escapeCode(code string) string {
+	return url.PathEscape(strings.ReplaceAll(code, "\uFE0F", ""))
+}
+
+// splitShortUrl - Функция, реализующая разделение короткой ссылки на основу и код в процентной записи
+// (строка без схемы считается кодом на основном домене)
+func splitShortUrl(shortUrl string) (string, string) {
+
+	if code, found := strings.CutPrefix(shortUrl, config.GenUrl); found {
+		return config.GenUrl, code
+	}
+
+	if _, rest, found := strings.Cut(shortUrl, "://"); found {
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			n := len(shortUrl) - len(rest) + i + 1
+			return shortUrl[:n], shortUrl[n:]
+		}
+	}
+
+	return config.GenUrl, shortUrl
+}
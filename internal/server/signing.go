@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/generator"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unsignedRefreshOverlap - Запас времени при дочитывании новых ссылок без подписи
+// (учитывает транзакции, зафиксированные позже начала предыдущего чтения)
+const unsignedRefreshOverlap = time.Minute
+
+// codeSigner - Тип данных, реализующий подпись сгенерированных кодов HMAC и контрольный символ
+// (переход по коду без верной подписи или контрольного символа отклоняется без обращения к БД, если код
+// не из числа известных кодов без подписи: пользовательских и созданных до включения подписи)
+type codeSigner struct {
+	key      []byte             // Ключ HMAC (nil, если подпись отключена)
+	length   int                // Длина подписи
+	checksum bool               // Добавляется ли контрольный символ
+	alphabet generator.Alphabet // Алфавит подписи
+	fold     bool               // Не различать ли регистр символов кодов
+
+	mu          sync.RWMutex
+	unsigned    map[string]struct{} // Короткие ссылки без подписи
+	refreshedAt time.Time           // Время последнего чтения ссылок без подписи
+	refreshing  sync.Mutex          // Блокировка чтения ссылок без подписи
+}
+
+// codeSignerFromEnv - Функция, позволяющая создать подпись кодов с ключом из переменной CODE_SIGNING_KEY
+// и длиной подписи из CODE_SIGNATURE_LENGTH (по умолчанию config.CodeSignatureLen), контрольный символ
+// добавляется при CODE_CHECKSUM=true (nil, если не задано ни того, ни другого)
+func codeSignerFromEnv(alphabet generator.Alphabet, fold bool) (*codeSigner, error) {
+
+	key := os.Getenv("CODE_SIGNING_KEY")
+	checksum := os.Getenv("CODE_CHECKSUM") == "true"
+
+	if key == "" && !checksum {
+		return nil, nil
+	}
+
+	length := config.CodeSignatureLen
+
+	if v := os.Getenv("CODE_SIGNATURE_LENGTH"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > config.ShortUrlMaxLen {
+			return nil, fmt.Errorf("error: CODE_SIGNATURE_LENGTH must be an integer from 1 to %d", config.ShortUrlMaxLen)
+		}
+
+		length = n
+	}
+
+	signer := &codeSigner{length: length, checksum: checksum, alphabet: alphabet, fold: fold, unsigned: map[string]struct{}{}}
+	if key != "" {
+		signer.key = []byte(key)
+	}
+
+	return signer, nil
+}
+
+// foldKey - Метод, возвращающий ключ короткой ссылки в наборе ссылок без подписи
+func (c *codeSigner) foldKey(shortUrl string) string {
+	if c.fold {
+		return strings.ToLower(shortUrl)
+	}
+
+	return shortUrl
+}
+
+// sign - Метод, возвращающий код с подписью и контрольным символом
+// (код с символами не из алфавита, например из эмодзи, остается без контрольного символа)
+func (c *codeSigner) sign(code string) string {
+
+	if c.key != nil {
+		code += c.alphabet.Signature(c.key, code, c.length)
+	}
+
+	if c.checksum {
+		if check, err := c.alphabet.CheckChar(code); err == nil {
+			code += string(check)
+		}
+	}
+
+	return code
+}
+
+// valid - Метод, проверяющий контрольный символ и подпись кода
+func (c *codeSigner) valid(code string) bool {
+
+	if c.fold {
+		code = strings.ToLower(code)
+	}
+
+	if c.checksum {
+		if !c.checksumValid(code) {
+			return false
+		}
+
+		code = code[:len(code)-1]
+	}
+
+	if c.key == nil {
+		return true
+	}
+
+	if len(code) <= c.length {
+		return false
+	}
+
+	body, signature := code[:len(code)-c.length], code[len(code)-c.length:]
+
+	return subtle.ConstantTimeCompare([]byte(signature), []byte(c.alphabet.Signature(c.key, body, c.length))) == 1
+}
+
+// checksumValid - Метод, проверяющий контрольный символ кода (последний символ)
+func (c *codeSigner) checksumValid(code string) bool {
+
+	if len(code) < 2 {
+		return false
+	}
+
+	if c.fold {
+		code = strings.ToLower(code)
+	}
+
+	check, err := c.alphabet.CheckChar(code[:len(code)-1])
+
+	return err == nil && check == code[len(code)-1]
+}
+
+// remember - Метод, реализующий учет созданной ссылки (ссылки без подписи запоминаются)
+func (c *codeSigner) remember(shortUrl string) {
+
+	if c.valid(codeFromShortUrl(shortUrl)) {
+		return
+	}
+
+	c.mu.Lock()
+	c.unsigned[c.foldKey(shortUrl)] = struct{}{}
+	c.mu.Unlock()
+}
+
+// known - Метод, проверяющий, является ли ссылка известной ссылкой без подписи
+func (c *codeSigner) known(shortUrl string) bool {
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, found := c.unsigned[c.foldKey(shortUrl)]
+	return found
+}
+
+// refresh - Метод, реализующий дочитывание из БД ссылок без подписи, созданных после предыдущего чтения
+// (не чаще config.UnsignedCodesRefreshInterval, если force = false; одновременные вызовы ждут одного чтения)
+func (c *codeSigner) refresh(ctx context.Context, db *database.Database, force bool) error {
+
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+
+	c.mu.RLock()
+	since := c.refreshedAt
+	c.mu.RUnlock()
+
+	if !force && time.Since(since) < config.UnsignedCodesRefreshInterval {
+		return nil
+	}
+
+	now := time.Now()
+
+	if !since.IsZero() {
+		since = since.Add(-unsignedRefreshOverlap)
+	}
+
+	shortUrls, err := db.ListShortUrlsSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, shortUrl := range shortUrls {
+		if !c.valid(codeFromShortUrl(shortUrl)) {
+			c.unsigned[c.foldKey(shortUrl)] = struct{}{}
+		}
+	}
+
+	c.refreshedAt = now
+
+	return nil
+}
+
+// signedCodeAllowed - Метод, проверяющий, что переход по коду может быть найден в БД
+// (коды с верной подписью, из кеша и известные коды без подписи; при неизвестном коде список кодов
+// без подписи дочитывается не чаще config.UnsignedCodesRefreshInterval, поэтому подбор кодов
+// не создает нагрузки на БД)
+func (s *Server) signedCodeAllowed(ctx context.Context, code, shortUrl string) bool {
+
+	if s.signer == nil || s.signer.valid(code) || s.signer.known(shortUrl) {
+		return true
+	}
+
+	if _, isExist := s.cacheWithShortUrlKey.Get(shortUrl); isExist {
+		return true
+	}
+
+	if s.db == nil {
+		return false
+	}
+
+	if err := s.signer.refresh(ctx, s.db, false); err != nil && !errors.Is(err, context.Canceled) {
+		s.logger.ErrorContext(ctx, "Failed to read unsigned links", "error", err)
+	}
+
+	return s.signer.known(shortUrl)
+}
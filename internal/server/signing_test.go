@@ -0,0 +1,134 @@
+package server
+
+import (
+	"my_project/urlgen/pkg/generator"
+	"testing"
+)
+
+func TestCodeSigner(t *testing.T) {
+
+	alphabet := generator.Alphabet(generator.DefaultAlphabet)
+	lower := generator.Alphabet(generator.DefaultLowerAlphabet)
+
+	tests := []struct {
+		name   string
+		signer *codeSigner
+	}{
+		{"signature", &codeSigner{key: []byte("secret"), length: 3, alphabet: alphabet}},
+		{"checksum", &codeSigner{length: 3, checksum: true, alphabet: alphabet}},
+		{"signature and checksum", &codeSigner{key: []byte("secret"), length: 3, checksum: true, alphabet: alphabet}},
+		{"case-insensitive", &codeSigner{key: []byte("secret"), length: 3, checksum: true, alphabet: lower, fold: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			c := tt.signer
+			code := "Hx7kPq"
+			if c.fold {
+				code = "hx7kpq"
+			}
+
+			signed := c.sign(code)
+
+			want := len(code)
+			if c.key != nil {
+				want += c.length
+			}
+			if c.checksum {
+				want++
+			}
+
+			if len(signed) != want {
+				t.Fatalf("sign(%q) = %q, want length %d", code, signed, want)
+			}
+
+			if !c.valid(signed) {
+				t.Errorf("valid(%q) = false for a signed code", signed)
+			}
+
+			if c.valid(code) {
+				t.Errorf("valid(%q) = true for an unsigned code", code)
+			}
+
+			// Замена последнего символа (контрольного или подписи) делает код недействительным
+			last := signed[len(signed)-1]
+			for i := 0; i < len(c.alphabet); i++ {
+				if c.alphabet[i] == last {
+					continue
+				}
+
+				changed := signed[:len(signed)-1] + string(c.alphabet[i])
+				if c.valid(changed) {
+					t.Errorf("valid(%q) = true for a changed code", changed)
+				}
+			}
+		})
+	}
+}
+
+func TestCodeSignerValid(t *testing.T) {
+
+	alphabet := generator.Alphabet(generator.DefaultAlphabet)
+	lower := generator.Alphabet(generator.DefaultLowerAlphabet)
+
+	signer := &codeSigner{key: []byte("secret"), length: 3, alphabet: alphabet}
+	other := &codeSigner{key: []byte("other"), length: 3, alphabet: alphabet}
+	folded := &codeSigner{key: []byte("secret"), length: 3, checksum: true, alphabet: lower, fold: true}
+	body := "abc"
+
+	tests := []struct {
+		name   string
+		signer *codeSigner
+		code   string
+		valid  bool
+	}{
+		{"signed", signer, signer.sign(body), true},
+		{"other key", signer, other.sign(body), false},
+		{"signature only", signer, alphabet.Signature([]byte("secret"), "", 3), false},
+		{"shorter than signature", signer, "ab", false},
+		{"empty", signer, "", false},
+		{"upper case folded", folded, "ABC" + folded.sign("abc")[3:], true},
+		{"checksum only", &codeSigner{checksum: true, alphabet: alphabet}, "x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.valid(tt.code); got != tt.valid {
+				t.Errorf("valid(%q) = %t, want %t", tt.code, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestCodeSignerUnsigned(t *testing.T) {
+
+	c := &codeSigner{
+		key:      []byte("secret"),
+		length:   3,
+		alphabet: generator.Alphabet(generator.DefaultLowerAlphabet),
+		fold:     true,
+		unsigned: map[string]struct{}{},
+	}
+
+	signed := shortUrlFromCode(c.sign("abc"))
+
+	c.remember(signed)
+	c.remember(shortUrlFromCode("MyAlias"))
+
+	tests := []struct {
+		shortUrl string
+		known    bool
+	}{
+		{signed, false},
+		{shortUrlFromCode("MyAlias"), true},
+		{shortUrlFromCode("myalias"), true},
+		{shortUrlFromCode("other"), false},
+	}
+
+	for _, tt := range tests {
+		if got := c.known(tt.shortUrl); got != tt.known {
+			t.Errorf("known(%q) = %t, want %t", tt.shortUrl, got, tt.known)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"my_project/urlgen/config"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	slackSignatureHeader = "X-Slack-Signature"         // Заголовок подписи запроса Slack
+	slackTimestampHeader = "X-Slack-Request-Timestamp" // Заголовок времени запроса Slack (Unix)
+	slackSignatureScheme = "v0"                        // Версия подписи запросов Slack
+	slackMaxBody         = 64 << 10                    // Максимальный размер тела запроса команды
+
+	slackEphemeral = "ephemeral"  // Ответ виден только вызвавшему команду
+	slackInChannel = "in_channel" // Ответ виден всем участникам канала
+)
+
+// slackCommand - Тип данных, описывающий обработку команды Slack "/shorten"
+type slackCommand struct {
+	signingSecret []byte            // Секрет подписи запросов приложения Slack
+	keys          map[string]string // Ключи API рабочих пространств по идентификатору пространства Slack (team_id)
+	responseType  string            // Видимость ответа с короткой ссылкой
+}
+
+// slackResponse - Тип данных, описывающий ответ на команду Slack
+type slackResponse struct {
+	ResponseType string `json:"response_type"` // Видимость ответа
+	Text         string `json:"text"`          // Текст ответа
+}
+
+// slackFromEnv - Функция, позволяющая получить обработку команды Slack из переменных окружения
+// (SLACK_SIGNING_SECRET - секрет подписи приложения Slack, nil, если не задан; SLACK_TEAM_KEYS - ключи API
+// рабочих пространств через запятую в виде "team_id:ключ"; SLACK_RESPONSE_TYPE - "ephemeral" (по умолчанию)
+// или "in_channel")
+func slackFromEnv() (*slackCommand, error) {
+
+	secret := os.Getenv("SLACK_SIGNING_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+
+	c := slackCommand{signingSecret: []byte(secret), keys: map[string]string{}, responseType: slackEphemeral}
+
+	for _, item := range strings.Split(os.Getenv("SLACK_TEAM_KEYS"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		team, key, found := strings.Cut(item, ":")
+		if !found || team == "" || !strings.HasPrefix(key, apiKeyPrefix) {
+			return nil, errors.New("error: SLACK_TEAM_KEYS must be a list of team_id:" + apiKeyPrefix + "key")
+		}
+
+		c.keys[team] = key
+	}
+
+	if len(c.keys) == 0 {
+		return nil, errors.New("error: SLACK_TEAM_KEYS is required with SLACK_SIGNING_SECRET")
+	}
+
+	switch v := os.Getenv("SLACK_RESPONSE_TYPE"); v {
+	case "":
+	case slackEphemeral, slackInChannel:
+		c.responseType = v
+	default:
+		return nil, errors.New("error: SLACK_RESPONSE_TYPE must be ephemeral or in_channel")
+	}
+
+	return &c, nil
+}
+
+// verify - Метод, проверяющий подпись запроса Slack и его время (отклоняются запросы старше
+// config.SlackRequestMaxAge, чтобы перехваченный запрос нельзя было повторить)
+func (c *slackCommand) verify(header http.Header, body []byte) bool {
+
+	ts, err := strconv.ParseInt(header.Get(slackTimestampHeader), 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if age := time.Since(time.Unix(ts, 0)); age > config.SlackRequestMaxAge || age < -config.SlackRequestMaxAge {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, c.signingSecret)
+	mac.Write([]byte(slackSignatureScheme + ":" + strconv.FormatInt(ts, 10) + ":"))
+	mac.Write(body)
+
+	expected := slackSignatureScheme + "=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(header.Get(slackSignatureHeader)))
+}
+
+// slackCommandUrl - Функция, возвращающая ссылку из текста команды
+// (Slack может передать ссылку в разметке "<https://...>" или "<https://...|текст>")
+func slackCommandUrl(text string) string {
+
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "<") && strings.HasSuffix(text, ">") {
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "<"), ">")
+		text, _, _ = strings.Cut(text, "|")
+	}
+
+	return text
+}
+
+// SlackCommand - Метод, реализующий обработку "Post" запроса команды Slack "/shorten https://..."
+// (ссылка создается ключом API, сопоставленным рабочему пространству Slack; ошибки пользователя возвращаются
+// текстом ответа, так как Slack не показывает тело ответа с ошибкой)
+func (s *Server) SlackCommand(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, slackMaxBody))
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	if !s.slack.verify(r.Header, body) {
+		http.Error(w, "Error: Invalid Slack signature (status code: 401)", http.StatusUnauthorized)
+		s.logger.WarnContext(r.Context(), "Invalid Slack signature")
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	reply := func(text string) {
+		s.writeJSON(w, http.StatusOK, slackResponse{ResponseType: slackEphemeral, Text: text})
+	}
+
+	team := form.Get("team_id")
+
+	key, found := s.slack.keys[team]
+	if !found {
+		reply("This Slack workspace is not connected to the link shortener")
+		s.logger.WarnContext(r.Context(), "Slack team is not connected", "team_id", team)
+		return
+	}
+
+	rawUrl := slackCommandUrl(form.Get("text"))
+	if rawUrl == "" || rawUrl == "help" {
+		reply("Usage: " + form.Get("command") + " https://example.com/long/path")
+		return
+	}
+
+	shortUrl, problem := s.chatShorten(r.Context(), key, rawUrl)
+	if problem != "" {
+		reply(problem)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Slack command served", "team_id", team, "user_id", form.Get("user_id"),
+		"short_url", shortUrl)
+
+	s.writeJSON(w, http.StatusOK, slackResponse{ResponseType: s.slack.responseType, Text: shortUrl})
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/database"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultStatsTop = 10  // Размер списков самых частых значений по умолчанию
+	maxStatsTop     = 100 // Максимальный размер списков самых частых значений
+)
+
+// statsPeriods - Период статистики по умолчанию для допустимых интервалов
+var statsPeriods = map[string]time.Duration{
+	"hour": 48 * time.Hour,
+	"day":  30 * 24 * time.Hour,
+}
+
+// clickAnalytics - Интерфейс хранилища переходов, из которого читается их статистика (БД или ClickHouse)
+type clickAnalytics interface {
+	GetLinkStats(ctx context.Context, shortUrl, bucket string, from, to time.Time, top int,
+		includeBots bool) (*database.LinkStats, error)
+	GetWorkspaceStats(ctx context.Context, workspaceId int, today, week time.Time, top int) (*database.WorkspaceStats, error)
+	GetDailyClicks(ctx context.Context, shortUrl string, days int) ([]database.ClickCount, error)
+	ExportClicks(ctx context.Context, filter database.ClickExportFilter, fn func(database.ClickEvent) error) error
+	ExportClickCounts(ctx context.Context, filter database.ClickExportFilter, bucket string,
+		fn func(shortUrl string, count database.ClickCount) error) error
+	ExportDayClickAggregates(ctx context.Context, day time.Time, fn func(database.ClickAggregate) error) error
+}
+
+// StatsCounter - Тип данных, описывающий количество переходов для значения признака в API
+type StatsCounter struct {
+	Value  string `json:"value"`  // Значение признака
+	Clicks int    `json:"clicks"` // Количество переходов
+}
+
+// LinkStatsResponse - Тип данных, описывающий ответ на запрос статистики ссылки
+type LinkStatsResponse struct {
+	Code      string         `json:"code"`      // Код короткой ссылки
+	Bucket    string         `json:"bucket"`    // Интервал группировки ("hour" или "day")
+	From      time.Time      `json:"from"`      // Начало периода
+	To        time.Time      `json:"to"`        // Конец периода
+	Total     int            `json:"total"`     // Общее количество переходов за период
+	Bots      int            `json:"bots"`      // Количество переходов ботов за период
+	Timeline  []ClickPoint   `json:"timeline"`  // Количество переходов по интервалам
+	Referrers []StatsCounter `json:"referrers"` // Самые частые источники переходов
+	Countries []StatsCounter `json:"countries"` // Самые частые страны
+	Browsers  []StatsCounter `json:"browsers"`  // Самые частые браузеры
+	Systems   []StatsCounter `json:"os"`        // Самые частые операционные системы
+	Devices   []StatsCounter `json:"devices"`   // Распределение по классам устройств
+	Variants  []StatsCounter `json:"variants"`  // Распределение по вариантам исходной ссылки
+}
+
+// GetLinkStats - Метод, реализующий обработку "Get" запроса на получение статистики переходов по ссылке
+// (параметры: bucket=hour|day, from и to в формате RFC 3339, top - размер списков,
+// include_bots=true - учитывать переходы ботов)
+func (s *Server) GetLinkStats(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	code := ps.ByName("code")
+	shortUrl := shortUrlOnDomain(linkDomain(r), code)
+
+	if _, isExist := s.workspaceLink(w, r, shortUrl); !isExist {
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		bucket = "day"
+	}
+
+	period, found := statsPeriods[bucket]
+	if !found {
+		http.Error(w, "Error: Invalid bucket (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid stats bucket", "bucket", bucket)
+		return
+	}
+
+	from, to, err := parsePeriod(r, period)
+	if err != nil {
+		http.Error(w, "Error: Invalid period (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid stats period", "error", err)
+		return
+	}
+
+	top := queryInt(r, "top", defaultStatsTop, 1, maxStatsTop)
+
+	includeBots := r.URL.Query().Get("include_bots") == "true"
+
+	stats, err := s.analytics.GetLinkStats(r.Context(), shortUrl, bucket, from, to, top, includeBots)
+	if err != nil {
+		http.Error(w, "Error: Failed to read stats (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read stats", "error", err)
+		return
+	}
+
+	resp := LinkStatsResponse{
+		Code:      code,
+		Bucket:    bucket,
+		From:      from,
+		To:        to,
+		Total:     stats.Total,
+		Bots:      stats.Bots,
+		Timeline:  make([]ClickPoint, 0, len(stats.Timeline)),
+		Referrers: statsCounters(stats.Referrers),
+		Countries: statsCounters(stats.Countries),
+		Browsers:  statsCounters(stats.Browsers),
+		Systems:   statsCounters(stats.Systems),
+		Devices:   statsCounters(stats.Devices),
+		Variants:  statsCounters(stats.Variants),
+	}
+
+	for _, c := range stats.Timeline {
+		resp.Timeline = append(resp.Timeline, ClickPoint{Time: c.Time, Clicks: c.Clicks})
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// TopLink - Тип данных, описывающий ссылку из списка самых посещаемых в API
+type TopLink struct {
+	Code     string `json:"code"`      // Код короткой ссылки
+	ShortUrl string `json:"short_url"` // Короткая ссылка
+	Clicks   int    `json:"clicks"`    // Количество переходов
+}
+
+// WorkspaceStatsResponse - Тип данных, описывающий ответ на запрос сводной статистики рабочего пространства
+type WorkspaceStatsResponse struct {
+	Links       int       `json:"links"`        // Количество ссылок
+	ClicksToday int       `json:"clicks_today"` // Переходы с начала суток (UTC)
+	ClicksWeek  int       `json:"clicks_week"`  // Переходы с начала недели (понедельник, UTC)
+	TopLinks    []TopLink `json:"top_links"`    // Самые посещаемые за неделю ссылки
+}
+
+// GetWorkspaceStats - Метод, реализующий обработку "Get" запроса на получение сводной статистики
+// рабочего пространства (параметр top - размер списка самых посещаемых ссылок; переходы ботов не учитываются)
+func (s *Server) GetWorkspaceStats(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+	top := queryInt(r, "top", defaultStatsTop, 1, maxStatsTop)
+
+	stats, err := s.analytics.GetWorkspaceStats(r.Context(), workspaceIdFromContext(r.Context()), today, week, top)
+	if err != nil {
+		http.Error(w, "Error: Failed to read stats (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read stats", "error", err)
+		return
+	}
+
+	resp := WorkspaceStatsResponse{
+		Links:       stats.Links,
+		ClicksToday: stats.ClicksToday,
+		ClicksWeek:  stats.ClicksWeek,
+		TopLinks:    make([]TopLink, 0, len(stats.TopLinks)),
+	}
+
+	for _, c := range stats.TopLinks {
+		resp.TopLinks = append(resp.TopLinks, TopLink{Code: codeFromShortUrl(c.Value), ShortUrl: c.Value,
+			Clicks: c.Clicks})
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// parsePeriod - Функция, реализующая чтение периода из параметров from и to
+// (по умолчанию период заданной длительности, заканчивающийся в текущий момент)
+func parsePeriod(r *http.Request, def time.Duration) (time.Time, time.Time, error) {
+
+	to := time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = t
+	}
+
+	from := to.Add(-def)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = t
+	}
+
+	return from, to, nil
+}
+
+// statsCounters - Функция, реализующая преобразование счетчиков БД в представление для API
+func statsCounters(counters []database.Counter) []StatsCounter {
+
+	result := make([]StatsCounter, 0, len(counters))
+	for _, c := range counters {
+		result = append(result, StatsCounter{Value: c.Value, Clicks: c.Clicks})
+	}
+
+	return result
+}
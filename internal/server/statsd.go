@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"my_project/urlgen/config"
+	"my_project/urlgen/pkg/statsd"
+	"os"
+	"strings"
+	"time"
+)
+
+// statsdPush - Тип данных, описывающий отправку метрик сервера в StatsD в дополнение к /metrics
+type statsdPush struct {
+	emitter  *statsd.Emitter // Отправитель метрик
+	interval time.Duration   // Интервал отправки
+}
+
+// statsdFromEnv - Функция, позволяющая получить отправку метрик в StatsD из переменной STATSD_ADDR
+// ("host:port" агента, например "localhost:8125"; nil, если не задан): STATSD_FORMAT - "statsd" (по умолчанию)
+// или "dogstatsd", STATSD_PREFIX - префикс имен, STATSD_TAGS - постоянные теги DogStatsD через запятую,
+// STATSD_INTERVAL - интервал отправки (config.StatsDInterval по умолчанию)
+func statsdFromEnv(gatherer prometheus.Gatherer) (*statsdPush, error) {
+
+	addr := os.Getenv("STATSD_ADDR")
+	if addr == "" {
+		return nil, nil
+	}
+
+	format := os.Getenv("STATSD_FORMAT")
+	if format == "" {
+		format = statsd.FormatStatsD
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(os.Getenv("STATSD_TAGS"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) != 0 && format != statsd.FormatDogStatsD {
+		return nil, errors.New("error: STATSD_TAGS requires STATSD_FORMAT=dogstatsd")
+	}
+
+	interval := config.StatsDInterval
+	if v := os.Getenv("STATSD_INTERVAL"); v != "" {
+		var err error
+
+		interval, err = time.ParseDuration(v)
+		if err != nil || interval < time.Second {
+			return nil, errors.New("error: STATSD_INTERVAL must be a duration of at least 1s")
+		}
+	}
+
+	emitter, err := statsd.EmitterCreate(addr, format, os.Getenv("STATSD_PREFIX"), tags, gatherer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &statsdPush{emitter: emitter, interval: interval}, nil
+}
+
+// pushMetrics - Метод, реализующий фоновую отправку метрик в StatsD до остановки сервера
+// (при остановке метрики отправляются последний раз, чтобы не потерять приращения счетчиков)
+func (s *Server) pushMetrics() {
+
+	ticker := time.NewTicker(s.statsd.interval)
+	defer ticker.Stop()
+	defer s.statsd.emitter.Close()
+
+	for {
+		select {
+		case <-s.context.Done():
+			if err := s.statsd.emitter.Push(); err != nil {
+				s.logger.Warn("Failed to push metrics to StatsD", "error", err)
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.statsd.emitter.Push(); err != nil {
+			s.logger.Warn("Failed to push metrics to StatsD", "error", err)
+		}
+	}
+}
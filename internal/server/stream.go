@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/click_pipeline"
+	"time"
+)
+
+// Потоки событий
+const (
+	streamClicks = "clicks" // Переходы по ссылкам
+	streamLinks  = "links"  // События жизненного цикла ссылок
+)
+
+// StreamClick - Тип данных, описывающий сообщение о переходе в потоке событий
+type StreamClick struct {
+	SchemaVersion int       `json:"schema_version"`         // Версия схемы сообщений
+	Event         string    `json:"event"`                  // Тип события ("click")
+	WorkspaceId   int       `json:"workspace_id"`           // Рабочее пространство ссылки
+	Code          string    `json:"code"`                   // Код короткой ссылки
+	ShortUrl      string    `json:"short_url"`              // Короткая ссылка
+	Time          time.Time `json:"time"`                   // Время перехода
+	Referrer      string    `json:"referrer,omitempty"`     // Источник перехода
+	Country       string    `json:"country,omitempty"`      // Код страны клиента
+	Region        string    `json:"region,omitempty"`       // Код региона клиента
+	Browser       string    `json:"browser,omitempty"`      // Браузер клиента
+	OS            string    `json:"os,omitempty"`           // Операционная система клиента
+	DeviceClass   string    `json:"device_class,omitempty"` // Класс устройства клиента
+	Bot           bool      `json:"bot"`                    // Признак перехода бота или предзагрузки
+	Variant       int       `json:"variant"`                // Номер варианта исходной ссылки (0 - основная)
+}
+
+// StreamLinkEvent - Тип данных, описывающий сообщение о событии ссылки в потоке событий
+type StreamLinkEvent struct {
+	SchemaVersion int       `json:"schema_version"` // Версия схемы сообщений
+	Event         string    `json:"event"`          // Тип события ("link.created", "link.updated", ...)
+	Time          time.Time `json:"time"`           // Время события
+	Link          Link      `json:"link"`           // Ссылка
+}
+
+// EventMessage - Тип данных, описывающий сообщение потока событий
+type EventMessage struct {
+	Stream string // Поток (streamClicks или streamLinks)
+	Key    string // Ключ сообщения (короткая ссылка: события одной ссылки сохраняют порядок)
+	Event  string // Тип события
+	Value  []byte // Тело сообщения (JSON)
+}
+
+// EventPublisher - Интерфейс отправки сообщений потока событий во внешнюю систему (Kafka, NATS, RabbitMQ)
+// (Publish не ожидает доставки, ошибки учитываются самим отправителем; Close отправляет оставшиеся сообщения)
+type EventPublisher interface {
+	Publish(messages []EventMessage)
+	Close() error
+}
+
+// streamResult - Метод, реализующий учет результата отправки сообщений потока событий
+func (s *Server) streamResult(backend string, messages int, err error) {
+
+	if err != nil {
+		s.metrics.streamMessages.WithLabelValues(backend, "failed").Add(float64(messages))
+		s.logger.Error("Failed to publish events", "backend", backend, "messages", messages, "error", err)
+		return
+	}
+
+	s.metrics.streamMessages.WithLabelValues(backend, "published").Add(float64(messages))
+}
+
+// publishStream - Метод, реализующий отправку сообщений во все настроенные потоки событий
+func (s *Server) publishStream(messages []EventMessage) {
+
+	if len(messages) == 0 {
+		return
+	}
+
+	for _, p := range s.streams {
+		p.Publish(messages)
+	}
+}
+
+// streamClickEvents - Метод, реализующий отправку пачки переходов, записанных в БД, в потоки событий
+func (s *Server) streamClickEvents(events []click_pipeline.Event) {
+
+	if len(s.streams) == 0 {
+		return
+	}
+
+	messages := make([]EventMessage, 0, len(events))
+
+	for _, e := range events {
+		value, err := json.Marshal(StreamClick{
+			SchemaVersion: config.StreamSchemaVersion,
+			Event:         eventClick,
+			WorkspaceId:   e.WorkspaceId,
+			Code:          codeFromShortUrl(e.ShortUrl),
+			ShortUrl:      e.ShortUrl,
+			Time:          e.Time,
+			Referrer:      e.Referrer,
+			Country:       e.Country,
+			Region:        e.Region,
+			Browser:       e.Browser,
+			OS:            e.OS,
+			DeviceClass:   e.DeviceClass,
+			Bot:           e.Bot,
+			Variant:       e.Variant,
+		})
+		if err != nil {
+			s.logger.Error("Failed to encode click event", "error", err)
+			continue
+		}
+
+		messages = append(messages, EventMessage{Stream: streamClicks, Key: e.ShortUrl, Event: eventClick, Value: value})
+	}
+
+	s.publishStream(messages)
+}
+
+// streamLinkEvent - Метод, реализующий отправку события ссылки в потоки событий
+func (s *Server) streamLinkEvent(event string, row database.RowData) {
+
+	if len(s.streams) == 0 {
+		return
+	}
+
+	value, err := json.Marshal(StreamLinkEvent{
+		SchemaVersion: config.StreamSchemaVersion,
+		Event:         event,
+		Time:          time.Now(),
+		Link:          linkFromRow(row),
+	})
+	if err != nil {
+		s.logger.Error("Failed to encode link event", "error", err)
+		return
+	}
+
+	s.publishStream([]EventMessage{{Stream: streamLinks, Key: row.ShortUrl, Event: event, Value: value}})
+}
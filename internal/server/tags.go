@@ -0,0 +1,38 @@
+package server
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxLinkTags - Максимальное количество меток ссылки
+const maxLinkTags = 20
+
+// tagRegexp - Регулярное выражение допустимой метки ссылки
+var tagRegexp = regexp.MustCompile(`^[a-z0-9_.-]{1,32}$`)
+
+// normalizeTags - Функция, реализующая приведение меток ссылки к нижнему регистру с удалением повторов
+func normalizeTags(tags []string) ([]string, error) {
+
+	var result []string
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if !tagRegexp.MatchString(tag) {
+			return nil, errors.New("tags may contain only 1-32 latin letters, digits, \".\", \"-\" and \"_\"")
+		}
+
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	if len(result) > maxLinkTags {
+		return nil, errors.New("a link may have at most 20 tags")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/telegram"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegramSecretHeader - Заголовок секрета запросов вебхука Telegram
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// telegramUsage - Справка бота Telegram
+const telegramUsage = "Send a link to get a short one.\n/stats <code or short link> - clicks of a link"
+
+// telegramBot - Тип данных, описывающий бота Telegram, создающего короткие ссылки по сообщениям
+type telegramBot struct {
+	client       *telegram.Client // Клиент Bot API
+	key          string           // Ключ API, которым создаются ссылки и читается статистика
+	allowedIds   map[int64]bool   // Допущенные пользователи по идентификатору
+	allowedNames map[string]bool  // Допущенные пользователи по имени (строчными, без "@")
+	webhookUrl   string           // Адрес вебхука (пустой - сообщения читаются долгим опросом)
+	secret       string           // Секрет запросов вебхука
+}
+
+// telegramFromEnv - Функция, позволяющая получить бота Telegram из переменных окружения
+// (TELEGRAM_BOT_TOKEN - токен бота, nil, если не задан; TELEGRAM_API_KEY - ключ API; TELEGRAM_ALLOWED_USERS -
+// допущенные пользователи через запятую: идентификаторы или имена; TELEGRAM_WEBHOOK_URL - адрес вебхука
+// вместо долгого опроса; TELEGRAM_API_URL - адрес Bot API, например собственного сервера)
+func telegramFromEnv() (*telegramBot, error) {
+
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil, nil
+	}
+
+	b := telegramBot{
+		client:       telegram.ClientCreate(os.Getenv("TELEGRAM_API_URL"), token),
+		key:          os.Getenv("TELEGRAM_API_KEY"),
+		allowedIds:   map[int64]bool{},
+		allowedNames: map[string]bool{},
+		webhookUrl:   os.Getenv("TELEGRAM_WEBHOOK_URL"),
+	}
+
+	if !strings.HasPrefix(b.key, apiKeyPrefix) {
+		return nil, errors.New("error: TELEGRAM_API_KEY must be an api key (" + apiKeyPrefix + "...)")
+	}
+
+	for _, item := range strings.Split(os.Getenv("TELEGRAM_ALLOWED_USERS"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if id, err := strconv.ParseInt(item, 10, 64); err == nil {
+			b.allowedIds[id] = true
+		} else {
+			b.allowedNames[strings.ToLower(strings.TrimPrefix(item, "@"))] = true
+		}
+	}
+
+	if len(b.allowedIds) == 0 && len(b.allowedNames) == 0 {
+		return nil, errors.New("error: TELEGRAM_ALLOWED_USERS is required with TELEGRAM_BOT_TOKEN")
+	}
+
+	if b.webhookUrl != "" {
+		u, err := url.Parse(b.webhookUrl)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, errors.New("error: TELEGRAM_WEBHOOK_URL must be an https url")
+		}
+	}
+
+	// Секрет вебхука выводится из токена, чтобы все экземпляры сервера регистрировали один и тот же
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte("webhook"))
+	b.secret = hex.EncodeToString(mac.Sum(nil))
+
+	return &b, nil
+}
+
+// allows - Метод, проверяющий, допущен ли отправитель сообщения
+func (b *telegramBot) allows(from *telegram.User) bool {
+	return from != nil && (b.allowedIds[from.Id] || (from.Username != "" && b.allowedNames[strings.ToLower(from.Username)]))
+}
+
+// runTelegram - Метод, реализующий получение сообщений боту до остановки сервера: регистрацию вебхука
+// или долгий опрос (опрос выполняет только ведущий экземпляр, так как Telegram допускает один опрос)
+func (s *Server) runTelegram() {
+
+	for s.context.Err() == nil {
+		var err error
+
+		if s.telegram.webhookUrl != "" {
+			if err = s.telegram.client.SetWebhook(s.context, s.telegram.webhookUrl, s.telegram.secret); err == nil {
+				s.logger.Info("Telegram webhook registered", "url", s.telegram.webhookUrl)
+				return
+			}
+		} else if s.isLeader() {
+			err = s.pollTelegram()
+		}
+
+		if err != nil && s.context.Err() == nil {
+			s.logger.Warn("Failed to receive Telegram messages", "error", err)
+		}
+
+		select {
+		case <-s.context.Done():
+		case <-time.After(config.TelegramRetryDelay):
+		}
+	}
+}
+
+// pollTelegram - Метод, реализующий долгий опрос сообщений боту, пока экземпляр остается ведущим
+func (s *Server) pollTelegram() error {
+
+	// Долгий опрос недоступен, пока у бота есть вебхук
+	if err := s.telegram.client.DeleteWebhook(s.context); err != nil {
+		return err
+	}
+
+	var offset int64
+
+	for s.isLeader() {
+		ctx, cancel := context.WithTimeout(s.context, config.TelegramPollTimeout+config.TelegramTimeout)
+		updates, err := s.telegram.client.GetUpdates(ctx, offset, config.TelegramPollTimeout)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateId + 1
+			s.handleTelegram(s.context, update)
+		}
+	}
+
+	return nil
+}
+
+// TelegramWebhook - Метод, реализующий обработку "Post" запроса вебхука Telegram с сообщением боту
+func (s *Server) TelegramWebhook(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(telegramSecretHeader)), []byte(s.telegram.secret)) != 1 {
+		http.Error(w, "Error: Invalid Telegram secret (status code: 401)", http.StatusUnauthorized)
+		s.logger.WarnContext(r.Context(), "Invalid Telegram secret")
+		return
+	}
+
+	update := telegram.Update{}
+
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	s.handleTelegram(r.Context(), update)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleTelegram - Метод, реализующий ответ бота на сообщение: короткую ссылку, статистику или справку
+func (s *Server) handleTelegram(ctx context.Context, update telegram.Update) {
+
+	m := update.Message
+	if m == nil || strings.TrimSpace(m.Text) == "" {
+		return
+	}
+
+	var answer string
+
+	fields := strings.Fields(m.Text)
+
+	// Команды в группах дополняются именем бота ("/stats@bot")
+	command, _, _ := strings.Cut(fields[0], "@")
+	arg := strings.Join(fields[1:], " ")
+
+	switch {
+	case !s.telegram.allows(m.From):
+		answer = "You are not allowed to use this bot"
+		s.logger.WarnContext(ctx, "Telegram user is not allowed", "chat_id", m.Chat.Id)
+	case command == "/stats" && arg != "":
+		answer = s.telegramStats(ctx, arg)
+	case command == "/shorten" && arg != "":
+		answer = s.telegramShorten(ctx, arg)
+	case strings.HasPrefix(command, "/"):
+		answer = telegramUsage
+	default:
+		answer = s.telegramShorten(ctx, fields[0])
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, config.TelegramTimeout)
+	defer cancel()
+
+	if err := s.telegram.client.SendMessage(sendCtx, m.Chat.Id, answer, m.MessageId); err != nil {
+		s.logger.WarnContext(ctx, "Failed to send Telegram message", "chat_id", m.Chat.Id, "error", err)
+	}
+}
+
+// telegramShorten - Метод, возвращающий ответ бота на ссылку
+func (s *Server) telegramShorten(ctx context.Context, rawUrl string) string {
+
+	shortUrl, problem := s.chatShorten(ctx, s.telegram.key, rawUrl)
+	if problem != "" {
+		return problem
+	}
+
+	s.logger.InfoContext(ctx, "Telegram message served", "short_url", shortUrl)
+
+	return shortUrl
+}
+
+// telegramStats - Метод, возвращающий ответ бота со статистикой переходов по ссылке рабочего пространства
+// ключа API (по коду или короткой ссылке; переходы ботов не учитываются)
+func (s *Server) telegramStats(ctx context.Context, arg string) string {
+
+	row, problem := s.chatLink(ctx, s.telegram.key, arg)
+	if problem != "" {
+		return problem
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-config.TelegramStatsPeriod)
+
+	stats, err := s.analytics.GetLinkStats(ctx, row.ShortUrl, "day", from, to, config.TelegramStatsTop, false)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to read stats", "short_url", row.ShortUrl, "error", err)
+		return "Failed to read stats, try again later"
+	}
+
+	// Переходы за все время считаются хранилищем переходов с момента создания ссылки
+	// (счетчик ссылки ведется только для ссылок с ограничением количества переходов)
+	total, err := s.analytics.GetLinkStats(ctx, row.ShortUrl, "day", row.CreatedAt.UTC(), to, 1, false)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to read stats", "short_url", row.ShortUrl, "error", err)
+		return "Failed to read stats, try again later"
+	}
+
+	days := int(config.TelegramStatsPeriod / (24 * time.Hour))
+
+	lines := []string{
+		row.ShortUrl + " → " + row.Url,
+		fmt.Sprintf("Clicks: %d in total, %d in the last %d days", total.Total, stats.Total, days),
+	}
+
+	top := func(title string, counters []database.Counter) {
+		if len(counters) == 0 {
+			return
+		}
+
+		items := make([]string, 0, len(counters))
+		for _, c := range counters {
+			items = append(items, fmt.Sprintf("%s %d", c.Value, c.Clicks))
+		}
+
+		lines = append(lines, title+": "+strings.Join(items, ", "))
+	}
+
+	top("Countries", stats.Countries)
+	top("Referrers", stats.Referrers)
+	top("Devices", stats.Devices)
+
+	return strings.Join(lines, "\n")
+}
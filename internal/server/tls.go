@@ -0,0 +1,68 @@
+package server
+
+import (
+	"crypto/tls"
+	"golang.org/x/crypto/acme/autocert"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultCertCacheDir - Каталог для хранения полученных сертификатов по умолчанию
+const defaultCertCacheDir = "certs"
+
+// TLSSettings - Тип данных, описывающий настройки TLS сервера
+type TLSSettings struct {
+	CertFile string   // Путь к сертификату (ручная настройка)
+	KeyFile  string   // Путь к закрытому ключу (ручная настройка)
+	Domains  []string // Домены для автоматического получения сертификатов (Let's Encrypt)
+	CacheDir string   // Каталог для хранения полученных сертификатов
+	Email    string   // Контактный адрес для уведомлений центра сертификации
+}
+
+// TLSSettingsFromEnv - Функция, позволяющая получить настройки TLS из переменных окружения
+func TLSSettingsFromEnv() TLSSettings {
+
+	t := TLSSettings{
+		CertFile: os.Getenv("TLS_CERT_FILE"),
+		KeyFile:  os.Getenv("TLS_KEY_FILE"),
+		CacheDir: os.Getenv("ACME_CACHE_DIR"),
+		Email:    os.Getenv("ACME_EMAIL"),
+	}
+
+	for _, d := range strings.Split(os.Getenv("ACME_DOMAINS"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			t.Domains = append(t.Domains, d)
+		}
+	}
+
+	if t.CacheDir == "" {
+		t.CacheDir = defaultCertCacheDir
+	}
+
+	return t
+}
+
+// Manual - Метод, проверяющий, заданы ли сертификат и ключ вручную
+func (t TLSSettings) Manual() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// Auto - Метод, проверяющий, включено ли автоматическое получение сертификатов
+func (t TLSSettings) Auto() bool {
+	return !t.Manual() && len(t.Domains) != 0
+}
+
+// AutocertManager - Метод, реализующий создание менеджера автоматического получения сертификатов
+// (возвращает TLS конфигурацию и обработчик HTTP-01 проверок, перенаправляющий остальные запросы на HTTPS)
+func (t TLSSettings) AutocertManager() (*tls.Config, http.Handler) {
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(t.Domains...),
+		Cache:      autocert.DirCache(t.CacheDir),
+		Email:      t.Email,
+	}
+
+	return m.TLSConfig(), m.HTTPHandler(nil)
+}
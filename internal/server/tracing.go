@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"my_project/urlgen/pkg/request_id"
+	"my_project/urlgen/pkg/tracing"
+	"net/http"
+)
+
+// tracingMiddleware - Метод, реализующий промежуточный обработчик, открывающий серверный спан запроса
+// (трасса продолжается из заголовка traceparent; спан получает маршрут в instrument; обработчики,
+// кеш, генератор кодов и БД добавляют в него события и дочерние спаны через контекст запроса)
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+
+	tracer := tracing.Tracer()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				attribute.String("http.request_id", request_id.FromContext(ctx)),
+			))
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// instrument - Метод, реализующий учет запросов к маршруту в метриках и в названии спана запроса
+func (s *Server) instrument(route, traffic string, next http.Handler) http.Handler {
+
+	measured := s.metrics.instrument(route, traffic, next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), attribute.String("urlgen.traffic", traffic))
+
+		measured.ServeHTTP(w, r)
+	})
+}
+
+// cacheLookup - Метод, реализующий учет обращения к кешу в метриках и событием спана запроса
+func (s *Server) cacheLookup(ctx context.Context, cache string, hit bool) {
+
+	s.metrics.cacheLookup(cache, hit)
+
+	event := "cache miss"
+	if hit {
+		event = "cache hit"
+	}
+
+	trace.SpanFromContext(ctx).AddEvent(event, trace.WithAttributes(attribute.String("cache", cache)))
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/net/idna"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultAllowedSchemes = "http,https"    // Допустимые схемы исходных ссылок по умолчанию
+	lookupTimeout         = 2 * time.Second // Время ожидания разрешения имени узла при проверке частных сетей
+)
+
+// urlPolicy - Тип данных, описывающий правила проверки и нормализации исходных ссылок
+type urlPolicy struct {
+	schemes        map[string]bool // Допустимые схемы
+	stripFragment  bool            // Удалять ли фрагмент ("#...")
+	blockPrivate   bool            // Запрещать ли ссылки на адреса частных сетей
+	domains        *domainPolicy   // Правила доменов исходных ссылок
+	resolver       *net.Resolver   // Сервис разрешения имен узлов
+	lookupDeadline time.Duration   // Время ожидания разрешения имени узла
+}
+
+// urlPolicyFromEnv - Функция, позволяющая получить правила проверки исходных ссылок из переменных окружения
+func urlPolicyFromEnv(domains *domainPolicy) *urlPolicy {
+
+	p := urlPolicy{
+		domains:        domains,
+		schemes:        map[string]bool{},
+		stripFragment:  os.Getenv("URL_STRIP_FRAGMENT") == "true",
+		blockPrivate:   os.Getenv("URL_BLOCK_PRIVATE") == "true",
+		resolver:       net.DefaultResolver,
+		lookupDeadline: lookupTimeout,
+	}
+
+	for _, scheme := range strings.Split(envOrDefault("URL_ALLOWED_SCHEMES", defaultAllowedSchemes), ",") {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if scheme != "" {
+			p.schemes[scheme] = true
+		}
+	}
+
+	return &p
+}
+
+// normalize - Метод, реализующий проверку и приведение исходной ссылки к единому виду
+// (схема и узел в нижнем регистре, узел в punycode, путь без "." и "..", ссылки без схемы считаются "http")
+func (p *urlPolicy) normalize(ctx context.Context, raw string) (string, error) {
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("url is empty")
+	}
+
+	// Ссылки вида "example.com/path", "example.com:8080" и "//example.com/path" дополняются схемой
+	if strings.HasPrefix(raw, "//") {
+		raw = "http:" + raw
+	} else if !hasScheme(raw) {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", errors.New("invalid url")
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if !p.schemes[u.Scheme] {
+		return "", errors.New("url scheme is not allowed")
+	}
+
+	// Приведение узла к нижнему регистру и punycode
+	host := strings.TrimSuffix(u.Hostname(), ".")
+	if host == "" {
+		return "", errors.New("url has no host")
+	}
+
+	if net.ParseIP(host) == nil {
+		host, err = idna.Lookup.ToASCII(strings.ToLower(host))
+		if err != nil {
+			return "", errors.New("invalid url host")
+		}
+	}
+
+	if port := u.Port(); port != "" && !isDefaultPort(u.Scheme, port) {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	// Удаление сегментов "." и ".." из пути
+	if u.Path != "" {
+		cleaned := path.Clean(u.Path)
+		if strings.HasSuffix(u.Path, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		u.Path = cleaned
+		u.RawPath = ""
+	}
+
+	if p.stripFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	if p.domains != nil && !p.domains.allows(host) {
+		return "", errDomainNotAllowed
+	}
+
+	if p.blockPrivate {
+		if err = p.checkPublic(ctx, host); err != nil {
+			return "", err
+		}
+	}
+
+	return u.String(), nil
+}
+
+// checkPublic - Метод, проверяющий, что узел не относится к частной, локальной или служебной сети
+func (p *urlPolicy) checkPublic(ctx context.Context, host string) error {
+
+	var ips []net.IP
+
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, p.lookupDeadline)
+		defer cancel()
+
+		addrs, err := p.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return errors.New("url host cannot be resolved")
+		}
+
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if isPrivateIP(ip) {
+			return errors.New("url points to a private network")
+		}
+	}
+
+	return nil
+}
+
+// normalizePublic - Метод, реализующий проверку и приведение ссылки, по которой запросы отправляет сам сервер
+// (адреса частных сетей отклоняются всегда, независимо от URL_BLOCK_PRIVATE)
+func (p *urlPolicy) normalizePublic(ctx context.Context, raw string) (string, error) {
+
+	normalized, err := p.normalize(ctx, raw)
+	if err != nil || p.blockPrivate {
+		return normalized, err
+	}
+
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return "", errors.New("invalid url")
+	}
+
+	if err = p.checkPublic(ctx, u.Hostname()); err != nil {
+		return "", err
+	}
+
+	return normalized, nil
+}
+
+// errPrivateAddress - Ошибка соединения сервера с адресом частной или локальной сети
+var errPrivateAddress = errors.New("destination points to a private network")
+
+// publicDialControl - Функция, реализующая проверку адреса перед соединением (для net.Dialer.Control)
+// (адрес проверяется после разрешения имени, поэтому смена записи DNS не приводит сервер в частную сеть)
+func publicDialControl(_, address string, _ syscall.RawConn) error {
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+		return errPrivateAddress
+	}
+
+	return nil
+}
+
+// sharedAddressSpace - Диапазон адресов операторской трансляции (RFC 6598), в котором облака размещают служебные сервисы
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPrivateIP - Функция, проверяющая, относится ли адрес к частной, локальной или служебной сети
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() || sharedAddressSpace.Contains(ip)
+}
+
+// hasScheme - Функция, проверяющая, начинается ли ссылка со схемы (RFC 3986: буква, затем буквы, цифры, "+", "-"
+// или "." до первого ":", который стоит раньше "/", "?" и "#"; "example.com:8080" - узел с портом, а не схема)
+func hasScheme(raw string) bool {
+
+	end := strings.IndexAny(raw, ":/?#")
+	if end <= 0 || raw[end] != ':' {
+		return false
+	}
+
+	for i, c := range raw[:end] {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+
+	rest := raw[end+1:]
+
+	return rest == "" || rest[0] < '0' || rest[0] > '9'
+}
+
+// isDefaultPort - Функция, проверяющая, является ли порт портом по умолчанию для схемы
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestHasScheme(t *testing.T) {
+
+	tests := []struct {
+		raw    string
+		scheme bool
+	}{
+		{"https://example.com", true},
+		{"mailto:user@example.com", true},
+		{"svn+ssh://host/repo", true},
+		{"web-cal.v2:x", true},
+		{"http:", true},
+		{"example.com", false},
+		{"example.com:8080", false},
+		{"example.com:8080/path", false},
+		{"localhost:3000", false},
+		{"example.com/a:b", false},
+		{"example.com?next=http://x", false},
+		{"example.com#x:y", false},
+		{"://example.com", false},
+		{"1http://example.com", false},
+		{"ht_tp://example.com", false},
+		{"//example.com", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := hasScheme(tt.raw); got != tt.scheme {
+			t.Errorf("hasScheme(%q) = %t, want %t", tt.raw, got, tt.scheme)
+		}
+	}
+}
+
+func TestUrlPolicyNormalize(t *testing.T) {
+
+	p := &urlPolicy{schemes: map[string]bool{"http": true, "https": true}}
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://example.com", "https://example.com"},
+		{"  HTTPS://Example.COM/Path  ", "https://example.com/Path"},
+		{"example.com/path", "http://example.com/path"},
+		{"example.com:8080", "http://example.com:8080"},
+		{"//example.com/x", "http://example.com/x"},
+		{"example.com/login?next=https://evil.example", "http://example.com/login?next=https://evil.example"},
+		{"http://example.com:80/", "http://example.com/"},
+		{"https://example.com:443/a", "https://example.com/a"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"http://example.com./a/./b/../c/", "http://example.com/a/c/"},
+		{"http://пример.рф/путь", "http://xn--e1afmkfd.xn--p1ai/%D0%BF%D1%83%D1%82%D1%8C"},
+		{"http://[::1]:80/", "http://[::1]/"},
+		{"https://example.com/#top", "https://example.com/#top"},
+	}
+
+	for _, tt := range tests {
+		got, err := p.normalize(context.Background(), tt.raw)
+		if err != nil || got != tt.want {
+			t.Errorf("normalize(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestUrlPolicyReject(t *testing.T) {
+
+	p := &urlPolicy{schemes: map[string]bool{"http": true, "https": true}, blockPrivate: true, stripFragment: true}
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", "   "},
+		{"javascript", "javascript:alert(1)"},
+		{"data", "data:text/html,<script>"},
+		{"file", "file:///etc/passwd"},
+		{"no host", "http://"},
+		{"bad host", "http://exa mple.com"},
+		{"loopback", "http://127.0.0.1/"},
+		{"private", "http://10.1.2.3/"},
+		{"private with port", "192.168.0.1:8080"},
+		{"link-local metadata", "http://169.254.169.254/latest/meta-data"},
+		{"shared address space", "http://100.64.0.1/"},
+		{"unspecified", "http://0.0.0.0/"},
+		{"ipv6 loopback", "http://[::1]/"},
+		{"ipv6 unique local", "http://[fd00::1]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := p.normalize(context.Background(), tt.raw); err == nil {
+				t.Errorf("normalize(%q) = %q, want an error", tt.raw, got)
+			}
+		})
+	}
+
+	got, err := p.normalize(context.Background(), "https://93.184.215.14/page#section")
+	if err != nil || got != "https://93.184.215.14/page" {
+		t.Errorf("normalize of a public address = %q, %v", got, err)
+	}
+}
+
+func TestIsPrivateIP(t *testing.T) {
+
+	tests := []struct {
+		ip      string
+		private bool
+	}{
+		{"8.8.8.8", false},
+		{"100.63.255.255", false},
+		{"100.128.0.0", false},
+		{"2001:4860:4860::8888", false},
+		{"10.0.0.1", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"127.0.0.2", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"100.127.255.255", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fc00::1", true},
+		{"::ffff:127.0.0.1", true},
+	}
+
+	for _, tt := range tests {
+		if got := isPrivateIP(net.ParseIP(tt.ip)); got != tt.private {
+			t.Errorf("isPrivateIP(%s) = %t, want %t", tt.ip, got, tt.private)
+		}
+	}
+}
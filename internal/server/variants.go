@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"my_project/urlgen/database"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	maxVariants         = 10                  // Максимальное количество вариантов исходной ссылки
+	maxVariantWeight    = 1000                // Максимальный вес варианта
+	variantCookiePrefix = "lnk_v_"            // Префикс названия cookie с закрепленным вариантом
+	variantCookieMaxAge = 30 * 24 * time.Hour // Время хранения cookie с закрепленным вариантом
+)
+
+// prepareVariants - Метод, реализующий проверку и нормализацию вариантов исходной ссылки
+func (s *Server) prepareVariants(ctx context.Context, variants []database.Variant) ([]database.Variant, error) {
+
+	if len(variants) > maxVariants {
+		return nil, errors.New("too many variants")
+	}
+
+	result := make([]database.Variant, 0, len(variants))
+
+	for _, v := range variants {
+		if v.Weight <= 0 || v.Weight > maxVariantWeight {
+			return nil, errors.New("variant weight must be from 1 to " + strconv.Itoa(maxVariantWeight))
+		}
+
+		url, err := s.urls.normalize(ctx, v.Url)
+		if err != nil {
+			return nil, errors.New("variant " + err.Error())
+		}
+
+		if s.checkUrl(ctx, url).Malicious {
+			return nil, errors.New("variant url is flagged as malicious")
+		}
+
+		result = append(result, database.Variant{Url: url, Weight: v.Weight})
+	}
+
+	return result, nil
+}
+
+// pickVariant - Метод, реализующий выбор варианта исходной ссылки для перехода
+// (возвращает номер варианта, начиная с 1, или 0, если варианты не заданы; при закреплении вариант
+// сохраняется в cookie и повторно выбирается для того же клиента)
+func (s *Server) pickVariant(w http.ResponseWriter, r *http.Request, row *database.RowData, code string) int {
+
+	if len(row.Variants) == 0 {
+		return 0
+	}
+
+	name := variantCookiePrefix + code
+
+	if row.StickyVariants {
+		if cookie, err := r.Cookie(name); err == nil {
+			if n, err := strconv.Atoi(cookie.Value); err == nil && n >= 1 && n <= len(row.Variants) {
+				return n
+			}
+		}
+	}
+
+	// Выбор варианта с вероятностью, пропорциональной весу
+	total := 0
+	for _, v := range row.Variants {
+		total += v.Weight
+	}
+
+	n := len(row.Variants)
+	point := rand.Intn(total)
+	for i, v := range row.Variants {
+		if point < v.Weight {
+			n = i + 1
+			break
+		}
+		point -= v.Weight
+	}
+
+	if row.StickyVariants {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    strconv.Itoa(n),
+			Path:     "/" + code,
+			MaxAge:   int(variantCookieMaxAge.Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	return n
+}
+
+// variantUrl - Функция, возвращающая исходную ссылку для заданного номера варианта (0 - основная ссылка)
+func variantUrl(row *database.RowData, variant int) string {
+
+	if variant < 1 || variant > len(row.Variants) {
+		return row.Url
+	}
+
+	return row.Variants[variant-1].Url
+}
@@ -0,0 +1,439 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/config"
+	"my_project/urlgen/database"
+	"my_project/urlgen/pkg/click_pipeline"
+	"my_project/urlgen/pkg/webhook"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Типы событий вебхуков
+const (
+	eventClick       = "click"        // Переходы по ссылкам
+	eventLinkCreated = "link.created" // Создание ссылки
+	eventLinkUpdated = "link.updated" // Изменение ссылки
+	eventLinkDeleted = "link.deleted" // Удаление ссылки
+	eventLinkExpired = "link.expired" // Истечение срока действия ссылки
+)
+
+// expirationCheckInterval - Интервал поиска ссылок с истекшим сроком действия для событий "link.expired"
+const expirationCheckInterval = time.Minute
+
+// webhookEvents - Допустимые типы событий вебхуков
+var webhookEvents = map[string]bool{
+	eventClick:       true,
+	eventLinkCreated: true,
+	eventLinkUpdated: true,
+	eventLinkDeleted: true,
+	eventLinkExpired: true,
+}
+
+// Webhook - Тип данных, описывающий представление вебхука в API
+type Webhook struct {
+	Id        int       `json:"id"`               // Идентификатор
+	Url       string    `json:"url"`              // Адрес получателя
+	Secret    string    `json:"secret,omitempty"` // Секрет подписи (возвращается только при создании)
+	Code      string    `json:"code,omitempty"`   // Код ссылки (пустой для вебхука всех ссылок)
+	Events    []string  `json:"events"`           // Типы событий
+	CreatedAt time.Time `json:"created_at"`       // Время создания
+}
+
+// WebhookRequest - Тип данных, описывающий тело запроса на создание вебхука
+type WebhookRequest struct {
+	Url    string   `json:"url"`              // Адрес получателя
+	Secret string   `json:"secret,omitempty"` // Секрет подписи (генерируется, если не задан)
+	Code   string   `json:"code,omitempty"`   // Код ссылки (пустой для вебхука всех ссылок)
+	Events []string `json:"events,omitempty"` // Типы событий (по умолчанию "click")
+}
+
+// ClickPayload - Тип данных, описывающий переход в теле вебхука
+type ClickPayload struct {
+	Code        string    `json:"code"`                   // Код короткой ссылки
+	ShortUrl    string    `json:"short_url"`              // Короткая ссылка
+	Time        time.Time `json:"time"`                   // Время перехода
+	Referrer    string    `json:"referrer,omitempty"`     // Источник перехода
+	Country     string    `json:"country,omitempty"`      // Код страны клиента
+	Region      string    `json:"region,omitempty"`       // Код региона клиента
+	Browser     string    `json:"browser,omitempty"`      // Браузер клиента
+	OS          string    `json:"os,omitempty"`           // Операционная система клиента
+	DeviceClass string    `json:"device_class,omitempty"` // Класс устройства клиента
+	Bot         bool      `json:"bot,omitempty"`          // Признак перехода бота
+}
+
+// WebhookPayload - Тип данных, описывающий тело вебхука
+type WebhookPayload struct {
+	Event  string         `json:"event"`            // Тип события
+	Time   time.Time      `json:"time"`             // Время отправки
+	Clicks []ClickPayload `json:"clicks,omitempty"` // Переходы (для события "click")
+	Link   *Link          `json:"link,omitempty"`   // Ссылка (для событий "link.*")
+}
+
+// webhookClient - Функция, возвращающая клиент HTTP доставки вебхуков (адрес проверяется при каждом соединении,
+// перенаправления не выполняются, поэтому ни получатель, ни его записи DNS не приводят сервер в частную сеть)
+func webhookClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         (&net.Dialer{Timeout: timeout, Control: publicDialControl}).DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        config.WebhookWorkers,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookRegistry - Тип данных, описывающий загруженные из БД вебхуки
+type webhookRegistry struct {
+	sync.RWMutex
+	hooks []database.WebhookData // Вебхуки
+}
+
+// load - Метод, реализующий загрузку вебхуков из БД
+func (reg *webhookRegistry) load(ctx context.Context, db *database.Database) error {
+
+	hooks, err := db.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	reg.Lock()
+	reg.hooks = hooks
+	reg.Unlock()
+
+	return nil
+}
+
+// subscribed - Метод, возвращающий вебхуки, подписанные на заданный тип события
+func (reg *webhookRegistry) subscribed(event string) []database.WebhookData {
+
+	reg.RLock()
+	defer reg.RUnlock()
+
+	var result []database.WebhookData
+
+	for _, hook := range reg.hooks {
+		for _, e := range hook.Events {
+			if e == event {
+				result = append(result, hook)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// clickSink - Тип данных, реализующий хранилище конвейера переходов (запись в БД или ClickHouse и рассылка вебхуков)
+type clickSink struct {
+	server *Server // Сервер
+}
+
+// WriteClicks - Метод, реализующий запись пачки переходов в БД (или в ClickHouse, если он настроен)
+// и отправку их подписанным вебхукам и в потоки событий
+func (c clickSink) WriteClicks(ctx context.Context, events []click_pipeline.Event) error {
+
+	var err error
+	if c.server.clickhouse != nil {
+		err = c.server.clickhouse.WriteClicks(ctx, events)
+	} else {
+		err = c.server.db.WriteClicks(ctx, events)
+	}
+
+	c.server.dispatchClicks(events)
+	c.server.streamClickEvents(events)
+
+	return err
+}
+
+// dispatchClicks - Метод, реализующий отправку пачки переходов подписанным вебхукам
+// (вебхук получает только переходы по ссылкам своего рабочего пространства, вебхук ссылки - только по ней)
+func (s *Server) dispatchClicks(events []click_pipeline.Event) {
+
+	for _, hook := range s.webhookRegistry.subscribed(eventClick) {
+
+		payload := WebhookPayload{Event: eventClick, Time: time.Now()}
+
+		for _, e := range events {
+			if !hookCovers(hook, e.WorkspaceId, e.ShortUrl) {
+				continue
+			}
+
+			payload.Clicks = append(payload.Clicks, ClickPayload{
+				Code:        codeFromShortUrl(e.ShortUrl),
+				ShortUrl:    e.ShortUrl,
+				Time:        e.Time,
+				Referrer:    e.Referrer,
+				Country:     e.Country,
+				Region:      e.Region,
+				Browser:     e.Browser,
+				OS:          e.OS,
+				DeviceClass: e.DeviceClass,
+				Bot:         e.Bot,
+			})
+		}
+
+		if len(payload.Clicks) != 0 {
+			s.sendWebhook(hook, payload)
+		}
+	}
+}
+
+// hookCovers - Функция, проверяющая, относится ли ссылка рабочего пространства к вебхуку
+func hookCovers(hook database.WebhookData, workspaceId int, shortUrl string) bool {
+	return hook.WorkspaceId == workspaceId && (hook.ShortUrl == "" || hook.ShortUrl == shortUrl)
+}
+
+// emitLinkEvent - Метод, реализующий отправку события жизненного цикла ссылки подписанным вебхукам
+// и в потоки событий
+func (s *Server) emitLinkEvent(event string, row database.RowData) {
+
+	link := linkFromRow(row)
+
+	for _, hook := range s.webhookRegistry.subscribed(event) {
+		if !hookCovers(hook, row.WorkspaceId, row.ShortUrl) {
+			continue
+		}
+
+		s.sendWebhook(hook, WebhookPayload{Event: event, Time: time.Now(), Link: &link})
+	}
+
+	s.streamLinkEvent(event, row)
+}
+
+// watchExpirations - Метод, реализующий периодический поиск ссылок с истекшим сроком действия
+// и отправку для них событий "link.expired" (ведущим экземпляром, чтобы событие отправлялось один раз;
+// до отмены контекста сервера)
+func (s *Server) watchExpirations() {
+
+	ticker := time.NewTicker(expirationCheckInterval)
+	defer ticker.Stop()
+
+	since := time.Now()
+
+	for {
+		select {
+		case <-s.context.Done():
+			return
+		case now := <-ticker.C:
+			if !s.isLeader() || (len(s.webhookRegistry.subscribed(eventLinkExpired)) == 0 && len(s.streams) == 0) {
+				since = now
+				continue
+			}
+
+			rows, err := s.db.ListExpiredRows(s.context, since, now)
+			if err != nil {
+				s.logger.Error("Failed to read expired links", "error", err)
+				continue
+			}
+
+			for _, row := range rows {
+				s.emitLinkEvent(eventLinkExpired, row)
+			}
+
+			since = now
+		}
+	}
+}
+
+// sendWebhook - Метод, реализующий постановку тела вебхука в очередь доставки
+func (s *Server) sendWebhook(hook database.WebhookData, payload WebhookPayload) {
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.Error("Failed to encode webhook payload", "error", err)
+		return
+	}
+
+	err = s.webhooks.Send(webhook.Delivery{Url: hook.Url, Secret: hook.Secret, Payload: body})
+	if err != nil {
+		s.logger.Error("Failed to queue webhook", "webhook_id", hook.Id, "error", err)
+	}
+}
+
+// webhookFromData - Функция, реализующая преобразование вебхука БД в представление для API (без секрета)
+func webhookFromData(hook database.WebhookData) Webhook {
+
+	w := Webhook{
+		Id:        hook.Id,
+		Url:       hook.Url,
+		Events:    hook.Events,
+		CreatedAt: hook.CreatedAt,
+	}
+
+	if hook.ShortUrl != "" {
+		w.Code = codeFromShortUrl(hook.ShortUrl)
+	}
+
+	return w
+}
+
+// ListWebhooks - Метод, реализующий обработку "Get" запроса на получение вебхуков рабочего пространства
+func (s *Server) ListWebhooks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	hooks, err := s.db.ListWebhooks(r.Context())
+	if err != nil {
+		http.Error(w, "Error: Failed to read webhooks (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read webhooks", "error", err)
+		return
+	}
+
+	workspaceId := workspaceIdFromContext(r.Context())
+
+	result := make([]Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook.WorkspaceId == workspaceId {
+			result = append(result, webhookFromData(hook))
+		}
+	}
+
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+// CreateWebhook - Метод, реализующий обработку "Post" запроса на создание вебхука
+func (s *Server) CreateWebhook(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	req := WebhookRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.Url == "" {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	if len(req.Events) == 0 {
+		req.Events = []string{eventClick}
+	}
+
+	for _, e := range req.Events {
+		if !webhookEvents[e] {
+			http.Error(w, "Error: Unknown event "+strconv.Quote(e)+" (status code: 400)", http.StatusBadRequest)
+			s.logger.WarnContext(r.Context(), "Unknown webhook event", "event", e)
+			return
+		}
+	}
+
+	// Адрес получателя проверяется по тем же правилам, что и исходные ссылки, но частные сети запрещены всегда
+	url, err := s.urls.normalizePublic(r.Context(), req.Url)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error()+" (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Invalid webhook url", "error", err)
+		return
+	}
+
+	hook := database.WebhookData{
+		Url:    url,
+		Secret: req.Secret,
+		Events: req.Events,
+		UserId: userIdFromContext(r.Context()),
+
+		WorkspaceId: workspaceIdFromContext(r.Context()),
+	}
+
+	if req.Code != "" {
+		hook.ShortUrl = shortUrlOnDomain(linkDomain(r), req.Code)
+
+		if _, isExist := s.workspaceLink(w, r, hook.ShortUrl); !isExist {
+			return
+		}
+	}
+
+	if hook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err = rand.Read(secret); err != nil {
+			http.Error(w, "Error: Failed to generate secret (status code: 500)", http.StatusInternalServerError)
+			s.logger.ErrorContext(r.Context(), "Failed to generate webhook secret", "error", err)
+			return
+		}
+
+		hook.Secret = hex.EncodeToString(secret)
+	}
+
+	created, err := s.db.CreateWebhook(r.Context(), hook)
+	if err != nil {
+		http.Error(w, "Error: Failed to save webhook (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save webhook", "error", err)
+		return
+	}
+
+	s.reloadWebhooks(r.Context())
+
+	s.logger.InfoContext(r.Context(), "Webhook was created", "webhook_id", created.Id, "url", created.Url)
+	s.audit(r.Context(), auditWebhookCreate, strconv.Itoa(created.Id), map[string]any{"url": created.Url})
+
+	resp := webhookFromData(*created)
+	resp.Secret = created.Secret
+
+	s.writeJSON(w, http.StatusCreated, resp)
+}
+
+// DeleteWebhook - Метод, реализующий обработку "Delete" запроса на удаление вебхука
+func (s *Server) DeleteWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.Error(w, "Error: Webhook not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Webhook not found")
+		return
+	}
+
+	found, err := s.db.DeleteWebhook(r.Context(), workspaceIdFromContext(r.Context()), id)
+	if err != nil {
+		http.Error(w, "Error: Failed to delete webhook (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to delete webhook", "error", err)
+		return
+	}
+
+	if !found {
+		http.Error(w, "Error: Webhook not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Webhook not found", "webhook_id", id)
+		return
+	}
+
+	s.reloadWebhooks(r.Context())
+
+	s.logger.InfoContext(r.Context(), "Webhook was deleted", "webhook_id", id)
+	s.audit(r.Context(), auditWebhookDelete, strconv.Itoa(id), nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadWebhooks - Метод, реализующий повторную загрузку вебхуков из БД после изменения
+func (s *Server) reloadWebhooks(ctx context.Context) {
+
+	err := s.webhookRegistry.load(ctx, s.db)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to reload webhooks", "error", err)
+	}
+}
+
+// watchWebhooks - Метод, реализующий периодическое перечитывание вебхуков, созданных и удаленных через API
+// на других экземплярах сервера
+func (s *Server) watchWebhooks() {
+
+	ticker := time.NewTicker(config.WebhooksRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.context.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.reloadWebhooks(s.context)
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateWebhookPrivateUrl(t *testing.T) {
+
+	s := testServer()
+	s.urls = &urlPolicy{
+		schemes:        map[string]bool{"http": true, "https": true},
+		resolver:       net.DefaultResolver,
+		lookupDeadline: lookupTimeout,
+	}
+
+	tests := []string{
+		"http://127.0.0.1:8080/hook",
+		"127.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/hook",
+		"http://[::1]/hook",
+		"http://100.100.100.200/hook",
+	}
+
+	for _, target := range tests {
+		t.Run(target, func(t *testing.T) {
+
+			body := `{"url": "` + target + `"}`
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			s.CreateWebhook(rec, r, nil)
+
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "private network") {
+				t.Errorf("status = %d, body = %q, want 400 for a private network", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWebhookClientPrivateAddress(t *testing.T) {
+
+	reached := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer target.Close()
+
+	client := webhookClient(time.Second)
+
+	for _, u := range []string{target.URL, "http://169.254.169.254/latest/meta-data", "http://[::1]:9/"} {
+		resp, err := client.Post(u, "application/json", strings.NewReader("{}"))
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		if !errors.Is(err, errPrivateAddress) {
+			t.Errorf("Post(%s) error = %v, want errPrivateAddress", u, err)
+		}
+	}
+
+	if reached {
+		t.Error("webhook reached a loopback address")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1/", nil)
+	if err := client.CheckRedirect(req, nil); !errors.Is(err, http.ErrUseLastResponse) {
+		t.Errorf("CheckRedirect = %v, want redirects not followed", err)
+	}
+}
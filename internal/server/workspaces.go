@@ -0,0 +1,557 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"my_project/urlgen/database"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Роли участников рабочего пространства (в порядке возрастания прав)
+const (
+	roleMember = "member" // Управление ссылками
+	roleAdmin  = "admin"  // Дополнительно управление вебхуками, участниками и ключами API
+	roleOwner  = "owner"  // Дополнительно назначение владельцев
+)
+
+const (
+	workspaceHeader = "X-Workspace-Id" // Заголовок выбора рабочего пространства запроса
+	apiKeyPrefix    = "lsk_"           // Префикс ключей API (отличает их от JWT в заголовке "Authorization")
+)
+
+// errWorkspaceNotFound - Ошибка выбора рабочего пространства, в котором пользователь не состоит
+var errWorkspaceNotFound = errors.New("workspace not found")
+
+// roleRanks - Уровни прав ролей рабочего пространства
+var roleRanks = map[string]int{
+	roleMember: 1,
+	roleAdmin:  2,
+	roleOwner:  3,
+}
+
+// workspaceContextKey - Тип данных, описывающий ключ для хранения доступа к рабочему пространству в контексте запроса
+type workspaceContextKey struct{}
+
+// workspaceAccess - Тип данных, описывающий доступ запроса к рабочему пространству
+type workspaceAccess struct {
+	Id    int    // Идентификатор рабочего пространства
+	Role  string // Роль пользователя или ключа API
+	KeyId int    // Идентификатор ключа API (0 для запроса пользователя)
+	Tier  string // Уровень квот ключа API
+}
+
+// allows - Метод, проверяющий, достаточно ли роли доступа для заданной роли
+func (a *workspaceAccess) allows(role string) bool {
+	return roleRanks[a.Role] >= roleRanks[role]
+}
+
+// Workspace - Тип данных, описывающий представление рабочего пространства в API
+type Workspace struct {
+	Id        int       `json:"id"`             // Идентификатор
+	Name      string    `json:"name"`           // Название
+	Role      string    `json:"role,omitempty"` // Роль текущего пользователя
+	CreatedAt time.Time `json:"created_at"`     // Время создания
+}
+
+// Member - Тип данных, описывающий участника рабочего пространства в API
+type Member struct {
+	UserId    int       `json:"user_id"`              // Идентификатор пользователя
+	Username  string    `json:"username,omitempty"`   // Имя пользователя
+	Role      string    `json:"role"`                 // Роль
+	CreatedAt time.Time `json:"created_at,omitempty"` // Время добавления
+}
+
+// MemberRequest - Тип данных, описывающий тело запроса на добавление участника
+type MemberRequest struct {
+	Username string `json:"username"` // Имя пользователя
+	Role     string `json:"role"`     // Роль (по умолчанию "member")
+}
+
+// ApiKey - Тип данных, описывающий ключ API в API
+type ApiKey struct {
+	Id        int       `json:"id"`             // Идентификатор
+	Name      string    `json:"name"`           // Название
+	Role      string    `json:"role"`           // Роль ("admin" или "member")
+	Tier      string    `json:"tier,omitempty"` // Назначенный уровень квот
+	Key       string    `json:"key,omitempty"`  // Ключ (возвращается только при создании)
+	CreatedAt time.Time `json:"created_at"`     // Время создания
+}
+
+// apiKeyFromData - Функция, преобразующая ключ API из БД в представление API (без самого ключа)
+func apiKeyFromData(k database.ApiKeyData) ApiKey {
+	return ApiKey{Id: k.Id, Name: k.Name, Role: k.Role, Tier: k.Tier, CreatedAt: k.CreatedAt}
+}
+
+// ApiKeyRequest - Тип данных, описывающий тело запроса на создание ключа API
+type ApiKeyRequest struct {
+	Name string `json:"name"` // Название
+	Role string `json:"role"` // Роль (по умолчанию "member")
+}
+
+// requireWorkspace - Метод, реализующий промежуточный обработчик, допускающий аутентифицированные запросы
+// участников рабочего пространства с ролью не ниже заданной
+func (s *Server) requireWorkspace(role string, next httprouter.Handle) httprouter.Handle {
+	return s.requireAuth(s.withWorkspace(role, next))
+}
+
+// withWorkspace - Метод, реализующий промежуточный обработчик выбора рабочего пространства запроса
+// (параметр пути "workspace", заголовок "X-Workspace-Id" или пространство пользователя по умолчанию;
+// ключ API всегда действует в своем пространстве, анонимный запрос пропускается без пространства)
+func (s *Server) withWorkspace(role string, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		requested := ps.ByName("workspace")
+		if requested == "" {
+			requested = r.Header.Get(workspaceHeader)
+		}
+
+		access, ok := r.Context().Value(workspaceContextKey{}).(*workspaceAccess)
+
+		switch {
+		case ok:
+			// Ключ API
+			if requested != "" && requested != strconv.Itoa(access.Id) {
+				http.Error(w, "Error: Api key belongs to another workspace (status code: 403)", http.StatusForbidden)
+				s.logger.WarnContext(r.Context(), "Api key belongs to another workspace", "key_id", access.KeyId, "workspace", requested)
+				return
+			}
+		default:
+			claims, isUser := userFromContext(r.Context())
+			if !isUser {
+				next(w, r, ps)
+				return
+			}
+
+			var err error
+			access, err = s.userWorkspace(r.Context(), claims.UserId, claims.Username, requested)
+			if errors.Is(err, errWorkspaceNotFound) {
+				http.Error(w, "Error: Workspace not found (status code: 404)", http.StatusNotFound)
+				s.logger.WarnContext(r.Context(), "Workspace not found", "user_id", claims.UserId, "workspace", requested)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Error: Failed to read workspace (status code: 500)", http.StatusInternalServerError)
+				s.logger.ErrorContext(r.Context(), "Failed to read workspace", "user_id", claims.UserId, "error", err)
+				return
+			}
+		}
+
+		if !access.allows(role) {
+			http.Error(w, "Error: Insufficient workspace role (status code: 403)", http.StatusForbidden)
+			s.logger.WarnContext(r.Context(), "Insufficient workspace role", "workspace_id", access.Id, "role", access.Role)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), workspaceContextKey{}, access)), ps)
+	}
+}
+
+// userWorkspace - Метод, реализующий определение рабочего пространства запроса пользователя
+// (errWorkspaceNotFound для чужого или несуществующего пространства)
+func (s *Server) userWorkspace(ctx context.Context, userId int, username, requested string) (*workspaceAccess, error) {
+
+	if requested == "" {
+		ws, err := s.db.EnsureDefaultWorkspace(ctx, userId, username)
+		if err != nil {
+			return nil, err
+		}
+
+		return &workspaceAccess{Id: ws.Id, Role: ws.Role}, nil
+	}
+
+	id, err := strconv.Atoi(requested)
+	if err != nil {
+		return nil, errWorkspaceNotFound
+	}
+
+	role, found, err := s.db.GetMemberRole(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errWorkspaceNotFound
+	}
+
+	return &workspaceAccess{Id: id, Role: role}, nil
+}
+
+// authenticateApiKey - Метод, реализующий проверку ключа API и добавление доступа к его пространству в контекст
+func (s *Server) authenticateApiKey(r *http.Request, key string) (*http.Request, bool) {
+
+	ctx, valid := s.apiKeyContext(r.Context(), key)
+	if !valid {
+		return r, false
+	}
+
+	return r.WithContext(ctx), true
+}
+
+// apiKeyContext - Метод, возвращающий контекст с доступом ключа API к его пространству
+// (false, если ключ не найден или отозван)
+func (s *Server) apiKeyContext(ctx context.Context, key string) (context.Context, bool) {
+
+	sum := sha256.Sum256([]byte(key))
+
+	apiKey, isExist := s.db.GetApiKey(ctx, hex.EncodeToString(sum[:]))
+	if !isExist {
+		return ctx, false
+	}
+
+	return context.WithValue(ctx, workspaceContextKey{}, &workspaceAccess{
+		Id:    apiKey.WorkspaceId,
+		Role:  apiKey.Role,
+		KeyId: apiKey.Id,
+		Tier:  apiKey.Tier,
+	}), true
+}
+
+// workspaceFromContext - Функция, позволяющая получить доступ к рабочему пространству из контекста запроса
+// (пустой доступ для запроса без пространства)
+func workspaceFromContext(ctx context.Context) *workspaceAccess {
+	if access, ok := ctx.Value(workspaceContextKey{}).(*workspaceAccess); ok {
+		return access
+	}
+
+	return &workspaceAccess{}
+}
+
+// workspaceIdFromContext - Функция, позволяющая получить идентификатор рабочего пространства запроса
+func workspaceIdFromContext(ctx context.Context) int {
+	return workspaceFromContext(ctx).Id
+}
+
+// workspaceLink - Метод, реализующий получение ссылки рабочего пространства запроса по короткой ссылке
+// (ссылки других пространств не раскрываются: ответ 404 записывается методом)
+func (s *Server) workspaceLink(w http.ResponseWriter, r *http.Request, shortUrl string) (*database.RowData, bool) {
+
+	row, isExist := s.db.GetShortUrlRow(r.Context(), shortUrl)
+	if !isExist || row.WorkspaceId != workspaceIdFromContext(r.Context()) {
+		http.Error(w, "Error: Url not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Url not found", "short_url", shortUrl)
+		return nil, false
+	}
+
+	return row, true
+}
+
+// originalUrlKey - Функция, возвращающая ключ кеша исходной ссылки рабочего пространства на домене коротких ссылок
+// (ключи основного домена не содержат домена)
+func originalUrlKey(workspaceId int, domain, url string) string {
+
+	if domain != "" {
+		url = domain + " " + url
+	}
+
+	if workspaceId == 0 {
+		return url
+	}
+
+	return strconv.Itoa(workspaceId) + " " + url
+}
+
+// requireUserToken - Метод, проверяющий, что запрос выполнен пользователем, а не ключом API
+// (при отказе ответ 403 записывается методом)
+func (s *Server) requireUserToken(w http.ResponseWriter, r *http.Request) bool {
+
+	if workspaceFromContext(r.Context()).KeyId != 0 {
+		http.Error(w, "Error: User token required (status code: 403)", http.StatusForbidden)
+		s.logger.WarnContext(r.Context(), "User token required")
+		return false
+	}
+
+	return true
+}
+
+// ListWorkspaces - Метод, реализующий обработку "Get" запроса на получение рабочих пространств пользователя
+func (s *Server) ListWorkspaces(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	spaces, err := s.db.ListUserWorkspaces(r.Context(), userIdFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, "Error: Failed to read workspaces (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read workspaces", "error", err)
+		return
+	}
+
+	result := make([]Workspace, 0, len(spaces))
+	for _, ws := range spaces {
+		result = append(result, Workspace{Id: ws.Id, Name: ws.Name, Role: ws.Role, CreatedAt: ws.CreatedAt})
+	}
+
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+// CreateWorkspace - Метод, реализующий обработку "Post" запроса на создание рабочего пространства
+// (пользователь становится его владельцем)
+func (s *Server) CreateWorkspace(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	req := Workspace{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	ws, err := s.db.CreateWorkspace(r.Context(), strings.TrimSpace(req.Name), userIdFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, "Error: Failed to save workspace (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save workspace", "error", err)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Workspace was created", "workspace_id", ws.Id, "name", ws.Name)
+
+	s.writeJSON(w, http.StatusCreated, Workspace{Id: ws.Id, Name: ws.Name, Role: ws.Role, CreatedAt: ws.CreatedAt})
+}
+
+// ListMembers - Метод, реализующий обработку "Get" запроса на получение участников рабочего пространства
+func (s *Server) ListMembers(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	members, err := s.db.ListMembers(r.Context(), workspaceIdFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, "Error: Failed to read members (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read members", "error", err)
+		return
+	}
+
+	result := make([]Member, 0, len(members))
+	for _, m := range members {
+		result = append(result, Member{UserId: m.UserId, Username: m.Username, Role: m.Role, CreatedAt: m.CreatedAt})
+	}
+
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+// AddMember - Метод, реализующий обработку "Post" запроса на добавление участника или изменение его роли
+// (назначать и понижать владельцев может только владелец)
+func (s *Server) AddMember(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	req := MemberRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if req.Role == "" {
+		req.Role = roleMember
+	}
+	if err != nil || req.Username == "" || roleRanks[req.Role] == 0 {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	access := workspaceFromContext(r.Context())
+
+	user, isExist := s.db.GetUser(r.Context(), req.Username)
+	if !isExist {
+		http.Error(w, "Error: User not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "User not found", "username", req.Username)
+		return
+	}
+
+	current, isMember, err := s.db.GetMemberRole(r.Context(), access.Id, user.Id)
+	if err != nil {
+		http.Error(w, "Error: Failed to read members (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read members", "error", err)
+		return
+	}
+
+	if (req.Role == roleOwner || (isMember && current == roleOwner)) && !access.allows(roleOwner) {
+		http.Error(w, "Error: Only owners may manage owners (status code: 403)", http.StatusForbidden)
+		s.logger.WarnContext(r.Context(), "Only owners may manage owners", "workspace_id", access.Id)
+		return
+	}
+
+	member, err := s.db.SetMember(r.Context(), access.Id, user.Id, req.Role)
+	if errors.Is(err, database.ErrLastOwner) {
+		http.Error(w, "Error: Workspace must keep an owner (status code: 409)", http.StatusConflict)
+		s.logger.WarnContext(r.Context(), "Workspace must keep an owner", "workspace_id", access.Id)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error: Failed to save member (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save member", "error", err)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Workspace member was saved", "workspace_id", access.Id, "user_id", user.Id, "role", member.Role)
+	s.audit(r.Context(), auditMemberSave, strconv.Itoa(user.Id), map[string]any{"username": user.Username, "role": member.Role})
+
+	s.writeJSON(w, http.StatusOK, Member{UserId: member.UserId, Username: user.Username, Role: member.Role,
+		CreatedAt: member.CreatedAt})
+}
+
+// DeleteMember - Метод, реализующий обработку "Delete" запроса на исключение участника рабочего пространства
+func (s *Server) DeleteMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	access := workspaceFromContext(r.Context())
+
+	userId, err := strconv.Atoi(ps.ByName("user"))
+	if err != nil {
+		http.Error(w, "Error: Member not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Member not found")
+		return
+	}
+
+	current, isMember, err := s.db.GetMemberRole(r.Context(), access.Id, userId)
+	if err == nil && isMember && current == roleOwner && !access.allows(roleOwner) {
+		http.Error(w, "Error: Only owners may manage owners (status code: 403)", http.StatusForbidden)
+		s.logger.WarnContext(r.Context(), "Only owners may manage owners", "workspace_id", access.Id)
+		return
+	}
+
+	found := false
+	if err == nil && isMember {
+		found, err = s.db.DeleteMember(r.Context(), access.Id, userId)
+	}
+
+	if errors.Is(err, database.ErrLastOwner) {
+		http.Error(w, "Error: Workspace must keep an owner (status code: 409)", http.StatusConflict)
+		s.logger.WarnContext(r.Context(), "Workspace must keep an owner", "workspace_id", access.Id)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error: Failed to delete member (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to delete member", "error", err)
+		return
+	}
+
+	if !found {
+		http.Error(w, "Error: Member not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Member not found", "workspace_id", access.Id, "user_id", userId)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Workspace member was deleted", "workspace_id", access.Id, "user_id", userId)
+	s.audit(r.Context(), auditMemberDelete, strconv.Itoa(userId), map[string]any{"role": current})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListApiKeys - Метод, реализующий обработку "Get" запроса на получение ключей API рабочего пространства
+func (s *Server) ListApiKeys(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	keys, err := s.db.ListApiKeys(r.Context(), workspaceIdFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, "Error: Failed to read api keys (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to read api keys", "error", err)
+		return
+	}
+
+	result := make([]ApiKey, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, apiKeyFromData(k))
+	}
+
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+// CreateApiKey - Метод, реализующий обработку "Post" запроса на выпуск ключа API рабочего пространства
+// (ключ возвращается только в ответе, в БД хранится его хеш)
+func (s *Server) CreateApiKey(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	req := ApiKeyRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if req.Role == "" {
+		req.Role = roleMember
+	}
+	if err != nil || strings.TrimSpace(req.Name) == "" || (req.Role != roleMember && req.Role != roleAdmin) {
+		http.Error(w, "Error: Failed to read request (status code: 400)", http.StatusBadRequest)
+		s.logger.WarnContext(r.Context(), "Failed to read request")
+		return
+	}
+
+	secret := make([]byte, 24)
+	if _, err = rand.Read(secret); err != nil {
+		http.Error(w, "Error: Failed to generate api key (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to generate api key", "error", err)
+		return
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+	sum := sha256.Sum256([]byte(key))
+
+	created, err := s.db.CreateApiKey(r.Context(), database.ApiKeyData{
+		WorkspaceId: workspaceIdFromContext(r.Context()),
+		Name:        strings.TrimSpace(req.Name),
+		Role:        req.Role,
+		UserId:      userIdFromContext(r.Context()),
+	}, hex.EncodeToString(sum[:]))
+	if err != nil {
+		http.Error(w, "Error: Failed to save api key (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to save api key", "error", err)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Api key was created", "workspace_id", created.WorkspaceId, "key_id", created.Id)
+	s.audit(r.Context(), auditApiKeyCreate, strconv.Itoa(created.Id), map[string]any{"name": created.Name, "role": created.Role})
+
+	result := apiKeyFromData(*created)
+	result.Key = key
+
+	s.writeJSON(w, http.StatusCreated, result)
+}
+
+// DeleteApiKey - Метод, реализующий обработку "Delete" запроса на отзыв ключа API рабочего пространства
+func (s *Server) DeleteApiKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+	if !s.requireUserToken(w, r) {
+		return
+	}
+
+	workspaceId := workspaceIdFromContext(r.Context())
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.Error(w, "Error: Api key not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Api key not found")
+		return
+	}
+
+	found, err := s.db.DeleteApiKey(r.Context(), workspaceId, id)
+	if err != nil {
+		http.Error(w, "Error: Failed to delete api key (status code: 500)", http.StatusInternalServerError)
+		s.logger.ErrorContext(r.Context(), "Failed to delete api key", "error", err)
+		return
+	}
+
+	if !found {
+		http.Error(w, "Error: Api key not found (status code: 404)", http.StatusNotFound)
+		s.logger.WarnContext(r.Context(), "Api key not found", "workspace_id", workspaceId, "key_id", id)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "Api key was deleted", "workspace_id", workspaceId, "key_id", id)
+	s.audit(r.Context(), auditApiKeyDelete, strconv.Itoa(id), nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
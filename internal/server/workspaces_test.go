@@ -0,0 +1,93 @@
+package server
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWorkspaceAccessAllows(t *testing.T) {
+
+	tests := []struct {
+		role     string
+		required string
+		allowed  bool
+	}{
+		{roleMember, roleMember, true},
+		{roleMember, roleAdmin, false},
+		{roleMember, roleOwner, false},
+		{roleAdmin, roleMember, true},
+		{roleAdmin, roleAdmin, true},
+		{roleAdmin, roleOwner, false},
+		{roleOwner, roleOwner, true},
+		{"", roleMember, false},
+		{"superuser", roleMember, false},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		access := &workspaceAccess{Role: tt.role}
+		if got := access.allows(tt.required); got != tt.allowed {
+			t.Errorf("role %q allows %q = %t, want %t", tt.role, tt.required, got, tt.allowed)
+		}
+	}
+}
+
+func TestWithWorkspaceApiKey(t *testing.T) {
+
+	s := testServer()
+
+	member := &workspaceAccess{Id: 7, Role: roleMember, KeyId: 1}
+	admin := &workspaceAccess{Id: 7, Role: roleAdmin, KeyId: 2}
+
+	tests := []struct {
+		name   string
+		role   string
+		key    *workspaceAccess
+		header string
+		ps     httprouter.Params
+		status int
+	}{
+		{"member key for member route", roleMember, member, "", nil, http.StatusOK},
+		{"member key for admin route", roleAdmin, member, "", nil, http.StatusForbidden},
+		{"admin key for admin route", roleAdmin, admin, "", nil, http.StatusOK},
+		{"admin key for owner route", roleOwner, admin, "", nil, http.StatusForbidden},
+		{"own workspace header", roleMember, member, "7", nil, http.StatusOK},
+		{"other workspace header", roleMember, admin, "8", nil, http.StatusForbidden},
+		{"other workspace path", roleMember, admin, "", httprouter.Params{{Key: "workspace", Value: "8"}}, http.StatusForbidden},
+		{"own workspace path", roleMember, admin, "", httprouter.Params{{Key: "workspace", Value: "7"}}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+			if tt.header != "" {
+				r.Header.Set(workspaceHeader, tt.header)
+			}
+
+			withRole := func(next httprouter.Handle) httprouter.Handle { return s.withWorkspace(tt.role, next) }
+
+			status, passed := serveHandle(withRole, r, tt.key, tt.ps)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d", status, tt.status)
+			}
+
+			if status == http.StatusOK && passed != tt.key {
+				t.Errorf("workspace access = %+v, want %+v", passed, tt.key)
+			}
+		})
+	}
+}
+
+func TestWithWorkspaceAnonymous(t *testing.T) {
+
+	s := testServer()
+	withAdmin := func(next httprouter.Handle) httprouter.Handle { return s.withWorkspace(roleAdmin, next) }
+
+	status, passed := serveHandle(withAdmin, httptest.NewRequest(http.MethodGet, "/", nil), nil, nil)
+	if status != http.StatusOK || passed.Id != 0 || passed.Role != "" {
+		t.Errorf("anonymous request: status %d, access %+v, want 200 without a workspace", status, passed)
+	}
+}
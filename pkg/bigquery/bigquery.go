@@ -0,0 +1,367 @@
+package bigquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2/jwt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultApiUrl   = "https://bigquery.googleapis.com"          // Адрес API BigQuery
+	defaultTokenUrl = "https://oauth2.googleapis.com/token"      // Адрес выдачи токенов доступа по умолчанию
+	scope           = "https://www.googleapis.com/auth/bigquery" // Область доступа токенов
+	pollInterval    = 2 * time.Second                            // Интервал проверки завершения задания загрузки
+)
+
+// Режимы записи загружаемых строк
+const (
+	WriteTruncate = "WRITE_TRUNCATE" // Строки заменяют содержимое таблицы или секции
+	WriteAppend   = "WRITE_APPEND"   // Строки добавляются к содержимому таблицы
+)
+
+// Error - Тип данных, описывающий ошибку, которую вернул BigQuery
+type Error struct {
+	Status  int    // HTTP статус ответа (0 - ошибка задания загрузки)
+	Reason  string // Причина ошибки (например "notFound", "invalid")
+	Message string // Описание ошибки
+}
+
+// Error - Метод, возвращающий описание ошибки
+func (e *Error) Error() string {
+
+	if e.Status == 0 {
+		return fmt.Sprintf("error: BigQuery load job failed: %s: %s", e.Reason, e.Message)
+	}
+
+	return fmt.Sprintf("error: BigQuery answered with status %d: %s", e.Status, e.Message)
+}
+
+// Field - Тип данных, описывающий столбец схемы таблицы
+type Field struct {
+	Name string `json:"name"`           // Название столбца
+	Type string `json:"type"`           // Тип ("STRING", "INT64", "TIMESTAMP", ...)
+	Mode string `json:"mode,omitempty"` // Режим ("REQUIRED", "REPEATED", пустая строка - "NULLABLE")
+}
+
+// Table - Тип данных, описывающий таблицу набора данных
+type Table struct {
+	Dataset        string  // Набор данных
+	Table          string  // Таблица
+	Schema         []Field // Столбцы
+	PartitionField string  // Столбец DATE или TIMESTAMP, по суткам которого таблица разделена на секции (пустой - без секций)
+	Description    string  // Описание таблицы
+}
+
+// serviceAccount - Тип данных, описывающий ключ сервисного аккаунта Google Cloud в формате JSON
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ProjectId    string `json:"project_id"`
+	PrivateKeyId string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenUri     string `json:"token_uri"`
+}
+
+// Client - Тип данных, реализующий клиент API BigQuery v2 (создание таблиц и задания загрузки строк)
+type Client struct {
+	baseUrl string       // Адрес API
+	project string       // Проект, в котором создаются задания и находятся наборы данных
+	http    *http.Client // Клиент HTTP с токенами доступа сервисного аккаунта
+}
+
+// ClientCreate - Функция, реализующая создание клиента по ключу сервисного аккаунта в формате JSON
+// (пустой project - проект ключа)
+func ClientCreate(credentials []byte, project string) (*Client, error) {
+
+	key := serviceAccount{}
+	if err := json.Unmarshal(credentials, &key); err != nil || key.Type != "service_account" {
+		return nil, errors.New("error: BigQuery credentials must be a service account key in JSON")
+	}
+
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, errors.New("error: BigQuery service account key has no client_email or private_key")
+	}
+
+	if project == "" {
+		project = key.ProjectId
+	}
+	if project == "" {
+		return nil, errors.New("error: BigQuery project is not set")
+	}
+
+	tokenUrl := key.TokenUri
+	if tokenUrl == "" {
+		tokenUrl = defaultTokenUrl
+	}
+
+	conf := jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyId,
+		TokenURL:     tokenUrl,
+		Scopes:       []string{scope},
+	}
+
+	return &Client{baseUrl: defaultApiUrl, project: project, http: conf.Client(context.Background())}, nil
+}
+
+// Project - Метод, возвращающий проект клиента
+func (c *Client) Project() string {
+	return c.project
+}
+
+// EnsureTable - Метод, реализующий создание таблицы, если ее еще нет (схема существующей таблицы не изменяется)
+func (c *Client) EnsureTable(ctx context.Context, t Table) error {
+
+	table := map[string]any{
+		"tableReference": map[string]string{"projectId": c.project, "datasetId": t.Dataset, "tableId": t.Table},
+		"schema":         map[string]any{"fields": t.Schema},
+		"description":    t.Description,
+	}
+
+	if t.PartitionField != "" {
+		table["timePartitioning"] = map[string]string{"type": "DAY", "field": t.PartitionField}
+	}
+
+	body, err := json.Marshal(table)
+	if err != nil {
+		return err
+	}
+
+	rawUrl := fmt.Sprintf("%s/bigquery/v2/projects/%s/datasets/%s/tables", c.baseUrl, url.PathEscape(c.project),
+		url.PathEscape(t.Dataset))
+
+	err = c.call(ctx, http.MethodPost, rawUrl, bytes.NewReader(body), nil)
+
+	apiErr := &Error{}
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusConflict {
+		return nil
+	}
+
+	return err
+}
+
+// Load - Метод, реализующий загрузку строк в таблицу заданием загрузки и ожидание его завершения
+// (строки, передаваемые fill функции add, записываются во временный файл в формате JSON по строке
+// и передаются одним запросом; partition - сутки секции, заменяемой строками, nil - вся таблица;
+// возвращает количество загруженных строк)
+func (c *Client) Load(ctx context.Context, dataset, table string, partition *time.Time, mode string,
+	fill func(add func(row any) error) error) (int, error) {
+
+	file, err := os.CreateTemp("", "urlgen-bigquery-*.json")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	rows := 0
+	encoder := json.NewEncoder(file)
+
+	err = fill(func(row any) error {
+		rows++
+		return encoder.Encode(row)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	destination := table
+	if partition != nil {
+		destination += "$" + partition.UTC().Format("20060102")
+	}
+
+	job, err := c.upload(ctx, map[string]any{"configuration": map[string]any{"load": map[string]any{
+		"destinationTable":  map[string]string{"projectId": c.project, "datasetId": dataset, "tableId": destination},
+		"sourceFormat":      "NEWLINE_DELIMITED_JSON",
+		"writeDisposition":  mode,
+		"createDisposition": "CREATE_NEVER",
+	}}}, file, size)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = c.wait(ctx, job); err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+// jobStatus - Тип данных, описывающий задание BigQuery в ответах API
+type jobStatus struct {
+	JobReference struct {
+		JobId    string `json:"jobId"`
+		Location string `json:"location"`
+	} `json:"jobReference"`
+	Status struct {
+		State       string `json:"state"`
+		ErrorResult *struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"errorResult"`
+	} `json:"status"`
+}
+
+// upload - Метод, реализующий создание задания загрузки с передачей данных возобновляемой загрузкой
+// (данные передаются одним запросом после открытия сеанса загрузки)
+func (c *Client) upload(ctx context.Context, job any, data io.Reader, size int64) (*jobStatus, error) {
+
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+
+	rawUrl := fmt.Sprintf("%s/upload/bigquery/v2/projects/%s/jobs?uploadType=resumable", c.baseUrl,
+		url.PathEscape(c.project))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Type", "application/octet-stream")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error: BigQuery request failed: %w", err)
+	}
+	err = decodeResponse(resp, nil)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return nil, errors.New("error: BigQuery did not return an upload session")
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, session, data)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err = c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error: BigQuery upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	status := jobStatus{}
+	if err = decodeResponse(resp, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// wait - Метод, реализующий ожидание завершения задания с проверкой его результата
+func (c *Client) wait(ctx context.Context, job *jobStatus) error {
+
+	for {
+		if job.Status.State == "DONE" {
+			if e := job.Status.ErrorResult; e != nil {
+				return &Error{Reason: e.Reason, Message: e.Message}
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+
+		rawUrl := fmt.Sprintf("%s/bigquery/v2/projects/%s/jobs/%s?location=%s", c.baseUrl, url.PathEscape(c.project),
+			url.PathEscape(job.JobReference.JobId), url.QueryEscape(job.JobReference.Location))
+
+		next := jobStatus{}
+		if err := c.call(ctx, http.MethodGet, rawUrl, nil, &next); err != nil {
+			return err
+		}
+		job = &next
+	}
+}
+
+// call - Метод, реализующий запрос к API с телом и ответом в формате JSON
+func (c *Client) call(ctx context.Context, method, rawUrl string, body io.Reader, result any) error {
+
+	req, err := http.NewRequestWithContext(ctx, method, rawUrl, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error: BigQuery request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, result)
+}
+
+// decodeResponse - Функция, реализующая чтение ответа API: результата в result (nil - ответ не читается) или ошибки
+func decodeResponse(resp *http.Response, result any) error {
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{Status: resp.StatusCode}
+
+		answer := struct {
+			Error struct {
+				Message string `json:"message"`
+				Errors  []struct {
+					Reason string `json:"reason"`
+				} `json:"errors"`
+			} `json:"error"`
+		}{}
+
+		if json.NewDecoder(resp.Body).Decode(&answer) == nil {
+			apiErr.Message = answer.Error.Message
+			if len(answer.Error.Errors) != 0 {
+				apiErr.Reason = answer.Error.Errors[0].Reason
+			}
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+
+		return apiErr
+	}
+
+	if result == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error: failed to read BigQuery response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package bitly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultApiUrl - Адрес API Bitly по умолчанию
+const DefaultApiUrl = "https://api-ssl.bitly.com"
+
+// timeLayout - Формат времени в ответах API Bitly
+const timeLayout = "2006-01-02T15:04:05-0700"
+
+// Повторы запросов при превышении лимита запросов
+const (
+	rateLimitAttempts = 3                // Количество попыток запроса
+	rateLimitDelay    = 10 * time.Second // Пауза перед повтором, если API не указал ее в Retry-After
+)
+
+// Bitlink - Тип данных, описывающий короткую ссылку Bitly
+type Bitlink struct {
+	Id             string   `json:"id"`              // Короткая ссылка без схемы ("bit.ly/3abcXyz")
+	Link           string   `json:"link"`            // Короткая ссылка
+	CustomBitlinks []string `json:"custom_bitlinks"` // Короткие ссылки с пользовательскими кодами
+	LongUrl        string   `json:"long_url"`        // Исходная ссылка
+	Title          string   `json:"title"`           // Название
+	Archived       bool     `json:"archived"`        // Перенесена ли ссылка в архив
+	Tags           []string `json:"tags"`            // Метки
+}
+
+// ClickCount - Тип данных, описывающий количество переходов по ссылке за сутки
+type ClickCount struct {
+	Day    time.Time // Начало суток (UTC)
+	Clicks int       // Количество переходов
+}
+
+// Error - Тип данных, описывающий ошибку, возвращенную API Bitly
+type Error struct {
+	Status      int    // Статус HTTP
+	Message     string // Код ошибки ("FORBIDDEN", "NOT_FOUND", ...)
+	Description string // Описание ошибки
+}
+
+// Error - Метод, возвращающий текст ошибки API Bitly
+func (e *Error) Error() string {
+
+	if e.Description != "" {
+		return fmt.Sprintf("error: bitly api returned %d: %s (%s)", e.Status, e.Message, e.Description)
+	}
+
+	return fmt.Sprintf("error: bitly api returned %d: %s", e.Status, e.Message)
+}
+
+// Client - Тип данных, реализующий вызовы API Bitly v4
+type Client struct {
+	baseUrl string       // Адрес API без завершающей косой черты
+	token   string       // Токен доступа
+	http    *http.Client // Клиент HTTP
+}
+
+// ClientCreate - Функция, реализующая создание клиента API Bitly по токену доступа
+// (apiUrl - адрес API, DefaultApiUrl, если пустой)
+func ClientCreate(apiUrl, token string) *Client {
+
+	if apiUrl == "" {
+		apiUrl = DefaultApiUrl
+	}
+
+	return &Client{baseUrl: strings.TrimSuffix(apiUrl, "/"), token: token, http: &http.Client{Timeout: time.Minute}}
+}
+
+// DefaultGroup - Метод, возвращающий идентификатор группы пользователя по умолчанию
+func (c *Client) DefaultGroup(ctx context.Context) (string, error) {
+
+	user := struct {
+		DefaultGroupGuid string `json:"default_group_guid"`
+	}{}
+
+	if err := c.get(ctx, c.baseUrl+"/v4/user", &user); err != nil {
+		return "", err
+	}
+
+	if user.DefaultGroupGuid == "" {
+		return "", errors.New("error: the bitly user has no default group")
+	}
+
+	return user.DefaultGroupGuid, nil
+}
+
+// Bitlinks - Метод, реализующий постраничное получение ссылок группы (archived - включать ли ссылки из архива;
+// ссылки передаются fn по мере получения страниц; ошибка fn прерывает получение)
+func (c *Client) Bitlinks(ctx context.Context, group string, archived bool, fn func(Bitlink) error) error {
+
+	query := url.Values{"size": {"100"}, "archived": {"off"}}
+	if archived {
+		query.Set("archived", "both")
+	}
+
+	next := c.baseUrl + "/v4/groups/" + url.PathEscape(group) + "/bitlinks?" + query.Encode()
+
+	for next != "" {
+		page := struct {
+			Links      []Bitlink `json:"links"`
+			Pagination struct {
+				Next string `json:"next"`
+			} `json:"pagination"`
+		}{}
+
+		if err := c.get(ctx, next, &page); err != nil {
+			return err
+		}
+
+		for _, link := range page.Links {
+			if err := fn(link); err != nil {
+				return err
+			}
+		}
+
+		next = page.Pagination.Next
+	}
+
+	return nil
+}
+
+// DailyClicks - Метод, возвращающий количество переходов по ссылке Bitly по суткам за всю доступную историю
+// (bitlink - короткая ссылка без схемы; сутки без переходов пропускаются)
+func (c *Client) DailyClicks(ctx context.Context, bitlink string) ([]ClickCount, error) {
+
+	answer := struct {
+		LinkClicks []struct {
+			Date   string `json:"date"`
+			Clicks int    `json:"clicks"`
+		} `json:"link_clicks"`
+	}{}
+
+	err := c.get(ctx, c.baseUrl+"/v4/bitlinks/"+url.PathEscape(bitlink)+"/clicks?unit=day&units=-1", &answer)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]ClickCount, 0, len(answer.LinkClicks))
+
+	for _, item := range answer.LinkClicks {
+		if item.Clicks == 0 {
+			continue
+		}
+
+		day, err := time.Parse(timeLayout, item.Date)
+		if err != nil {
+			return nil, fmt.Errorf("error: failed to read bitly click date %q: %w", item.Date, err)
+		}
+
+		counts = append(counts, ClickCount{Day: day.UTC().Truncate(24 * time.Hour), Clicks: item.Clicks})
+	}
+
+	return counts, nil
+}
+
+// get - Метод, реализующий запрос к API с чтением ответа в JSON в result
+// (при превышении лимита запросов запрос повторяется после паузы)
+func (c *Client) get(ctx context.Context, rawUrl string, result any) error {
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return fmt.Errorf("error: bitly request failed: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < rateLimitAttempts {
+			delay := rateLimitDelay
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				delay = time.Duration(seconds) * time.Second
+			}
+			resp.Body.Close()
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			continue
+		}
+
+		err = decodeResponse(resp, result)
+		resp.Body.Close()
+
+		return err
+	}
+}
+
+// decodeResponse - Функция, реализующая чтение ответа API: результата в result или ошибки
+func decodeResponse(resp *http.Response, result any) error {
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{Status: resp.StatusCode}
+
+		answer := struct {
+			Message     string `json:"message"`
+			Description string `json:"description"`
+		}{}
+
+		if json.NewDecoder(resp.Body).Decode(&answer) == nil {
+			apiErr.Message, apiErr.Description = answer.Message, answer.Description
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+
+		return apiErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error: failed to read bitly response: %w", err)
+	}
+
+	return nil
+}
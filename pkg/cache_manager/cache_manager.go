@@ -2,34 +2,40 @@ package cache_manager
 
 import (
 	"errors"
+	"log/slog"
 	"sync"
 	"time"
 )
 
 // Cache - Тип данных, реализующий менеджер кеша для работы с кешируемыми данными
-type Cache struct {
-	sync.RWMutex                       // Асинхронность для корректного доступа для чтения и записи
-	defaultExpiration time.Duration    // Продолжительность жизни кеша по умолчанию
-	cleanupTime       time.Duration    // Интервал, после которого запускается очистка
-	data              map[string]Value // Непосредственно кешируемые данные
+type Cache[V any] struct {
+	sync.RWMutex                          // Асинхронность для корректного доступа для чтения и записи
+	defaultExpiration time.Duration       // Продолжительность жизни кеша по умолчанию
+	cleanupTime       time.Duration       // Интервал, после которого запускается очистка
+	data              map[string]Value[V] // Непосредственно кешируемые данные
+	stop              chan struct{}       // Сигнал остановки очистки кеша
+	stopOnce          sync.Once           // Однократная остановка очистки кеша
+	logger            *slog.Logger        // Журнал кеша
 }
 
 // Value - Тип данных, реализующий структуру конкретного элемента кеша
-type Value struct {
+type Value[V any] struct {
 	CreateTime time.Time // Время создания
 	Expiration int64     // Время истечения актуальности
-	Value      string    // Непосредственно значение
+	Value      V         // Непосредственно значение
 }
 
 // CacheCreate - Функция, реализующая создание кеша
-func CacheCreate(defaultExpiration, cleanupTime time.Duration) *Cache {
+func CacheCreate[V any](defaultExpiration, cleanupTime time.Duration, logger *slog.Logger) *Cache[V] {
 
-	data := make(map[string]Value)
+	data := make(map[string]Value[V])
 
-	cache := Cache{
+	cache := Cache[V]{
 		data:              data,
 		defaultExpiration: defaultExpiration,
 		cleanupTime:       cleanupTime,
+		stop:              make(chan struct{}),
+		logger:            logger,
 	}
 
 	if cleanupTime > 0 {
@@ -40,10 +46,13 @@ func CacheCreate(defaultExpiration, cleanupTime time.Duration) *Cache {
 }
 
 // Set - Метод, реализующий добавление заданных значений в кеш
-func (c *Cache) Set(key string, value string, duration time.Duration) {
+func (c *Cache[V]) Set(key string, value V, duration time.Duration) {
 
 	var expiration int64
 
+	c.Lock()
+	defer c.Unlock()
+
 	if duration == 0 {
 		duration = c.defaultExpiration
 	}
@@ -52,10 +61,7 @@ func (c *Cache) Set(key string, value string, duration time.Duration) {
 		expiration = time.Now().Add(duration).UnixNano()
 	}
 
-	c.Lock()
-	defer c.Unlock()
-
-	c.data[key] = Value{
+	c.data[key] = Value[V]{
 		Value:      value,
 		Expiration: expiration,
 		CreateTime: time.Now(),
@@ -63,8 +69,20 @@ func (c *Cache) Set(key string, value string, duration time.Duration) {
 
 }
 
+// SetDefaultExpiration - Метод, позволяющий изменить продолжительность жизни кеша по умолчанию
+// (действует на значения, добавляемые после изменения)
+func (c *Cache[V]) SetDefaultExpiration(defaultExpiration time.Duration) {
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.defaultExpiration = defaultExpiration
+}
+
 // Get - Метод, реализующий получение кеша по заданному ключу
-func (c *Cache) Get(key string) (string, bool) {
+func (c *Cache[V]) Get(key string) (V, bool) {
+
+	var zero V
 
 	c.RLock()
 	defer c.RUnlock()
@@ -72,19 +90,19 @@ func (c *Cache) Get(key string) (string, bool) {
 	item, found := c.data[key]
 
 	if !found {
-		return "", false
+		return zero, false
 	}
 
 	if item.Expiration > 0 &&
 		time.Now().UnixNano() > item.Expiration {
-		return "", false
+		return zero, false
 	}
 
 	return item.Value, true
 }
 
 // Delete - Метод, реализующий удаление элемента кеша
-func (c *Cache) Delete(key string) error {
+func (c *Cache[V]) Delete(key string) error {
 
 	c.Lock()
 	defer c.Unlock()
@@ -99,28 +117,39 @@ func (c *Cache) Delete(key string) error {
 }
 
 // startGC - Метод, реализующий запуск очистки кеша
-func (c *Cache) startGC() {
+func (c *Cache[V]) startGC() {
 	go c.gC()
 }
 
 // gC - Метод, реализующий очистку кеша
-func (c *Cache) gC() {
+func (c *Cache[V]) gC() {
 
-	for {
-		<-time.After(c.cleanupTime)
+	ticker := time.NewTicker(c.cleanupTime)
+	defer ticker.Stop()
 
-		if c.data == nil {
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.stop:
 			return
 		}
 
 		if keys := c.expiredKeys(); len(keys) != 0 {
 			c.clearValues(keys)
+			c.logger.Debug("Expired cache values removed", "count", len(keys))
 		}
 	}
 }
 
+// Close - Метод, реализующий остановку очистки кеша
+func (c *Cache[V]) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
 // expiredKeys - Метод, реализующий поиск неактуального кеша
-func (c *Cache) expiredKeys() (keys []string) {
+func (c *Cache[V]) expiredKeys() (keys []string) {
 
 	c.RLock()
 	defer c.RUnlock()
@@ -136,7 +165,7 @@ func (c *Cache) expiredKeys() (keys []string) {
 }
 
 // clearValues - Метод, реализующий очистку кеша по значению ключей
-func (c *Cache) clearValues(keys []string) {
+func (c *Cache[V]) clearValues(keys []string) {
 
 	c.Lock()
 	defer c.Unlock()
@@ -145,3 +174,12 @@ func (c *Cache) clearValues(keys []string) {
 		delete(c.data, k)
 	}
 }
+
+// Len - Метод, реализующий получение количества элементов кеша (включая неактуальные до очистки)
+func (c *Cache[V]) Len() int {
+
+	c.RLock()
+	defer c.RUnlock()
+
+	return len(c.data)
+}
@@ -0,0 +1,167 @@
+package click_pipeline
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrClosed = errors.New("error: Pipeline is closed")      // Ошибка добавления события в закрытый конвейер
+	ErrFull   = errors.New("error: Pipeline buffer is full") // Ошибка добавления события в переполненный буфер
+)
+
+// Event - Тип данных, реализующий структуру события перехода по короткой ссылке
+type Event struct {
+	ShortUrl    string    // Короткая ссылка
+	Time        time.Time // Время перехода
+	IP          string    // IP адрес клиента (используется для обогащения события и не сохраняется)
+	StoredIP    string    // Обезличенный IP адрес клиента, сохраняемый в БД (пустая строка - не сохраняется)
+	Referrer    string    // Источник перехода (заголовок Referer)
+	UserAgent   string    // Заголовок User-Agent клиента
+	Country     string    // Код страны клиента
+	Region      string    // Код региона клиента
+	Browser     string    // Браузер клиента
+	OS          string    // Операционная система клиента
+	DeviceClass string    // Класс устройства клиента
+	Bot         bool      // Признак перехода бота или предзагрузки (учитывается отдельно от переходов людей)
+	Variant     int       // Номер выбранного варианта исходной ссылки (0 - основная ссылка)
+	WorkspaceId int       // Рабочее пространство ссылки (используется для рассылки вебхуков и не сохраняется)
+}
+
+// Enricher - Интерфейс обработчика, дополняющего событие перед записью (выполняется в фоне конвейера)
+type Enricher interface {
+	Enrich(e *Event)
+}
+
+// Sink - Интерфейс хранилища, в которое конвейер записывает пачки событий
+type Sink interface {
+	WriteClicks(ctx context.Context, events []Event) error
+}
+
+// Pipeline - Тип данных, реализующий асинхронный конвейер записи событий переходов пачками
+type Pipeline struct {
+	sync.RWMutex                // Блокировка для корректного закрытия буфера
+	sink          Sink          // Хранилище событий
+	enrichers     []Enricher    // Обработчики, дополняющие события перед записью
+	batchSize     int           // Максимальный размер пачки
+	flushInterval time.Duration // Максимальное время ожидания перед записью неполной пачки
+
+	events  chan Event    // Буфер событий
+	done    chan struct{} // Сигнал окончания записи оставшихся событий
+	closed  bool          // Признак закрытия конвейера
+	dropped atomic.Int64  // Количество отброшенных из-за переполнения событий
+	logger  *slog.Logger  // Журнал конвейера
+}
+
+// PipelineCreate - Функция, реализующая создание и запуск конвейера
+func PipelineCreate(sink Sink, bufferSize, batchSize int, flushInterval time.Duration, logger *slog.Logger,
+	enrichers ...Enricher) *Pipeline {
+
+	p := Pipeline{
+		sink:          sink,
+		enrichers:     enrichers,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+
+		events: make(chan Event, bufferSize),
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+
+	go p.run()
+
+	return &p
+}
+
+// Push - Метод, реализующий добавление события без ожидания (при переполнении буфера событие отбрасывается)
+func (p *Pipeline) Push(e Event) error {
+
+	p.RLock()
+	defer p.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.events <- e:
+		return nil
+	default:
+		p.dropped.Add(1)
+		return ErrFull
+	}
+}
+
+// Dropped - Метод, возвращающий количество отброшенных событий
+func (p *Pipeline) Dropped() int64 {
+	return p.dropped.Load()
+}
+
+// Close - Метод, реализующий остановку конвейера с записью оставшихся событий
+func (p *Pipeline) Close(ctx context.Context) error {
+
+	p.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run - Метод, реализующий накопление и запись пачек событий
+func (p *Pipeline) run() {
+
+	defer close(p.done)
+
+	batch := make([]Event, 0, p.batchSize)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e, ok := <-p.events:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+
+			for _, enricher := range p.enrichers {
+				enricher.Enrich(&e)
+			}
+
+			batch = append(batch, e)
+			if len(batch) >= p.batchSize {
+				batch = p.flush(batch)
+			}
+		case <-ticker.C:
+			batch = p.flush(batch)
+		}
+	}
+}
+
+// flush - Метод, реализующий запись пачки событий в хранилище
+func (p *Pipeline) flush(batch []Event) []Event {
+
+	if len(batch) == 0 {
+		return batch
+	}
+
+	err := p.sink.WriteClicks(context.Background(), batch)
+	if err != nil {
+		p.logger.Error("Failed to write clicks", "count", len(batch), "error", err)
+	}
+
+	return batch[:0]
+}
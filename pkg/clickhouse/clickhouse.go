@@ -0,0 +1,210 @@
+package clickhouse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// paramTimeLayout - Формат времени в параметрах запросов (значения DateTime64(3) в UTC)
+const paramTimeLayout = "2006-01-02 15:04:05.000"
+
+// maxErrorSize - Наибольший читаемый размер текста ошибки ClickHouse
+const maxErrorSize = 4 << 10
+
+// Error - Тип данных, описывающий ошибку, которую вернул ClickHouse
+type Error struct {
+	Status  int    // HTTP статус ответа
+	Code    string // Код исключения ClickHouse (заголовок X-ClickHouse-Exception-Code)
+	Message string // Текст исключения
+}
+
+// Error - Метод, возвращающий описание ошибки
+func (e *Error) Error() string {
+
+	if e.Code == "" {
+		return fmt.Sprintf("error: ClickHouse answered with status %d: %s", e.Status, e.Message)
+	}
+
+	return fmt.Sprintf("error: ClickHouse exception %s: %s (status %d)", e.Code, e.Message, e.Status)
+}
+
+// Client - Тип данных, реализующий клиент HTTP интерфейса ClickHouse
+// (значения запросов передаются параметрами вида {name:Type}, результаты читаются в формате JSONEachRow)
+type Client struct {
+	endpoint string       // Адрес сервера без пути
+	database string       // База данных (пустая строка - база пользователя по умолчанию)
+	user     string       // Пользователь
+	password string       // Пароль
+	client   *http.Client // Клиент HTTP
+}
+
+// ClientCreate - Функция, реализующая создание клиента по адресу вида "http[s]://[user[:password]@]host:port[/database]"
+// (время выполнения запросов ограничивается их контекстом, так как выгрузки читаются долго)
+func ClientCreate(rawUrl string) (*Client, error) {
+
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("error: ClickHouse url must look like http://[user:password@]host:8123[/database]")
+	}
+
+	c := Client{
+		endpoint: u.Scheme + "://" + u.Host,
+		database: strings.Trim(u.Path, "/"),
+		client:   &http.Client{},
+	}
+
+	if u.User != nil {
+		c.user = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+
+	return &c, nil
+}
+
+// Exec - Метод, реализующий выполнение запроса без чтения результата
+func (c *Client) Exec(ctx context.Context, query string, params map[string]any) error {
+
+	resp, err := c.do(ctx, query, params, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(io.Discard, resp.Body)
+
+	return err
+}
+
+// Insert - Метод, реализующий вставку строк в таблицу одним запросом
+// (строки передаются в формате JSONEachRow, поля сопоставляются столбцам по тегам json)
+func (c *Client) Insert(ctx context.Context, table string, rows []any) error {
+
+	var body bytes.Buffer
+
+	encoder := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.do(ctx, "INSERT INTO "+table+" FORMAT JSONEachRow", nil, &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(io.Discard, resp.Body)
+
+	return err
+}
+
+// Select - Метод, реализующий выполнение запроса с последовательным чтением строк результата
+// (строки в формате JSON передаются fn по мере чтения ответа; ошибка fn прерывает чтение)
+func (c *Client) Select(ctx context.Context, query string, params map[string]any,
+	fn func(row json.RawMessage) error) error {
+
+	resp, err := c.do(ctx, query+" FORMAT JSONEachRow", params, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		// Исключение, возникшее после начала ответа, дописывается в его конец текстом
+		if !json.Valid(line) {
+			return &Error{Status: resp.StatusCode, Message: strings.TrimSpace(string(line))}
+		}
+
+		if err = fn(line); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Ping - Метод, реализующий проверку доступности сервера
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Exec(ctx, "SELECT 1", nil)
+}
+
+// do - Метод, реализующий отправку запроса (запрос, параметры и настройки передаются в адресе, данные вставки - в теле)
+func (c *Client) do(ctx context.Context, query string, params map[string]any, body io.Reader) (*http.Response, error) {
+
+	values := url.Values{
+		"query": {query},
+		// Целые числа выводятся числами, а время - в формате RFC 3339, понятном encoding/json
+		"output_format_json_quote_64bit_integers": {"0"},
+		"date_time_output_format":                 {"iso"},
+		"date_time_input_format":                  {"best_effort"},
+	}
+
+	if c.database != "" {
+		values.Set("database", c.database)
+	}
+
+	for name, value := range params {
+		values.Set("param_"+name, formatParam(value))
+	}
+
+	// Запросы "Get" выполняются в режиме readonly, запрещающем изменение настроек, поэтому все запросы - "Post"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/?"+values.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.user != "" {
+		req.Header.Set("X-ClickHouse-User", c.user)
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error: ClickHouse request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorSize))
+
+		return nil, &Error{Status: resp.StatusCode, Code: resp.Header.Get("X-ClickHouse-Exception-Code"),
+			Message: strings.TrimSpace(string(message))}
+	}
+
+	return resp, nil
+}
+
+// formatParam - Функция, возвращающая текстовое представление значения параметра запроса
+func formatParam(value any) string {
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(paramTimeLayout)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(v)
+	}
+}
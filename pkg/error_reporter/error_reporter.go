@@ -0,0 +1,171 @@
+package error_reporter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Event - Тип данных, описывающий сообщение об ошибке
+type Event struct {
+	Message string            // Описание ошибки (сообщение журнала)
+	Err     error             // Ошибка (nil для сообщения без ошибки)
+	Panic   any               // Значение паники (nil, если паники не было)
+	Request *http.Request     // Запрос, при обработке которого произошла ошибка (nil вне запроса)
+	UserId  string            // Пользователь запроса
+	Tags    map[string]string // Дополнительные признаки (атрибуты записи журнала)
+}
+
+// Reporter - Интерфейс отправки сообщений об ошибках во внешнюю систему
+// (сообщения отправляются асинхронно; Flush дожидается отправки при остановке)
+type Reporter interface {
+	Report(ctx context.Context, e Event) // Отправка сообщения с контекстом запроса или фоновой задачи
+	Flush(timeout time.Duration) bool    // Ожидание отправки очереди (false, если время истекло)
+}
+
+// Nop - Тип данных, реализующий отправитель, отбрасывающий сообщения (сообщения об ошибках выключены)
+type Nop struct{}
+
+// Report - Метод, реализующий отбрасывание сообщения
+func (Nop) Report(context.Context, Event) {}
+
+// Flush - Метод, реализующий пустое ожидание отправки
+func (Nop) Flush(time.Duration) bool { return true }
+
+// FromEnv - Функция, реализующая создание отправителя по переменным окружения
+// (SENTRY_DSN - проект Sentry, без него сообщения не отправляются; SENTRY_ENVIRONMENT - окружение,
+// SENTRY_SAMPLE_RATE - доля отправляемых сообщений от 0 до 1)
+func FromEnv() (Reporter, error) {
+
+	dsn := os.Getenv("SENTRY_DSN")
+	if dsn == "" {
+		return Nop{}, nil
+	}
+
+	rate := 1.0
+	if v := os.Getenv("SENTRY_SAMPLE_RATE"); v != "" {
+		var err error
+
+		rate, err = strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, errors.New("error: SENTRY_SAMPLE_RATE must be a number from 0 to 1")
+		}
+	}
+
+	// Sentry считает нулевую долю долей по умолчанию (отправка всех сообщений)
+	if rate == 0 {
+		return Nop{}, nil
+	}
+
+	reporter, err := SentryCreate(dsn, os.Getenv("SENTRY_ENVIRONMENT"), rate)
+	if err != nil {
+		return nil, err
+	}
+
+	return reporter, nil
+}
+
+// requestContextKey - Тип данных, описывающий ключ для хранения запроса в контексте
+type requestContextKey struct{}
+
+// NewContext - Функция, возвращающая контекст с запросом, который прикладывается к сообщениям об ошибках
+func NewContext(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, r)
+}
+
+// RequestFromContext - Функция, позволяющая получить запрос из контекста (nil вне запроса)
+func RequestFromContext(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestContextKey{}).(*http.Request)
+	return r
+}
+
+// reportedKey - Ключ атрибута записи журнала об ошибке, о которой уже сообщено отдельно
+const reportedKey = "error_reported"
+
+// Reported - Функция, возвращающая атрибут записи журнала об ошибке, о которой уже сообщено отдельно
+// (например, о панике со стеком вызовов), чтобы Handler не отправлял ее повторно
+func Reported() slog.Attr {
+	return slog.Bool(reportedKey, true)
+}
+
+// Handler - Тип данных, реализующий обработчик журнала, отправляющий записи уровня Error как сообщения об ошибках
+// (атрибут "error" становится ошибкой сообщения, прочие атрибуты - его признаками)
+type Handler struct {
+	slog.Handler                               // Исходный обработчик журнала
+	reporter     Reporter                      // Отправитель сообщений
+	enrich       func(context.Context, *Event) // Дополнение сообщения данными контекста (может быть nil)
+	attrs        []slog.Attr                   // Атрибуты, добавленные WithAttrs
+}
+
+// NewHandler - Функция, реализующая создание обработчика журнала с отправкой ошибок
+// (enrich позволяет приложению добавить в сообщение, например, пользователя запроса)
+func NewHandler(h slog.Handler, reporter Reporter, enrich func(context.Context, *Event)) *Handler {
+	return &Handler{Handler: h, reporter: reporter, enrich: enrich}
+}
+
+// Handle - Метод, реализующий запись в журнал и отправку записей уровня Error
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+
+	if record.Level >= slog.LevelError {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		e := Event{Message: record.Message, Request: RequestFromContext(ctx), Tags: map[string]string{}}
+		reported := false
+
+		collect := func(a slog.Attr) bool {
+			if err, ok := a.Value.Any().(error); ok && a.Key == "error" {
+				e.Err = err
+			} else if a.Key == reportedKey {
+				reported = true
+			} else {
+				e.Tags[a.Key] = a.Value.String()
+			}
+			return true
+		}
+
+		for _, a := range h.attrs {
+			collect(a)
+		}
+		record.Attrs(collect)
+
+		if reported {
+			return h.Handler.Handle(ctx, record)
+		}
+
+		if e.Err == nil {
+			if v, found := e.Tags["error"]; found {
+				e.Err = errors.New(v)
+				delete(e.Tags, "error")
+			}
+		}
+
+		if h.enrich != nil {
+			h.enrich(ctx, &e)
+		}
+
+		h.reporter.Report(ctx, e)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs - Метод, возвращающий обработчик с дополнительными атрибутами
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{
+		Handler:  h.Handler.WithAttrs(attrs),
+		reporter: h.reporter,
+		enrich:   h.enrich,
+		attrs:    append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+	}
+}
+
+// WithGroup - Метод, возвращающий обработчик с группой атрибутов
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{Handler: h.Handler.WithGroup(name), reporter: h.reporter, enrich: h.enrich, attrs: h.attrs}
+}
@@ -0,0 +1,73 @@
+package error_reporter
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/trace"
+	"my_project/urlgen/pkg/request_id"
+	"my_project/urlgen/pkg/version"
+	"time"
+)
+
+// Sentry - Тип данных, реализующий отправку сообщений об ошибках в Sentry
+type Sentry struct {
+	client *sentry.Client // Клиент Sentry (очередь отправки и повторы)
+}
+
+// SentryCreate - Функция, реализующая создание отправителя в проект Sentry с заданным DSN
+// (версия сборки становится выпуском сообщений; заголовки с секретами и cookie запросов не отправляются)
+func SentryCreate(dsn, environment string, sampleRate float64) (*Sentry, error) {
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              dsn,
+		Environment:      environment,
+		Release:          version.Get().Version,
+		SampleRate:       sampleRate,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sentry{client: client}, nil
+}
+
+// Report - Метод, реализующий отправку сообщения с запросом, пользователем, идентификатором запроса и трассы
+func (s *Sentry) Report(ctx context.Context, e Event) {
+
+	scope := sentry.NewScope()
+
+	if e.Request != nil {
+		scope.SetRequest(e.Request)
+	}
+	if e.UserId != "" {
+		scope.SetUser(sentry.User{ID: e.UserId})
+	}
+	for k, v := range e.Tags {
+		scope.SetTag(k, v)
+	}
+	if id := request_id.FromContext(ctx); id != "" {
+		scope.SetTag("request_id", id)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		scope.SetTag("trace_id", span.TraceID().String())
+	}
+
+	hub := sentry.NewHub(s.client, scope)
+
+	switch {
+	case e.Panic != nil:
+		hub.RecoverWithContext(ctx, e.Panic)
+	case e.Err != nil:
+		event := s.client.EventFromException(e.Err, sentry.LevelError)
+		event.Message = e.Message
+		hub.CaptureEvent(event)
+	default:
+		hub.CaptureMessage(e.Message)
+	}
+}
+
+// Flush - Метод, реализующий ожидание отправки сообщений из очереди
+func (s *Sentry) Flush(timeout time.Duration) bool {
+	return s.client.Flush(timeout)
+}
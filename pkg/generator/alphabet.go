@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math"
+	"strings"
+)
+
+// DefaultAlphabet - Алфавит кодов по умолчанию (base62 без легко путаемых символов 0, O, 1, l, I)
+const DefaultAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// DefaultLowerAlphabet - Алфавит кодов без учета регистра по умолчанию
+// (цифры и строчные латинские буквы без легко путаемых символов 0, 1, i, l, o)
+const DefaultLowerAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// ErrInvalidCode - Ошибка разбора кода, содержащего символы вне алфавита или слишком большое число
+var ErrInvalidCode = errors.New("error: Invalid code")
+
+// Alphabet - Тип данных, описывающий набор символов генерируемых кодов
+type Alphabet string
+
+// NewAlphabet - Функция, позволяющая создать алфавит кодов
+// (от 2 различных символов из латинских букв, цифр, "-" и "_")
+func NewAlphabet(chars string) (Alphabet, error) {
+
+	if len(chars) < 2 {
+		return "", errors.New("error: Alphabet must have at least 2 characters")
+	}
+
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return "", errors.New("error: Alphabet may only contain latin letters, digits, '-' and '_'")
+		}
+
+		if strings.IndexByte(chars[:i], c) >= 0 {
+			return "", errors.New("error: Alphabet characters must not repeat")
+		}
+	}
+
+	return Alphabet(chars), nil
+}
+
+// Lower - Метод, возвращающий алфавит из строчных символов алфавита (повторы после приведения удаляются)
+func (a Alphabet) Lower() Alphabet {
+
+	lower := make([]byte, 0, len(a))
+
+	for _, c := range []byte(strings.ToLower(string(a))) {
+		if !bytes.Contains(lower, []byte{c}) {
+			lower = append(lower, c)
+		}
+	}
+
+	return Alphabet(lower)
+}
+
+// Encode - Метод, реализующий запись числа кодом не короче length символов
+// (код минимальной длины дополняется первым символом алфавита слева, разные числа всегда дают разные коды)
+func (a Alphabet) Encode(n uint64, length int) string {
+
+	base := uint64(len(a))
+
+	var buf [64]byte // Достаточно для любого числа в алфавите из 2 символов
+	i := len(buf)
+
+	for n > 0 || i == len(buf) {
+		i--
+		buf[i] = a[n%base]
+		n /= base
+	}
+
+	code := string(buf[i:])
+	if len(code) >= length {
+		return code
+	}
+
+	return strings.Repeat(string(a[0]), length-len(code)) + code
+}
+
+// Decode - Метод, реализующий получение числа из кода
+func (a Alphabet) Decode(code string) (uint64, error) {
+
+	if code == "" {
+		return 0, ErrInvalidCode
+	}
+
+	base := uint64(len(a))
+
+	var n uint64
+
+	for i := 0; i < len(code); i++ {
+		digit := strings.IndexByte(string(a), code[i])
+		if digit < 0 {
+			return 0, ErrInvalidCode
+		}
+
+		if n > (math.MaxUint64-uint64(digit))/base {
+			return 0, ErrInvalidCode
+		}
+
+		n = n*base + uint64(digit)
+	}
+
+	return n, nil
+}
+
+// Hash - Метод, реализующий создание кода заданной длины (до 32 символов) из хеша SHA256 данных
+// (коды разной длины для одних данных совпадают по началу)
+func (a Alphabet) Hash(data string, length int) string {
+
+	sum := sha256.Sum256([]byte(data))
+
+	code := make([]byte, length)
+	for i := range code {
+		code[i] = a[int(sum[i])%len(a)]
+	}
+
+	return string(code)
+}
+
+// Random - Метод, реализующий создание случайного кода заданной длины
+// (используется криптографически стойкий генератор, символы алфавита равновероятны)
+func (a Alphabet) Random(length int) (string, error) {
+
+	code := make([]byte, 0, length)
+	limit := 256 - 256%len(a) // Байты не меньше limit отбрасываются, чтобы не смещать распределение
+
+	buf := make([]byte, length+length/2)
+
+	for len(code) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+
+		for _, b := range buf {
+			if int(b) < limit && len(code) < length {
+				code = append(code, a[int(b)%len(a)])
+			}
+		}
+	}
+
+	return string(code), nil
+}
+
+// CheckChar - Метод, возвращающий контрольный символ кода по алгоритму Луна для основания алфавита (Luhn mod N)
+// (обнаруживает перестановку большинства соседних символов и замену одного символа: любого для алфавита
+// из четного числа символов, для нечетного - кроме некоторых замен на удваиваемых позициях, нечетных с конца;
+// ErrInvalidCode, если код содержит символы не из алфавита)
+func (a Alphabet) CheckChar(code string) (byte, error) {
+
+	n := len(a)
+	factor, sum := 2, 0
+
+	for i := len(code) - 1; i >= 0; i-- {
+		digit := strings.IndexByte(string(a), code[i])
+		if digit < 0 {
+			return 0, ErrInvalidCode
+		}
+
+		addend := factor * digit
+		sum += addend/n + addend%n
+
+		factor = 3 - factor
+	}
+
+	return a[(n-sum%n)%n], nil
+}
+
+// Signature - Метод, реализующий создание подписи кода заданной длины (до 32 символов) с помощью HMAC-SHA256
+func (a Alphabet) Signature(key []byte, code string, length int) string {
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(code))
+	sum := mac.Sum(nil)
+
+	signature := make([]byte, length)
+	for i := range signature {
+		signature[i] = a[int(sum[i])%len(a)]
+	}
+
+	return string(signature)
+}
@@ -0,0 +1,201 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncodeLength(t *testing.T) {
+
+	a := Alphabet(DefaultAlphabet)
+
+	tests := []struct {
+		n      uint64
+		length int
+		code   string
+	}{
+		{0, 0, "2"},
+		{0, 4, "2222"},
+		{1, 3, "223"},
+		{57, 2, "32"},
+		{57, 1, "32"},
+	}
+
+	for _, tt := range tests {
+		got := a.Encode(tt.n, tt.length)
+		if got != tt.code {
+			t.Errorf("Encode(%d, %d) = %q, want %q", tt.n, tt.length, got, tt.code)
+		}
+
+		n, err := a.Decode(got)
+		if err != nil || n != tt.n {
+			t.Errorf("Decode(%q) = %d, %v, want %d", got, n, err, tt.n)
+		}
+	}
+}
+
+func TestNewAlphabet(t *testing.T) {
+
+	tests := []struct {
+		chars string
+		valid bool
+	}{
+		{"ab", true},
+		{DefaultAlphabet, true},
+		{"abc-_", true},
+		{"a", false},
+		{"", false},
+		{"aba", false},
+		{"ab+", false},
+		{"ab c", false},
+	}
+
+	for _, tt := range tests {
+		_, err := NewAlphabet(tt.chars)
+		if (err == nil) != tt.valid {
+			t.Errorf("NewAlphabet(%q) error = %v, want valid = %t", tt.chars, err, tt.valid)
+		}
+	}
+}
+
+func TestCheckChar(t *testing.T) {
+
+	// Пример Luhn mod N для шестнадцатеричного алфавита
+	hex := Alphabet("0123456789abcdef")
+	decimal := Alphabet("0123456789")
+
+	tests := []struct {
+		alphabet Alphabet
+		code     string
+		check    byte
+	}{
+		{decimal, "7992739871", '3'},
+		{decimal, "0", '0'},
+		{decimal, "", '0'},
+		{hex, "0", '0'},
+		{hex, "1", 'e'},
+		{hex, "f", '1'},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.alphabet.CheckChar(tt.code)
+		if err != nil || got != tt.check {
+			t.Errorf("%q.CheckChar(%q) = %q, %v, want %q", tt.alphabet, tt.code, got, err, tt.check)
+		}
+	}
+
+	if _, err := decimal.CheckChar("12a"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("CheckChar with a character outside the alphabet: error = %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestCheckCharDetectsErrors(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		alphabet Alphabet
+		code     string
+		doubled  bool // Проверяются ли замены на удваиваемых позициях (нечетных с конца)
+	}{
+		{"even alphabet", Alphabet("0123456789abcdef"), "3fa07c", true},
+		{"default alphabet", Alphabet(DefaultAlphabet), "Hx7kPq", false},
+		{"lower alphabet", Alphabet(DefaultLowerAlphabet), "hx7kpq", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			a, code := tt.alphabet, tt.code
+
+			check, err := a.CheckChar(code)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < len(code); i++ {
+				if !tt.doubled && (len(code)-1-i)%2 == 0 {
+					continue
+				}
+
+				for j := 0; j < len(a); j++ {
+					if a[j] == code[i] {
+						continue
+					}
+
+					changed := code[:i] + string(a[j]) + code[i+1:]
+					if got, _ := a.CheckChar(changed); got == check {
+						t.Errorf("CheckChar(%q) = CheckChar(%q) = %q", changed, code, check)
+					}
+				}
+			}
+
+			for i := 0; i+1 < len(code); i++ {
+				swapped := code[:i] + string(code[i+1]) + string(code[i]) + code[i+2:]
+				if got, _ := a.CheckChar(swapped); got == check {
+					t.Errorf("CheckChar(%q) = CheckChar(%q) = %q", swapped, code, check)
+				}
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+
+	a := Alphabet(DefaultAlphabet)
+	key := []byte("secret")
+
+	signature := a.Signature(key, "abc", 8)
+	if len(signature) != 8 {
+		t.Fatalf("Signature length = %d, want 8", len(signature))
+	}
+
+	for i := 0; i < len(signature); i++ {
+		if strings.IndexByte(DefaultAlphabet, signature[i]) < 0 {
+			t.Fatalf("Signature %q has a character outside the alphabet", signature)
+		}
+	}
+
+	tests := []struct {
+		name string
+		key  []byte
+		code string
+		same bool
+	}{
+		{"same input", key, "abc", true},
+		{"other code", key, "abd", false},
+		{"other key", []byte("secreT"), "abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.Signature(tt.key, tt.code, 8); (got == signature) != tt.same {
+				t.Errorf("Signature = %q, first = %q, want same = %t", got, signature, tt.same)
+			}
+		})
+	}
+
+	if short := a.Signature(key, "abc", 4); short != signature[:4] {
+		t.Errorf("Signature of length 4 = %q, want prefix %q", short, signature[:4])
+	}
+}
+
+func TestRandom(t *testing.T) {
+
+	a := Alphabet(DefaultLowerAlphabet)
+
+	for _, length := range []int{1, 7, 32} {
+		code, err := a.Random(length)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(code) != length {
+			t.Errorf("Random(%d) length = %d", length, len(code))
+		}
+
+		if _, err = a.Decode(code[:1]); err != nil {
+			t.Errorf("Random(%d) = %q has a character outside the alphabet", length, code)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package generator
+
+// Base62Alphabet - Алфавит кодов base62 (цифры, заглавные и строчные латинские буквы)
+const Base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// EncodeBase62 - Функция, реализующая запись числа в base62 кодом минимальной длины
+// (разные числа всегда дают разные коды)
+func EncodeBase62(n uint64) string {
+	return Alphabet(Base62Alphabet).Encode(n, 0)
+}
+
+// DecodeBase62 - Функция, реализующая получение числа из кода base62
+func DecodeBase62(code string) (uint64, error) {
+	return Alphabet(Base62Alphabet).Decode(code)
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBase62(t *testing.T) {
+
+	tests := []struct {
+		n    uint64
+		code string
+	}{
+		{0, "0"},
+		{9, "9"},
+		{10, "A"},
+		{61, "z"},
+		{62, "10"},
+		{3843, "zz"},
+		{3844, "100"},
+		{18446744073709551615, "LygHa16AHYF"},
+	}
+
+	for _, tt := range tests {
+		if got := EncodeBase62(tt.n); got != tt.code {
+			t.Errorf("EncodeBase62(%d) = %q, want %q", tt.n, got, tt.code)
+		}
+
+		got, err := DecodeBase62(tt.code)
+		if err != nil || got != tt.n {
+			t.Errorf("DecodeBase62(%q) = %d, %v, want %d", tt.code, got, err, tt.n)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"empty", ""},
+		{"outside alphabet", "ab-c"},
+		{"unicode", "абв"},
+		{"overflow", "LygHa16AHYG"},
+		{"too long", "zzzzzzzzzzzz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeBase62(tt.code); !errors.Is(err, ErrInvalidCode) {
+				t.Errorf("DecodeBase62(%q) error = %v, want ErrInvalidCode", tt.code, err)
+			}
+		})
+	}
+}
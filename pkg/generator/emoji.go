@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"my_project/urlgen/config"
+	"strconv"
+	"strings"
+)
+
+// EmojiAlphabet - Алфавит кодов из эмодзи: 128 эмодзи из одного символа Unicode, которые отображаются
+// картинкой без селектора варианта (лица U+1F600-U+1F64F и животные U+1F400-U+1F42F)
+var EmojiAlphabet = emojiRange(0x1F600, 0x1F64F) + emojiRange(0x1F400, 0x1F42F)
+
+// emojiRange - Функция, возвращающая строку из символов диапазона Unicode (включая границы)
+func emojiRange(first, last rune) string {
+
+	var b strings.Builder
+	for r := first; r <= last; r++ {
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// EmojiHash - Функция, реализующая получение кода из эмодзи заданной длины (до 32) из хеша SHA256 данных
+// (каждый байт хеша выбирает один из 128 эмодзи без смещения распределения)
+func EmojiHash(data string, length int) string {
+
+	emoji := []rune(EmojiAlphabet)
+	hash := sha256.Sum256([]byte(data))
+
+	var b strings.Builder
+	for _, c := range hash[:min(length, len(hash))] {
+		b.WriteRune(emoji[int(c)%len(emoji)])
+	}
+
+	return b.String()
+}
+
+// emojiGenerator - Тип данных, реализующий генерацию кодов из эмодзи по хешу исходной ссылки
+// (одна исходная ссылка всегда получает один и тот же код; при повторной попытке код на эмодзи длиннее)
+type emojiGenerator struct {
+	opts Options
+}
+
+// newEmojiGenerator - Функция, позволяющая создать генератор кодов из эмодзи
+func newEmojiGenerator(opts Options) (Generator, error) {
+	return &emojiGenerator{opts: opts}, nil
+}
+
+// Next - Метод, возвращающий код исходной ссылки из config.EmojiCodeLen эмодзи
+// (недопустимый код заменяется кодом из хеша исходной ссылки с номером варианта)
+func (g *emojiGenerator) Next(ctx context.Context, dest string) (string, error) {
+
+	length := config.EmojiCodeLen + Attempt(ctx) - 1
+	if length > sha256.Size {
+		return "", ErrNoCode
+	}
+
+	code := EmojiHash(dest, length)
+
+	for variant := 1; variant <= config.CodeFilterAttempts && g.opts.rejected(code); variant++ {
+		code = EmojiHash(dest+"\n"+strconv.Itoa(variant), length)
+	}
+
+	return code, nil
+}
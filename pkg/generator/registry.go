@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNoCode - Ошибка генератора, у которого не осталось кодов для исходной ссылки
+var ErrNoCode = errors.New("error: No more codes for the destination")
+
+// Generator - Тип данных, описывающий генератор кодов коротких ссылок
+// (dest - исходная ссылка с рабочим пространством; если код оказался занят, Next вызывается повторно
+// с номером попытки в контексте, см. Attempt)
+type Generator interface {
+	Next(ctx context.Context, dest string) (string, error)
+}
+
+// RowIdGenerator - Тип данных, описывающий генератор, коды которого задают идентификатор сохраняемой строки в БД
+type RowIdGenerator interface {
+	Generator
+	RowId(code string) (int, error)
+}
+
+// Options - Тип данных, описывающий параметры создания генератора кодов
+type Options struct {
+	Alphabet  Alphabet                                        // Алфавит кодов
+	MinLength int                                             // Заданная длина кодов
+	Length    func() int                                      // Текущая длина кодов (с учетом ее увеличения)
+	Reject    func(code string) bool                          // Проверка недопустимого кода (nil - допустимы все коды)
+	NextIds   func(ctx context.Context, n int) ([]int, error) // Выдача идентификаторов новых строк БД
+	Counter   func(ctx context.Context, n int) ([]int, error) // Выдача номеров внешнего счетчика (nil - не задан)
+	Node      int                                             // Номер экземпляра сервера
+	Entropy   int                                             // Энтропия случайных кодов, в битах
+}
+
+// rejected - Метод, проверяющий, что код нельзя выдавать
+func (o Options) rejected(code string) bool {
+	return o.Reject != nil && o.Reject(code)
+}
+
+// Factory - Тип данных, описывающий функцию создания генератора кодов
+type Factory func(opts Options) (Generator, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		"hash":      newHashGenerator,
+		"sequence":  newSequenceGenerator,
+		"snowflake": newSnowflakeGenerator,
+		"emoji":     newEmojiGenerator,
+		"nanoid":    newNanoIdGenerator,
+		"redis":     newCounterGenerator,
+	}
+)
+
+// Register - Функция, позволяющая зарегистрировать генератор кодов под заданным именем
+// (вызывается до запуска сервера, например в init; имя выбирается настройкой CODE_STRATEGY)
+func Register(name string, factory Factory) {
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("generator: Register factory is nil")
+	}
+	if _, found := factories[name]; found {
+		panic("generator: Register called twice for " + name)
+	}
+
+	factories[name] = factory
+}
+
+// Names - Функция, возвращающая имена зарегистрированных генераторов кодов
+func Names() []string {
+
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New - Функция, позволяющая создать зарегистрированный генератор кодов по имени
+func New(name string, opts Options) (Generator, error) {
+
+	factoriesMu.RLock()
+	factory, found := factories[name]
+	factoriesMu.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("error: unknown code generator %q (registered: %s)", name, strings.Join(Names(), ", "))
+	}
+
+	return factory(opts)
+}
+
+// attemptKey - Тип данных ключа контекста с номером попытки
+type attemptKey struct{}
+
+// WithAttempt - Функция, возвращающая контекст с номером попытки получения кода (с 1)
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// Attempt - Функция, возвращающая номер попытки получения кода из контекста (1, если не задан)
+func Attempt(ctx context.Context) int {
+
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok && attempt > 0 {
+		return attempt
+	}
+
+	return 1
+}
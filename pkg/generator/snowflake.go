@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Разрядность частей идентификатора Snowflake (старший бит не используется)
+const (
+	snowflakeNodeBits     = 10                           // Номер узла
+	snowflakeSequenceBits = 12                           // Порядковый номер в пределах миллисекунды
+	SnowflakeMaxNode      = 1<<snowflakeNodeBits - 1     // Максимальный номер узла
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1 // Максимальный порядковый номер
+)
+
+// SnowflakeEpoch - Начало отсчета времени идентификаторов Snowflake
+// (чем оно ближе, тем короче коды из идентификаторов)
+var SnowflakeEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake - Тип данных, реализующий генерацию уникальных идентификаторов без обращения к БД
+// (идентификатор состоит из миллисекунд от SnowflakeEpoch, номера узла и порядкового номера,
+// поэтому узлы с разными номерами не выдают одинаковых идентификаторов)
+type Snowflake struct {
+	mu       sync.Mutex
+	node     uint64 // Номер узла
+	last     int64  // Время последнего идентификатора в миллисекундах от SnowflakeEpoch
+	sequence uint64 // Порядковый номер последнего идентификатора
+}
+
+// NewSnowflake - Функция, позволяющая создать генератор идентификаторов узла с заданным номером (от 0 до 1023)
+func NewSnowflake(node int) (*Snowflake, error) {
+
+	if node < 0 || node > SnowflakeMaxNode {
+		return nil, errors.New("error: Snowflake node must be from 0 to 1023")
+	}
+
+	return &Snowflake{node: uint64(node)}, nil
+}
+
+// Next - Метод, возвращающий следующий идентификатор
+// (идентификаторы узла возрастают; если порядковые номера миллисекунды исчерпаны или часы
+// переведены назад, метод ждет следующей миллисекунды)
+func (g *Snowflake) Next() uint64 {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := time.Since(SnowflakeEpoch).Milliseconds()
+
+	if ms <= g.last {
+		if g.sequence < snowflakeMaxSequence {
+			g.sequence++
+			return g.id(g.last)
+		}
+
+		for ms <= g.last {
+			time.Sleep(time.Duration(g.last-ms+1) * time.Millisecond)
+			ms = time.Since(SnowflakeEpoch).Milliseconds()
+		}
+	}
+
+	g.last, g.sequence = ms, 0
+
+	return g.id(ms)
+}
+
+// id - Метод, реализующий сборку идентификатора из его частей
+func (g *Snowflake) id(ms int64) uint64 {
+	return uint64(ms)<<(snowflakeNodeBits+snowflakeSequenceBits) | g.node<<snowflakeSequenceBits | g.sequence
+}
@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"my_project/urlgen/config"
+	"strconv"
+	"sync"
+)
+
+// hashGenerator - Тип данных, реализующий генерацию кодов из хеша SHA256 исходной ссылки
+// (одна исходная ссылка всегда получает один и тот же код; при повторной попытке код на символ длиннее)
+type hashGenerator struct {
+	opts Options
+}
+
+// newHashGenerator - Функция, позволяющая создать генератор кодов из хеша
+func newHashGenerator(opts Options) (Generator, error) {
+
+	if opts.Length == nil {
+		opts.Length = func() int { return opts.MinLength }
+	}
+
+	return &hashGenerator{opts: opts}, nil
+}
+
+// Next - Метод, возвращающий код исходной ссылки
+// (недопустимый код заменяется кодом из хеша исходной ссылки с номером варианта)
+func (g *hashGenerator) Next(ctx context.Context, dest string) (string, error) {
+
+	length := g.opts.Length() + Attempt(ctx) - 1
+	if length > config.ShortUrlMaxLen {
+		return "", ErrNoCode
+	}
+
+	code := g.opts.Alphabet.Hash(dest, length)
+
+	for variant := 1; variant <= config.CodeFilterAttempts && g.opts.rejected(code); variant++ {
+		code = g.opts.Alphabet.Hash(dest+"\n"+strconv.Itoa(variant), length)
+	}
+
+	return code, nil
+}
+
+// idBlocks - Тип данных, реализующий выдачу кодов из номеров, запрашиваемых пачками
+// (коды уникальны без проверки на совпадение, если уникальны номера)
+type idBlocks struct {
+	opts Options
+	next func(ctx context.Context, n int) ([]int, error) // Выдача пачки номеров
+
+	mu  sync.Mutex
+	ids []int // Полученные, но еще не выданные номера
+}
+
+// Next - Метод, возвращающий код следующего номера (недопустимые коды пропускаются)
+func (b *idBlocks) Next(ctx context.Context, _ string) (string, error) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for {
+		if len(b.ids) == 0 {
+			ids, err := b.next(ctx, config.CodeIdBlockSize)
+			if err != nil {
+				return "", err
+			}
+
+			b.ids = ids
+		}
+
+		id := b.ids[0]
+		b.ids = b.ids[1:]
+
+		if code := b.opts.Alphabet.Encode(uint64(id), b.opts.MinLength); !b.opts.rejected(code) {
+			return code, nil
+		}
+	}
+}
+
+// sequenceGenerator - Тип данных, реализующий генерацию кодов из идентификаторов строк в БД
+// (идентификаторы запрашиваются пачками)
+type sequenceGenerator struct {
+	idBlocks
+}
+
+// newSequenceGenerator - Функция, позволяющая создать генератор кодов из идентификаторов строк
+func newSequenceGenerator(opts Options) (Generator, error) {
+
+	if opts.NextIds == nil {
+		return nil, errors.New("error: sequence code generator needs a database")
+	}
+
+	return &sequenceGenerator{idBlocks{opts: opts, next: opts.NextIds}}, nil
+}
+
+// RowId - Метод, возвращающий идентификатор строки, записанный кодом
+func (g *sequenceGenerator) RowId(code string) (int, error) {
+
+	id, err := g.opts.Alphabet.Decode(code)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// counterGenerator - Тип данных, реализующий генерацию кодов из номеров внешнего счетчика (Redis)
+// (номера запрашиваются пачками, поэтому экземпляры сервера выдают последовательные коды без обращения к БД;
+// строки получают идентификаторы БД независимо от кодов)
+type counterGenerator struct {
+	idBlocks
+}
+
+// newCounterGenerator - Функция, позволяющая создать генератор кодов из номеров внешнего счетчика
+func newCounterGenerator(opts Options) (Generator, error) {
+
+	if opts.Counter == nil {
+		return nil, errors.New("error: redis code generator needs REDIS_URL")
+	}
+
+	return &counterGenerator{idBlocks{opts: opts, next: opts.Counter}}, nil
+}
+
+// snowflakeGenerator - Тип данных, реализующий генерацию кодов из идентификаторов Snowflake без обращения к БД
+type snowflakeGenerator struct {
+	opts      Options
+	snowflake *Snowflake
+}
+
+// newSnowflakeGenerator - Функция, позволяющая создать генератор кодов из идентификаторов Snowflake
+func newSnowflakeGenerator(opts Options) (Generator, error) {
+
+	snowflake, err := NewSnowflake(opts.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snowflakeGenerator{opts: opts, snowflake: snowflake}, nil
+}
+
+// Next - Метод, возвращающий код следующего идентификатора (недопустимые коды пропускаются)
+func (g *snowflakeGenerator) Next(_ context.Context, _ string) (string, error) {
+
+	for {
+		if code := g.opts.Alphabet.Encode(g.snowflake.Next(), g.opts.MinLength); !g.opts.rejected(code) {
+			return code, nil
+		}
+	}
+}
+
+// nanoIdGenerator - Тип данных, реализующий генерацию криптографически случайных кодов в духе NanoID
+// (длина кода выбирается по заданной энтропии, коды нельзя угадать по соседним кодам)
+type nanoIdGenerator struct {
+	opts   Options
+	length int // Длина кодов
+}
+
+// newNanoIdGenerator - Функция, позволяющая создать генератор случайных кодов с энтропией opts.Entropy бит
+// (0 - config.CodeEntropyBits)
+func newNanoIdGenerator(opts Options) (Generator, error) {
+
+	if opts.Entropy == 0 {
+		opts.Entropy = config.CodeEntropyBits
+	}
+
+	if opts.Entropy < config.CodeMinEntropyBits {
+		return nil, fmt.Errorf("error: code entropy must be at least %d bits", config.CodeMinEntropyBits)
+	}
+
+	length := int(math.Ceil(float64(opts.Entropy) / math.Log2(float64(len(opts.Alphabet)))))
+	if length > config.ShortUrlMaxLen {
+		return nil, fmt.Errorf("error: %d bits of entropy need codes longer than %d characters", opts.Entropy,
+			config.ShortUrlMaxLen)
+	}
+
+	return &nanoIdGenerator{opts: opts, length: length}, nil
+}
+
+// Next - Метод, возвращающий новый случайный код (недопустимые коды пропускаются)
+func (g *nanoIdGenerator) Next(_ context.Context, _ string) (string, error) {
+
+	for {
+		code, err := g.opts.Alphabet.Random(g.length)
+		if err != nil {
+			return "", err
+		}
+
+		if !g.opts.rejected(code) {
+			return code, nil
+		}
+	}
+}
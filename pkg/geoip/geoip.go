@@ -0,0 +1,69 @@
+package geoip
+
+import (
+	"github.com/oschwald/geoip2-golang"
+	"log/slog"
+	"my_project/urlgen/pkg/click_pipeline"
+	"net"
+	"time"
+)
+
+// Locator - Тип данных, реализующий определение страны и региона клиента по базе MaxMind (GeoIP2 / GeoLite2)
+type Locator struct {
+	reader *geoip2.Reader // Читатель базы
+	logger *slog.Logger   // Журнал определения местоположения
+}
+
+// LocatorCreate - Функция, реализующая открытие базы по заданному пути
+func LocatorCreate(path string, logger *slog.Logger) (*Locator, error) {
+
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Locator{reader: reader, logger: logger}, nil
+}
+
+// Lookup - Метод, позволяющий получить код страны и код региона для заданного IP адреса
+// (пустые строки, если адрес не найден в базе)
+func (l *Locator) Lookup(addr string) (country, region string) {
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "", ""
+	}
+
+	city, err := l.reader.City(ip)
+	if err != nil {
+		l.logger.Debug("GeoIP lookup failed", "ip", addr, "error", err)
+		return "", ""
+	}
+
+	country = city.Country.IsoCode
+	if len(city.Subdivisions) != 0 {
+		region = city.Subdivisions[0].IsoCode
+	}
+
+	return country, region
+}
+
+// Enrich - Метод, реализующий заполнение страны и региона события перехода
+func (l *Locator) Enrich(e *click_pipeline.Event) {
+
+	if e.IP == "" || e.Country != "" {
+		return
+	}
+
+	e.Country, e.Region = l.Lookup(e.IP)
+}
+
+// BuildTime - Метод, возвращающий время сборки открытой базы (по нему видно, что база давно не обновлялась)
+func (l *Locator) BuildTime() time.Time {
+	return time.Unix(int64(l.reader.Metadata().BuildEpoch), 0)
+}
+
+// Close - Метод, реализующий закрытие базы
+func (l *Locator) Close() error {
+	return l.reader.Close()
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Level - Тип данных, реализующий уровень журнала с временным изменением
+// (по истечении времени восстанавливается настроенный уровень, чтобы подробный журнал не остался включенным)
+type Level struct {
+	mu    sync.Mutex
+	v     *slog.LevelVar // Уровень, читаемый журналом при каждой записи
+	base  slog.Level     // Настроенный уровень (LOG_LEVEL)
+	until time.Time      // Окончание временного уровня (нулевое время - действует настроенный уровень)
+	timer *time.Timer    // Таймер восстановления настроенного уровня
+}
+
+// LevelState - Тип данных, описывающий состояние уровня журнала
+type LevelState struct {
+	Level slog.Level // Действующий уровень
+	Base  slog.Level // Настроенный уровень
+	Until time.Time  // Окончание временного уровня (нулевое время, если он не задан)
+}
+
+// LevelCreate - Функция, реализующая создание управления уровнем журнала (текущий уровень v считается настроенным)
+func LevelCreate(v *slog.LevelVar) *Level {
+	return &Level{v: v, base: v.Level()}
+}
+
+// SetBase - Метод, реализующий изменение настроенного уровня (при перечитывании настроек)
+// (действующий временный уровень сохраняется до своего окончания)
+func (l *Level) SetBase(level slog.Level) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.base = level
+	if l.until.IsZero() {
+		l.v.Set(level)
+	}
+}
+
+// Override - Метод, реализующий временное изменение уровня на заданное время
+// (повторный вызов заменяет прежний временный уровень и его окончание)
+func (l *Level) Override(level slog.Level, d time.Duration) LevelState {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+
+	until := time.Now().Add(d)
+
+	l.until = until
+	l.v.Set(level)
+	l.timer = time.AfterFunc(d, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		// Таймер мог сработать одновременно с заменой временного уровня
+		if l.until.Equal(until) {
+			l.restore()
+		}
+	})
+
+	return l.state()
+}
+
+// Restore - Метод, реализующий досрочное восстановление настроенного уровня
+// (возвращает false, если временный уровень не был задан)
+func (l *Level) Restore() bool {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.until.IsZero() {
+		return false
+	}
+
+	l.timer.Stop()
+	l.restore()
+
+	return true
+}
+
+// State - Метод, возвращающий состояние уровня журнала
+func (l *Level) State() LevelState {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.state()
+}
+
+// restore - Метод, реализующий восстановление настроенного уровня (вызывается под блокировкой)
+func (l *Level) restore() {
+	l.until = time.Time{}
+	l.timer = nil
+	l.v.Set(l.base)
+}
+
+// state - Метод, возвращающий состояние уровня журнала (вызывается под блокировкой)
+func (l *Level) state() LevelState {
+	return LevelState{Level: l.v.Level(), Base: l.base, Until: l.until}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"my_project/urlgen/pkg/request_id"
+	"os"
+	"strings"
+)
+
+// ParseLevel - Функция, реализующая разбор названия уровня журнала ("debug", "info", "warn", "error")
+func ParseLevel(name string) (slog.Level, error) {
+
+	var level slog.Level
+
+	err := level.UnmarshalText([]byte(name))
+	if err != nil {
+		return slog.LevelInfo, errors.New("error: Unknown log level " + name)
+	}
+
+	return level, nil
+}
+
+// LoggerCreate - Функция, реализующая создание логгера с заданным форматом вывода ("json" или "console")
+// (уровень читается из level при каждой записи, что позволяет менять его во время работы;
+// записи с контекстом запроса получают его идентификатор)
+func LoggerCreate(w io.Writer, format string, level *slog.LevelVar) (*slog.Logger, error) {
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(format) {
+	case "", "console", "text":
+		return slog.New(request_id.NewHandler(slog.NewTextHandler(w, opts))), nil
+	case "json":
+		return slog.New(request_id.NewHandler(slog.NewJSONHandler(w, opts))), nil
+	default:
+		return nil, errors.New("error: Unknown log format " + format)
+	}
+}
+
+// FromEnv - Функция, реализующая создание логгера по переменным LOG_LEVEL и LOG_FORMAT
+// (возвращает также изменяемый уровень журнала)
+func FromEnv() (*slog.Logger, *slog.LevelVar, error) {
+
+	level := &slog.LevelVar{}
+
+	if name := os.Getenv("LOG_LEVEL"); name != "" {
+		l, err := ParseLevel(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		level.Set(l)
+	}
+
+	log, err := LoggerCreate(os.Stderr, os.Getenv("LOG_FORMAT"), level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return log, level, nil
+}
@@ -0,0 +1,332 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dialTimeout = 5 * time.Second // Время ожидания подключения к Redis
+	poolSize    = 10              // Наибольшее количество одновременно открытых подключений
+)
+
+// ErrNil - Ошибка ответа Redis без значения (отсутствующий ключ, невыполненное условие NX)
+var ErrNil = errors.New("error: Redis nil reply")
+
+// Error - Тип данных, описывающий ошибку, которую вернул сервер Redis
+type Error string
+
+// Error - Метод, возвращающий описание ошибки
+func (e Error) Error() string {
+	return "error: Redis: " + string(e)
+}
+
+// Client - Тип данных, реализующий клиент Redis с пулом подключений по протоколу RESP
+// (команда занимает подключение до получения ответа; подключение с ошибкой ввода-вывода закрывается)
+type Client struct {
+	addr     string // Адрес сервера host:port
+	username string // Пользователь ACL (пустая строка - пользователь по умолчанию)
+	password string // Пароль (пустая строка - без аутентификации)
+	db       int    // Номер базы данных
+
+	slots chan struct{} // Занятые подключения (емкость - poolSize)
+
+	mu     sync.Mutex
+	idle   []*conn // Свободные подключения
+	closed bool    // Закрыт ли клиент
+}
+
+// conn - Тип данных, описывающий подключение к серверу
+type conn struct {
+	net.Conn
+	reader *bufio.Reader // Чтение ответов подключения
+}
+
+// ClientCreate - Функция, реализующая создание клиента по адресу вида "redis://[[user]:password@]host[:port][/db]"
+// (подключения открываются по мере необходимости)
+func ClientCreate(rawUrl string) (*Client, error) {
+
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return nil, errors.New("error: Redis url must look like redis://[[user]:password@]host[:port][/db]")
+	}
+
+	c := Client{addr: u.Host, slots: make(chan struct{}, poolSize)}
+
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+
+		// Адрес вида redis://password@host задает только пароль
+		if _, isSet := u.User.Password(); !isSet {
+			c.username, c.password = "", c.username
+		}
+	}
+
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		c.db, err = strconv.Atoi(db)
+		if err != nil || c.db < 0 {
+			return nil, fmt.Errorf("error: invalid Redis database %q", db)
+		}
+	}
+
+	return &c, nil
+}
+
+// Do - Метод, реализующий выполнение команды (ответ - string, int64, []any или nil; ошибка сервера - Error)
+// (если все подключения заняты, команда ждет освобождения одного из них до отмены контекста)
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-c.slots }()
+
+	cn := c.takeIdle()
+	if cn == nil {
+		var err error
+
+		cn, err = c.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := cn.exchange(ctx, args)
+
+	// Ответы об ошибке и без значения не нарушают обмен, подключение закрывается только при сбое
+	var serverErr Error
+	if err != nil && !errors.As(err, &serverErr) && !errors.Is(err, ErrNil) {
+		_ = cn.Close()
+	} else {
+		c.putIdle(cn)
+	}
+
+	return reply, err
+}
+
+// IncrBy - Метод, реализующий увеличение счетчика на n (возвращает новое значение)
+func (c *Client) IncrBy(ctx context.Context, key string, n int64) (int64, error) {
+
+	reply, err := c.Do(ctx, "INCRBY", key, strconv.FormatInt(n, 10))
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("error: unexpected Redis reply %v", reply)
+	}
+
+	return value, nil
+}
+
+// SetNX - Метод, реализующий запись значения ключа, если ключа нет (возвращает признак записи)
+func (c *Client) SetNX(ctx context.Context, key, value string) (bool, error) {
+
+	_, err := c.Do(ctx, "SET", key, value, "NX")
+	if errors.Is(err, ErrNil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Ping - Метод, реализующий проверку доступности сервера
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Close - Метод, реализующий закрытие подключений (занятые подключения закрываются по завершении команд)
+func (c *Client) Close() error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, cn := range c.idle {
+		_ = cn.Close()
+	}
+	c.idle, c.closed = nil, true
+
+	return nil
+}
+
+// takeIdle - Метод, возвращающий свободное подключение (nil, если свободных нет)
+func (c *Client) takeIdle() *conn {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.idle) == 0 {
+		return nil
+	}
+
+	cn := c.idle[len(c.idle)-1]
+	c.idle = c.idle[:len(c.idle)-1]
+
+	return cn
+}
+
+// putIdle - Метод, возвращающий подключение в пул свободных (после закрытия клиента подключение закрывается)
+func (c *Client) putIdle(cn *conn) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		_ = cn.Close()
+		return
+	}
+
+	c.idle = append(c.idle, cn)
+}
+
+// connect - Метод, реализующий открытие подключения с аутентификацией и выбором базы данных
+func (c *Client) connect(ctx context.Context) (*conn, error) {
+
+	dialer := net.Dialer{Timeout: dialTimeout}
+
+	netConn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &conn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if c.password != "" {
+		args := []string{"AUTH", c.password}
+		if c.username != "" {
+			args = []string{"AUTH", c.username, c.password}
+		}
+
+		if _, err = cn.exchange(ctx, args); err != nil {
+			_ = cn.Close()
+			return nil, err
+		}
+	}
+
+	if c.db != 0 {
+		if _, err = cn.exchange(ctx, []string{"SELECT", strconv.Itoa(c.db)}); err != nil {
+			_ = cn.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+// exchange - Метод, реализующий отправку команды и чтение ответа в подключении
+// (отмена контекста или истечение его срока прерывает ожидание ответа и возвращается ошибкой контекста)
+func (cn *conn) exchange(ctx context.Context, args []string) (any, error) {
+
+	// Срок подключения сбрасывается только при отмене контекста, иначе он мог бы истечь раньше контекста
+	if err := cn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = cn.SetDeadline(time.Now()) })
+
+	var b strings.Builder
+
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+
+	_, err := io.WriteString(cn.Conn, b.String())
+
+	var reply any
+	if err == nil {
+		reply, err = readReply(cn.reader)
+	}
+
+	// После отмены контекста срок подключения уже сброшен, поэтому оно не используется повторно
+	if !stop() && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return reply, err
+}
+
+// readReply - Функция, реализующая чтение ответа RESP
+func readReply(r *bufio.Reader) (any, error) {
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("error: malformed Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+
+		// Ошибки элементов возвращаются значениями Error, чтобы дочитать ответ целиком
+		items := make([]any, n)
+		for i := range items {
+			items[i], err = readReply(r)
+
+			var serverErr Error
+			switch {
+			case errors.As(err, &serverErr):
+				items[i] = serverErr
+			case err != nil && !errors.Is(err, ErrNil):
+				return nil, err
+			}
+		}
+
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("error: unexpected Redis reply %q", line)
+}
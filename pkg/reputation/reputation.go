@@ -0,0 +1,115 @@
+package reputation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	safeBrowsingEndpoint = "https://safebrowsing.googleapis.com/v4/threatMatches:find" // Адрес Google Safe Browsing Lookup API
+	requestTimeout       = 3 * time.Second                                             // Время ожидания ответа сервиса
+)
+
+// Verdict - Тип данных, реализующий структуру результата проверки ссылки
+type Verdict struct {
+	Malicious bool   // Признана ли ссылка опасной
+	Threat    string // Тип угрозы (например, "MALWARE" или "SOCIAL_ENGINEERING")
+}
+
+// Checker - Интерфейс сервиса проверки репутации ссылок
+type Checker interface {
+	Check(ctx context.Context, url string) (Verdict, error)
+}
+
+// SafeBrowsing - Тип данных, реализующий проверку ссылок через Google Safe Browsing Lookup API (v4)
+type SafeBrowsing struct {
+	apiKey   string       // Ключ API
+	endpoint string       // Адрес API
+	client   *http.Client // Клиент HTTP
+}
+
+// SafeBrowsingCreate - Функция, реализующая создание клиента Google Safe Browsing
+func SafeBrowsingCreate(apiKey string) *SafeBrowsing {
+	return &SafeBrowsing{
+		apiKey:   apiKey,
+		endpoint: safeBrowsingEndpoint,
+		client:   &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// threatEntry - Тип данных, описывающий проверяемый адрес в запросе к API
+type threatEntry struct {
+	Url string `json:"url"`
+}
+
+// findRequest - Тип данных, описывающий тело запроса threatMatches:find
+type findRequest struct {
+	Client struct {
+		ClientId      string `json:"clientId"`
+		ClientVersion string `json:"clientVersion"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string      `json:"threatTypes"`
+		PlatformTypes    []string      `json:"platformTypes"`
+		ThreatEntryTypes []string      `json:"threatEntryTypes"`
+		ThreatEntries    []threatEntry `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+// findResponse - Тип данных, описывающий ответ threatMatches:find (пустой список, если угроз не найдено)
+type findResponse struct {
+	Matches []struct {
+		ThreatType string      `json:"threatType"`
+		Threat     threatEntry `json:"threat"`
+	} `json:"matches"`
+}
+
+// Check - Метод, реализующий проверку ссылки по спискам угроз Google Safe Browsing
+func (c *SafeBrowsing) Check(ctx context.Context, url string) (Verdict, error) {
+
+	req := findRequest{}
+	req.Client.ClientId = "urlgen"
+	req.Client.ClientVersion = "1.0"
+	req.ThreatInfo.ThreatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+	req.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	req.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	req.ThreatInfo.ThreatEntries = []threatEntry{{Url: url}}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"?key="+c.apiKey, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return Verdict{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("error: Safe Browsing answered with status %d", resp.StatusCode)
+	}
+
+	found := findResponse{}
+
+	err = json.NewDecoder(resp.Body).Decode(&found)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	if len(found.Matches) == 0 {
+		return Verdict{}, nil
+	}
+
+	return Verdict{Malicious: true, Threat: found.Matches[0].ThreatType}, nil
+}
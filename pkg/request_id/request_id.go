@@ -0,0 +1,73 @@
+package request_id
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+)
+
+// Header - Заголовок запроса и ответа с идентификатором запроса
+const Header = "X-Request-ID"
+
+// idRegexp - Регулярное выражение допустимого идентификатора, полученного от клиента или прокси
+var idRegexp = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// contextKey - Тип данных, описывающий ключ для хранения идентификатора запроса в контексте
+type contextKey struct{}
+
+// Generate - Функция, реализующая генерацию нового идентификатора запроса (32 шестнадцатеричных символа)
+func Generate() string {
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// Valid - Функция, проверяющая, что полученный идентификатор можно принять (без пробелов и управляющих символов)
+func Valid(id string) bool {
+	return idRegexp.MatchString(id)
+}
+
+// NewContext - Функция, возвращающая контекст с заданным идентификатором запроса
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext - Функция, позволяющая получить идентификатор запроса из контекста (пустая строка, если его нет)
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Handler - Тип данных, реализующий обработчик журнала, добавляющий идентификатор запроса из контекста записи
+type Handler struct {
+	slog.Handler // Исходный обработчик журнала
+}
+
+// NewHandler - Функция, реализующая создание обработчика журнала с идентификатором запроса
+func NewHandler(h slog.Handler) *Handler {
+	return &Handler{Handler: h}
+}
+
+// Handle - Метод, реализующий запись с атрибутом "request_id", если он есть в контексте
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+
+	if id := FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs - Метод, возвращающий обработчик с дополнительными атрибутами
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup - Метод, возвращающий обработчик с группой атрибутов
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,331 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRegion = "us-east-1"                                                        // Регион по умолчанию (MinIO принимает любой регион)
+	emptyHash     = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // SHA-256 пустого тела
+)
+
+// ErrNotFound - Ошибка запроса отсутствующего объекта
+var ErrNotFound = errors.New("error: S3 object not found")
+
+// Error - Тип данных, описывающий ошибку, которую вернуло хранилище
+type Error struct {
+	Status  int    // HTTP статус ответа
+	Code    string // Код ошибки (например "AccessDenied")
+	Message string // Описание ошибки
+}
+
+// Error - Метод, возвращающий описание ошибки
+func (e *Error) Error() string {
+
+	if e.Code == "" {
+		return fmt.Sprintf("error: S3 answered with status %d", e.Status)
+	}
+
+	return fmt.Sprintf("error: S3: %s: %s (status %d)", e.Code, e.Message, e.Status)
+}
+
+// Object - Тип данных, описывающий объект в списке объектов хранилища
+type Object struct {
+	Key          string    // Ключ объекта
+	Size         int64     // Размер в байтах
+	LastModified time.Time // Время последнего изменения
+}
+
+// Client - Тип данных, реализующий клиент S3-совместимого хранилища (AWS S3, MinIO) для одного бакета
+// (запросы подписываются AWS Signature Version 4, бакет указывается в пути адреса)
+type Client struct {
+	endpoint  *url.URL     // Адрес хранилища
+	bucket    string       // Бакет
+	region    string       // Регион подписи
+	accessKey string       // Идентификатор ключа доступа
+	secretKey string       // Секретный ключ доступа
+	client    *http.Client // Клиент HTTP
+}
+
+// ClientCreate - Функция, реализующая создание клиента по адресу хранилища вида "https://host[:port]"
+// (пустой регион - "us-east-1")
+func ClientCreate(endpoint, bucket, region, accessKey, secretKey string) (*Client, error) {
+
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("error: S3 endpoint must look like https://host[:port]")
+	}
+
+	if bucket == "" {
+		return nil, errors.New("error: S3 bucket is required")
+	}
+
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("error: S3 access key and secret key are required")
+	}
+
+	if region == "" {
+		region = defaultRegion
+	}
+
+	return &Client{
+		endpoint:  &url.URL{Scheme: u.Scheme, Host: u.Host},
+		bucket:    bucket,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{},
+	}, nil
+}
+
+// Put - Метод, реализующий запись объекта (тело читается дважды: для подписи и для отправки)
+func (c *Client) Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+
+	hash := sha256.New()
+
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return err
+	}
+
+	if _, err = body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, key, nil, io.NopCloser(body), size, hex.EncodeToString(hash.Sum(nil)),
+		contentType)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// Get - Метод, реализующий чтение объекта (тело ответа закрывает вызывающий; ErrNotFound - объекта нет)
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil, 0, emptyHash, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// Delete - Метод, реализующий удаление объекта (удаление отсутствующего объекта не является ошибкой)
+func (c *Client) Delete(ctx context.Context, key string) error {
+
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil, 0, emptyHash, "")
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// listResult - Тип данных, описывающий ответ ListObjectsV2
+type listResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List - Метод, позволяющий получить все объекты с ключами, начинающимися с prefix (в порядке ключей)
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+
+	var (
+		objects []Object
+		token   string
+	)
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, 0, emptyHash, "")
+		if err != nil {
+			return nil, err
+		}
+
+		result := listResult{}
+
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range result.Contents {
+			objects = append(objects, Object{Key: o.Key, Size: o.Size, LastModified: o.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+
+		token = result.NextContinuationToken
+	}
+}
+
+// errorResponse - Тип данных, описывающий тело ответа хранилища с ошибкой
+type errorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do - Метод, реализующий подписанный запрос к объекту key (пустой ключ - к бакету)
+// (ответ с кодом не 2xx возвращается ошибкой Error или ErrNotFound, его тело закрывается)
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, body io.ReadCloser, size int64,
+	payloadHash, contentType string) (*http.Response, error) {
+
+	u := *c.endpoint
+	u.Path = "/" + c.bucket
+	u.RawPath = "/" + uriEncode(c.bucket, true)
+	if key != "" {
+		u.Path += "/" + key
+		u.RawPath += "/" + uriEncode(key, false)
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	c.sign(req, u.RawPath, payloadHash, time.Now().UTC())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && key != "" {
+		return nil, ErrNotFound
+	}
+
+	failure := errorResponse{}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+
+	return nil, &Error{Status: resp.StatusCode, Code: failure.Code, Message: failure.Message}
+}
+
+// sign - Метод, реализующий подпись запроса AWS Signature Version 4 (заголовок Authorization)
+func (c *Client) sign(req *http.Request, path, payloadHash string, now time.Time) {
+
+	date := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Подписываются адрес хранилища, время запроса и хеш тела
+	names := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := []string{req.URL.Host, payloadHash, amzDate}
+
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		names = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+		values = append([]string{contentType}, values...)
+	}
+
+	var headers strings.Builder
+	for i, name := range names {
+		headers.WriteString(name + ":" + strings.TrimSpace(values[i]) + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{req.Method, path, req.URL.RawQuery, headers.String(), signedHeaders,
+		payloadHash}, "\n")
+
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+// hmacSHA256 - Функция, возвращающая HMAC-SHA256 данных
+func hmacSHA256(key []byte, data string) []byte {
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// canonicalQuery - Функция, возвращающая параметры запроса в каноническом виде подписи (сортировка по имени)
+func canonicalQuery(query url.Values) string {
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		for _, v := range query[name] {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// uriEncode - Функция, реализующая кодирование строки для подписи (незакодированными остаются только буквы
+// латиницы, цифры и "-_.~", а также "/", если encodeSlash не задан)
+func uriEncode(s string, encodeSlash bool) string {
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,185 @@
+package statsd
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Форматы строк метрик
+const (
+	FormatStatsD    = "statsd"    // StatsD: значения меток добавляются к имени метрики через точку
+	FormatDogStatsD = "dogstatsd" // DogStatsD (Datadog): метки передаются тегами "|#метка:значение"
+)
+
+// maxPacketSize - Максимальный размер датаграммы UDP (укладывается в MTU без фрагментации)
+const maxPacketSize = 1432
+
+// Emitter - Тип данных, реализующий отправку метрик реестра Prometheus в StatsD по UDP
+// (счетчики отправляются приращением с прошлой отправки, датчики - текущим значением,
+// гистограммы и сводки - приращениями количества и суммы наблюдений)
+type Emitter struct {
+	conn     net.Conn            // Подключение UDP
+	format   string              // Формат строк метрик
+	prefix   string              // Префикс имен метрик
+	tags     []string            // Постоянные теги ("метка:значение", только для DogStatsD)
+	gatherer prometheus.Gatherer // Источник метрик
+	last     map[string]float64  // Значения счетчиков при прошлой отправке по ключу ряда
+}
+
+// EmitterCreate - Функция, реализующая создание отправителя метрик по адресу "host:port"
+func EmitterCreate(addr, format, prefix string, tags []string, gatherer prometheus.Gatherer) (*Emitter, error) {
+
+	if format != FormatStatsD && format != FormatDogStatsD {
+		return nil, errors.New("error: StatsD format must be statsd or dogstatsd")
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Emitter{conn: conn, format: format, prefix: prefix, tags: tags, gatherer: gatherer,
+		last: map[string]float64{}}, nil
+}
+
+// Push - Метод, реализующий отправку текущих значений метрик (вызывается из одной горутины)
+// (UDP не подтверждает доставку: ошибка означает только сбой чтения метрик или отправки датаграммы)
+func (e *Emitter) Push() error {
+
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+
+	var packet []byte
+
+	send := func(line string) error {
+		if len(packet) > 0 && len(packet)+1+len(line) > maxPacketSize {
+			if _, err := e.conn.Write(packet); err != nil {
+				return err
+			}
+			packet = packet[:0]
+		}
+
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+
+		return nil
+	}
+
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, line := range e.lines(family, m) {
+				if err = send(line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(packet) > 0 {
+		_, err = e.conn.Write(packet)
+	}
+
+	return err
+}
+
+// Close - Метод, реализующий закрытие подключения
+func (e *Emitter) Close() error {
+	return e.conn.Close()
+}
+
+// lines - Метод, возвращающий строки StatsD ряда метрики (пустой список, если отправлять нечего)
+func (e *Emitter) lines(family *dto.MetricFamily, m *dto.Metric) []string {
+
+	name, tags := e.series(family.GetName(), m.GetLabel())
+
+	var lines []string
+
+	add := func(suffix string, value float64, kind string) {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return
+		}
+		lines = append(lines, name+suffix+":"+strconv.FormatFloat(value, 'f', -1, 64)+"|"+kind+tags)
+	}
+
+	// Приращения счетчиков (сброс счетчика при перезапуске отсчитывается от нуля)
+	delta := func(suffix string, value float64) {
+		key := name + suffix + tags
+		d := value - e.last[key]
+		if d < 0 {
+			d = value
+		}
+		e.last[key] = value
+
+		if d != 0 {
+			add(suffix, d, "c")
+		}
+	}
+
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		delta("", m.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		add("", m.GetGauge().GetValue(), "g")
+	case dto.MetricType_UNTYPED:
+		add("", m.GetUntyped().GetValue(), "g")
+	case dto.MetricType_HISTOGRAM:
+		delta(".count", float64(m.GetHistogram().GetSampleCount()))
+		delta(".sum", m.GetHistogram().GetSampleSum())
+	case dto.MetricType_SUMMARY:
+		delta(".count", float64(m.GetSummary().GetSampleCount()))
+		delta(".sum", m.GetSummary().GetSampleSum())
+	}
+
+	return lines
+}
+
+// series - Метод, возвращающий имя ряда метрики и теги в формате отправителя
+func (e *Emitter) series(name string, labels []*dto.LabelPair) (string, string) {
+
+	name = e.prefix + name
+
+	if e.format == FormatStatsD {
+		for _, l := range labels {
+			// Точки разделяют части имени StatsD, а "/" не допускают серверы Graphite
+			name += "." + strings.NewReplacer(".", "_", "/", "_").Replace(sanitize(l.GetValue()))
+		}
+		return name, ""
+	}
+
+	tags := append([]string{}, e.tags...)
+	for _, l := range labels {
+		tags = append(tags, l.GetName()+":"+sanitize(l.GetValue()))
+	}
+
+	if len(tags) == 0 {
+		return name, ""
+	}
+
+	return name, "|#" + strings.Join(tags, ",")
+}
+
+// sanitize - Функция, реализующая замену символов, недопустимых в строке метрики (":", "|", "@", ",", "#"
+// и пробельных), на "_" (пустое значение заменяется на "none")
+func sanitize(v string) string {
+
+	if v == "" {
+		return "none"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', ',', '#', ' ', '\n', '\t':
+			return '_'
+		}
+		return r
+	}, v)
+}
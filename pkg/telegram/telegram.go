@@ -0,0 +1,173 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultApiUrl - Адрес Bot API Telegram по умолчанию
+const DefaultApiUrl = "https://api.telegram.org"
+
+// User - Тип данных, описывающий отправителя сообщения
+type User struct {
+	Id       int64  `json:"id"`                 // Идентификатор пользователя
+	Username string `json:"username,omitempty"` // Имя пользователя (без "@", может отсутствовать)
+}
+
+// Chat - Тип данных, описывающий чат сообщения
+type Chat struct {
+	Id int64 `json:"id"` // Идентификатор чата
+}
+
+// Message - Тип данных, описывающий сообщение боту
+type Message struct {
+	MessageId int64  `json:"message_id"`     // Идентификатор сообщения в чате
+	From      *User  `json:"from,omitempty"` // Отправитель (отсутствует в сообщениях каналов)
+	Chat      Chat   `json:"chat"`           // Чат
+	Text      string `json:"text,omitempty"` // Текст сообщения
+}
+
+// Update - Тип данных, описывающий входящее обновление бота
+type Update struct {
+	UpdateId int64    `json:"update_id"`         // Идентификатор обновления (возрастает)
+	Message  *Message `json:"message,omitempty"` // Новое сообщение (nil для обновлений других видов)
+}
+
+// Error - Тип данных, описывающий ошибку, возвращенную Bot API
+type Error struct {
+	Code        int    // Код ошибки (совпадает со статусом HTTP)
+	Description string // Описание ошибки
+}
+
+// Error - Метод, возвращающий текст ошибки Bot API
+func (e *Error) Error() string {
+	return fmt.Sprintf("error: telegram api returned %d: %s", e.Code, e.Description)
+}
+
+// Client - Тип данных, реализующий вызовы Bot API Telegram
+type Client struct {
+	baseUrl string       // Адрес методов бота ("<адрес API>/bot<токен>/")
+	http    *http.Client // Клиент HTTP
+}
+
+// ClientCreate - Функция, реализующая создание клиента Bot API по токену бота
+// (apiUrl - адрес Bot API, DefaultApiUrl, если пустой)
+func ClientCreate(apiUrl, token string) *Client {
+
+	if apiUrl == "" {
+		apiUrl = DefaultApiUrl
+	}
+
+	return &Client{baseUrl: strings.TrimSuffix(apiUrl, "/") + "/bot" + token + "/", http: &http.Client{}}
+}
+
+// GetUpdates - Метод, реализующий получение новых сообщений долгим опросом
+// (offset - идентификатор следующего ожидаемого обновления, timeout - время ожидания сообщений сервером)
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+
+	var updates []Update
+
+	err := c.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+
+	return updates, err
+}
+
+// SendMessage - Метод, реализующий отправку текстового сообщения в чат (ответом на сообщение replyTo, если не 0)
+func (c *Client) SendMessage(ctx context.Context, chatId int64, text string, replyTo int64) error {
+
+	params := map[string]any{
+		"chat_id":                  chatId,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}
+
+	if replyTo != 0 {
+		params["reply_to_message_id"] = replyTo
+	}
+
+	return c.call(ctx, "sendMessage", params, nil)
+}
+
+// SetWebhook - Метод, реализующий подписку бота на доставку сообщений вебхуком по адресу webhookUrl
+// (secret передается Telegram в заголовке X-Telegram-Bot-Api-Secret-Token каждого запроса)
+func (c *Client) SetWebhook(ctx context.Context, webhookUrl, secret string) error {
+	return c.call(ctx, "setWebhook", map[string]any{
+		"url":             webhookUrl,
+		"secret_token":    secret,
+		"allowed_updates": []string{"message"},
+	}, nil)
+}
+
+// DeleteWebhook - Метод, реализующий отмену доставки сообщений вебхуком (без нее долгий опрос недоступен)
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	return c.call(ctx, "deleteWebhook", map[string]any{}, nil)
+}
+
+// call - Метод, реализующий вызов метода Bot API с параметрами в JSON и чтение результата в result
+func (c *Client) call(ctx context.Context, method string, params map[string]any, result any) error {
+
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Ошибка запроса содержит адрес с токеном бота
+		return fmt.Errorf("error: telegram %s request failed: %w", method, unwrapUrlError(err))
+	}
+	defer resp.Body.Close()
+
+	answer := struct {
+		Ok          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		ErrorCode   int             `json:"error_code"`
+		Description string          `json:"description"`
+	}{}
+
+	if err = json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return fmt.Errorf("error: failed to read telegram %s response (status %d): %w", method, resp.StatusCode, err)
+	}
+
+	if !answer.Ok {
+		code := answer.ErrorCode
+		if code == 0 {
+			code = resp.StatusCode
+		}
+		return &Error{Code: code, Description: answer.Description}
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(answer.Result, result)
+}
+
+// unwrapUrlError - Функция, возвращающая причину ошибки запроса без адреса запроса
+func unwrapUrlError(err error) error {
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+
+	return err
+}
@@ -0,0 +1,69 @@
+package token_manager
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// Claims - Тип данных, реализующий структуру полезной нагрузки JWT
+type Claims struct {
+	UserId   int    `json:"uid"`      // Идентификатор пользователя
+	Username string `json:"username"` // Имя пользователя
+	jwt.RegisteredClaims
+}
+
+// TokenManager - Тип данных, реализующий менеджер для выпуска и проверки JWT
+type TokenManager struct {
+	secret []byte        // Секретный ключ подписи
+	ttl    time.Duration // Время жизни токена
+}
+
+// TokenManagerCreate - Функция, реализующая создание менеджера токенов
+func TokenManagerCreate(secret []byte, ttl time.Duration) *TokenManager {
+	return &TokenManager{
+		secret: secret,
+		ttl:    ttl,
+	}
+}
+
+// Issue - Метод, реализующий выпуск подписанного токена для заданного пользователя
+func (m *TokenManager) Issue(userId int, username string) (string, error) {
+
+	now := time.Now()
+
+	claims := Claims{
+		UserId:   userId,
+		Username: username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+}
+
+// Parse - Метод, реализующий проверку токена и получение его полезной нагрузки
+func (m *TokenManager) Parse(token string) (*Claims, error) {
+
+	claims := Claims{}
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.UserId == 0 {
+		return nil, errors.New("error: Token has no user")
+	}
+
+	return &claims, nil
+}
+
+// TTL - Метод, возвращающий время жизни выпускаемых токенов
+func (m *TokenManager) TTL() time.Duration {
+	return m.ttl
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"my_project/urlgen/pkg/version"
+	"os"
+	"strconv"
+)
+
+// instrumentationName - Название инструментирования, под которым создаются спаны
+const instrumentationName = "my_project/urlgen"
+
+// Tracer - Функция, возвращающая трассировщик приложения
+// (до настройки FromEnv или без адреса сборщика спаны не записываются)
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// FromEnv - Функция, реализующая настройку трассировки по переменным окружения
+// (OTEL_EXPORTER_OTLP_ENDPOINT - адрес сборщика OTLP/HTTP, без него трассировка выключена;
+// OTEL_SERVICE_NAME - название сервиса, TRACING_SAMPLE_RATIO - доля записываемых трасс от 0 до 1;
+// контекст трассы принимается из заголовков traceparent и baggage в любом случае;
+// возвращает функцию, отправляющую оставшиеся спаны при остановке)
+func FromEnv(ctx context.Context) (func(context.Context) error, error) {
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	ratio := 1.0
+	if v := os.Getenv("TRACING_SAMPLE_RATIO"); v != "" {
+		var err error
+
+		ratio, err = strconv.ParseFloat(v, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, errors.New("error: TRACING_SAMPLE_RATIO must be a number from 0 to 1")
+		}
+	}
+
+	// Адрес, заголовки и параметры TLS сборщика exporter читает из переменных OTEL_EXPORTER_OTLP_*
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "urlgen"
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(service),
+		semconv.ServiceVersion(version.Get().Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
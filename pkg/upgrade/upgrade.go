@@ -0,0 +1,246 @@
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	listenersEnv = "URLGEN_UPGRADE_LISTENERS" // Адреса переданных сокетов через ";" (в порядке дескрипторов)
+	readyFd      = 3                          // Дескриптор канала сообщения о готовности нового процесса
+	listenersFd  = 4                          // Дескриптор первого переданного сокета
+)
+
+// ErrInProgress - Ошибка повторного обновления, пока предыдущее не завершено
+var ErrInProgress = errors.New("error: upgrade is already in progress")
+
+// Upgrader - Тип данных, реализующий обновление процесса без закрытия сокетов (передачей их новому процессу)
+// (новый процесс принимает соединения на тех же сокетах, а старый завершает текущие запросы и выходит,
+// поэтому ни одно соединение не отклоняется)
+type Upgrader struct {
+	mu        sync.Mutex
+	inherited map[string]*os.File // Сокеты, полученные от прежнего процесса, по адресу (еще не открытые)
+	listeners []*net.TCPListener  // Открытые сокеты (передаются следующему процессу)
+	addrs     []string            // Адреса открытых сокетов
+	parent    *os.File            // Канал сообщения о готовности прежнему процессу (nil, если процесс запущен не им)
+	environ   []string            // Окружение процесса до применения настроек (передается новому процессу)
+	upgrading bool                // Выполняется ли обновление
+}
+
+// UpgraderCreate - Функция, реализующая создание обновления процесса
+// (в процессе, запущенном обновлением, забираются переданные сокеты; вызывается до загрузки настроек,
+// чтобы новый процесс получил исходное окружение и сам прочитал файл настроек, а не его значения как переменные)
+func UpgraderCreate() (*Upgrader, error) {
+
+	u := Upgrader{inherited: map[string]*os.File{}}
+
+	v, found := os.LookupEnv(listenersEnv)
+	if found {
+		_ = os.Unsetenv(listenersEnv)
+	}
+
+	u.environ = os.Environ()
+
+	if !found {
+		return &u, nil
+	}
+
+	u.parent = os.NewFile(readyFd, "upgrade-ready")
+
+	if v != "" {
+		for i, addr := range strings.Split(v, ";") {
+			u.inherited[addr] = os.NewFile(uintptr(listenersFd+i), "listener "+addr)
+		}
+	}
+
+	return &u, nil
+}
+
+// Listen - Метод, позволяющий получить сокет TCP по адресу: переданный прежним процессом или новый
+func (u *Upgrader) Listen(addr string) (net.Listener, error) {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var (
+		l   net.Listener
+		err error
+	)
+
+	if f, found := u.inherited[addr]; found {
+		delete(u.inherited, addr)
+
+		l, err = net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error: failed to use inherited socket %s: %w", addr, err)
+		}
+	} else {
+		l, err = net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tcp, ok := l.(*net.TCPListener)
+	if !ok {
+		l.Close()
+		return nil, fmt.Errorf("error: socket %s is not a TCP socket", addr)
+	}
+
+	u.listeners = append(u.listeners, tcp)
+	u.addrs = append(u.addrs, addr)
+
+	return tcp, nil
+}
+
+// Ready - Метод, реализующий сообщение прежнему процессу о готовности принимать соединения
+// (переданные, но не открытые сокеты закрываются; вне обновления ничего не делает)
+func (u *Upgrader) Ready() error {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for addr, f := range u.inherited {
+		f.Close()
+		delete(u.inherited, addr)
+	}
+
+	if u.parent == nil {
+		return nil
+	}
+
+	_, err := u.parent.Write([]byte{1})
+	if closeErr := u.parent.Close(); err == nil {
+		err = closeErr
+	}
+	u.parent = nil
+
+	return err
+}
+
+// Upgrade - Метод, реализующий запуск нового процесса из исполняемого файла текущего (с теми же аргументами
+// и окружением) с передачей ему открытых сокетов; возвращает идентификатор нового процесса, когда он
+// сообщил о готовности (если он завершился или не сообщил о готовности за timeout, он останавливается,
+// а текущий продолжает работу и может повторить обновление)
+func (u *Upgrader) Upgrade(ctx context.Context, timeout time.Duration) (int, error) {
+
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return 0, ErrInProgress
+	}
+	u.upgrading = true
+	listeners, addrs := u.listeners, strings.Join(u.addrs, ";")
+	u.mu.Unlock()
+
+	pid, err := u.start(ctx, listeners, addrs, timeout)
+
+	// После успешного обновления процесс завершается, повторное обновление не допускается
+	if err != nil {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}
+
+	return pid, err
+}
+
+// start - Метод, реализующий запуск нового процесса и ожидание его готовности
+func (u *Upgrader) start(ctx context.Context, listeners []*net.TCPListener, addrs string,
+	timeout time.Duration) (int, error) {
+
+	// Копии дескрипторов сокетов нужны только для передачи новому процессу
+	files := make([]*os.File, 0, len(listeners))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, l := range listeners {
+		f, err := l.File()
+		if err != nil {
+			return 0, err
+		}
+		files = append(files, f)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+
+	readyRead, readyWrite, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer readyRead.Close()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = u.childEnviron(addrs)
+	cmd.ExtraFiles = append([]*os.File{readyWrite}, files...)
+
+	err = cmd.Start()
+	readyWrite.Close()
+	if err != nil {
+		return 0, fmt.Errorf("error: failed to start %s: %w", executable, err)
+	}
+
+	// Канал закрывается без сообщения, если новый процесс завершился до готовности
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := readyRead.Read(buf); err != nil {
+			if err == io.EOF {
+				err = errors.New("error: new process exited before it was ready")
+			}
+			ready <- err
+			return
+		}
+		ready <- nil
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-ready:
+	case <-timer.C:
+		err = fmt.Errorf("error: new process was not ready in %s", timeout)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		<-exited
+		return 0, err
+	}
+
+	return cmd.Process.Pid, nil
+}
+
+// childEnviron - Метод, возвращающий окружение нового процесса: исходное окружение и адреса переданных сокетов
+func (u *Upgrader) childEnviron(addrs string) []string {
+
+	env := make([]string, 0, len(u.environ)+1)
+	env = append(env, u.environ...)
+
+	return append(env, listenersEnv+"="+addrs)
+}
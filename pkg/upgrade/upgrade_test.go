@@ -0,0 +1,92 @@
+package upgrade
+
+import (
+	"my_project/urlgen/config"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// restoreEnviron - Функция, реализующая восстановление окружения процесса по завершении теста
+func restoreEnviron(t *testing.T) {
+
+	saved := os.Environ()
+
+	t.Cleanup(func() {
+		os.Clearenv()
+		for _, kv := range saved {
+			key, value, _ := strings.Cut(kv, "=")
+			_ = os.Setenv(key, value)
+		}
+	})
+}
+
+func TestUpgradeKeepsFileSettingsReloadable(t *testing.T) {
+
+	restoreEnviron(t)
+	_ = os.Unsetenv("CACHE_TTL")
+	_ = os.Unsetenv("JWT_SECRET")
+
+	file := filepath.Join(t.TempDir(), "urlgen.yaml")
+	write := func(ttl string) {
+		if err := os.WriteFile(file, []byte("auth:\n  jwt_secret: secret\ncache:\n  ttl: "+ttl+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("5m")
+
+	u, err := UpgraderCreate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = config.Load([]string{"-config", file}); err != nil {
+		t.Fatal(err)
+	}
+
+	env := u.childEnviron("127.0.0.1:4000")
+
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "CACHE_TTL=") || strings.HasPrefix(kv, "JWT_SECRET=") {
+			t.Errorf("new process gets file setting %s as an environment variable", kv)
+		}
+	}
+
+	if !slices.Contains(env, listenersEnv+"=127.0.0.1:4000") {
+		t.Errorf("new process environment has no %s", listenersEnv)
+	}
+
+	// Новый процесс: окружение, переданное обновлением, и файл, измененный до сигнала
+	write("7m")
+
+	os.Clearenv()
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		if key != listenersEnv {
+			_ = os.Setenv(key, value)
+		}
+	}
+
+	settings, err := config.Load([]string{"-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := settings.Get("cache.ttl"); got != "7m" || settings.Source("cache.ttl") != "file" {
+		t.Fatalf("cache.ttl = %q from %s, want 7m from the file", got, settings.Source("cache.ttl"))
+	}
+
+	write("10m")
+
+	applied, _, err := settings.Reload(func() error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(applied, "cache.ttl") || settings.Get("cache.ttl") != "10m" {
+		t.Errorf("Reload applied %q, cache.ttl = %q, want 10m", applied, settings.Get("cache.ttl"))
+	}
+}
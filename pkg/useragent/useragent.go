@@ -0,0 +1,140 @@
+package useragent
+
+import (
+	"my_project/urlgen/pkg/click_pipeline"
+	"strings"
+)
+
+// Классы устройств
+const (
+	DeviceDesktop = "desktop" // Настольный компьютер
+	DeviceMobile  = "mobile"  // Телефон
+	DeviceTablet  = "tablet"  // Планшет
+	DeviceBot     = "bot"     // Бот или автоматический клиент
+	DeviceUnknown = "unknown" // Класс устройства не определен
+)
+
+// Info - Тип данных, реализующий структуру разобранного заголовка User-Agent
+type Info struct {
+	Browser     string // Браузер
+	OS          string // Операционная система
+	DeviceClass string // Класс устройства
+}
+
+// rule - Тип данных, описывающий правило распознавания по подстроке заголовка User-Agent
+type rule struct {
+	token string // Искомая подстрока (в нижнем регистре)
+	name  string // Название, соответствующее подстроке
+}
+
+// botTokens - Подстроки, по которым распознаются боты и автоматические клиенты
+var botTokens = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-", "go-http-client",
+	"java/", "okhttp", "headless", "preview", "facebookexternalhit", "facebookcatalog", "whatsapp", "slack",
+	"discord", "telegram", "embedly", "pinterest", "vkshare", "ia_archiver", "archive.org", "httpclient",
+	"axios/", "node-fetch", "undici", "lighthouse", "pingdom", "monitor", "scanner", "validator", "feedfetcher"}
+
+// browserRules - Правила распознавания браузеров (порядок важен: более частные правила раньше общих)
+var browserRules = []rule{
+	{"edg/", "Edge"},
+	{"edge/", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"yabrowser/", "Yandex Browser"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"chromium/", "Chromium"},
+	{"safari/", "Safari"},
+	{"msie ", "Internet Explorer"},
+	{"trident/", "Internet Explorer"},
+}
+
+// osRules - Правила распознавания операционных систем (порядок важен: более частные правила раньше общих)
+var osRules = []rule{
+	{"windows phone", "Windows Phone"},
+	{"windows", "Windows"},
+	{"iphone", "iOS"},
+	{"ipad", "iOS"},
+	{"ipod", "iOS"},
+	{"android", "Android"},
+	{"cros", "ChromeOS"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+}
+
+// Parse - Функция, реализующая разбор заголовка User-Agent на браузер, операционную систему и класс устройства
+// (пустые значения, если браузер или система не распознаны)
+func Parse(ua string) Info {
+
+	if ua == "" {
+		return Info{DeviceClass: DeviceUnknown}
+	}
+
+	lower := strings.ToLower(ua)
+
+	info := Info{
+		Browser: match(lower, browserRules),
+		OS:      match(lower, osRules),
+	}
+
+	// Определение класса устройства
+	switch {
+	case IsBot(lower):
+		info.DeviceClass = DeviceBot
+	case strings.Contains(lower, "ipad") || strings.Contains(lower, "tablet") ||
+		(strings.Contains(lower, "android") && !strings.Contains(lower, "mobile")):
+		info.DeviceClass = DeviceTablet
+	case strings.Contains(lower, "mobi") || strings.Contains(lower, "iphone") || strings.Contains(lower, "ipod"):
+		info.DeviceClass = DeviceMobile
+	case info.OS != "":
+		info.DeviceClass = DeviceDesktop
+	default:
+		info.DeviceClass = DeviceUnknown
+	}
+
+	return info
+}
+
+// IsBot - Функция, проверяющая, принадлежит ли заголовок User-Agent боту или автоматическому клиенту
+func IsBot(ua string) bool {
+
+	lower := strings.ToLower(ua)
+
+	for _, token := range botTokens {
+		if strings.Contains(lower, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match - Функция, возвращающая название первого правила, подстрока которого найдена в заголовке
+func match(lower string, rules []rule) string {
+
+	for _, r := range rules {
+		if strings.Contains(lower, r.token) {
+			return r.name
+		}
+	}
+
+	return ""
+}
+
+// Enricher - Тип данных, реализующий заполнение полей события перехода по заголовку User-Agent
+type Enricher struct{}
+
+// Enrich - Метод, реализующий заполнение браузера, операционной системы и класса устройства события перехода
+// (переходы ботов и клиентов без User-Agent помечаются признаком Bot)
+func (Enricher) Enrich(e *click_pipeline.Event) {
+
+	info := Parse(e.UserAgent)
+
+	e.Browser = info.Browser
+	e.OS = info.OS
+	e.DeviceClass = info.DeviceClass
+	e.Bot = e.Bot || e.UserAgent == "" || info.DeviceClass == DeviceBot
+}
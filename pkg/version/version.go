@@ -0,0 +1,50 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Сведения о сборке, задаваемые при сборке флагами компоновщика, например:
+// go build -ldflags "-X my_project/urlgen/pkg/version.Version=1.2.0 -X my_project/urlgen/pkg/version.Commit=$(git rev-parse HEAD)"
+var (
+	Version   = "dev" // Версия приложения
+	Commit    = ""    // Коммит, из которого собрано приложение
+	BuildDate = ""    // Время сборки (RFC 3339)
+)
+
+// Info - Тип данных, описывающий сведения о сборке приложения
+type Info struct {
+	Version   string `json:"version"`              // Версия приложения
+	Commit    string `json:"commit,omitempty"`     // Коммит, из которого собрано приложение
+	BuildDate string `json:"build_date,omitempty"` // Время сборки
+	GoVersion string `json:"go_version"`           // Версия Go, которой собрано приложение
+	Modified  bool   `json:"modified,omitempty"`   // Собрано ли приложение из измененного рабочего каталога
+}
+
+// Get - Функция, возвращающая сведения о сборке приложения
+// (если коммит не задан флагом компоновщика, он берется из сведений системы контроля версий, записанных go build)
+func Get() Info {
+
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range build.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	return info
+}
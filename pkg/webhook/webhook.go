@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SignatureHeader - Заголовок с подписью тела запроса (HMAC-SHA256 от "<timestamp>.<body>" в виде "sha256=<hex>")
+const SignatureHeader = "X-Webhook-Signature"
+
+// TimestampHeader - Заголовок со временем отправки запроса (Unix время в секундах)
+const TimestampHeader = "X-Webhook-Timestamp"
+
+var (
+	ErrClosed = errors.New("error: Dispatcher is closed")     // Ошибка отправки через закрытый диспетчер
+	ErrFull   = errors.New("error: Dispatcher queue is full") // Ошибка отправки при переполненной очереди
+)
+
+// Delivery - Тип данных, реализующий структуру доставки тела запроса получателю
+type Delivery struct {
+	Url     string // Адрес получателя
+	Secret  string // Секрет подписи
+	Payload []byte // Тело запроса (JSON)
+}
+
+// Dispatcher - Тип данных, реализующий асинхронную доставку вебхуков с повторами и экспоненциальной задержкой
+type Dispatcher struct {
+	sync.RWMutex               // Блокировка для корректного закрытия очереди
+	client       *http.Client  // Клиент HTTP
+	maxAttempts  int           // Максимальное количество попыток доставки
+	backoff      time.Duration // Задержка перед первым повтором (удваивается с каждой попыткой)
+
+	queue  chan Delivery  // Очередь доставок
+	wg     sync.WaitGroup // Ожидание завершения обработчиков
+	stop   chan struct{}  // Сигнал прекращения повторов при остановке
+	once   sync.Once      // Однократная отправка сигнала прекращения повторов
+	closed bool           // Признак закрытия диспетчера
+	logger *slog.Logger   // Журнал диспетчера
+}
+
+// DispatcherCreate - Функция, реализующая создание и запуск диспетчера с заданным количеством обработчиков
+// (клиент HTTP задает время ожидания и проверку адресов получателей)
+func DispatcherCreate(workers, queueSize, maxAttempts int, backoff time.Duration, client *http.Client,
+	logger *slog.Logger) *Dispatcher {
+
+	d := Dispatcher{
+		client:      client,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+
+		queue:  make(chan Delivery, queueSize),
+		stop:   make(chan struct{}),
+		logger: logger,
+	}
+
+	d.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go d.run()
+	}
+
+	return &d
+}
+
+// Send - Метод, реализующий добавление доставки в очередь без ожидания
+func (d *Dispatcher) Send(delivery Delivery) error {
+
+	d.RLock()
+	defer d.RUnlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+
+	select {
+	case d.queue <- delivery:
+		return nil
+	default:
+		return ErrFull
+	}
+}
+
+// Queued - Метод, возвращающий количество доставок в очереди и размер очереди
+func (d *Dispatcher) Queued() (int, int) {
+	return len(d.queue), cap(d.queue)
+}
+
+// Close - Метод, реализующий остановку диспетчера с доставкой оставшихся в очереди запросов
+// (повторы прекращаются при отмене контекста)
+func (d *Dispatcher) Close(ctx context.Context) error {
+
+	d.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.once.Do(func() { close(d.stop) })
+		return ctx.Err()
+	}
+}
+
+// run - Метод, реализующий обработку очереди доставок
+func (d *Dispatcher) run() {
+
+	defer d.wg.Done()
+
+	for delivery := range d.queue {
+		d.deliver(delivery)
+	}
+}
+
+// deliver - Метод, реализующий доставку с повторами при ошибке сети или ответе 5xx/429
+func (d *Dispatcher) deliver(delivery Delivery) {
+
+	delay := d.backoff
+
+	for attempt := 1; ; attempt++ {
+		retry, err := d.post(delivery)
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt >= d.maxAttempts {
+			d.logger.Error("Failed to deliver webhook", "url", delivery.Url, "attempts", attempt, "error", err)
+			return
+		}
+
+		d.logger.Warn("Webhook delivery failed, retrying", "url", delivery.Url, "attempt", attempt, "error", err)
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// post - Метод, реализующий одну попытку доставки (возвращает, имеет ли смысл повтор)
+func (d *Dispatcher) post(delivery Delivery) (bool, error) {
+
+	req, err := http.NewRequest(http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+
+	return retry, fmt.Errorf("error: Webhook answered with status %d", resp.StatusCode)
+}
+
+// Sign - Функция, реализующая вычисление подписи тела запроса
+func Sign(secret, timestamp string, payload []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}